@@ -0,0 +1,280 @@
+package gortsplib
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/pcapng"
+)
+
+const (
+	captureProtocolTCP = 6
+	captureProtocolUDP = 17
+
+	captureTCPFlagSYN = 0x02
+	captureTCPFlagPSH = 0x08
+	captureTCPFlagACK = 0x10
+
+	// maximum size of the payload of a synthetic TCP segment.
+	captureMaxSegmentSize = 65000
+)
+
+func captureChecksum(sum uint32, byts []byte) uint32 {
+	for len(byts) >= 2 {
+		sum += uint32(byts[0])<<8 | uint32(byts[1])
+		byts = byts[2:]
+	}
+	if len(byts) == 1 {
+		sum += uint32(byts[0]) << 8
+	}
+	return sum
+}
+
+func captureChecksumFinalize(sum uint32) uint16 {
+	for (sum >> 16) != 0 {
+		sum = (sum & 0xFFFF) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+// captureIPs returns the source and destination IPs in the same family.
+// Unspecified IPs (i.e. listeners bound to all interfaces)
+// are converted into the family of the other IP.
+func captureIPs(src net.IP, dst net.IP) (net.IP, net.IP) {
+	src4 := src.To4()
+	dst4 := dst.To4()
+
+	switch {
+	case src4 != nil && dst4 != nil:
+		return src4, dst4
+
+	case src4 != nil && (dst == nil || dst.IsUnspecified()):
+		return src4, net.IPv4zero.To4()
+
+	case dst4 != nil && (src == nil || src.IsUnspecified()):
+		return net.IPv4zero.To4(), dst4
+	}
+
+	src = src.To16()
+	if src == nil {
+		src = net.IPv6unspecified
+	}
+
+	dst = dst.To16()
+	if dst == nil {
+		dst = net.IPv6unspecified
+	}
+
+	return src, dst
+}
+
+// captureIPPacket generates an IP packet that contains a TCP segment or an UDP packet,
+// and fills its checksum.
+func captureIPPacket(src net.IP, dst net.IP, protocol byte, transport []byte) []byte {
+	src, dst = captureIPs(src, dst)
+
+	var pseudo []byte
+	var ret []byte
+
+	if len(src) == net.IPv4len {
+		ret = make([]byte, 20+len(transport))
+		ret[0] = 0x45 // version and header length
+		binary.BigEndian.PutUint16(ret[2:], uint16(len(ret)))
+		ret[6] = 0x40 // don't fragment
+		ret[8] = 64   // TTL
+		ret[9] = protocol
+		copy(ret[12:], src)
+		copy(ret[16:], dst)
+		binary.BigEndian.PutUint16(ret[10:], captureChecksumFinalize(captureChecksum(0, ret[:20])))
+		copy(ret[20:], transport)
+
+		pseudo = make([]byte, 12)
+		copy(pseudo[0:], src)
+		copy(pseudo[4:], dst)
+		pseudo[9] = protocol
+		binary.BigEndian.PutUint16(pseudo[10:], uint16(len(transport)))
+	} else {
+		ret = make([]byte, 40+len(transport))
+		ret[0] = 0x60 // version
+		binary.BigEndian.PutUint16(ret[4:], uint16(len(transport)))
+		ret[6] = protocol
+		ret[7] = 64 // hop limit
+		copy(ret[8:], src)
+		copy(ret[24:], dst)
+		copy(ret[40:], transport)
+
+		pseudo = make([]byte, 40)
+		copy(pseudo[0:], src)
+		copy(pseudo[16:], dst)
+		binary.BigEndian.PutUint32(pseudo[32:], uint32(len(transport)))
+		pseudo[39] = protocol
+	}
+
+	transport = ret[len(ret)-len(transport):]
+
+	checksumPos := 16
+	if protocol == captureProtocolUDP {
+		checksumPos = 6
+	}
+
+	cs := captureChecksumFinalize(captureChecksum(captureChecksum(0, pseudo), transport))
+	if cs == 0 && protocol == captureProtocolUDP {
+		cs = 0xFFFF
+	}
+	binary.BigEndian.PutUint16(transport[checksumPos:], cs)
+
+	return ret
+}
+
+// Capture writes the traffic of a Client or of a Server into a pcapng file.
+// RTSP requests and responses, interleaved frames and UDP packets are
+// written with synthetic IP, TCP and UDP headers, therefore the file can
+// be opened with Wireshark or replayed with CaptureReplayer.
+// When TLS is in use, the decrypted traffic is written.
+// Errors that happen while writing the file are ignored.
+type Capture struct {
+	w     *pcapng.Writer
+	mutex sync.Mutex
+}
+
+// NewCapture allocates a Capture that writes into w.
+func NewCapture(w io.Writer) (*Capture, error) {
+	pw, err := pcapng.NewWriter(w, pcapng.LinkTypeRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capture{
+		w: pw,
+	}, nil
+}
+
+func (c *Capture) writePacket(byts []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.w.WritePacket(time.Now(), byts)
+}
+
+func (c *Capture) writeTCP(src *net.TCPAddr, dst *net.TCPAddr,
+	seq uint32, ack uint32, flags byte, payload []byte) {
+	seg := make([]byte, 20+len(payload))
+	binary.BigEndian.PutUint16(seg[0:], uint16(src.Port))
+	binary.BigEndian.PutUint16(seg[2:], uint16(dst.Port))
+	binary.BigEndian.PutUint32(seg[4:], seq)
+	binary.BigEndian.PutUint32(seg[8:], ack)
+	seg[12] = 5 << 4 // header length
+	seg[13] = flags
+	binary.BigEndian.PutUint16(seg[14:], 0xFFFF) // window
+	copy(seg[20:], payload)
+
+	c.writePacket(captureIPPacket(src.IP, dst.IP, captureProtocolTCP, seg))
+}
+
+func (c *Capture) writeUDP(src *net.UDPAddr, dst *net.UDPAddr, payload []byte) {
+	pkt := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint16(pkt[0:], uint16(src.Port))
+	binary.BigEndian.PutUint16(pkt[2:], uint16(dst.Port))
+	binary.BigEndian.PutUint16(pkt[4:], uint16(len(pkt)))
+	copy(pkt[8:], payload)
+
+	c.writePacket(captureIPPacket(src.IP, dst.IP, captureProtocolUDP, pkt))
+}
+
+// wrapConn returns a net.Conn that writes the traffic of conn into the capture.
+func (c *Capture) wrapConn(conn net.Conn, isClient bool) net.Conn {
+	cc := &captureConn{
+		Conn:       conn,
+		c:          c,
+		localAddr:  captureTCPAddr(conn.LocalAddr()),
+		remoteAddr: captureTCPAddr(conn.RemoteAddr()),
+		localSeq:   randUint32(),
+		remoteSeq:  randUint32(),
+	}
+
+	// write a synthetic handshake, in order to allow readers
+	// to distinguish connections and their direction.
+	client, server := cc.localAddr, cc.remoteAddr
+	clientSeq, serverSeq := &cc.localSeq, &cc.remoteSeq
+	if !isClient {
+		client, server = server, client
+		clientSeq, serverSeq = serverSeq, clientSeq
+	}
+
+	c.writeTCP(client, server, *clientSeq, 0, captureTCPFlagSYN, nil)
+	(*clientSeq)++
+	c.writeTCP(server, client, *serverSeq, *clientSeq, captureTCPFlagSYN|captureTCPFlagACK, nil)
+	(*serverSeq)++
+	c.writeTCP(client, server, *clientSeq, *serverSeq, captureTCPFlagACK, nil)
+
+	return cc
+}
+
+func captureTCPAddr(addr net.Addr) *net.TCPAddr {
+	if ta, ok := addr.(*net.TCPAddr); ok {
+		return ta
+	}
+	return &net.TCPAddr{}
+}
+
+func captureUDPAddr(addr net.Addr) *net.UDPAddr {
+	if ua, ok := addr.(*net.UDPAddr); ok {
+		return ua
+	}
+	return &net.UDPAddr{}
+}
+
+type captureConn struct {
+	net.Conn
+	c          *Capture
+	localAddr  *net.TCPAddr
+	remoteAddr *net.TCPAddr
+
+	mutex     sync.Mutex
+	localSeq  uint32
+	remoteSeq uint32
+}
+
+func (cc *captureConn) write(fromLocal bool, payload []byte) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	for len(payload) > 0 {
+		l := len(payload)
+		if l > captureMaxSegmentSize {
+			l = captureMaxSegmentSize
+		}
+
+		if fromLocal {
+			cc.c.writeTCP(cc.localAddr, cc.remoteAddr, cc.localSeq, cc.remoteSeq,
+				captureTCPFlagPSH|captureTCPFlagACK, payload[:l])
+			cc.localSeq += uint32(l)
+		} else {
+			cc.c.writeTCP(cc.remoteAddr, cc.localAddr, cc.remoteSeq, cc.localSeq,
+				captureTCPFlagPSH|captureTCPFlagACK, payload[:l])
+			cc.remoteSeq += uint32(l)
+		}
+
+		payload = payload[l:]
+	}
+}
+
+// Read implements net.Conn.
+func (cc *captureConn) Read(b []byte) (int, error) {
+	n, err := cc.Conn.Read(b)
+	if n > 0 {
+		cc.write(false, b[:n])
+	}
+	return n, err
+}
+
+// Write implements net.Conn.
+func (cc *captureConn) Write(b []byte) (int, error) {
+	n, err := cc.Conn.Write(b)
+	if n > 0 {
+		cc.write(true, b[:n])
+	}
+	return n, err
+}
@@ -0,0 +1,246 @@
+package gortsplib
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/pcapng"
+)
+
+func TestCaptureIPPacket(t *testing.T) {
+	pkt := captureIPPacket([]byte{127, 0, 0, 1}, []byte{127, 0, 0, 1}, captureProtocolUDP,
+		[]byte{0x1f, 0x40, 0x8a, 0x8a, 0x00, 0x0c, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04})
+	require.Equal(t, []byte{
+		0x45, 0x00, 0x00, 0x20, 0x00, 0x00, 0x40, 0x00,
+		0x40, 0x11, 0x3c, 0xcb, 0x7f, 0x00, 0x00, 0x01,
+		0x7f, 0x00, 0x00, 0x01, 0x1f, 0x40, 0x8a, 0x8a,
+		0x00, 0x0c, 0x54, 0x03, 0x01, 0x02, 0x03, 0x04,
+	}, pkt)
+
+	parsed, ok := captureParsePacket(&pcapng.Packet{
+		LinkType: pcapng.LinkTypeRaw,
+		Data:     pkt,
+	})
+	require.Equal(t, true, ok)
+	require.Equal(t, &capturePacket{
+		protocol: captureProtocolUDP,
+		src:      captureEndpoint{"127.0.0.1", 8000},
+		dst:      captureEndpoint{"127.0.0.1", 35466},
+		payload:  []byte{0x01, 0x02, 0x03, 0x04},
+	}, parsed)
+
+	pkt = captureIPPacket(nil, []byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		captureProtocolTCP, make([]byte, 20))
+	require.Equal(t, 60, len(pkt))
+	require.Equal(t, byte(0x60), pkt[0])
+}
+
+func TestCaptureReplay(t *testing.T) {
+	for _, ca := range []string{
+		"server udp",
+		"client tcp",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gortsplib-capture")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			var buf bytes.Buffer
+			capt, err := NewCapture(&buf)
+			require.NoError(t, err)
+
+			track, err := NewTrackH264(96, &TrackConfigH264{
+				SPS: testRecorderSPS,
+				PPS: testRecorderPPS,
+			})
+			require.NoError(t, err)
+
+			stream := NewServerStream(Tracks{track})
+			defer stream.Close()
+
+			var sent [][]byte
+			for i := 0; i < 3; i++ {
+				byts, err := (&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: uint16(100 + i),
+						Timestamp:      uint32(i * 9000),
+						SSRC:           0x38F27A2F,
+					},
+					Payload: []byte{0x01, 0x02, 0x03, byte(i)},
+				}).Marshal()
+				require.NoError(t, err)
+				sent = append(sent, byts)
+			}
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						go func() {
+							for _, byts := range sent {
+								time.Sleep(100 * time.Millisecond)
+								stream.WritePacketRTP(0, byts)
+							}
+						}()
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress: "localhost:8560",
+			}
+
+			transport := TransportTCP
+			if ca == "server udp" {
+				s.UDPRTPAddress = "127.0.0.1:8000"
+				s.UDPRTCPAddress = "127.0.0.1:8001"
+				s.Capture = capt
+				transport = TransportUDP
+			}
+
+			err = s.Start()
+			require.NoError(t, err)
+
+			read := func(capt *Capture) ([][]byte, time.Duration) {
+				var mutex sync.Mutex
+				var received [][]byte
+				done := make(chan struct{})
+
+				c := Client{
+					Capture:   capt,
+					Transport: &transport,
+					OnPacketRTP: func(trackID int, payload []byte) {
+						mutex.Lock()
+						defer mutex.Unlock()
+
+						received = append(received, append([]byte(nil), payload...))
+						if len(received) == len(sent) {
+							close(done)
+						}
+					},
+				}
+
+				start := time.Now()
+
+				err := c.StartReading("rtsp://localhost:8560/teststream")
+				require.NoError(t, err)
+				defer c.Close()
+
+				select {
+				case <-done:
+				case <-time.After(5 * time.Second):
+					t.Errorf("packets not received")
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				return received, time.Since(start)
+			}
+
+			var clientCapt *Capture
+			if ca == "client tcp" {
+				clientCapt = capt
+			}
+
+			received, _ := read(clientCapt)
+			require.Equal(t, sent, received)
+
+			s.Close()
+
+			fpath := filepath.Join(dir, "capture.pcapng")
+			err = ioutil.WriteFile(fpath, buf.Bytes(), 0o644)
+			require.NoError(t, err)
+
+			r := &CaptureReplayer{
+				Path:        fpath,
+				RTSPAddress: "localhost:8560",
+			}
+			err = r.Start()
+			require.NoError(t, err)
+			defer r.Close()
+
+			received, elapsed := read(nil)
+			require.Equal(t, sent, received)
+
+			// packets are paced with their recorded timing
+			require.Greater(t, int64(elapsed), int64(250*time.Millisecond))
+		})
+	}
+}
+
+func TestCaptureReplayerErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gortsplib-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	_, err = NewCapture(&buf)
+	require.NoError(t, err)
+
+	emptyPath := filepath.Join(dir, "empty.pcapng")
+	err = ioutil.WriteFile(emptyPath, buf.Bytes(), 0o644)
+	require.NoError(t, err)
+
+	invalidPath := filepath.Join(dir, "invalid.pcapng")
+	err = ioutil.WriteFile(invalidPath, []byte{0x01, 0x02}, 0o644)
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name string
+		path string
+		err  string
+	}{
+		{
+			"empty",
+			emptyPath,
+			"no RTSP connections found",
+		},
+		{
+			"invalid",
+			invalidPath,
+			io.ErrUnexpectedEOF.Error(),
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			r := &CaptureReplayer{
+				Path:        ca.path,
+				RTSPAddress: "localhost:8560",
+			}
+			err := r.Start()
+			require.EqualError(t, err, ca.err)
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCaptureReplayerCloseBeforeStart(t *testing.T) {
+	r := &CaptureReplayer{
+		RTSPAddress: "localhost:8560",
+	}
+	err := r.Close()
+	require.NoError(t, err)
+}
@@ -0,0 +1,635 @@
+package gortsplib
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
+	"github.com/aler9/gortsplib/pkg/pcapng"
+)
+
+type captureEndpoint struct {
+	ip   string
+	port int
+}
+
+type capturePacket struct {
+	time     time.Time
+	protocol byte
+	src      captureEndpoint
+	dst      captureEndpoint
+	seq      uint32
+	flags    byte
+	payload  []byte
+}
+
+// captureParsePacket parses an IP packet that contains a TCP segment or an UDP packet.
+func captureParsePacket(p *pcapng.Packet) (*capturePacket, bool) {
+	byts := p.Data
+
+	switch p.LinkType {
+	case pcapng.LinkTypeEthernet:
+		if len(byts) < 14 {
+			return nil, false
+		}
+		etherType := binary.BigEndian.Uint16(byts[12:])
+		byts = byts[14:]
+
+		if etherType == 0x8100 { // VLAN
+			if len(byts) < 4 {
+				return nil, false
+			}
+			etherType = binary.BigEndian.Uint16(byts[2:])
+			byts = byts[4:]
+		}
+
+		if etherType != 0x0800 && etherType != 0x86DD {
+			return nil, false
+		}
+
+	case pcapng.LinkTypeRaw:
+
+	default:
+		return nil, false
+	}
+
+	if len(byts) < 1 {
+		return nil, false
+	}
+
+	ret := &capturePacket{
+		time: p.Time,
+	}
+
+	switch byts[0] >> 4 {
+	case 4:
+		if len(byts) < 20 {
+			return nil, false
+		}
+
+		headerLen := int(byts[0]&0x0F) * 4
+		totalLen := int(binary.BigEndian.Uint16(byts[2:]))
+		if headerLen < 20 || totalLen < headerLen || totalLen > len(byts) {
+			return nil, false
+		}
+
+		// fragments are not supported
+		if (binary.BigEndian.Uint16(byts[6:]) & 0x3FFF) != 0 {
+			return nil, false
+		}
+
+		ret.protocol = byts[9]
+		ret.src.ip = net.IP(byts[12:16]).String()
+		ret.dst.ip = net.IP(byts[16:20]).String()
+		byts = byts[headerLen:totalLen]
+
+	case 6:
+		if len(byts) < 40 {
+			return nil, false
+		}
+
+		payloadLen := int(binary.BigEndian.Uint16(byts[4:]))
+		if (40 + payloadLen) > len(byts) {
+			return nil, false
+		}
+
+		// extension headers are not supported
+		ret.protocol = byts[6]
+		ret.src.ip = net.IP(byts[8:24]).String()
+		ret.dst.ip = net.IP(byts[24:40]).String()
+		byts = byts[40 : 40+payloadLen]
+
+	default:
+		return nil, false
+	}
+
+	switch ret.protocol {
+	case captureProtocolTCP:
+		if len(byts) < 20 {
+			return nil, false
+		}
+
+		headerLen := int(byts[12]>>4) * 4
+		if headerLen < 20 || headerLen > len(byts) {
+			return nil, false
+		}
+
+		ret.src.port = int(binary.BigEndian.Uint16(byts[0:]))
+		ret.dst.port = int(binary.BigEndian.Uint16(byts[2:]))
+		ret.seq = binary.BigEndian.Uint32(byts[4:])
+		ret.flags = byts[13]
+		ret.payload = byts[headerLen:]
+
+	case captureProtocolUDP:
+		if len(byts) < 8 {
+			return nil, false
+		}
+
+		l := int(binary.BigEndian.Uint16(byts[4:]))
+		if l < 8 || l > len(byts) {
+			return nil, false
+		}
+
+		ret.src.port = int(binary.BigEndian.Uint16(byts[0:]))
+		ret.dst.port = int(binary.BigEndian.Uint16(byts[2:]))
+		ret.payload = byts[8:l]
+
+	default:
+		return nil, false
+	}
+
+	return ret, true
+}
+
+// captureIPMatches checks whether the IP of an UDP packet matches the IP of a TCP
+// connection. UDP listeners can be bound to all interfaces, therefore
+// unspecified IPs match any IP.
+func captureIPMatches(connIP string, udpIP string) bool {
+	return connIP == udpIP || net.ParseIP(udpIP).IsUnspecified()
+}
+
+// captureRequestCounter counts the requests contained into a stream
+// and extracts the client ports of SETUP requests.
+type captureRequestCounter struct {
+	buf         []byte
+	count       int
+	clientPorts []*[2]int
+}
+
+func (rc *captureRequestCounter) process(req *base.Request) {
+	rc.count++
+
+	if req.Method == base.Setup {
+		var th headers.Transport
+		err := th.Read(req.Header["Transport"])
+		if err != nil || th.Protocol != headers.TransportProtocolUDP {
+			rc.clientPorts = append(rc.clientPorts, nil)
+		} else {
+			rc.clientPorts = append(rc.clientPorts, th.ClientPorts)
+		}
+	}
+}
+
+func (rc *captureRequestCounter) write(byts []byte) {
+	rc.buf = append(rc.buf, byts...)
+
+	for len(rc.buf) > 0 {
+		r := bytes.NewReader(rc.buf)
+		br := bufio.NewReader(r)
+
+		var req base.Request
+		var frame base.InterleavedFrame
+		what, err := base.ReadInterleavedFrameOrRequest(&frame, &req, br)
+		if err != nil {
+			// wait for the rest of the message
+			return
+		}
+
+		rc.buf = rc.buf[len(rc.buf)-r.Len()-br.Buffered():]
+
+		if _, ok := what.(*base.Request); ok {
+			rc.process(&req)
+		}
+	}
+}
+
+// captureReplayerEvent is something sent by the server.
+type captureReplayerEvent struct {
+	time time.Time
+
+	// number of requests that must be received before sending the event.
+	requests int
+
+	// data to write into the TCP connection.
+	tcp []byte
+
+	// UDP packet, that must be sent from srcPort
+	// to one of the ports of the setupIndex-th SETUP request.
+	udp        []byte
+	srcPort    int
+	setupIndex int
+	isRTCP     bool
+}
+
+type captureReplayerConn struct {
+	client        captureEndpoint
+	server        captureEndpoint
+	clientNextSeq *uint32
+	serverNextSeq *uint32
+	requests      captureRequestCounter
+	events        []*captureReplayerEvent
+}
+
+func captureTrimSegment(nextSeq **uint32, pkt *capturePacket) []byte {
+	payload := pkt.payload
+	seq := pkt.seq
+
+	if (pkt.flags & captureTCPFlagSYN) != 0 {
+		seq++
+		v := seq
+		*nextSeq = &v
+		return nil
+	}
+
+	if *nextSeq == nil {
+		v := seq
+		*nextSeq = &v
+	}
+
+	// discard retransmitted data
+	if diff := int32(seq - **nextSeq); diff < 0 {
+		if int(-diff) >= len(payload) {
+			return nil
+		}
+		payload = payload[-diff:]
+		seq = **nextSeq
+	}
+
+	**nextSeq = seq + uint32(len(payload))
+	return payload
+}
+
+func captureLoadConns(fpath string) ([]*captureReplayerConn, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := pcapng.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	var conns []*captureReplayerConn
+	active := make(map[[2]captureEndpoint]*captureReplayerConn)
+
+	for {
+		p, err := r.ReadPacket()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		pkt, ok := captureParsePacket(p)
+		if !ok {
+			continue
+		}
+
+		if pkt.protocol == captureProtocolUDP {
+			// find the connection whose client requested the destination port
+			for i := len(conns) - 1; i >= 0; i-- {
+				conn := conns[i]
+				if !captureIPMatches(conn.server.ip, pkt.src.ip) ||
+					!captureIPMatches(conn.client.ip, pkt.dst.ip) {
+					continue
+				}
+
+				found := false
+				for j, ports := range conn.requests.clientPorts {
+					if ports != nil && (ports[0] == pkt.dst.port || ports[1] == pkt.dst.port) {
+						conn.events = append(conn.events, &captureReplayerEvent{
+							time:       pkt.time,
+							requests:   conn.requests.count,
+							udp:        pkt.payload,
+							srcPort:    pkt.src.port,
+							setupIndex: j,
+							isRTCP:     ports[1] == pkt.dst.port,
+						})
+						found = true
+						break
+					}
+				}
+
+				if found {
+					break
+				}
+			}
+			continue
+		}
+
+		isSYN := (pkt.flags&captureTCPFlagSYN) != 0 && (pkt.flags&captureTCPFlagACK) == 0
+
+		conn, fromClient := active[[2]captureEndpoint{pkt.src, pkt.dst}], true
+		if conn == nil {
+			conn, fromClient = active[[2]captureEndpoint{pkt.dst, pkt.src}], false
+		}
+
+		// a SYN starts a new connection
+		if conn != nil && isSYN && fromClient && conn.clientNextSeq != nil &&
+			*conn.clientNextSeq != pkt.seq+1 {
+			conn = nil
+		}
+
+		if conn == nil {
+			// detect the direction of the connection
+			switch {
+			case isSYN:
+				fromClient = true
+
+			case bytes.HasPrefix(pkt.payload, []byte("RTSP/")):
+				fromClient = false
+
+			case bytes.Contains(pkt.payload, []byte(" RTSP/1.0\r\n")):
+				fromClient = true
+
+			default:
+				continue
+			}
+
+			conn = &captureReplayerConn{}
+			if fromClient {
+				conn.client, conn.server = pkt.src, pkt.dst
+			} else {
+				conn.client, conn.server = pkt.dst, pkt.src
+			}
+
+			conns = append(conns, conn)
+			active[[2]captureEndpoint{conn.client, conn.server}] = conn
+		}
+
+		if fromClient {
+			payload := captureTrimSegment(&conn.clientNextSeq, pkt)
+			if len(payload) > 0 {
+				conn.requests.write(payload)
+			}
+		} else {
+			payload := captureTrimSegment(&conn.serverNextSeq, pkt)
+			if len(payload) > 0 {
+				conn.events = append(conn.events, &captureReplayerEvent{
+					time:     pkt.time,
+					requests: conn.requests.count,
+					tcp:      payload,
+				})
+			}
+		}
+	}
+
+	return conns, nil
+}
+
+// CaptureReplayer is a fake server that replays the server side of the RTSP
+// sessions contained into a pcapng file, like the ones generated by Capture.
+// Every incoming connection is associated with the next recorded connection.
+// Recorded responses and packets are sent as soon as the client has sent
+// the same number of requests that were sent in the recorded session,
+// and are then paced with their recorded timing.
+// UDP packets are sent from the recorded server ports
+// to the client ports of the corresponding SETUP requests.
+type CaptureReplayer struct {
+	// path of the pcapng file.
+	Path string
+	// the RTSP address of the replayer.
+	RTSPAddress string
+
+	//
+	// system functions
+	//
+	// function used to initialize the TCP listener.
+	// It defaults to net.Listen.
+	Listen func(network string, address string) (net.Listener, error)
+	// function used to initialize UDP listeners.
+	// It defaults to net.ListenPacket.
+	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	conns       []*captureReplayerConn
+	ctx         context.Context
+	ctxCancel   func()
+	wg          sync.WaitGroup
+	tcpListener net.Listener
+	udpConns    map[int]net.PacketConn
+}
+
+// Start starts the replayer.
+func (r *CaptureReplayer) Start() error {
+	if r.Listen == nil {
+		r.Listen = net.Listen
+	}
+	if r.ListenPacket == nil {
+		r.ListenPacket = net.ListenPacket
+	}
+
+	if r.RTSPAddress == "" {
+		return fmt.Errorf("RTSPAddress not provided")
+	}
+
+	host, _, err := net.SplitHostPort(r.RTSPAddress)
+	if err != nil {
+		return err
+	}
+
+	r.conns, err = captureLoadConns(r.Path)
+	if err != nil {
+		return err
+	}
+
+	if r.conns == nil {
+		return fmt.Errorf("no RTSP connections found")
+	}
+
+	r.udpConns = make(map[int]net.PacketConn)
+
+	for _, conn := range r.conns {
+		for _, ev := range conn.events {
+			if ev.udp == nil {
+				continue
+			}
+
+			if _, ok := r.udpConns[ev.srcPort]; ok {
+				continue
+			}
+
+			pc, err := r.ListenPacket("udp", net.JoinHostPort(host, strconv.FormatInt(int64(ev.srcPort), 10)))
+			if err != nil {
+				r.closeUDPConns()
+				return err
+			}
+
+			r.udpConns[ev.srcPort] = pc
+		}
+	}
+
+	r.tcpListener, err = r.Listen("tcp", r.RTSPAddress)
+	if err != nil {
+		r.closeUDPConns()
+		return err
+	}
+
+	r.ctx, r.ctxCancel = context.WithCancel(context.Background())
+
+	r.wg.Add(1)
+	go r.run()
+
+	return nil
+}
+
+// Close closes the replayer and all its connections.
+func (r *CaptureReplayer) Close() error {
+	// the replayer has not been started
+	if r.ctxCancel == nil {
+		return nil
+	}
+
+	r.ctxCancel()
+	r.tcpListener.Close()
+	r.wg.Wait()
+	r.closeUDPConns()
+	return nil
+}
+
+func (r *CaptureReplayer) closeUDPConns() {
+	for _, pc := range r.udpConns {
+		pc.Close()
+	}
+}
+
+func (r *CaptureReplayer) run() {
+	defer r.wg.Done()
+
+	for i := 0; ; i++ {
+		nconn, err := r.tcpListener.Accept()
+		if err != nil {
+			return
+		}
+
+		// all recorded connections have been replayed
+		if i >= len(r.conns) {
+			nconn.Close()
+			continue
+		}
+
+		r.wg.Add(1)
+		go r.runConn(nconn, r.conns[i])
+	}
+}
+
+func (r *CaptureReplayer) runConn(nconn net.Conn, rc *captureReplayerConn) {
+	defer r.wg.Done()
+	defer nconn.Close()
+
+	var mutex sync.Mutex
+	var live captureRequestCounter
+	requestReceived := make(chan struct{}, 1)
+	readDone := make(chan struct{})
+
+	go func() {
+		defer close(readDone)
+
+		br := bufio.NewReader(nconn)
+		var req base.Request
+		var frame base.InterleavedFrame
+
+		for {
+			what, err := base.ReadInterleavedFrameOrRequest(&frame, &req, br)
+			if err != nil {
+				return
+			}
+
+			if _, ok := what.(*base.Request); ok {
+				mutex.Lock()
+				live.process(&req)
+				mutex.Unlock()
+
+				select {
+				case requestReceived <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	// close the connection when the replayer is closed
+	go func() {
+		select {
+		case <-r.ctx.Done():
+			nconn.Close()
+		case <-readDone:
+		}
+	}()
+
+	t := time.NewTimer(0)
+	defer t.Stop()
+	<-t.C
+
+	var recordedRef time.Time
+	var liveRef time.Time
+	remoteIP := captureTCPAddr(nconn.RemoteAddr()).IP
+
+	for _, ev := range rc.events {
+		waited := false
+
+		for {
+			mutex.Lock()
+			count := live.count
+			mutex.Unlock()
+
+			if count >= ev.requests {
+				break
+			}
+
+			waited = true
+
+			select {
+			case <-requestReceived:
+			case <-readDone:
+				return
+			}
+		}
+
+		if waited || liveRef.IsZero() {
+			recordedRef = ev.time
+			liveRef = time.Now()
+		} else if wait := time.Until(liveRef.Add(ev.time.Sub(recordedRef))); wait > 0 {
+			t.Reset(wait)
+
+			select {
+			case <-t.C:
+			case <-readDone:
+				return
+			}
+		}
+
+		if ev.tcp != nil {
+			_, err := nconn.Write(ev.tcp)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		mutex.Lock()
+		var ports *[2]int
+		if ev.setupIndex < len(live.clientPorts) {
+			ports = live.clientPorts[ev.setupIndex]
+		}
+		mutex.Unlock()
+
+		if ports == nil {
+			continue
+		}
+
+		port := ports[0]
+		if ev.isRTCP {
+			port = ports[1]
+		}
+
+		r.udpConns[ev.srcPort].WriteTo(ev.udp, &net.UDPAddr{
+			IP:   remoteIP,
+			Port: port,
+		})
+	}
+
+	<-readDone
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/aler9/gortsplib/pkg/headers"
 	"github.com/aler9/gortsplib/pkg/liberrors"
 	"github.com/aler9/gortsplib/pkg/multibuffer"
+	"github.com/aler9/gortsplib/pkg/ntpsync"
+	"github.com/aler9/gortsplib/pkg/rtcpnacker"
 	"github.com/aler9/gortsplib/pkg/rtcpreceiver"
 	"github.com/aler9/gortsplib/pkg/rtcpsender"
 )
@@ -36,6 +38,7 @@ const (
 	clientWriteBufferSize    = 4096
 	clientCheckStreamPeriod  = 1 * time.Second
 	clientUDPKeepalivePeriod = 20 * time.Second
+	clientNACKPeriod         = 100 * time.Millisecond
 )
 
 func isAnyPort(p int) bool {
@@ -58,6 +61,8 @@ type clientTrack struct {
 	udpRTCPListener *clientUDPListener
 	tcpChannel      int
 	rtcpReceiver    *rtcpreceiver.RTCPReceiver
+	rtcpNacker      *rtcpnacker.RTCPNacker
+	ntpSync         *ntpsync.Synchronizer
 	rtcpSender      *rtcpsender.RTCPSender
 }
 
@@ -135,6 +140,16 @@ type Client struct {
 	OnPacketRTP func(int, []byte)
 	// called when a RTCP packet arrives.
 	OnPacketRTCP func(int, []byte)
+	// called when the jitter buffer detects that RTP packets are lost.
+	OnPacketsLost func(int, int)
+	// called when the server asks for a keyframe of a track that is being published.
+	OnKeyframeRequest func(int)
+
+	//
+	// debugging
+	//
+	// if set, the traffic of the client is written into it.
+	Capture *Capture
 
 	//
 	// RTSP parameters
@@ -163,6 +178,10 @@ type Client struct {
 	// at least a packet within this timeout.
 	// It defaults to 3 seconds.
 	InitialUDPReadTimeout time.Duration
+	// if greater than zero, RTP packets received with UDP are reordered
+	// by sequence number, waiting at most this duration for missing packets.
+	// It defaults to zero (disabled).
+	JitterBufferLatency time.Duration
 	// read buffer count.
 	// If greater than 1, allows to pass buffers to routines different than the one
 	// that is reading frames.
@@ -216,6 +235,7 @@ type Client struct {
 	checkStreamInitial bool
 	tcpLastFrameTime   int64
 	keepaliveTimer     *time.Timer
+	nackTimer          *time.Timer
 	closeError         error
 
 	// connCloser channels
@@ -249,6 +269,14 @@ func (c *Client) Start(scheme string, host string) error {
 		c.OnPacketRTCP = func(trackID int, payload []byte) {
 		}
 	}
+	if c.OnPacketsLost == nil {
+		c.OnPacketsLost = func(trackID int, count int) {
+		}
+	}
+	if c.OnKeyframeRequest == nil {
+		c.OnKeyframeRequest = func(trackID int) {
+		}
+	}
 
 	// RTSP parameters
 	if c.ReadTimeout == 0 {
@@ -295,6 +323,7 @@ func (c *Client) Start(scheme string, host string) error {
 	c.reportTimer = emptyTimer()
 	c.checkStreamTimer = emptyTimer()
 	c.keepaliveTimer = emptyTimer()
+	c.nackTimer = emptyTimer()
 	c.options = make(chan optionsReq)
 	c.describe = make(chan describeReq)
 	c.announce = make(chan announceReq)
@@ -373,33 +402,36 @@ func (c *Client) StartPublishing(address string, tracks Tracks) error {
 		return err
 	}
 
-	_, err = c.Options(u)
+	err = c.startPublishing(u, tracks)
 	if err != nil {
 		c.Close()
 		return err
 	}
 
+	return nil
+}
+
+// startPublishing announces the tracks and starts recording on a started client.
+func (c *Client) startPublishing(u *base.URL, tracks Tracks) error {
+	_, err := c.Options(u)
+	if err != nil {
+		return err
+	}
+
 	_, err = c.Announce(u, tracks)
 	if err != nil {
-		c.Close()
 		return err
 	}
 
 	for _, track := range tracks {
 		_, err := c.Setup(false, u, track, 0, 0)
 		if err != nil {
-			c.Close()
 			return err
 		}
 	}
 
 	_, err = c.Record()
-	if err != nil {
-		c.Close()
-		return err
-	}
-
-	return nil
+	return err
 }
 
 // Close closes all client resources and waits for them to close.
@@ -490,6 +522,19 @@ func (c *Client) run() {
 					c.reportTimer = time.NewTimer(c.senderReportPeriod)
 				}
 
+			case <-c.nackTimer.C:
+				now := time.Now()
+				for trackID, cct := range c.tracks {
+					if cct.rtcpNacker != nil {
+						nack := cct.rtcpNacker.Report(now)
+						if nack != nil {
+							c.WritePacketRTCP(trackID, nack)
+						}
+					}
+				}
+
+				c.nackTimer = time.NewTimer(clientNACKPeriod)
+
 			case <-c.checkStreamTimer.C:
 				if *c.protocol == TransportUDP ||
 					*c.protocol == TransportUDPMulticast {
@@ -691,6 +736,7 @@ func (c *Client) playRecordStart() {
 			c.checkStreamTimer = time.NewTimer(c.InitialUDPReadTimeout)
 			c.checkStreamInitial = true
 			c.keepaliveTimer = time.NewTimer(clientUDPKeepalivePeriod)
+			c.nackTimer = time.NewTimer(clientNACKPeriod)
 
 		case TransportUDPMulticast:
 			c.checkStreamTimer = time.NewTimer(clientCheckStreamPeriod)
@@ -738,13 +784,16 @@ func (c *Client) runReader() error {
 					c.OnPacketRTP(trackID, payload)
 				} else {
 					c.tracks[trackID].rtcpReceiver.ProcessPacketRTCP(now, payload)
+					if c.tracks[trackID].ntpSync != nil {
+						c.tracks[trackID].ntpSync.ProcessPacketRTCP(payload)
+					}
 					c.OnPacketRTCP(trackID, payload)
 				}
 			}
 		} else {
 			processFunc = func(trackID int, isRTP bool, payload []byte) {
 				if !isRTP {
-					c.OnPacketRTCP(trackID, payload)
+					c.processRecordPacketRTCP(trackID, payload)
 				}
 			}
 		}
@@ -786,6 +835,7 @@ func (c *Client) playRecordStop(isClosing bool) {
 	c.reportTimer = emptyTimer()
 	c.checkStreamTimer = emptyTimer()
 	c.keepaliveTimer = emptyTimer()
+	c.nackTimer = emptyTimer()
 
 	// forbid writing
 	c.writeMutex.Lock()
@@ -826,6 +876,10 @@ func (c *Client) connOpen() error {
 		return nconn
 	}()
 
+	if c.Capture != nil {
+		conn = c.Capture.wrapConn(conn, true)
+	}
+
 	c.nconn = nconn
 	c.br = bufio.NewReaderSize(conn, clientReadBufferSize)
 	c.bw = bufio.NewWriterSize(conn, clientWriteBufferSize)
@@ -1402,6 +1456,16 @@ func (c *Client) doSetup(
 	if mode == headers.TransportModePlay {
 		c.state = clientStatePrePlay
 		cct.rtcpReceiver = rtcpreceiver.New(nil, clockRate)
+
+		// absolute times can't be computed without the clock rate
+		if clockRate > 0 {
+			cct.ntpSync = ntpsync.New(clockRate)
+		}
+
+		// request retransmissions only when the server supports them
+		if proto == TransportUDP && track.hasRTCPFeedback("nack") {
+			cct.rtcpNacker = rtcpnacker.New(nil)
+		}
 	} else {
 		c.state = clientStatePreRecord
 		cct.rtcpSender = rtcpsender.New(clockRate)
@@ -1752,6 +1816,44 @@ func (c *Client) WritePacketRTP(trackID int, payload []byte) error {
 	}
 }
 
+func (c *Client) processRecordPacketRTCP(trackID int, payload []byte) {
+	pli, fir := keyframeRequestTypes(payload)
+	if pli || fir {
+		c.OnKeyframeRequest(trackID)
+	}
+
+	c.OnPacketRTCP(trackID, payload)
+}
+
+// PacketNTP returns the absolute (NTP) time of a RTP packet of a track that is being read,
+// computed by using the RTCP sender reports sent by the server.
+// The absolute time of an access unit decoded with rtph264 or rtpaac
+// is the one of the packet that completed the access unit.
+// It returns false when no sender report has been received yet,
+// or when the clock rate of the track is unknown.
+func (c *Client) PacketNTP(trackID int, payload []byte) (time.Time, bool) {
+	cct, ok := c.tracks[trackID]
+	if !ok || cct.ntpSync == nil {
+		return time.Time{}, false
+	}
+
+	return cct.ntpSync.PacketNTPTime(payload)
+}
+
+// RequestKeyframe asks the server to send a keyframe of a track that is being read,
+// by sending a RTCP Picture Loss Indication (PLI) and a Full Intra Request (FIR).
+func (c *Client) RequestKeyframe(trackID int) error {
+	cct, ok := c.tracks[trackID]
+	if !ok || cct.rtcpReceiver == nil {
+		return fmt.Errorf("track %d is not being read", trackID)
+	}
+
+	byts := cct.rtcpReceiver.PictureLossIndication()
+	byts = append(byts, cct.rtcpReceiver.FullIntraRequest()...)
+
+	return c.WritePacketRTCP(trackID, byts)
+}
+
 // WritePacketRTCP writes a RTCP packet.
 func (c *Client) WritePacketRTCP(trackID int, payload []byte) error {
 	c.writeMutex.RLock()
@@ -2343,3 +2343,205 @@ func TestClientReadSeek(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestClientReadNTP(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			track, err := NewTrackH264(96, &TrackConfigH264{
+				SPS: testRecorderSPS,
+				PPS: testRecorderPPS,
+			})
+			require.NoError(t, err)
+
+			stream := NewServerStream(Tracks{track})
+			defer stream.Close()
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						go func() {
+							time.Sleep(100 * time.Millisecond)
+
+							byts, _ := (&rtcp.SenderReport{
+								SSRC:    0x38F27A2F,
+								NTPTime: uint64(2208988800+1000) << 32,
+								RTPTime: 54352,
+							}).Marshal()
+							stream.WritePacketRTCP(0, byts)
+
+							time.Sleep(100 * time.Millisecond)
+
+							byts, _ = (&rtp.Packet{
+								Header: rtp.Header{
+									Version:        2,
+									PayloadType:    96,
+									SequenceNumber: 946,
+									Timestamp:      54352 + 45000,
+									SSRC:           0x38F27A2F,
+								},
+								Payload: []byte{0x05, 0x02, 0x03, 0x04},
+							}).Marshal()
+							stream.WritePacketRTP(0, byts)
+						}()
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress: "localhost:8554",
+			}
+
+			if transport == "udp" {
+				s.UDPRTPAddress = "127.0.0.1:8000"
+				s.UDPRTCPAddress = "127.0.0.1:8001"
+			}
+
+			err = s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			done := make(chan time.Time, 1)
+
+			var c *Client
+			c = &Client{
+				Transport: func() *Transport {
+					if transport == "udp" {
+						v := TransportUDP
+						return &v
+					}
+					v := TransportTCP
+					return &v
+				}(),
+				OnPacketRTP: func(trackID int, payload []byte) {
+					ntp, ok := c.PacketNTP(trackID, payload)
+					require.Equal(t, true, ok)
+					done <- ntp
+				},
+			}
+
+			err = c.StartReading("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+			defer c.Close()
+
+			select {
+			case ntp := <-done:
+				require.Equal(t, time.Unix(1000, 500000000).UnixNano(), ntp.UnixNano())
+			case <-time.After(2 * time.Second):
+				t.Errorf("packet not received")
+			}
+
+			_, ok := c.PacketNTP(1, nil)
+			require.Equal(t, false, ok)
+		})
+	}
+}
+
+func TestClientReadNTPUnknownClockRate(t *testing.T) {
+	track := &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "application",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"98"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "98 private/0",
+				},
+			},
+		},
+	}
+
+	stream := NewServerStream(Tracks{track})
+	defer stream.Close()
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+
+					byts, _ := (&rtcp.SenderReport{
+						SSRC:    0x38F27A2F,
+						NTPTime: uint64(2208988800+1000) << 32,
+						RTPTime: 54352,
+					}).Marshal()
+					stream.WritePacketRTCP(0, byts)
+
+					time.Sleep(100 * time.Millisecond)
+
+					byts, _ = (&rtp.Packet{
+						Header: rtp.Header{
+							Version:        2,
+							PayloadType:    98,
+							SequenceNumber: 946,
+							Timestamp:      54352 + 45000,
+							SSRC:           0x38F27A2F,
+						},
+						Payload: []byte{0x05, 0x02, 0x03, 0x04},
+					}).Marshal()
+					stream.WritePacketRTP(0, byts)
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	done := make(chan bool, 1)
+
+	var c *Client
+	c = &Client{
+		Transport: func() *Transport {
+			v := TransportTCP
+			return &v
+		}(),
+		OnPacketRTP: func(trackID int, payload []byte) {
+			_, ok := c.PacketNTP(trackID, payload)
+			done <- ok
+		},
+	}
+
+	err = c.StartReading("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+	defer c.Close()
+
+	select {
+	case ok := <-done:
+		require.Equal(t, false, ok)
+	case <-time.After(2 * time.Second):
+		t.Errorf("packet not received")
+	}
+}
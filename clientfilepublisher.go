@@ -0,0 +1,431 @@
+package gortsplib
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/h264"
+	"github.com/aler9/gortsplib/pkg/rtpaac"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+const (
+	clientFilePublisherH264PayloadType = 96
+	clientFilePublisherAACPayloadType  = 97
+	clientFilePublisherDefaultFPS      = 25
+)
+
+type clientFileAU struct {
+	dts  time.Duration
+	pts  time.Duration
+	data [][]byte
+}
+
+type clientFileTrack struct {
+	track       *Track
+	aus         []*clientFileAU
+	duration    time.Duration
+	h264Encoder *rtph264.Encoder
+	aacEncoder  *rtpaac.Encoder
+}
+
+// ClientFilePublisher publishes the content of a H264 file in Annex-B format
+// and/or of an AAC file in ADTS format, in real time.
+// Tracks are built from the SPS and PPS of the H264 file and from
+// the ADTS header of the AAC file.
+// Since Annex-B files don't contain timestamps, access units are
+// supposed to have a constant frame rate. Access units are stored in
+// decoding order, and their presentation order is obtained from their
+// picture order count (POC).
+type ClientFilePublisher struct {
+	// client used to publish.
+	// It defaults to a Client with default settings.
+	Client *Client
+
+	// path of a H264 file in Annex-B format.
+	H264Path string
+
+	// path of an AAC file in ADTS format.
+	AACPath string
+
+	// frame rate of the H264 file.
+	// It defaults to the frame rate contained in the SPS, or to 25.
+	FPS float64
+
+	// whether to restart from the beginning when the end of the files is reached.
+	Loop bool
+}
+
+func (p *ClientFilePublisher) readH264() (*clientFileTrack, error) {
+	byts, err := ioutil.ReadFile(p.H264Path)
+	if err != nil {
+		return nil, err
+	}
+
+	nalus, err := h264.DecodeAnnexB(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	var sps []byte
+	var pps []byte
+	var aus [][][]byte
+	var cur [][]byte
+	curHasSlice := false
+
+	for _, nalu := range nalus {
+		typ := h264.NALUType(nalu[0] & 0x1F)
+
+		// an access unit ends when a slice has been received and
+		// a NALU that starts a new access unit is found.
+		// ref: ISO 14496-10, 7.4.1.2.3
+		if curHasSlice {
+			switch typ {
+			case h264.NALUTypeAccessUnitDelimiter, h264.NALUTypeSPS,
+				h264.NALUTypePPS, h264.NALUTypeSEI:
+				aus = append(aus, cur)
+				cur = nil
+				curHasSlice = false
+
+			case h264.NALUTypeIDR, h264.NALUTypeNonIDR:
+				// first_mb_in_slice is zero
+				if len(nalu) >= 2 && (nalu[1]&0x80) != 0 {
+					aus = append(aus, cur)
+					cur = nil
+					curHasSlice = false
+				}
+			}
+		}
+
+		switch typ {
+		case h264.NALUTypeSPS:
+			if sps == nil {
+				sps = nalu
+			}
+
+		case h264.NALUTypePPS:
+			if pps == nil {
+				pps = nalu
+			}
+
+		case h264.NALUTypeIDR, h264.NALUTypeNonIDR:
+			curHasSlice = true
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			// AUDs are not needed by RTP
+			continue
+		}
+
+		cur = append(cur, nalu)
+	}
+
+	if curHasSlice {
+		aus = append(aus, cur)
+	}
+
+	if sps == nil || pps == nil {
+		return nil, fmt.Errorf("SPS or PPS not found")
+	}
+
+	track, err := NewTrackH264(clientFilePublisherH264PayloadType, &TrackConfigH264{
+		SPS: sps,
+		PPS: pps,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fps := p.FPS
+	if fps == 0 {
+		var s h264.SPS
+		err := s.Unmarshal(sps)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPS: %v", err)
+		}
+
+		fps = s.FPS()
+		if fps == 0 {
+			fps = clientFilePublisherDefaultFPS
+		}
+	}
+
+	displayIdxs, err := h264DisplayOrder(sps, pps, aus)
+	if err != nil {
+		return nil, err
+	}
+
+	// access units are sent in decoding order, with a delay that allows
+	// the DTS to be lower or equal than the PTS.
+	delay := 0
+	for i, displayIdx := range displayIdxs {
+		if (i - displayIdx) > delay {
+			delay = i - displayIdx
+		}
+	}
+
+	ft := &clientFileTrack{
+		track:       track,
+		h264Encoder: rtph264.NewEncoder(clientFilePublisherH264PayloadType, nil, nil, nil),
+	}
+
+	for i, au := range aus {
+		ft.aus = append(ft.aus, &clientFileAU{
+			dts:  time.Duration(float64(i-delay) * float64(time.Second) / fps),
+			pts:  time.Duration(float64(displayIdxs[i]) * float64(time.Second) / fps),
+			data: au,
+		})
+	}
+	ft.duration = time.Duration(float64(len(aus)) * float64(time.Second) / fps)
+
+	return ft, nil
+}
+
+// h264DisplayOrder returns the position in display order of each access unit.
+func h264DisplayOrder(sps []byte, pps []byte, aus [][][]byte) ([]int, error) {
+	pocExtractor := h264.NewPOCExtractor()
+	err := pocExtractor.SetParams(sps, pps)
+	if err != nil {
+		return nil, err
+	}
+
+	pocs := make([]int32, len(aus))
+	displayIdxs := make([]int, len(aus))
+
+	// access units of a IDR period are displayed after the ones of the previous periods
+	sortPeriod := func(start int, end int) {
+		idxs := make([]int, end-start)
+		for i := range idxs {
+			idxs[i] = start + i
+		}
+
+		sort.SliceStable(idxs, func(a, b int) bool {
+			return pocs[idxs[a]] < pocs[idxs[b]]
+		})
+
+		for rank, i := range idxs {
+			displayIdxs[i] = start + rank
+		}
+	}
+
+	periodStart := 0
+
+	for i, au := range aus {
+		poc, idr, err := pocExtractor.Extract(au)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compute the POC of access unit %d: %v", i, err)
+		}
+
+		if idr && i != periodStart {
+			sortPeriod(periodStart, i)
+			periodStart = i
+		}
+
+		pocs[i] = poc
+	}
+
+	sortPeriod(periodStart, len(aus))
+
+	return displayIdxs, nil
+}
+
+func (p *ClientFilePublisher) readAAC() (*clientFileTrack, error) {
+	byts, err := ioutil.ReadFile(p.AACPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkts, err := aac.DecodeADTS(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkts) == 0 {
+		return nil, fmt.Errorf("AAC file is empty")
+	}
+
+	track, err := NewTrackAAC(clientFilePublisherAACPayloadType, &TrackConfigAAC{
+		Type:         pkts[0].Type,
+		SampleRate:   pkts[0].SampleRate,
+		ChannelCount: pkts[0].ChannelCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ft := &clientFileTrack{
+		track: track,
+		aacEncoder: rtpaac.NewEncoder(clientFilePublisherAACPayloadType,
+			pkts[0].SampleRate, nil, nil, nil),
+	}
+
+	auDuration := func(n int) time.Duration {
+		return time.Duration(n) * 1024 * time.Second / time.Duration(pkts[0].SampleRate)
+	}
+
+	for i, pkt := range pkts {
+		ft.aus = append(ft.aus, &clientFileAU{
+			dts:  auDuration(i),
+			pts:  auDuration(i),
+			data: [][]byte{pkt.AU},
+		})
+	}
+	ft.duration = auDuration(len(pkts))
+
+	return ft, nil
+}
+
+// Publish connects to a server and publishes the files.
+// It returns when the end of the files is reached (if Loop is false),
+// when ctx is canceled or when an error occurs.
+func (p *ClientFilePublisher) Publish(ctx context.Context, address string) error {
+	var tracks []*clientFileTrack
+
+	if p.H264Path != "" {
+		ft, err := p.readH264()
+		if err != nil {
+			return err
+		}
+		tracks = append(tracks, ft)
+	}
+
+	if p.AACPath != "" {
+		ft, err := p.readAAC()
+		if err != nil {
+			return err
+		}
+		tracks = append(tracks, ft)
+	}
+
+	if tracks == nil {
+		return fmt.Errorf("at least one file must be provided")
+	}
+
+	c := p.Client
+	if c == nil {
+		c = &Client{}
+	}
+
+	var gtracks Tracks
+	duration := time.Duration(0)
+	for _, ft := range tracks {
+		gtracks = append(gtracks, ft.track)
+
+		if ft.duration > duration {
+			duration = ft.duration
+		}
+	}
+
+	u, err := base.ParseURL(address)
+	if err != nil {
+		return err
+	}
+
+	err = c.Start(u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// close the client when ctx is canceled while publishing is being started,
+	// in order to interrupt pending requests.
+	startDone := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-startDone:
+		}
+	}()
+
+	err = c.startPublishing(u, gtracks)
+	close(startDone)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	t := time.NewTimer(0)
+	defer t.Stop()
+	<-t.C
+
+	startTime := time.Now()
+	loopOffset := time.Duration(0)
+
+	for {
+		next := make([]int, len(tracks))
+
+		for {
+			// pick the access unit with the lowest DTS
+			trackID := -1
+			for i, ft := range tracks {
+				if next[i] < len(ft.aus) &&
+					(trackID < 0 || ft.aus[next[i]].dts < tracks[trackID].aus[next[trackID]].dts) {
+					trackID = i
+				}
+			}
+
+			if trackID < 0 {
+				break
+			}
+
+			ft := tracks[trackID]
+			au := ft.aus[next[trackID]]
+			next[trackID]++
+			pts := loopOffset + au.pts
+
+			wait := time.Until(startTime.Add(loopOffset + au.dts))
+			if wait > 0 {
+				t.Reset(wait)
+
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			} else {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+				}
+			}
+
+			var pkts []*rtp.Packet
+			if ft.h264Encoder != nil {
+				pkts, err = ft.h264Encoder.Encode(au.data, pts)
+			} else {
+				pkts, err = ft.aacEncoder.Encode(au.data, pts)
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, pkt := range pkts {
+				byts, err := pkt.Marshal()
+				if err != nil {
+					return err
+				}
+
+				err = c.WritePacketRTP(trackID, byts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if !p.Loop {
+			return nil
+		}
+
+		loopOffset += duration
+	}
+}
@@ -0,0 +1,361 @@
+package gortsplib
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icza/bitio"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+func writeTestPublisherFiles(t *testing.T, dir string) (string, string) {
+	var nalus [][]byte
+	for i := 0; i < 20; i++ {
+		nalus = append(nalus, []byte{byte(h264.NALUTypeAccessUnitDelimiter), 0xf0})
+		if (i % 10) == 0 {
+			nalus = append(nalus, testRecorderSPS, testRecorderPPS, []byte{0x65, 0x88, 0x86})
+		} else {
+			nalus = append(nalus, []byte{0x41, 0x9a, 0x30})
+		}
+	}
+
+	byts, err := h264.EncodeAnnexB(nalus)
+	require.NoError(t, err)
+
+	h264Path := filepath.Join(dir, "test.h264")
+	err = ioutil.WriteFile(h264Path, byts, 0o644)
+	require.NoError(t, err)
+
+	var pkts []*aac.ADTSPacket
+	for i := 0; i < 10; i++ {
+		pkts = append(pkts, &aac.ADTSPacket{
+			Type:         2,
+			SampleRate:   48000,
+			ChannelCount: 2,
+			AU:           []byte{0x01, 0x02, 0x03, 0x04},
+		})
+	}
+
+	byts, err = aac.EncodeADTS(pkts)
+	require.NoError(t, err)
+
+	aacPath := filepath.Join(dir, "test.aac")
+	err = ioutil.WriteFile(aacPath, byts, 0o644)
+	require.NoError(t, err)
+
+	return h264Path, aacPath
+}
+
+func TestClientFilePublisher(t *testing.T) {
+	for _, ca := range []string{"once", "loop"} {
+		t.Run(ca, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gortsplib-publisher")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			h264Path, aacPath := writeTestPublisherFiles(t, dir)
+
+			var mutex sync.Mutex
+			timestamps := make(map[int]map[uint32]struct{})
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						require.Equal(t, 2, len(ctx.Tracks))
+						require.Equal(t, true, ctx.Tracks[0].IsH264())
+						require.Equal(t, true, ctx.Tracks[1].IsAAC())
+
+						conf, err := ctx.Tracks[0].ExtractConfigH264()
+						require.NoError(t, err)
+						require.Equal(t, testRecorderSPS, conf.SPS)
+						require.Equal(t, testRecorderPPS, conf.PPS)
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onPacketRTP: func(ctx *ServerHandlerOnPacketRTPCtx) {
+						var pkt rtp.Packet
+						err := pkt.Unmarshal(ctx.Payload)
+						require.NoError(t, err)
+
+						mutex.Lock()
+						defer mutex.Unlock()
+
+						if timestamps[ctx.TrackID] == nil {
+							timestamps[ctx.TrackID] = make(map[uint32]struct{})
+						}
+						timestamps[ctx.TrackID][pkt.Timestamp] = struct{}{}
+					},
+				},
+				RTSPAddress: "localhost:8559",
+			}
+
+			err = s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			p := &ClientFilePublisher{
+				Client: &Client{
+					Transport: func() *Transport {
+						v := TransportTCP
+						return &v
+					}(),
+				},
+				H264Path: h264Path,
+				AACPath:  aacPath,
+				FPS:      100,
+				Loop:     ca == "loop",
+			}
+
+			start := time.Now()
+
+			if ca == "once" {
+				err = p.Publish(context.Background(), "rtsp://localhost:8559/teststream")
+				require.NoError(t, err)
+
+				// content is sent in real time
+				require.Greater(t, int64(time.Since(start)), int64(190*time.Millisecond))
+			} else {
+				ctx, ctxCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+				defer ctxCancel()
+
+				err = p.Publish(ctx, "rtsp://localhost:8559/teststream")
+				require.Equal(t, context.DeadlineExceeded, err)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if ca == "once" {
+				// one timestamp for each access unit
+				require.Equal(t, 20, len(timestamps[0]))
+				require.Equal(t, 10, len(timestamps[1]))
+			} else {
+				require.Greater(t, len(timestamps[0]), 40)
+				require.Greater(t, len(timestamps[1]), 20)
+			}
+		})
+	}
+}
+
+func TestClientFilePublisherReordering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gortsplib-publisher")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// SPS with pic_order_cnt_type = 0
+	sps := []byte{
+		0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50,
+		0x05, 0xbb, 0x01, 0x6c, 0x80, 0x00, 0x00, 0x03,
+		0x00, 0x80, 0x00, 0x00, 0x1e, 0x07, 0x8c, 0x18,
+		0xcb,
+	}
+	pps := []byte{0x68, 0xee, 0x3c, 0x80}
+
+	slice := func(idr bool, refIdc uint8, frameNum uint32, poc uint32) []byte {
+		var buf bytes.Buffer
+		w := bitio.NewWriter(&buf)
+
+		writeGolombUnsigned := func(v uint32) {
+			v++
+			n := uint8(0)
+			for (v >> n) > 1 {
+				n++
+			}
+			w.WriteBits(0, n)
+			w.WriteBits(uint64(v), n+1)
+		}
+
+		typ := h264.NALUTypeNonIDR
+		if idr {
+			typ = h264.NALUTypeIDR
+		}
+		w.WriteBits(uint64(refIdc)<<5|uint64(typ), 8)
+
+		writeGolombUnsigned(0) // first_mb_in_slice
+		writeGolombUnsigned(5) // slice_type
+		writeGolombUnsigned(0) // pic_parameter_set_id
+		w.WriteBits(uint64(frameNum), 4)
+		if idr {
+			writeGolombUnsigned(0) // idr_pic_id
+		}
+		w.WriteBits(uint64(poc), 6)
+		w.WriteBool(true) // rbsp_stop_one_bit
+		w.Close()
+
+		return buf.Bytes()
+	}
+
+	// decoding order: I0 P2 B1 P4 B3
+	byts, err := h264.EncodeAnnexB([][]byte{
+		sps,
+		pps,
+		slice(true, 3, 0, 0),
+		slice(false, 2, 1, 4),
+		slice(false, 0, 2, 2),
+		slice(false, 2, 2, 8),
+		slice(false, 0, 3, 6),
+	})
+	require.NoError(t, err)
+
+	h264Path := filepath.Join(dir, "test.h264")
+	err = ioutil.WriteFile(h264Path, byts, 0o644)
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	var timestamps []uint32
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPacketRTP: func(ctx *ServerHandlerOnPacketRTPCtx) {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(ctx.Payload)
+				require.NoError(t, err)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if len(timestamps) == 0 || timestamps[len(timestamps)-1] != pkt.Timestamp {
+					timestamps = append(timestamps, pkt.Timestamp)
+				}
+			},
+		},
+		RTSPAddress: "localhost:8559",
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	p := &ClientFilePublisher{
+		Client: &Client{
+			Transport: func() *Transport {
+				v := TransportTCP
+				return &v
+			}(),
+		},
+		H264Path: h264Path,
+		FPS:      100,
+	}
+
+	err = p.Publish(context.Background(), "rtsp://localhost:8559/teststream")
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// access units are sent in decoding order, with presentation timestamps
+	require.Equal(t, 5, len(timestamps))
+	for i, display := range []uint32{0, 2, 1, 4, 3} {
+		require.Equal(t, display*900, timestamps[i]-timestamps[0])
+	}
+}
+
+func TestClientFilePublisherCancelDuringStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gortsplib-publisher")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	h264Path, _ := writeTestPublisherFiles(t, dir)
+
+	// server that accepts connections and never replies
+	l, err := net.Listen("tcp", "localhost:8559")
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		for {
+			nconn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer nconn.Close()
+		}
+	}()
+
+	p := &ClientFilePublisher{
+		H264Path: h264Path,
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer ctxCancel()
+
+	start := time.Now()
+	err = p.Publish(ctx, "rtsp://localhost:8559/teststream")
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Less(t, int64(time.Since(start)), int64(2*time.Second))
+}
+
+func TestClientFilePublisherErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gortsplib-publisher")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	noSPSPath := filepath.Join(dir, "nosps.h264")
+	err = ioutil.WriteFile(noSPSPath, []byte{0x00, 0x00, 0x00, 0x01, 0x65, 0x88, 0x86}, 0o644)
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name string
+		p    *ClientFilePublisher
+		err  string
+	}{
+		{
+			"no files",
+			&ClientFilePublisher{},
+			"at least one file must be provided",
+		},
+		{
+			"no SPS",
+			&ClientFilePublisher{H264Path: noSPSPath},
+			"SPS or PPS not found",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := ca.p.Publish(context.Background(), "rtsp://localhost:8559/teststream")
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -42,6 +42,7 @@ type clientUDPListener struct {
 	lastFrameTime *int64
 	writeMutex    sync.Mutex
 	processFunc   func(time.Time, []byte)
+	jitterBuffer  *udpJitterBuffer
 
 	// out
 	done chan struct{}
@@ -140,6 +141,17 @@ func (l *clientUDPListener) start() {
 	if l.c.state == clientStatePlay {
 		if l.isRTP {
 			l.processFunc = l.processPlayRTP
+
+			if l.c.JitterBufferLatency > 0 {
+				l.jitterBuffer = newUDPJitterBuffer(
+					l.c.JitterBufferLatency,
+					func(payload []byte) {
+						l.c.OnPacketRTP(l.trackID, payload)
+					},
+					func(count int) {
+						l.c.OnPacketsLost(l.trackID, count)
+					})
+			}
 		} else {
 			l.processFunc = l.processPlayRTCP
 		}
@@ -156,6 +168,11 @@ func (l *clientUDPListener) start() {
 func (l *clientUDPListener) stop() {
 	l.pc.SetReadDeadline(time.Now())
 	<-l.done
+
+	if l.jitterBuffer != nil {
+		l.jitterBuffer.close()
+		l.jitterBuffer = nil
+	}
 }
 
 func (l *clientUDPListener) run() {
@@ -170,6 +187,10 @@ func (l *clientUDPListener) run() {
 
 		uaddr := addr.(*net.UDPAddr)
 
+		if l.c.Capture != nil {
+			l.c.Capture.writeUDP(uaddr, captureUDPAddr(l.pc.LocalAddr()), buf[:n])
+		}
+
 		if !l.remoteReadIP.Equal(uaddr.IP) || (l.remotePort != 0 && l.remotePort != uaddr.Port) {
 			continue
 		}
@@ -183,27 +204,46 @@ func (l *clientUDPListener) run() {
 
 func (l *clientUDPListener) processPlayRTP(now time.Time, payload []byte) {
 	l.c.tracks[l.trackID].rtcpReceiver.ProcessPacketRTP(now, payload)
+
+	if l.c.tracks[l.trackID].rtcpNacker != nil {
+		l.c.tracks[l.trackID].rtcpNacker.ProcessPacketRTP(now, payload)
+	}
+
+	if l.jitterBuffer != nil {
+		l.jitterBuffer.push(now, payload)
+		return
+	}
+
 	l.c.OnPacketRTP(l.trackID, payload)
 }
 
 func (l *clientUDPListener) processPlayRTCP(now time.Time, payload []byte) {
 	l.c.tracks[l.trackID].rtcpReceiver.ProcessPacketRTCP(now, payload)
+	if l.c.tracks[l.trackID].ntpSync != nil {
+		l.c.tracks[l.trackID].ntpSync.ProcessPacketRTCP(payload)
+	}
 	l.c.OnPacketRTCP(l.trackID, payload)
 }
 
 func (l *clientUDPListener) processRecord(now time.Time, payload []byte) {
-	l.c.OnPacketRTCP(l.trackID, payload)
+	l.c.processRecordPacketRTCP(l.trackID, payload)
 }
 
 func (l *clientUDPListener) write(buf []byte) error {
 	l.writeMutex.Lock()
 	defer l.writeMutex.Unlock()
 
-	l.pc.SetWriteDeadline(time.Now().Add(l.c.WriteTimeout))
-	_, err := l.pc.WriteTo(buf, &net.UDPAddr{
+	addr := &net.UDPAddr{
 		IP:   l.remoteWriteIP,
 		Zone: l.remoteZone,
 		Port: l.remotePort,
-	})
+	}
+
+	if l.c.Capture != nil {
+		l.c.Capture.writeUDP(captureUDPAddr(l.pc.LocalAddr()), addr, buf)
+	}
+
+	l.pc.SetWriteDeadline(time.Now().Add(l.c.WriteTimeout))
+	_, err := l.pc.WriteTo(buf, addr)
 	return err
 }
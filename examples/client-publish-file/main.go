@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aler9/gortsplib"
+)
+
+// This example shows how to
+// 1. read a H264 file in Annex-B format and an AAC file in ADTS format
+// 2. connect to a RTSP server, announce a H264 track and an AAC track
+// 3. publish the files in real time, in a loop
+
+func main() {
+	p := &gortsplib.ClientFilePublisher{
+		H264Path: "myfile.h264",
+		AACPath:  "myfile.aac",
+		Loop:     true,
+	}
+
+	err := p.Publish(context.Background(), "rtsp://localhost:8554/mystream")
+	if err != nil {
+		panic(err)
+	}
+}
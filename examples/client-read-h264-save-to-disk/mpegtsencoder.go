@@ -2,25 +2,23 @@ package main
 
 import (
 	"bufio"
-	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/aler9/gortsplib"
 	"github.com/aler9/gortsplib/pkg/h264"
-	"github.com/asticode/go-astits"
+	"github.com/aler9/gortsplib/pkg/mpegts"
 )
 
 // mpegtsEncoder allows to encode H264 NALUs into MPEG-TS.
 type mpegtsEncoder struct {
 	f                  *os.File
 	b                  *bufio.Writer
-	mux                *astits.Muxer
-	dtsEst             *h264.DTSEstimator
+	mux                *mpegts.Muxer
+	tsEncoder          *mpegts.TimestampEncoder
 	firstPacketWritten bool
 	startPTS           time.Duration
-	h264Conf           *gortsplib.TrackConfigH264
 }
 
 // newMPEGTSEncoder allocates a mpegtsEncoder.
@@ -31,19 +29,29 @@ func newMPEGTSEncoder(h264Conf *gortsplib.TrackConfigH264) (*mpegtsEncoder, erro
 	}
 	b := bufio.NewWriter(f)
 
-	mux := astits.NewMuxer(context.Background(), b)
-	mux.AddElementaryStream(astits.PMTElementaryStream{
-		ElementaryPID: 256,
-		StreamType:    astits.StreamTypeH264Video,
-	})
-	mux.SetPCRPID(256)
+	videoTrack := &mpegts.VideoTrack{
+		SPS: h264Conf.SPS,
+		PPS: h264Conf.PPS,
+	}
+
+	mux, err := mpegts.NewMuxer(b, videoTrack, nil)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// the timestamp encoder computes the DTS and shifts timestamps
+	tsEncoder, err := mpegts.NewTimestampEncoder(videoTrack)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
 
 	return &mpegtsEncoder{
-		f:        f,
-		b:        b,
-		mux:      mux,
-		dtsEst:   h264.NewDTSEstimator(),
-		h264Conf: h264Conf,
+		f:         f,
+		b:         b,
+		mux:       mux,
+		tsEncoder: tsEncoder,
 	}, nil
 }
 
@@ -55,11 +63,6 @@ func (e *mpegtsEncoder) close() {
 
 // encode encodes H264 NALUs into MPEG-TS.
 func (e *mpegtsEncoder) encode(nalus [][]byte, pts time.Duration) error {
-	if !e.firstPacketWritten {
-		e.firstPacketWritten = true
-		e.startPTS = pts
-	}
-
 	// check whether there's an IDR
 	idrPresent := func() bool {
 		for _, nalu := range nalus {
@@ -71,56 +74,24 @@ func (e *mpegtsEncoder) encode(nalus [][]byte, pts time.Duration) error {
 		return false
 	}()
 
-	// prepend an AUD. This is required by some players
-	filteredNALUs := [][]byte{
-		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
-	}
-
-	for _, nalu := range nalus {
-		// remove existing SPS, PPS, AUD
-		typ := h264.NALUType(nalu[0] & 0x1F)
-		switch typ {
-		case h264.NALUTypeSPS, h264.NALUTypePPS, h264.NALUTypeAccessUnitDelimiter:
-			continue
-		}
-
-		// add SPS and PPS before every IDR
-		if typ == h264.NALUTypeIDR {
-			filteredNALUs = append(filteredNALUs, e.h264Conf.SPS)
-			filteredNALUs = append(filteredNALUs, e.h264Conf.PPS)
+	if !e.firstPacketWritten {
+		// wait for the first IDR
+		if !idrPresent {
+			return nil
 		}
 
-		filteredNALUs = append(filteredNALUs, nalu)
+		e.firstPacketWritten = true
+		e.startPTS = pts
 	}
 
-	// encode into Annex-B
-	enc, err := h264.EncodeAnnexB(filteredNALUs)
+	pts, dts, err := e.tsEncoder.EncodeH264(pts-e.startPTS, nalus)
 	if err != nil {
 		return err
 	}
 
-	dts := e.dtsEst.Feed(pts - e.startPTS)
-	pts = pts - e.startPTS
-
-	// write TS packet
-	_, err = e.mux.WriteData(&astits.MuxerData{
-		PID: 256,
-		AdaptationField: &astits.PacketAdaptationField{
-			RandomAccessIndicator: idrPresent,
-		},
-		PES: &astits.PESData{
-			Header: &astits.PESHeader{
-				OptionalHeader: &astits.PESOptionalHeader{
-					MarkerBits:      2,
-					PTSDTSIndicator: astits.PTSDTSIndicatorBothPresent,
-					DTS:             &astits.ClockReference{Base: int64(dts.Seconds() * 90000)},
-					PTS:             &astits.ClockReference{Base: int64(pts.Seconds() * 90000)},
-				},
-				StreamID: 224, // video
-			},
-			Data: enc,
-		},
-	})
+	// write TS packets.
+	// SPS, PPS and AUD are inserted by the muxer.
+	err = e.mux.WriteH264(pts, dts, nalus)
 	if err != nil {
 		return err
 	}
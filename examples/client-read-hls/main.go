@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aler9/gortsplib"
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/hls"
+)
+
+// This example shows how to
+// 1. connect to a RTSP server and read all tracks on a path
+// 2. convert the H264 and AAC tracks into a HLS playlist
+// 3. serve the playlist to browsers on http://localhost:8888/mystream/index.m3u8
+
+func main() {
+	c := gortsplib.Client{}
+
+	// parse URL
+	u, err := base.ParseURL("rtsp://localhost:8554/mystream")
+	if err != nil {
+		panic(err)
+	}
+
+	// connect to the server
+	err = c.Start(u.Scheme, u.Host)
+	if err != nil {
+		panic(err)
+	}
+
+	// find published tracks
+	tracks, baseURL, _, err := c.Describe(u)
+	if err != nil {
+		panic(err)
+	}
+
+	// setup a HLS muxer that keeps 3 segments of at least 1 second
+	m, err := hls.NewMuxer(hls.SegmentFormatMPEGTS, 3, 1*time.Second, tracks)
+	if err != nil {
+		panic(err)
+	}
+
+	// called when a RTP packet arrives
+	c.OnPacketRTP = func(trackID int, payload []byte) {
+		err := m.WritePacketRTP(trackID, payload)
+		if err != nil {
+			log.Printf("ERR: %v", err)
+		}
+	}
+
+	// setup all tracks
+	for _, t := range tracks {
+		_, err := c.Setup(true, baseURL, t, 0, 0)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// start reading tracks
+	_, err = c.Play(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// serve the playlist
+	go http.ListenAndServe(":8888", m)
+
+	// wait until a fatal error
+	panic(c.Wait())
+}
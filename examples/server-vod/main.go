@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/aler9/gortsplib"
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+// This example shows how to
+// 1. create a RTSP server which serves a MP4 file on demand
+// 2. allow multiple clients to read the file, each with its own position
+// 3. allow clients to seek and pause
+
+type serverHandler struct {
+	file *gortsplib.ServerFile
+
+	mutex   sync.Mutex
+	streams map[*gortsplib.ServerSession]*gortsplib.ServerFileStream
+}
+
+// called when a session is closed.
+func (sh *serverHandler) OnSessionClose(ctx *gortsplib.ServerHandlerOnSessionCloseCtx) {
+	log.Printf("session closed")
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	if fs, ok := sh.streams[ctx.Session]; ok {
+		fs.Close()
+		delete(sh.streams, ctx.Session)
+	}
+}
+
+// called after receiving a DESCRIBE request.
+func (sh *serverHandler) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	log.Printf("describe request")
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, gortsplib.NewServerStream(sh.file.Tracks()), nil
+}
+
+// called after receiving a SETUP request.
+func (sh *serverHandler) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	log.Printf("setup request")
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	// each session reads the file with its own stream
+	fs, ok := sh.streams[ctx.Session]
+	if !ok {
+		var err error
+		fs, err = sh.file.NewStream()
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusInternalServerError,
+			}, nil, err
+		}
+		sh.streams[ctx.Session] = fs
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, fs.Stream(), nil
+}
+
+// called after receiving a PLAY request.
+func (sh *serverHandler) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	log.Printf("play request")
+
+	sh.mutex.Lock()
+	fs := sh.streams[ctx.Session]
+	sh.mutex.Unlock()
+
+	// start sending the file from the position contained in the Range header
+	return fs.Play(ctx)
+}
+
+// called after receiving a PAUSE request.
+func (sh *serverHandler) OnPause(ctx *gortsplib.ServerHandlerOnPauseCtx) (*base.Response, error) {
+	log.Printf("pause request")
+
+	sh.mutex.Lock()
+	fs := sh.streams[ctx.Session]
+	sh.mutex.Unlock()
+
+	return fs.Pause(ctx)
+}
+
+func main() {
+	// open the file
+	file, err := gortsplib.OpenServerFile("myfile.mp4")
+	if err != nil {
+		panic(err)
+	}
+
+	// configure server
+	s := &gortsplib.Server{
+		Handler: &serverHandler{
+			file:    file,
+			streams: make(map[*gortsplib.ServerSession]*gortsplib.ServerFileStream),
+		},
+		RTSPAddress:    ":8554",
+		UDPRTPAddress:  ":8000",
+		UDPRTCPAddress: ":8001",
+	}
+
+	// start server and wait until a fatal error
+	log.Printf("server is ready")
+	panic(s.StartAndWait())
+}
@@ -0,0 +1,29 @@
+package gortsplib
+
+import (
+	"github.com/pion/rtcp"
+)
+
+// keyframeRequestTypes checks whether a RTCP packet contains
+// Picture Loss Indications (PLI) or Full Intra Requests (FIR).
+func keyframeRequestTypes(payload []byte) (bool, bool) {
+	packets, err := rtcp.Unmarshal(payload)
+	if err != nil {
+		return false, false
+	}
+
+	pli := false
+	fir := false
+
+	for _, pkt := range packets {
+		switch pkt.(type) {
+		case *rtcp.PictureLossIndication:
+			pli = true
+
+		case *rtcp.FullIntraRequest:
+			fir = true
+		}
+	}
+
+	return pli, fir
+}
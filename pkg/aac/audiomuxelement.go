@@ -0,0 +1,112 @@
+package aac
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/icza/bitio"
+)
+
+// DecodeAudioMuxElements decodes a sequence of LATM AudioMuxElements into AUs.
+// When muxConfigPresent is true, AudioMuxElements can contain a StreamMuxConfig,
+// that replaces the current one. It returns the current StreamMuxConfig and the AUs.
+func DecodeAudioMuxElements(byts []byte, muxConfigPresent bool,
+	config *StreamMuxConfig) (*StreamMuxConfig, [][]byte, error) {
+	// ref: ISO 14496-3, 1.7.3.1
+	buf := bytes.NewBuffer(byts)
+	r := bitio.NewReader(buf)
+	var aus [][]byte
+
+	for buf.Len() > 0 {
+		if muxConfigPresent {
+			useSameStreamMux, err := r.ReadBool()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if !useSameStreamMux {
+				var newConfig StreamMuxConfig
+				err := newConfig.read(r)
+				if err != nil {
+					return nil, nil, err
+				}
+				config = &newConfig
+			}
+		}
+
+		if config == nil {
+			return nil, nil, fmt.Errorf("StreamMuxConfig has not been received yet")
+		}
+
+		for i := uint(0); i <= config.NumSubFrames; i++ {
+			// PayloadLengthInfo
+			le := 0
+			for {
+				tmp, err := r.ReadBits(8)
+				if err != nil {
+					return nil, nil, err
+				}
+				le += int(tmp)
+
+				if tmp != 255 {
+					break
+				}
+			}
+
+			// PayloadMux
+			au := make([]byte, le)
+			for j := range au {
+				tmp, err := r.ReadBits(8)
+				if err != nil {
+					return nil, nil, err
+				}
+				au[j] = uint8(tmp)
+			}
+
+			aus = append(aus, au)
+		}
+
+		if config.OtherDataPresent {
+			for i := uint32(0); i < config.OtherDataLenBits; i++ {
+				_, err := r.ReadBits(1)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+
+		r.Align()
+	}
+
+	return config, aus, nil
+}
+
+// EncodeAudioMuxElement encodes AUs into a LATM AudioMuxElement,
+// without StreamMuxConfig. The number of AUs must be equal to NumSubFrames + 1.
+func EncodeAudioMuxElement(aus [][]byte) ([]byte, error) {
+	if len(aus) == 0 || len(aus) > 64 {
+		return nil, fmt.Errorf("invalid AU count (%d)", len(aus))
+	}
+
+	var buf bytes.Buffer
+	w := bitio.NewWriter(&buf)
+
+	for _, au := range aus {
+		// PayloadLengthInfo
+		le := len(au)
+		for le >= 255 {
+			w.WriteBits(255, 8)
+			le -= 255
+		}
+		w.WriteBits(uint64(le), 8)
+
+		// PayloadMux
+		for _, b := range au {
+			w.WriteBits(uint64(b), 8)
+		}
+	}
+
+	w.Close()
+
+	return buf.Bytes(), nil
+}
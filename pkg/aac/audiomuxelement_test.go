@@ -0,0 +1,91 @@
+package aac
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/icza/bitio"
+	"github.com/stretchr/testify/require"
+)
+
+var testStreamMuxConfig = &StreamMuxConfig{
+	NumSubFrames: 1,
+	Config: &MPEG4AudioConfig{
+		Type:         MPEG4AudioTypeAACLC,
+		SampleRate:   48000,
+		ChannelCount: 1,
+	},
+	LatmBufferFullness: 255,
+}
+
+var testAUs = [][]byte{
+	{0x01, 0x02, 0x03, 0x04},
+	bytes.Repeat([]byte{0x05}, 300),
+}
+
+func TestAudioMuxElementEncode(t *testing.T) {
+	enc, err := EncodeAudioMuxElement(testAUs)
+	require.NoError(t, err)
+	require.Equal(t, append(
+		[]byte{0x04, 0x01, 0x02, 0x03, 0x04, 0xff, 0x2d},
+		bytes.Repeat([]byte{0x05}, 300)...,
+	), enc)
+}
+
+func TestAudioMuxElementEncodeErrors(t *testing.T) {
+	_, err := EncodeAudioMuxElement(nil)
+	require.EqualError(t, err, "invalid AU count (0)")
+}
+
+func TestAudioMuxElementsDecodeOutOfBand(t *testing.T) {
+	enc, err := EncodeAudioMuxElement(testAUs)
+	require.NoError(t, err)
+
+	config, aus, err := DecodeAudioMuxElements(append(enc, enc...), false, testStreamMuxConfig)
+	require.NoError(t, err)
+	require.Equal(t, testStreamMuxConfig, config)
+	require.Equal(t, append(testAUs, testAUs...), aus)
+}
+
+func TestAudioMuxElementsDecodeInBand(t *testing.T) {
+	var buf bytes.Buffer
+	w := bitio.NewWriter(&buf)
+
+	// first element contains the StreamMuxConfig
+	w.WriteBool(false) // useSameStreamMux
+	err := testStreamMuxConfig.write(w)
+	require.NoError(t, err)
+	for _, au := range testAUs {
+		le := len(au)
+		for le >= 255 {
+			w.WriteBits(255, 8)
+			le -= 255
+		}
+		w.WriteBits(uint64(le), 8)
+		for _, b := range au {
+			w.WriteBits(uint64(b), 8)
+		}
+	}
+	w.Align()
+
+	// second element uses the same StreamMuxConfig
+	w.WriteBool(true) // useSameStreamMux
+	w.WriteBits(1, 8)
+	w.WriteBits(0x06, 8)
+	w.WriteBits(1, 8)
+	w.WriteBits(0x07, 8)
+	w.Close()
+
+	config, aus, err := DecodeAudioMuxElements(buf.Bytes(), true, nil)
+	require.NoError(t, err)
+	require.Equal(t, testStreamMuxConfig, config)
+	require.Equal(t, append(testAUs, []byte{0x06}, []byte{0x07}), aus)
+}
+
+func TestAudioMuxElementsDecodeErrors(t *testing.T) {
+	_, _, err := DecodeAudioMuxElements([]byte{0x80, 0x00}, true, nil)
+	require.EqualError(t, err, "StreamMuxConfig has not been received yet")
+
+	_, _, err = DecodeAudioMuxElements([]byte{0x04, 0x01}, false, testStreamMuxConfig)
+	require.EqualError(t, err, "EOF")
+}
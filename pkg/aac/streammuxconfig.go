@@ -0,0 +1,401 @@
+package aac
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/icza/bitio"
+)
+
+// StreamMuxConfig is a LATM StreamMuxConfig.
+// Only configurations with a single program and a single layer are supported.
+type StreamMuxConfig struct {
+	// number of AUs contained in each AudioMuxElement, minus one.
+	NumSubFrames uint
+
+	// configuration of the audio stream.
+	Config *MPEG4AudioConfig
+
+	LatmBufferFullness uint8
+	OtherDataPresent   bool
+	OtherDataLenBits   uint32
+	CRCCheckPresent    bool
+	CRCCheckSum        uint8
+}
+
+func latmGetValue(r *bitio.Reader) (uint32, error) {
+	bytesForValue, err := r.ReadBits(2)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := r.ReadBits(uint8(bytesForValue+1) * 8)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32(v), nil
+}
+
+// readAudioSpecificConfig reads an AudioSpecificConfig from a bitstream.
+// It returns the configuration and the number of bits that have been read.
+func readAudioSpecificConfig(r *bitio.Reader) (*MPEG4AudioConfig, int, error) {
+	// ref: ISO 14496-3, 1.6.2.1
+	c := &MPEG4AudioConfig{}
+	n := 0
+
+	tmp, err := r.ReadBits(5)
+	if err != nil {
+		return nil, 0, err
+	}
+	n += 5
+	c.Type = MPEG4AudioType(tmp)
+
+	switch c.Type {
+	case MPEG4AudioTypeAACLC:
+	default:
+		return nil, 0, fmt.Errorf("unsupported type: %d", c.Type)
+	}
+
+	sampleRateIndex, err := r.ReadBits(4)
+	if err != nil {
+		return nil, 0, err
+	}
+	n += 4
+
+	switch {
+	case sampleRateIndex <= 12:
+		c.SampleRate = sampleRates[sampleRateIndex]
+
+	case sampleRateIndex == 15:
+		tmp, err := r.ReadBits(24)
+		if err != nil {
+			return nil, 0, err
+		}
+		n += 24
+		c.SampleRate = int(tmp)
+
+	default:
+		return nil, 0, fmt.Errorf("invalid sample rate index (%d)", sampleRateIndex)
+	}
+
+	channelConfig, err := r.ReadBits(4)
+	if err != nil {
+		return nil, 0, err
+	}
+	n += 4
+
+	switch {
+	case channelConfig == 0:
+		return nil, 0, fmt.Errorf("not yet supported")
+
+	case channelConfig >= 1 && channelConfig <= 7:
+		c.ChannelCount = channelCounts[channelConfig-1]
+
+	default:
+		return nil, 0, fmt.Errorf("invalid channel configuration (%d)", channelConfig)
+	}
+
+	// GASpecificConfig
+	// ref: ISO 14496-3, 4.4.1
+	_, err = r.ReadBits(1) // frameLengthFlag
+	if err != nil {
+		return nil, 0, err
+	}
+	n++
+
+	dependsOnCoreCoder, err := r.ReadBool()
+	if err != nil {
+		return nil, 0, err
+	}
+	n++
+
+	if dependsOnCoreCoder {
+		_, err := r.ReadBits(14) // coreCoderDelay
+		if err != nil {
+			return nil, 0, err
+		}
+		n += 14
+	}
+
+	extensionFlag, err := r.ReadBool()
+	if err != nil {
+		return nil, 0, err
+	}
+	n++
+
+	if extensionFlag {
+		_, err := r.ReadBits(1) // extensionFlag3
+		if err != nil {
+			return nil, 0, err
+		}
+		n++
+	}
+
+	return c, n, nil
+}
+
+// writeAudioSpecificConfig writes an AudioSpecificConfig into a bitstream.
+// The GASpecificConfig is always empty.
+func writeAudioSpecificConfig(w *bitio.Writer, c *MPEG4AudioConfig) error {
+	w.WriteBits(uint64(c.Type), 5)
+
+	sampleRateIndex := func() int {
+		for i, s := range sampleRates {
+			if s == c.SampleRate {
+				return i
+			}
+		}
+		return -1
+	}()
+
+	if sampleRateIndex != -1 {
+		w.WriteBits(uint64(sampleRateIndex), 4)
+	} else {
+		w.WriteBits(uint64(15), 4)
+		w.WriteBits(uint64(c.SampleRate), 24)
+	}
+
+	channelConfig := func() int {
+		for i, co := range channelCounts {
+			if co == c.ChannelCount {
+				return i + 1
+			}
+		}
+		return -1
+	}()
+
+	if channelConfig == -1 {
+		return fmt.Errorf("invalid channel count (%d)", c.ChannelCount)
+	}
+
+	w.WriteBits(uint64(channelConfig), 4)
+
+	// GASpecificConfig
+	w.WriteBits(0, 1) // frameLengthFlag
+	w.WriteBits(0, 1) // dependsOnCoreCoder
+	w.WriteBits(0, 1) // extensionFlag
+
+	return nil
+}
+
+// Decode decodes a StreamMuxConfig.
+func (c *StreamMuxConfig) Decode(byts []byte) error {
+	return c.read(bitio.NewReader(bytes.NewBuffer(byts)))
+}
+
+func (c *StreamMuxConfig) read(r *bitio.Reader) error {
+	// ref: ISO 14496-3, 1.7.3.1
+	audioMuxVersion, err := r.ReadBits(1)
+	if err != nil {
+		return err
+	}
+
+	if audioMuxVersion == 1 {
+		audioMuxVersionA, err := r.ReadBits(1)
+		if err != nil {
+			return err
+		}
+
+		if audioMuxVersionA != 0 {
+			return fmt.Errorf("audioMuxVersionA = 1 is not supported")
+		}
+
+		_, err = latmGetValue(r) // taraBufferFullness
+		if err != nil {
+			return err
+		}
+	}
+
+	allStreamsSameTimeFraming, err := r.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if !allStreamsSameTimeFraming {
+		return fmt.Errorf("allStreamsSameTimeFraming = 0 is not supported")
+	}
+
+	tmp, err := r.ReadBits(6)
+	if err != nil {
+		return err
+	}
+	c.NumSubFrames = uint(tmp)
+
+	numProgram, err := r.ReadBits(4)
+	if err != nil {
+		return err
+	}
+
+	if numProgram != 0 {
+		return fmt.Errorf("multiple programs are not supported")
+	}
+
+	numLayer, err := r.ReadBits(3)
+	if err != nil {
+		return err
+	}
+
+	if numLayer != 0 {
+		return fmt.Errorf("multiple layers are not supported")
+	}
+
+	if audioMuxVersion == 0 {
+		c.Config, _, err = readAudioSpecificConfig(r)
+		if err != nil {
+			return err
+		}
+	} else {
+		ascLen, err := latmGetValue(r)
+		if err != nil {
+			return err
+		}
+
+		var n int
+		c.Config, n, err = readAudioSpecificConfig(r)
+		if err != nil {
+			return err
+		}
+
+		if uint32(n) > ascLen {
+			return fmt.Errorf("invalid AudioSpecificConfig length (%d)", ascLen)
+		}
+
+		// fill bits
+		for i := uint32(n); i < ascLen; i++ {
+			_, err := r.ReadBits(1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	frameLengthType, err := r.ReadBits(3)
+	if err != nil {
+		return err
+	}
+
+	if frameLengthType != 0 {
+		return fmt.Errorf("frameLengthType = %d is not supported", frameLengthType)
+	}
+
+	tmp, err = r.ReadBits(8)
+	if err != nil {
+		return err
+	}
+	c.LatmBufferFullness = uint8(tmp)
+
+	c.OtherDataPresent, err = r.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if c.OtherDataPresent {
+		if audioMuxVersion == 1 {
+			c.OtherDataLenBits, err = latmGetValue(r)
+			if err != nil {
+				return err
+			}
+		} else {
+			c.OtherDataLenBits = 0
+			for {
+				c.OtherDataLenBits *= 256 // 2^8
+
+				otherDataLenEsc, err := r.ReadBool()
+				if err != nil {
+					return err
+				}
+
+				otherDataLenTmp, err := r.ReadBits(8)
+				if err != nil {
+					return err
+				}
+
+				c.OtherDataLenBits += uint32(otherDataLenTmp)
+
+				if !otherDataLenEsc {
+					break
+				}
+			}
+		}
+	}
+
+	c.CRCCheckPresent, err = r.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if c.CRCCheckPresent {
+		tmp, err := r.ReadBits(8)
+		if err != nil {
+			return err
+		}
+		c.CRCCheckSum = uint8(tmp)
+	}
+
+	return nil
+}
+
+// Encode encodes a StreamMuxConfig.
+func (c StreamMuxConfig) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	w := bitio.NewWriter(&buf)
+
+	err := c.write(w)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Close()
+
+	return buf.Bytes(), nil
+}
+
+func (c StreamMuxConfig) write(w *bitio.Writer) error {
+	if c.Config == nil {
+		return fmt.Errorf("config is missing")
+	}
+
+	if c.NumSubFrames > 63 {
+		return fmt.Errorf("invalid number of sub frames (%d)", c.NumSubFrames)
+	}
+
+	w.WriteBits(0, 1) // audioMuxVersion
+	w.WriteBool(true) // allStreamsSameTimeFraming
+	w.WriteBits(uint64(c.NumSubFrames), 6)
+	w.WriteBits(0, 4) // numProgram
+	w.WriteBits(0, 3) // numLayer
+
+	err := writeAudioSpecificConfig(w, c.Config)
+	if err != nil {
+		return err
+	}
+
+	w.WriteBits(0, 3) // frameLengthType
+	w.WriteBits(uint64(c.LatmBufferFullness), 8)
+
+	w.WriteBool(c.OtherDataPresent)
+	if c.OtherDataPresent {
+		var chunks []uint8
+		v := c.OtherDataLenBits
+		for {
+			chunks = append([]uint8{uint8(v)}, chunks...)
+			v >>= 8
+			if v == 0 {
+				break
+			}
+		}
+
+		for i, chunk := range chunks {
+			w.WriteBool(i != (len(chunks) - 1)) // otherDataLenEsc
+			w.WriteBits(uint64(chunk), 8)
+		}
+	}
+
+	w.WriteBool(c.CRCCheckPresent)
+	if c.CRCCheckPresent {
+		w.WriteBits(uint64(c.CRCCheckSum), 8)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package aac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var streamMuxConfigCases = []struct {
+	name string
+	enc  []byte
+	dec  StreamMuxConfig
+}{
+	{
+		"aac-lc 44.1khz stereo",
+		[]byte{0x40, 0x00, 0x24, 0x20, 0x3f, 0xc0},
+		StreamMuxConfig{
+			Config: &MPEG4AudioConfig{
+				Type:         MPEG4AudioTypeAACLC,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			LatmBufferFullness: 255,
+		},
+	},
+	{
+		"aac-lc 48khz mono, sub frames",
+		[]byte{0x41, 0x00, 0x23, 0x10, 0x3f, 0xc0},
+		StreamMuxConfig{
+			NumSubFrames: 1,
+			Config: &MPEG4AudioConfig{
+				Type:         MPEG4AudioTypeAACLC,
+				SampleRate:   48000,
+				ChannelCount: 1,
+			},
+			LatmBufferFullness: 255,
+		},
+	},
+	{
+		"aac-lc 53khz stereo, other data, crc",
+		[]byte{0x40, 0x00, 0x2f, 0x00, 0xcf, 0x08, 0x20, 0x3f, 0xf0, 0x10, 0x14, 0x14},
+		StreamMuxConfig{
+			Config: &MPEG4AudioConfig{
+				Type:         MPEG4AudioTypeAACLC,
+				SampleRate:   53000,
+				ChannelCount: 2,
+			},
+			LatmBufferFullness: 255,
+			OtherDataPresent:   true,
+			OtherDataLenBits:   258,
+			CRCCheckPresent:    true,
+			CRCCheckSum:        5,
+		},
+	},
+}
+
+func TestStreamMuxConfigDecode(t *testing.T) {
+	for _, ca := range streamMuxConfigCases {
+		t.Run(ca.name, func(t *testing.T) {
+			var dec StreamMuxConfig
+			err := dec.Decode(ca.enc)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, dec)
+		})
+	}
+}
+
+func TestStreamMuxConfigDecodeVersion1(t *testing.T) {
+	// audioMuxVersion = 1, taraBufferFullness = 0xFF, ascLen = 16
+	var dec StreamMuxConfig
+	err := dec.Decode([]byte{0x8f, 0xf8, 0x00, 0x01, 0x01, 0x21, 0x01, 0xfe, 0x00})
+	require.NoError(t, err)
+	require.Equal(t, StreamMuxConfig{
+		Config: &MPEG4AudioConfig{
+			Type:         MPEG4AudioTypeAACLC,
+			SampleRate:   44100,
+			ChannelCount: 2,
+		},
+		LatmBufferFullness: 255,
+	}, dec)
+}
+
+func TestStreamMuxConfigDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"EOF",
+		},
+		{
+			"audioMuxVersionA",
+			[]byte{0xc0},
+			"audioMuxVersionA = 1 is not supported",
+		},
+		{
+			"allStreamsSameTimeFraming",
+			[]byte{0x00, 0x00},
+			"allStreamsSameTimeFraming = 0 is not supported",
+		},
+		{
+			"multiple programs",
+			[]byte{0x40, 0x80},
+			"multiple programs are not supported",
+		},
+		{
+			"multiple layers",
+			[]byte{0x40, 0x04},
+			"multiple layers are not supported",
+		},
+		{
+			"unsupported audio type",
+			[]byte{0x40, 0x00, 0x00, 0x00},
+			"unsupported type: 0",
+		},
+		{
+			"frameLengthType",
+			[]byte{0x40, 0x00, 0x24, 0x21, 0x3f, 0xc0},
+			"frameLengthType = 4 is not supported",
+		},
+		{
+			"truncated",
+			[]byte{0x40, 0x00, 0x24, 0x20},
+			"EOF",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var dec StreamMuxConfig
+			err := dec.Decode(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestStreamMuxConfigEncode(t *testing.T) {
+	for _, ca := range streamMuxConfigCases {
+		t.Run(ca.name, func(t *testing.T) {
+			enc, err := ca.dec.Encode()
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, enc)
+		})
+	}
+}
+
+func TestStreamMuxConfigEncodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		conf StreamMuxConfig
+		err  string
+	}{
+		{
+			"missing config",
+			StreamMuxConfig{},
+			"config is missing",
+		},
+		{
+			"invalid sub frames",
+			StreamMuxConfig{
+				NumSubFrames: 64,
+				Config: &MPEG4AudioConfig{
+					Type:         MPEG4AudioTypeAACLC,
+					SampleRate:   44100,
+					ChannelCount: 2,
+				},
+			},
+			"invalid number of sub frames (64)",
+		},
+		{
+			"invalid channel count",
+			StreamMuxConfig{
+				Config: &MPEG4AudioConfig{
+					Type:         MPEG4AudioTypeAACLC,
+					SampleRate:   44100,
+					ChannelCount: 0,
+				},
+			},
+			"invalid channel count (0)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := ca.conf.Encode()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
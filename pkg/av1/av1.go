@@ -0,0 +1,2 @@
+// Package av1 contains utilities to work with the AV1 codec.
+package av1
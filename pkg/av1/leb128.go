@@ -0,0 +1,43 @@
+package av1
+
+import (
+	"fmt"
+)
+
+// DecodeLEB128 decodes an unsigned integer in the LEB128 format.
+// It returns the value and the number of bytes that were read.
+func DecodeLEB128(byts []byte) (uint, int, error) {
+	// ref: AV1 Bitstream & Decoding Process Specification, 4.10.5
+	var v uint
+
+	for i := 0; i < 8; i++ {
+		if i >= len(byts) {
+			return 0, 0, fmt.Errorf("not enough bytes")
+		}
+
+		v |= (uint(byts[i]) & 0x7f) << (i * 7)
+
+		if (byts[i] & 0x80) == 0 {
+			return v, i + 1, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("LEB128 value is too long")
+}
+
+// EncodeLEB128 encodes an unsigned integer in the LEB128 format.
+func EncodeLEB128(v uint) []byte {
+	var ret []byte
+
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+
+		if v != 0 {
+			ret = append(ret, b|0x80)
+		} else {
+			ret = append(ret, b)
+			return ret
+		}
+	}
+}
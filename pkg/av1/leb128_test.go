@@ -0,0 +1,77 @@
+package av1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesLEB128 = []struct {
+	name string
+	dec  uint
+	enc  []byte
+}{
+	{
+		"1 byte",
+		0x2a,
+		[]byte{0x2a},
+	},
+	{
+		"2 bytes",
+		0x3ab,
+		[]byte{0xab, 0x07},
+	},
+	{
+		"3 bytes",
+		0x1f2a3,
+		[]byte{0xa3, 0xe5, 0x07},
+	},
+}
+
+func TestDecodeLEB128(t *testing.T) {
+	for _, ca := range casesLEB128 {
+		t.Run(ca.name, func(t *testing.T) {
+			dec, n, err := DecodeLEB128(ca.enc)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, dec)
+			require.Equal(t, len(ca.enc), n)
+		})
+	}
+}
+
+func TestDecodeLEB128Errors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"not enough bytes",
+		},
+		{
+			"missing continuation",
+			[]byte{0x80},
+			"not enough bytes",
+		},
+		{
+			"too long",
+			[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01},
+			"LEB128 value is too long",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, _, err := DecodeLEB128(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncodeLEB128(t *testing.T) {
+	for _, ca := range casesLEB128 {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.enc, EncodeLEB128(ca.dec))
+		})
+	}
+}
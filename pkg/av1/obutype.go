@@ -0,0 +1,41 @@
+package av1
+
+import (
+	"fmt"
+)
+
+// OBUType is the type of an OBU.
+type OBUType uint8
+
+// standard OBU types.
+const (
+	OBUTypeSequenceHeader       OBUType = 1
+	OBUTypeTemporalDelimiter    OBUType = 2
+	OBUTypeFrameHeader          OBUType = 3
+	OBUTypeTileGroup            OBUType = 4
+	OBUTypeMetadata             OBUType = 5
+	OBUTypeFrame                OBUType = 6
+	OBUTypeRedundantFrameHeader OBUType = 7
+	OBUTypeTileList             OBUType = 8
+	OBUTypePadding              OBUType = 15
+)
+
+var obuTypeLabels = map[OBUType]string{
+	OBUTypeSequenceHeader:       "SequenceHeader",
+	OBUTypeTemporalDelimiter:    "TemporalDelimiter",
+	OBUTypeFrameHeader:          "FrameHeader",
+	OBUTypeTileGroup:            "TileGroup",
+	OBUTypeMetadata:             "Metadata",
+	OBUTypeFrame:                "Frame",
+	OBUTypeRedundantFrameHeader: "RedundantFrameHeader",
+	OBUTypeTileList:             "TileList",
+	OBUTypePadding:              "Padding",
+}
+
+// String implements fmt.Stringer.
+func (ot OBUType) String() string {
+	if l, ok := obuTypeLabels[ot]; ok {
+		return l
+	}
+	return fmt.Sprintf("unknown (%d)", ot)
+}
@@ -0,0 +1,64 @@
+package fmp4
+
+import (
+	"encoding/binary"
+)
+
+// fields is a buffer that is used to build the content of a box.
+type fields []byte
+
+func (f *fields) uint8(v uint8) {
+	*f = append(*f, v)
+}
+
+func (f *fields) uint16(v uint16) {
+	*f = append(*f, byte(v>>8), byte(v))
+}
+
+func (f *fields) uint32(v uint32) {
+	*f = append(*f, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (f *fields) uint64(v uint64) {
+	f.uint32(uint32(v >> 32))
+	f.uint32(uint32(v))
+}
+
+func (f *fields) bytes(v []byte) {
+	*f = append(*f, v...)
+}
+
+func (f *fields) zeros(n int) {
+	*f = append(*f, make([]byte, n)...)
+}
+
+// box generates a box with the given type and content.
+func box(typ string, content ...[]byte) []byte {
+	size := 8
+	for _, c := range content {
+		size += len(c)
+	}
+
+	ret := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(ret, uint32(size))
+	copy(ret[4:], typ)
+
+	for _, c := range content {
+		ret = append(ret, c...)
+	}
+
+	return ret
+}
+
+// fullBox generates a box with a version and flags.
+func fullBox(typ string, version uint8, flags uint32, content ...[]byte) []byte {
+	header := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return box(typ, append([][]byte{header}, content...)...)
+}
+
+// matrix is the unity transformation matrix.
+var matrix = []byte{
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00,
+}
@@ -0,0 +1,30 @@
+// Package fmp4 contains a fragmented MP4 (fMP4) writer.
+package fmp4
+
+import (
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+const (
+	videoTimescale = 90000
+)
+
+// VideoTrack is a H264 track.
+type VideoTrack struct {
+	SPS []byte
+	PPS []byte
+}
+
+// AudioTrack is an AAC track.
+type AudioTrack struct {
+	Config *aac.MPEG4AudioConfig
+}
+
+func durationToTicks(v time.Duration, timescale int64) int64 {
+	// avoid overflows of v * timescale
+	secs := v / time.Second
+	dec := v % time.Second
+	return int64(secs)*timescale + int64(dec)*timescale/int64(time.Second)
+}
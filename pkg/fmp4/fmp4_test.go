@@ -0,0 +1,86 @@
+package fmp4
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+var testVideoTrack = &VideoTrack{
+	SPS: []byte{0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95},
+	PPS: []byte{0x68, 0xce, 0x3c, 0x80},
+}
+
+var testAudioTrack = &AudioTrack{
+	Config: &aac.MPEG4AudioConfig{
+		Type:         aac.MPEG4AudioTypeAACLC,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	},
+}
+
+type testBox struct {
+	typ      string
+	content  []byte
+	children []*testBox
+}
+
+var testContainerBoxes = map[string]struct{}{
+	"moov": {},
+	"trak": {},
+	"mdia": {},
+	"minf": {},
+	"stbl": {},
+	"dinf": {},
+	"mvex": {},
+	"moof": {},
+	"traf": {},
+}
+
+func parseBoxes(t *testing.T, byts []byte) []*testBox {
+	var ret []*testBox
+
+	for len(byts) > 0 {
+		require.GreaterOrEqual(t, len(byts), 8)
+		size := int(binary.BigEndian.Uint32(byts))
+		require.GreaterOrEqual(t, size, 8)
+		require.LessOrEqual(t, size, len(byts))
+
+		b := &testBox{
+			typ:     string(byts[4:8]),
+			content: byts[8:size],
+		}
+
+		if _, ok := testContainerBoxes[b.typ]; ok {
+			b.children = parseBoxes(t, b.content)
+		}
+
+		ret = append(ret, b)
+		byts = byts[size:]
+	}
+
+	return ret
+}
+
+func boxTypes(boxes []*testBox) []string {
+	var ret []string
+	for _, b := range boxes {
+		ret = append(ret, b.typ)
+	}
+	return ret
+}
+
+func findBox(boxes []*testBox, path ...string) *testBox {
+	for _, b := range boxes {
+		if b.typ == path[0] {
+			if len(path) == 1 {
+				return b
+			}
+			return findBox(b.children, path[1:]...)
+		}
+	}
+	return nil
+}
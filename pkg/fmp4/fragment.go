@@ -0,0 +1,97 @@
+package fmp4
+
+type fragmentTrack struct {
+	id       int
+	baseTime uint64
+	samples  []*sample
+	video    bool
+}
+
+const (
+	trunFlagDataOffsetPresent                   = 0x01
+	trunFlagSampleDurationPresent               = 0x100
+	trunFlagSampleSizePresent                   = 0x200
+	trunFlagSampleFlagsPresent                  = 0x400
+	trunFlagSampleCompositionTimeOffsetsPresent = 0x800
+
+	tfhdFlagDefaultBaseIsMoof = 0x020000
+
+	sampleFlagsSync    = 0x02000000 // sample_depends_on = 2
+	sampleFlagsNonSync = 0x01010000 // sample_depends_on = 1, sample_is_non_sync_sample = 1
+)
+
+func generateTraf(track *fragmentTrack, dataOffset int) []byte {
+	var tfhd fields
+	tfhd.uint32(uint32(track.id))
+
+	var tfdt fields
+	tfdt.uint64(track.baseTime)
+
+	flags := uint32(trunFlagDataOffsetPresent |
+		trunFlagSampleDurationPresent |
+		trunFlagSampleSizePresent)
+	if track.video {
+		flags |= trunFlagSampleFlagsPresent |
+			trunFlagSampleCompositionTimeOffsetsPresent
+	}
+
+	var trun fields
+	trun.uint32(uint32(len(track.samples)))
+	trun.uint32(uint32(dataOffset))
+
+	for _, s := range track.samples {
+		trun.uint32(s.duration)
+		trun.uint32(uint32(len(s.payload)))
+
+		if track.video {
+			if s.nonSync {
+				trun.uint32(sampleFlagsNonSync)
+			} else {
+				trun.uint32(sampleFlagsSync)
+			}
+			trun.uint32(uint32(s.ptsOffset))
+		}
+	}
+
+	return box("traf",
+		fullBox("tfhd", 0, tfhdFlagDefaultBaseIsMoof, tfhd),
+		fullBox("tfdt", 1, 0, tfdt),
+		fullBox("trun", 1, flags, trun))
+}
+
+func generateMoof(sequenceNumber uint32, tracks []*fragmentTrack, moofSize int) []byte {
+	var mfhd fields
+	mfhd.uint32(sequenceNumber)
+
+	content := [][]byte{fullBox("mfhd", 0, 0, mfhd)}
+
+	// data starts after the moof and the mdat header
+	dataOffset := moofSize + 8
+
+	for _, track := range tracks {
+		content = append(content, generateTraf(track, dataOffset))
+
+		for _, s := range track.samples {
+			dataOffset += len(s.payload)
+		}
+	}
+
+	return box("moof", content...)
+}
+
+// generateFragment generates a fragment (moof + mdat).
+func generateFragment(sequenceNumber uint32, tracks []*fragmentTrack) []byte {
+	// the size of the moof doesn't depend on data offsets,
+	// therefore it can be computed in advance.
+	moofSize := len(generateMoof(sequenceNumber, tracks, 0))
+	moof := generateMoof(sequenceNumber, tracks, moofSize)
+
+	var mdat [][]byte
+	for _, track := range tracks {
+		for _, s := range track.samples {
+			mdat = append(mdat, s.payload)
+		}
+	}
+
+	return append(moof, box("mdat", mdat...)...)
+}
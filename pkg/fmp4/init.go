@@ -0,0 +1,286 @@
+package fmp4
+
+import (
+	"fmt"
+
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+func generateFtyp() []byte {
+	var f fields
+	f.bytes([]byte("iso5")) // major brand
+	f.uint32(1)             // minor version
+	f.bytes([]byte("iso5")) // compatible brands
+	f.bytes([]byte("iso6"))
+	f.bytes([]byte("mp41"))
+	return box("ftyp", f)
+}
+
+func generateMvhd(nextTrackID int) []byte {
+	var f fields
+	f.uint32(0)          // creation time
+	f.uint32(0)          // modification time
+	f.uint32(1000)       // timescale
+	f.uint32(0)          // duration
+	f.uint32(0x00010000) // rate
+	f.uint16(0x0100)     // volume
+	f.zeros(10)          // reserved
+	f.bytes(matrix)
+	f.zeros(24) // pre-defined
+	f.uint32(uint32(nextTrackID))
+	return fullBox("mvhd", 0, 0, f)
+}
+
+func generateTkhd(trackID int, audio bool, width int, height int) []byte {
+	var f fields
+	f.uint32(0) // creation time
+	f.uint32(0) // modification time
+	f.uint32(uint32(trackID))
+	f.uint32(0) // reserved
+	f.uint32(0) // duration
+	f.zeros(8)  // reserved
+	f.uint16(0) // layer
+	f.uint16(0) // alternate group
+	if audio {
+		f.uint16(0x0100) // volume
+	} else {
+		f.uint16(0)
+	}
+	f.uint16(0) // reserved
+	f.bytes(matrix)
+	f.uint32(uint32(width) << 16)
+	f.uint32(uint32(height) << 16)
+	return fullBox("tkhd", 0, 3, f) // track enabled, track in movie
+}
+
+func generateMdhd(timescale int) []byte {
+	var f fields
+	f.uint32(0) // creation time
+	f.uint32(0) // modification time
+	f.uint32(uint32(timescale))
+	f.uint32(0)      // duration
+	f.uint16(0x55c4) // language: und
+	f.uint16(0)      // pre-defined
+	return fullBox("mdhd", 0, 0, f)
+}
+
+func generateHdlr(audio bool) []byte {
+	var f fields
+	f.uint32(0) // pre-defined
+	if audio {
+		f.bytes([]byte("soun"))
+	} else {
+		f.bytes([]byte("vide"))
+	}
+	f.zeros(12) // reserved
+	if audio {
+		f.bytes([]byte("SoundHandler\x00"))
+	} else {
+		f.bytes([]byte("VideoHandler\x00"))
+	}
+	return fullBox("hdlr", 0, 0, f)
+}
+
+func generateDinf() []byte {
+	var f fields
+	f.uint32(1) // entry count
+	return box("dinf",
+		fullBox("dref", 0, 0, f,
+			fullBox("url ", 0, 1))) // media data is in the same file
+}
+
+func generateStbl(stsd []byte) []byte {
+	var empty fields
+	empty.uint32(0) // entry count
+
+	var stsz fields
+	stsz.uint32(0) // sample size
+	stsz.uint32(0) // sample count
+
+	return box("stbl",
+		stsd,
+		fullBox("stts", 0, 0, empty),
+		fullBox("stsc", 0, 0, empty),
+		fullBox("stsz", 0, 0, stsz),
+		fullBox("stco", 0, 0, empty))
+}
+
+func generateStsd(entry []byte) []byte {
+	var f fields
+	f.uint32(1) // entry count
+	return fullBox("stsd", 0, 0, f, entry)
+}
+
+func generateAvc1(track *VideoTrack, width int, height int) []byte {
+	var avcc fields
+	avcc.uint8(1)            // configuration version
+	avcc.uint8(track.SPS[1]) // profile
+	avcc.uint8(track.SPS[2]) // profile compatibility
+	avcc.uint8(track.SPS[3]) // level
+	avcc.uint8(0xFC | 0x03)  // length size minus one = 3
+	avcc.uint8(0xE0 | 0x01)  // number of SPS
+	avcc.uint16(uint16(len(track.SPS)))
+	avcc.bytes(track.SPS)
+	avcc.uint8(1) // number of PPS
+	avcc.uint16(uint16(len(track.PPS)))
+	avcc.bytes(track.PPS)
+
+	var f fields
+	f.zeros(6)  // reserved
+	f.uint16(1) // data reference index
+	f.zeros(16) // pre-defined, reserved
+	f.uint16(uint16(width))
+	f.uint16(uint16(height))
+	f.uint32(0x00480000) // horizontal resolution
+	f.uint32(0x00480000) // vertical resolution
+	f.uint32(0)          // reserved
+	f.uint16(1)          // frame count
+	f.zeros(32)          // compressor name
+	f.uint16(0x0018)     // depth
+	f.uint16(0xFFFF)     // pre-defined
+
+	return box("avc1", f, box("avcC", avcc))
+}
+
+func generateEsds(trackID int, config []byte) []byte {
+	// ref: ISO 14496-1, 7.2.6
+	var dsi fields
+	dsi.uint8(0x05) // DecoderSpecificInfo tag
+	dsi.uint8(uint8(len(config)))
+	dsi.bytes(config)
+
+	var dcd fields
+	dcd.uint8(0x04) // DecoderConfigDescriptor tag
+	dcd.uint8(uint8(13 + len(dsi)))
+	dcd.uint8(0x40) // object type indication: MPEG-4 audio
+	dcd.uint8(0x15) // stream type: audio
+	dcd.zeros(3)    // buffer size
+	dcd.uint32(0)   // max bitrate
+	dcd.uint32(0)   // average bitrate
+	dcd.bytes(dsi)
+
+	var es fields
+	es.uint8(0x03) // ES_Descriptor tag
+	es.uint8(uint8(3 + len(dcd) + 3))
+	es.uint16(uint16(trackID)) // ES ID
+	es.uint8(0)                // flags
+	es.bytes(dcd)
+	es.uint8(0x06) // SLConfigDescriptor tag
+	es.uint8(1)
+	es.uint8(0x02) // predefined
+
+	return fullBox("esds", 0, 0, es)
+}
+
+func generateMp4a(trackID int, track *AudioTrack, config []byte) []byte {
+	var f fields
+	f.zeros(6)  // reserved
+	f.uint16(1) // data reference index
+	f.zeros(8)  // reserved
+	f.uint16(uint16(track.Config.ChannelCount))
+	f.uint16(16) // sample size
+	f.uint16(0)  // pre-defined
+	f.uint16(0)  // reserved
+	f.uint32(uint32(track.Config.SampleRate) << 16)
+
+	return box("mp4a", f, generateEsds(trackID, config))
+}
+
+func generateVideoTrak(trackID int, track *VideoTrack) ([]byte, error) {
+	var sps h264.SPS
+	err := sps.Unmarshal(track.SPS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPS: %v", err)
+	}
+
+	if len(track.PPS) == 0 {
+		return nil, fmt.Errorf("PPS is missing")
+	}
+
+	width := sps.Width()
+	height := sps.Height()
+
+	var vmhd fields
+	vmhd.zeros(8) // graphics mode, opcolor
+
+	return box("trak",
+		generateTkhd(trackID, false, width, height),
+		box("mdia",
+			generateMdhd(videoTimescale),
+			generateHdlr(false),
+			box("minf",
+				fullBox("vmhd", 0, 1, vmhd),
+				generateDinf(),
+				generateStbl(generateStsd(generateAvc1(track, width, height)))))), nil
+}
+
+func generateAudioTrak(trackID int, track *AudioTrack) ([]byte, error) {
+	if track.Config == nil {
+		return nil, fmt.Errorf("AAC configuration is missing")
+	}
+
+	config, err := track.Config.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var smhd fields
+	smhd.zeros(4) // balance, reserved
+
+	return box("trak",
+		generateTkhd(trackID, true, 0, 0),
+		box("mdia",
+			generateMdhd(track.Config.SampleRate),
+			generateHdlr(true),
+			box("minf",
+				fullBox("smhd", 0, 0, smhd),
+				generateDinf(),
+				generateStbl(generateStsd(generateMp4a(trackID, track, config)))))), nil
+}
+
+func generateTrex(trackID int) []byte {
+	var f fields
+	f.uint32(uint32(trackID))
+	f.uint32(1) // default sample description index
+	f.uint32(0) // default sample duration
+	f.uint32(0) // default sample size
+	f.uint32(0) // default sample flags
+	return fullBox("trex", 0, 0, f)
+}
+
+// GenerateInit generates a fMP4 initialization segment (ftyp + moov).
+// At least one track must be provided.
+func GenerateInit(videoTrack *VideoTrack, audioTrack *AudioTrack) ([]byte, error) {
+	if videoTrack == nil && audioTrack == nil {
+		return nil, fmt.Errorf("at least one track must be provided")
+	}
+
+	var traks [][]byte
+	var trexs [][]byte
+	trackID := 1
+
+	if videoTrack != nil {
+		trak, err := generateVideoTrak(trackID, videoTrack)
+		if err != nil {
+			return nil, err
+		}
+		traks = append(traks, trak)
+		trexs = append(trexs, generateTrex(trackID))
+		trackID++
+	}
+
+	if audioTrack != nil {
+		trak, err := generateAudioTrak(trackID, audioTrack)
+		if err != nil {
+			return nil, err
+		}
+		traks = append(traks, trak)
+		trexs = append(trexs, generateTrex(trackID))
+		trackID++
+	}
+
+	moovContent := append([][]byte{generateMvhd(trackID)}, traks...)
+	moovContent = append(moovContent, box("mvex", trexs...))
+
+	return append(generateFtyp(), box("moov", moovContent...)...), nil
+}
@@ -0,0 +1,124 @@
+package fmp4
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateInit(t *testing.T) {
+	byts, err := GenerateInit(testVideoTrack, testAudioTrack)
+	require.NoError(t, err)
+
+	boxes := parseBoxes(t, byts)
+	require.Equal(t, []string{"ftyp", "moov"}, boxTypes(boxes))
+
+	require.Equal(t, []byte{
+		'i', 's', 'o', '5',
+		0x00, 0x00, 0x00, 0x01,
+		'i', 's', 'o', '5',
+		'i', 's', 'o', '6',
+		'm', 'p', '4', '1',
+	}, boxes[0].content)
+
+	moov := boxes[1]
+	require.Equal(t, []string{"mvhd", "trak", "trak", "mvex"}, boxTypes(moov.children))
+	require.Equal(t, []string{"trex", "trex"}, boxTypes(moov.children[3].children))
+
+	video := moov.children[1]
+	require.Equal(t, []string{"tkhd", "mdia"}, boxTypes(video.children))
+
+	tkhd := findBox(video.children, "tkhd")
+	require.Equal(t, []byte{0x00, 0x00, 0x00, 0x01}, tkhd.content[12:16]) // track ID
+	require.Equal(t, []byte{
+		0x07, 0x80, 0x00, 0x00, // width
+		0x04, 0x38, 0x00, 0x00, // height
+	}, tkhd.content[76:84])
+
+	mdhd := findBox(video.children, "mdia", "mdhd")
+	require.Equal(t, []byte{0x00, 0x01, 0x5f, 0x90}, mdhd.content[12:16]) // timescale
+
+	stsd := findBox(video.children, "mdia", "minf", "stbl", "stsd")
+	require.Equal(t, "avc1", string(stsd.content[12:16]))
+	require.Equal(t, []byte{
+		0x00, 0x00, 0x00, 0x21, 'a', 'v', 'c', 'C',
+		0x01, 0x42, 0xc0, 0x28, 0xff, 0xe1,
+		0x00, 0x0a, 0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95,
+		0x01,
+		0x00, 0x04, 0x68, 0xce, 0x3c, 0x80,
+	}, stsd.content[8+86:])
+
+	audio := moov.children[2]
+
+	tkhd = findBox(audio.children, "tkhd")
+	require.Equal(t, []byte{0x00, 0x00, 0x00, 0x02}, tkhd.content[12:16]) // track ID
+
+	mdhd = findBox(audio.children, "mdia", "mdhd")
+	require.Equal(t, []byte{0x00, 0x00, 0xbb, 0x80}, mdhd.content[12:16]) // timescale
+
+	hdlr := findBox(audio.children, "mdia", "hdlr")
+	require.Equal(t, "soun", string(hdlr.content[8:12]))
+
+	stsd = findBox(audio.children, "mdia", "minf", "stbl", "stsd")
+	require.Equal(t, "mp4a", string(stsd.content[12:16]))
+	require.Equal(t, []byte{
+		0x00, 0x00, 0x00, 0x27, 'e', 's', 'd', 's',
+		0x00, 0x00, 0x00, 0x00,
+		0x03, 0x19, 0x00, 0x02, 0x00,
+		0x04, 0x11, 0x40, 0x15, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x05, 0x02, 0x11, 0x90,
+		0x06, 0x01, 0x02,
+	}, stsd.content[8+36:])
+}
+
+func TestGenerateInitSingleTrack(t *testing.T) {
+	byts, err := GenerateInit(nil, testAudioTrack)
+	require.NoError(t, err)
+
+	boxes := parseBoxes(t, byts)
+	moov := findBox(boxes, "moov")
+	require.Equal(t, []string{"mvhd", "trak", "mvex"}, boxTypes(moov.children))
+
+	tkhd := findBox(moov.children, "trak", "tkhd")
+	require.Equal(t, []byte{0x00, 0x00, 0x00, 0x01}, tkhd.content[12:16]) // track ID
+}
+
+func TestGenerateInitErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		video *VideoTrack
+		audio *AudioTrack
+		err   string
+	}{
+		{
+			"no tracks",
+			nil,
+			nil,
+			"at least one track must be provided",
+		},
+		{
+			"invalid SPS",
+			&VideoTrack{SPS: []byte{0x67}, PPS: []byte{0x68}},
+			nil,
+			"invalid SPS: buffer is too short",
+		},
+		{
+			"missing PPS",
+			&VideoTrack{SPS: testVideoTrack.SPS},
+			nil,
+			"PPS is missing",
+		},
+		{
+			"missing AAC config",
+			nil,
+			&AudioTrack{},
+			"AAC configuration is missing",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := GenerateInit(ca.video, ca.audio)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package fmp4
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+const (
+	// number of samples contained in an AAC AU.
+	aacSamplesPerAU = 1024
+
+	// duration of fragments when there's no video track.
+	audioFragmentDuration = 1 * time.Second
+
+	// duration of the last video sample when it can't be computed.
+	defaultVideoSampleDuration = videoTimescale / 30
+)
+
+type sample struct {
+	duration  uint32
+	ptsOffset int32
+	nonSync   bool
+	payload   []byte
+}
+
+// Writer is a fMP4 writer.
+// It writes an initialization segment, followed by fragments.
+// When a video track is present, fragments are cut on IDR boundaries.
+type Writer struct {
+	w            io.Writer
+	videoTrack   *VideoTrack
+	audioTrack   *AudioTrack
+	videoTrackID int
+	audioTrackID int
+
+	dtsExtractor   *h264.DTSExtractor
+	sequenceNumber uint32
+	started        bool
+	startPTS       time.Duration
+	startDTS       time.Duration
+
+	videoSamples    []*sample
+	videoBaseTime   uint64
+	videoPending    *sample
+	videoPendingDTS int64
+	videoPrevDur    uint32
+
+	audioStarted  bool
+	audioSamples  []*sample
+	audioBaseTime uint64
+}
+
+// NewWriter allocates a Writer and writes the initialization segment.
+func NewWriter(w io.Writer, videoTrack *VideoTrack, audioTrack *AudioTrack) (*Writer, error) {
+	init, err := GenerateInit(videoTrack, audioTrack)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(init)
+	if err != nil {
+		return nil, err
+	}
+
+	wr := &Writer{
+		w:          w,
+		videoTrack: videoTrack,
+		audioTrack: audioTrack,
+	}
+
+	trackID := 1
+
+	if videoTrack != nil {
+		wr.videoTrackID = trackID
+		trackID++
+		wr.dtsExtractor = h264.NewDTSExtractor()
+
+		err := wr.dtsExtractor.SetParams(videoTrack.SPS, videoTrack.PPS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if audioTrack != nil {
+		wr.audioTrackID = trackID
+	}
+
+	return wr, nil
+}
+
+// WriteH264 writes a H264 access unit.
+// Access units that precede the first IDR are discarded.
+func (w *Writer) WriteH264(pts time.Duration, au [][]byte) error {
+	if w.videoTrack == nil {
+		return fmt.Errorf("video track is not present")
+	}
+
+	idr := false
+	for _, nalu := range au {
+		if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeIDR {
+			idr = true
+			break
+		}
+	}
+
+	if !w.started {
+		if !idr {
+			return nil
+		}
+	}
+
+	dts, err := w.dtsExtractor.Extract(au, pts)
+	if err != nil {
+		return err
+	}
+
+	if !w.started {
+		w.started = true
+		w.startPTS = pts
+		w.startDTS = dts
+	}
+
+	avcc, err := h264.EncodeAVCC(au)
+	if err != nil {
+		return err
+	}
+
+	dtsTicks := durationToTicks(dts-w.startDTS, videoTimescale)
+	ptsTicks := durationToTicks(pts-w.startDTS, videoTimescale)
+
+	if w.videoPending != nil {
+		w.videoPending.duration = uint32(dtsTicks - w.videoPendingDTS)
+		w.videoPrevDur = w.videoPending.duration
+		w.videoSamples = append(w.videoSamples, w.videoPending)
+	}
+
+	if idr && len(w.videoSamples) > 0 {
+		err := w.flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	w.videoPending = &sample{
+		ptsOffset: int32(ptsTicks - dtsTicks),
+		nonSync:   !idr,
+		payload:   avcc,
+	}
+	w.videoPendingDTS = dtsTicks
+
+	return nil
+}
+
+// WriteAAC writes an AAC AU.
+// When a video track is present, AUs that precede the first IDR are discarded.
+func (w *Writer) WriteAAC(pts time.Duration, au []byte) error {
+	if w.audioTrack == nil {
+		return fmt.Errorf("audio track is not present")
+	}
+
+	if !w.started {
+		if w.videoTrack != nil {
+			return nil
+		}
+
+		w.started = true
+		w.startPTS = pts
+		w.startDTS = pts
+	}
+
+	if pts < w.startPTS {
+		return nil
+	}
+
+	if !w.audioStarted {
+		w.audioStarted = true
+		// video samples are placed on the timeline by DTS, therefore the first
+		// video sample is shown at startPTS - startDTS. Audio is placed on the same
+		// timeline in order to keep tracks in sync.
+		w.audioBaseTime = uint64(durationToTicks(pts-w.startDTS, int64(w.audioTrack.Config.SampleRate)))
+	}
+
+	w.audioSamples = append(w.audioSamples, &sample{
+		duration: aacSamplesPerAU,
+		payload:  au,
+	})
+
+	if w.videoTrack == nil &&
+		int64(len(w.audioSamples)*aacSamplesPerAU) >=
+			durationToTicks(audioFragmentDuration, int64(w.audioTrack.Config.SampleRate)) {
+		return w.flush()
+	}
+
+	return nil
+}
+
+// Close writes the remaining samples.
+func (w *Writer) Close() error {
+	if w.videoPending != nil {
+		w.videoPending.duration = w.videoPrevDur
+		if w.videoPending.duration == 0 {
+			w.videoPending.duration = defaultVideoSampleDuration
+		}
+		w.videoSamples = append(w.videoSamples, w.videoPending)
+		w.videoPending = nil
+	}
+
+	if len(w.videoSamples) == 0 && len(w.audioSamples) == 0 {
+		return nil
+	}
+
+	return w.flush()
+}
+
+func (w *Writer) flush() error {
+	var tracks []*fragmentTrack
+
+	if len(w.videoSamples) > 0 {
+		tracks = append(tracks, &fragmentTrack{
+			id:       w.videoTrackID,
+			baseTime: w.videoBaseTime,
+			samples:  w.videoSamples,
+			video:    true,
+		})
+
+		for _, s := range w.videoSamples {
+			w.videoBaseTime += uint64(s.duration)
+		}
+		w.videoSamples = nil
+	}
+
+	if len(w.audioSamples) > 0 {
+		tracks = append(tracks, &fragmentTrack{
+			id:       w.audioTrackID,
+			baseTime: w.audioBaseTime,
+			samples:  w.audioSamples,
+		})
+
+		for _, s := range w.audioSamples {
+			w.audioBaseTime += uint64(s.duration)
+		}
+		w.audioSamples = nil
+	}
+
+	w.sequenceNumber++
+
+	_, err := w.w.Write(generateFragment(w.sequenceNumber, tracks))
+	return err
+}
@@ -0,0 +1,275 @@
+package fmp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testIDR = []byte{0x65, 0x88, 0x86}
+	testP1  = []byte{0x41, 0x9a, 0x30}
+	testP2  = []byte{0x41, 0x9a, 0x50}
+)
+
+type testTrun struct {
+	dataOffset uint32
+	durations  []uint32
+	sizes      []uint32
+	flags      []uint32
+	ptsOffsets []int32
+}
+
+func parseTrun(t *testing.T, b *testBox) testTrun {
+	flags := binary.BigEndian.Uint32(b.content[0:4]) & 0xFFFFFF
+	count := int(binary.BigEndian.Uint32(b.content[4:8]))
+
+	tr := testTrun{
+		dataOffset: binary.BigEndian.Uint32(b.content[8:12]),
+	}
+
+	pos := 12
+	for i := 0; i < count; i++ {
+		tr.durations = append(tr.durations, binary.BigEndian.Uint32(b.content[pos:]))
+		tr.sizes = append(tr.sizes, binary.BigEndian.Uint32(b.content[pos+4:]))
+		pos += 8
+
+		if (flags & trunFlagSampleFlagsPresent) != 0 {
+			tr.flags = append(tr.flags, binary.BigEndian.Uint32(b.content[pos:]))
+			tr.ptsOffsets = append(tr.ptsOffsets, int32(binary.BigEndian.Uint32(b.content[pos+4:])))
+			pos += 8
+		}
+	}
+
+	require.Equal(t, len(b.content), pos)
+	return tr
+}
+
+func tfdtTime(b *testBox) uint64 {
+	return binary.BigEndian.Uint64(b.content[4:])
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, testVideoTrack, testAudioTrack)
+	require.NoError(t, err)
+
+	init, err := GenerateInit(testVideoTrack, testAudioTrack)
+	require.NoError(t, err)
+	require.Equal(t, init, buf.Bytes())
+	buf.Reset()
+
+	audioAU := []byte{0x01, 0x02, 0x03, 0x04}
+	start := 2 * time.Second
+
+	// audio and video that precede the first IDR are discarded
+	err = w.WriteAAC(start-10*time.Millisecond, audioAU)
+	require.NoError(t, err)
+	err = w.WriteH264(start-40*time.Millisecond, [][]byte{testP1})
+	require.NoError(t, err)
+	require.Equal(t, 0, buf.Len())
+
+	// SPS and PPS are provided by the track
+	err = w.WriteH264(start, [][]byte{testIDR})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = w.WriteAAC(start+time.Duration(i)*time.Second*1024/48000, audioAU)
+		require.NoError(t, err)
+	}
+
+	err = w.WriteH264(start+40*time.Millisecond, [][]byte{testP1})
+	require.NoError(t, err)
+	err = w.WriteH264(start+80*time.Millisecond, [][]byte{testP2})
+	require.NoError(t, err)
+	require.Equal(t, 0, buf.Len())
+
+	// second IDR: the first fragment is written
+	err = w.WriteH264(start+120*time.Millisecond, [][]byte{testIDR})
+	require.NoError(t, err)
+
+	err = w.WriteAAC(start+3*time.Second*1024/48000, audioAU)
+	require.NoError(t, err)
+	err = w.WriteH264(start+160*time.Millisecond, [][]byte{testP1})
+	require.NoError(t, err)
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	byts := buf.Bytes()
+	boxes := parseBoxes(t, byts)
+	require.Equal(t, []string{"moof", "mdat", "moof", "mdat"}, boxTypes(boxes))
+
+	for i, ca := range []struct {
+		videoTime  uint64
+		videoTrun  testTrun
+		videoData  []byte
+		audioTime  uint64
+		audioCount int
+	}{
+		{
+			0,
+			testTrun{
+				durations:  []uint32{3600, 3600, 3600},
+				sizes:      []uint32{7, 7, 7},
+				flags:      []uint32{sampleFlagsSync, sampleFlagsNonSync, sampleFlagsNonSync},
+				ptsOffsets: []int32{0, 0, 0},
+			},
+			[]byte{
+				0x00, 0x00, 0x00, 0x03, 0x65, 0x88, 0x86,
+				0x00, 0x00, 0x00, 0x03, 0x41, 0x9a, 0x30,
+				0x00, 0x00, 0x00, 0x03, 0x41, 0x9a, 0x50,
+			},
+			0,
+			3,
+		},
+		{
+			10800,
+			testTrun{
+				durations:  []uint32{3600, 3600},
+				sizes:      []uint32{7, 7},
+				flags:      []uint32{sampleFlagsSync, sampleFlagsNonSync},
+				ptsOffsets: []int32{0, 0},
+			},
+			[]byte{
+				0x00, 0x00, 0x00, 0x03, 0x65, 0x88, 0x86,
+				0x00, 0x00, 0x00, 0x03, 0x41, 0x9a, 0x30,
+			},
+			3072,
+			1,
+		},
+	} {
+		moof := boxes[i*2]
+		mdat := boxes[i*2+1]
+
+		require.Equal(t, uint32(i+1), binary.BigEndian.Uint32(findBox(moof.children, "mfhd").content[4:]))
+
+		require.Equal(t, []string{"mfhd", "traf", "traf"}, boxTypes(moof.children))
+		video := moof.children[1]
+		audio := moof.children[2]
+
+		require.Equal(t, ca.videoTime, tfdtTime(findBox(video.children, "tfdt")))
+		vtrun := parseTrun(t, findBox(video.children, "trun"))
+		require.Equal(t, uint32(len(moof.content)+16), vtrun.dataOffset)
+		vtrun.dataOffset = 0
+		require.Equal(t, ca.videoTrun, vtrun)
+		require.Equal(t, ca.videoData, mdat.content[:len(ca.videoData)])
+
+		require.Equal(t, ca.audioTime, tfdtTime(findBox(audio.children, "tfdt")))
+		atrun := parseTrun(t, findBox(audio.children, "trun"))
+		require.Equal(t, vtrun.dataOffset+uint32(len(moof.content)+16+len(ca.videoData)), atrun.dataOffset)
+		require.Equal(t, ca.audioCount, len(atrun.durations))
+		for j := range atrun.durations {
+			require.Equal(t, uint32(1024), atrun.durations[j])
+			require.Equal(t, uint32(4), atrun.sizes[j])
+		}
+		require.Equal(t, len(ca.videoData)+ca.audioCount*4, len(mdat.content))
+	}
+}
+
+func TestWriterReordering(t *testing.T) {
+	var buf bytes.Buffer
+
+	// SPS with max_num_reorder_frames = 1
+	videoTrack := &VideoTrack{
+		SPS: []byte{
+			0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50,
+			0x05, 0xbb, 0x01, 0x6c, 0x80, 0x00, 0x00, 0x03,
+			0x00, 0x80, 0x00, 0x00, 0x1e, 0x07, 0x8c, 0x18,
+			0xcb,
+		},
+		PPS: []byte{0x68, 0xee, 0x3c, 0x80},
+	}
+
+	w, err := NewWriter(&buf, videoTrack, testAudioTrack)
+	require.NoError(t, err)
+	buf.Reset()
+
+	start := 2 * time.Second
+	frameDuration := 40 * time.Millisecond
+
+	// decoding order: I0 P2 B1 P4 B3
+	for _, f := range []struct {
+		display int
+		nalu    []byte
+	}{
+		{0, []byte{0x65, 0x9a, 0x10, 0x20}},
+		{2, []byte{0x41, 0x9a, 0x22, 0x40}},
+		{1, []byte{0x01, 0x9a, 0x41, 0x40}},
+		{4, []byte{0x41, 0x9a, 0x44, 0x40}},
+		{3, []byte{0x01, 0x9a, 0x63, 0x40}},
+	} {
+		pts := start + time.Duration(f.display)*frameDuration
+
+		err = w.WriteH264(pts, [][]byte{f.nalu})
+		require.NoError(t, err)
+
+		err = w.WriteAAC(pts, []byte{0x01, 0x02, 0x03, 0x04})
+		require.NoError(t, err)
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	boxes := parseBoxes(t, buf.Bytes())
+	moof := findBox(boxes, "moof")
+	video := moof.children[1]
+	audio := moof.children[2]
+
+	// the first video sample is shown at its decoding time plus its PTS offset
+	vtrun := parseTrun(t, findBox(video.children, "trun"))
+	require.Greater(t, vtrun.ptsOffsets[0], int32(0))
+	videoStart := time.Duration(int64(tfdtTime(findBox(video.children, "tfdt")))+int64(vtrun.ptsOffsets[0])) *
+		time.Second / videoTimescale
+
+	// the first audio sample is shown at the same time, minus rounding errors
+	audioStart := time.Duration(tfdtTime(findBox(audio.children, "tfdt"))) *
+		time.Second / time.Duration(testAudioTrack.Config.SampleRate)
+
+	require.InDelta(t, float64(videoStart), float64(audioStart), float64(time.Millisecond))
+}
+
+func TestWriterAudioOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, nil, testAudioTrack)
+	require.NoError(t, err)
+	buf.Reset()
+
+	// 47 AUs are needed to fill a fragment of 1 second at 48khz
+	for i := 0; i < 50; i++ {
+		err = w.WriteAAC(time.Duration(i)*time.Second*1024/48000, []byte{byte(i)})
+		require.NoError(t, err)
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	boxes := parseBoxes(t, buf.Bytes())
+	require.Equal(t, []string{"moof", "mdat", "moof", "mdat"}, boxTypes(boxes))
+
+	trun := parseTrun(t, findBox(boxes[0].children, "traf", "trun"))
+	require.Equal(t, 47, len(trun.durations))
+
+	require.Equal(t, uint64(47*1024), tfdtTime(findBox(boxes[2].children, "traf", "tfdt")))
+	trun = parseTrun(t, findBox(boxes[2].children, "traf", "trun"))
+	require.Equal(t, 3, len(trun.durations))
+}
+
+func TestWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, nil, testAudioTrack)
+	require.NoError(t, err)
+	err = w.WriteH264(0, [][]byte{testIDR})
+	require.EqualError(t, err, "video track is not present")
+
+	w, err = NewWriter(&buf, testVideoTrack, nil)
+	require.NoError(t, err)
+	err = w.WriteAAC(0, []byte{0x01})
+	require.EqualError(t, err, "audio track is not present")
+}
@@ -0,0 +1,175 @@
+package h264
+
+import (
+	"fmt"
+	"time"
+)
+
+type dtsExtractorFrame struct {
+	period int64
+	poc    int32
+	pts    time.Duration
+}
+
+// DTSExtractor computes the DTS of H264 access units.
+// The DTS is obtained from the picture order count (POC) of each frame
+// and from the max_num_reorder_frames field of the SPS.
+// When the SPS doesn't provide max_num_reorder_frames, or when the stream
+// contains field pictures, DTSExtractor falls back to DTSEstimator.
+type DTSExtractor struct {
+	poc      *POCExtractor
+	fallback *DTSEstimator
+
+	initialized   bool
+	reorderFrames int64
+	frameDuration time.Duration
+	prevDTSFilled bool
+	prevDTS       time.Duration
+
+	// index of the current IDR period
+	period int64
+	// number of frames decoded since the beginning
+	totalDecodeCount int64
+	// frames that can still be used to compute the DTS, sorted by display order
+	frames []dtsExtractorFrame
+	// number of frames removed from the beginning of frames
+	removedFrames int64
+}
+
+// NewDTSExtractor allocates a DTSExtractor.
+func NewDTSExtractor() *DTSExtractor {
+	return &DTSExtractor{
+		poc: NewPOCExtractor(),
+	}
+}
+
+// SetParams provides SPS and PPS that are transmitted out of band
+// (for instance, in the SDP) and are used until the stream contains other ones.
+func (d *DTSExtractor) SetParams(sps []byte, pps []byte) error {
+	return d.poc.SetParams(sps, pps)
+}
+
+func (d *DTSExtractor) initialize() {
+	d.initialized = true
+	d.poc.spsChanged = false
+	d.fallback = nil
+
+	sps := d.poc.sps
+
+	switch {
+	case sps.PicOrderCntType == 2:
+		// output order is equal to decoding order
+		d.reorderFrames = 0
+
+	case sps.VUI != nil && sps.VUI.BitstreamRestriction != nil && sps.FrameMbsOnlyFlag:
+		d.reorderFrames = int64(sps.VUI.BitstreamRestriction.MaxNumReorderFrames)
+
+	default:
+		d.fallback = NewDTSEstimator()
+	}
+
+	d.frameDuration = time.Millisecond
+	if sps.VUI != nil && sps.VUI.TimingInfo != nil && sps.VUI.TimingInfo.TimeScale != 0 {
+		d.frameDuration = time.Duration(sps.VUI.TimingInfo.NumUnitsInTick) * 2 *
+			time.Second / time.Duration(sps.VUI.TimingInfo.TimeScale)
+	}
+}
+
+// Extract returns the DTS of an access unit.
+// SPS and PPS can be either contained into the access unit or
+// provided before the first IDR in a previous call.
+func (d *DTSExtractor) Extract(au [][]byte, pts time.Duration) (time.Duration, error) {
+	err := d.poc.updateParams(au)
+	if err != nil {
+		return 0, err
+	}
+
+	slice, idr := findSlice(au)
+	if slice == nil {
+		return 0, fmt.Errorf("access unit doesn't contain a slice")
+	}
+
+	if !d.initialized {
+		if !idr {
+			return 0, fmt.Errorf("IDR not received yet")
+		}
+
+		if d.poc.sps == nil || len(d.poc.ppss) == 0 {
+			return 0, fmt.Errorf("SPS or PPS not received yet")
+		}
+
+		d.initialize()
+	} else if idr && d.poc.spsChanged {
+		d.initialize()
+	}
+
+	if d.fallback != nil {
+		return d.fallback.Feed(pts), nil
+	}
+
+	poc, err := d.poc.extractSlice(slice, idr)
+	if err != nil {
+		return 0, err
+	}
+
+	if idr {
+		d.period++
+	}
+
+	// insert the frame in display order. Frames of a IDR period are
+	// displayed after all frames of the previous periods.
+	pos := len(d.frames)
+	for pos > 0 && d.frames[pos-1].period == d.period && d.frames[pos-1].poc > poc {
+		pos--
+	}
+	d.frames = append(d.frames, dtsExtractorFrame{})
+	copy(d.frames[pos+1:], d.frames[pos:])
+	d.frames[pos] = dtsExtractorFrame{
+		period: d.period,
+		poc:    poc,
+		pts:    pts,
+	}
+
+	// the DTS of the n-th decoded frame is the PTS of the (n - reorderFrames)-th displayed frame.
+	// Since no more than reorderFrames frames precede a frame in decoding order
+	// and follow it in display order, the (n - reorderFrames)-th displayed frame
+	// has always been received, and the POC step doesn't need to be known.
+	targetIdx := d.totalDecodeCount - d.reorderFrames
+	d.totalDecodeCount++
+
+	firstIdx := d.removedFrames
+	lastIdx := d.removedFrames + int64(len(d.frames)) - 1
+
+	var dts time.Duration
+	switch {
+	case targetIdx < firstIdx:
+		// the target frame precedes the first received frame:
+		// extrapolate from the first frame
+		dts = d.frames[0].pts - time.Duration(firstIdx-targetIdx)*d.frameDuration
+
+	case targetIdx > lastIdx:
+		// the target frame has not been received:
+		// extrapolate from the last frame
+		dts = d.frames[len(d.frames)-1].pts + time.Duration(targetIdx-lastIdx)*d.frameDuration
+
+	default:
+		dts = d.frames[targetIdx-firstIdx].pts
+	}
+
+	if n := targetIdx - d.removedFrames; n > 0 {
+		if n > int64(len(d.frames)) {
+			n = int64(len(d.frames))
+		}
+		d.frames = d.frames[n:]
+		d.removedFrames += n
+	}
+
+	// DTS must be strictly increasing
+	if d.prevDTSFilled && dts <= d.prevDTS {
+		dts = d.prevDTS + time.Microsecond
+	}
+	d.prevDTSFilled = true
+	d.prevDTS = dts
+
+	return dts, nil
+}
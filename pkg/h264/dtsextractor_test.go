@@ -0,0 +1,272 @@
+package h264
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/icza/bitio"
+	"github.com/stretchr/testify/require"
+)
+
+var testDTSSPS = []byte{
+	0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50,
+	0x05, 0xbb, 0x01, 0x6c, 0x80, 0x00, 0x00, 0x03,
+	0x00, 0x80, 0x00, 0x00, 0x1e, 0x07, 0x8c, 0x18,
+	0xcb,
+}
+
+var testDTSPPS = []byte{0x68, 0xee, 0x3c, 0x80}
+
+func writeGolombUnsigned(w *bitio.Writer, v uint32) {
+	v++
+	n := uint8(0)
+	for (v >> n) > 1 {
+		n++
+	}
+	w.WriteBits(0, n)
+	w.WriteBits(uint64(v), n+1)
+}
+
+// testSlice generates a slice NALU that uses testDTSSPS and testDTSPPS.
+func testSlice(idr bool, refIdc uint8, frameNum uint32, poc uint32) []byte {
+	return testSliceWithPPS(0, idr, refIdc, frameNum, poc)
+}
+
+// testSliceWithPPS generates a slice NALU that uses testDTSSPS and the PPS with the given ID.
+func testSliceWithPPS(ppsID uint32, idr bool, refIdc uint8, frameNum uint32, poc uint32) []byte {
+	var buf bytes.Buffer
+	w := bitio.NewWriter(&buf)
+
+	typ := NALUTypeNonIDR
+	if idr {
+		typ = NALUTypeIDR
+	}
+	w.WriteBits(uint64(refIdc)<<5|uint64(typ), 8)
+
+	writeGolombUnsigned(w, 0)     // first_mb_in_slice
+	writeGolombUnsigned(w, 5)     // slice_type
+	writeGolombUnsigned(w, ppsID) // pic_parameter_set_id
+	w.WriteBits(uint64(frameNum), 4)
+	if idr {
+		writeGolombUnsigned(w, 0) // idr_pic_id
+	}
+	w.WriteBits(uint64(poc), 6)
+	w.WriteBool(true) // rbsp_stop_one_bit
+	w.Close()
+
+	return buf.Bytes()
+}
+
+func TestDTSExtractor(t *testing.T) {
+	frameDuration := time.Second / 30
+	displayPTS := func(i int64) time.Duration {
+		return time.Second + time.Duration(i)*frameDuration
+	}
+
+	type frame struct {
+		idr      bool
+		refIdc   uint8
+		frameNum uint32
+		poc      uint32
+		display  int64
+	}
+
+	// B-frame pyramid, followed by a second IDR period
+	frames := []frame{
+		{true, 3, 0, 0, 0},
+		{false, 2, 1, 8, 4},
+		{false, 2, 2, 4, 2},
+		{false, 0, 3, 2, 1},
+		{false, 0, 3, 6, 3},
+		{false, 2, 3, 16, 8},
+		{false, 2, 4, 12, 6},
+		{false, 0, 5, 10, 5},
+		{false, 0, 5, 14, 7},
+		{true, 3, 0, 0, 9},
+		{false, 2, 1, 4, 11},
+		{false, 0, 2, 2, 10},
+	}
+
+	expDTS := []time.Duration{
+		displayPTS(-2),
+		displayPTS(-1),
+		displayPTS(0),
+		displayPTS(1),
+		displayPTS(2),
+		displayPTS(3),
+		displayPTS(4),
+		displayPTS(5),
+		displayPTS(6),
+		displayPTS(7),
+		displayPTS(8),
+		displayPTS(9),
+	}
+
+	ex := NewDTSExtractor()
+
+	for i, f := range frames {
+		au := [][]byte{testSlice(f.idr, f.refIdc, f.frameNum, f.poc)}
+		if i == 0 {
+			au = append([][]byte{testDTSSPS, testDTSPPS}, au...)
+		}
+
+		pts := displayPTS(f.display)
+		dts, err := ex.Extract(au, pts)
+		require.NoError(t, err)
+		require.Equal(t, expDTS[i], dts, "frame %d", i)
+		require.LessOrEqual(t, int64(dts), int64(pts))
+	}
+}
+
+func TestDTSExtractorPOCStep(t *testing.T) {
+	frameDuration := time.Second / 30
+	displayPTS := func(i int64) time.Duration {
+		return time.Second + time.Duration(i)*frameDuration
+	}
+
+	ex := NewDTSExtractor()
+
+	// POC increases by 1 at every frame, access units contain empty NALUs
+	for i, display := range []int64{0, 4, 2, 1, 3, 8, 6, 5, 7} {
+		au := [][]byte{{}, testSlice(i == 0, 2, uint32(i), uint32(display))}
+		if i == 0 {
+			au = append([][]byte{testDTSSPS, testDTSPPS}, au...)
+		}
+
+		dts, err := ex.Extract(au, displayPTS(display))
+		require.NoError(t, err)
+		require.Equal(t, displayPTS(int64(i-2)), dts, "frame %d", i)
+	}
+}
+
+func TestDTSExtractorSetParams(t *testing.T) {
+	ex := NewDTSExtractor()
+	err := ex.SetParams(testDTSSPS, testDTSPPS)
+	require.NoError(t, err)
+
+	// SPS and PPS are not contained into the access unit
+	dts, err := ex.Extract([][]byte{testSlice(true, 3, 0, 0)}, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, time.Second-2*time.Second/30, dts)
+}
+
+func TestDTSExtractorPOCWrap(t *testing.T) {
+	frameDuration := time.Second / 30
+	ex := NewDTSExtractor()
+
+	// P-frames only, pic_order_cnt_lsb wraps around every 32 frames
+	for i := 0; i < 100; i++ {
+		au := [][]byte{testSlice(i == 0, 2, uint32(i%16), uint32(i*2)%64)}
+		if i == 0 {
+			au = append([][]byte{testDTSSPS, testDTSPPS}, au...)
+		}
+
+		pts := time.Duration(i) * frameDuration
+		dts, err := ex.Extract(au, pts)
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(i-2)*frameDuration, dts, "frame %d", i)
+	}
+}
+
+func TestDTSExtractorNoReordering(t *testing.T) {
+	// POC type 2
+	sps := []byte{
+		0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08,
+		0x9f, 0x95,
+	}
+
+	ex := NewDTSExtractor()
+
+	for i := 0; i < 5; i++ {
+		var buf bytes.Buffer
+		w := bitio.NewWriter(&buf)
+		if i == 0 {
+			w.WriteBits(uint64(0x60|NALUTypeIDR), 8)
+		} else {
+			w.WriteBits(uint64(0x40|NALUTypeNonIDR), 8)
+		}
+		writeGolombUnsigned(w, 0)
+		writeGolombUnsigned(w, 5)
+		writeGolombUnsigned(w, 0)
+		w.WriteBits(uint64(i), 4)
+		w.WriteBool(true)
+		w.Close()
+
+		au := [][]byte{sps, testDTSPPS, buf.Bytes()}
+		pts := time.Duration(i) * 40 * time.Millisecond
+
+		dts, err := ex.Extract(au, pts)
+		require.NoError(t, err)
+		require.Equal(t, pts, dts)
+	}
+}
+
+func TestDTSExtractorFallback(t *testing.T) {
+	// no bitstream restriction and field pictures
+	sps := []byte{
+		0x67, 0x4d, 0x40, 0x1e, 0xd1, 0x37, 0xb0, 0x2d,
+		0x09, 0x37, 0xfe, 0x00, 0x20, 0x00, 0x16, 0x20,
+		0x00, 0x00, 0x03, 0x00, 0x20, 0x00, 0x00, 0x06,
+		0x50, 0x80,
+	}
+
+	ex := NewDTSExtractor()
+	est := NewDTSEstimator()
+
+	for i, pts := range []time.Duration{
+		0,
+		800 * time.Millisecond,
+		600 * time.Millisecond,
+		time.Second,
+	} {
+		au := [][]byte{{byte(NALUTypeNonIDR), 0x00}}
+		if i == 0 {
+			au = [][]byte{sps, testDTSPPS, {byte(NALUTypeIDR), 0x00}}
+		}
+
+		dts, err := ex.Extract(au, pts)
+		require.NoError(t, err)
+		require.Equal(t, est.Feed(pts), dts)
+	}
+}
+
+func TestDTSExtractorErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		au   [][]byte
+		err  string
+	}{
+		{
+			"no slice",
+			[][]byte{testDTSSPS, testDTSPPS},
+			"access unit doesn't contain a slice",
+		},
+		{
+			"no IDR",
+			[][]byte{testDTSSPS, testDTSPPS, testSlice(false, 2, 1, 2)},
+			"IDR not received yet",
+		},
+		{
+			"no SPS",
+			[][]byte{testSlice(true, 3, 0, 0)},
+			"SPS or PPS not received yet",
+		},
+		{
+			"invalid SPS",
+			[][]byte{{0x67, 0x64}},
+			"invalid SPS: buffer is too short",
+		},
+		{
+			"invalid slice header",
+			[][]byte{testDTSSPS, testDTSPPS, {byte(NALUTypeIDR) | 0x60}},
+			"invalid slice header: EOF",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			ex := NewDTSExtractor()
+			_, err := ex.Extract(ca.au, 0)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package h264
+
+import (
+	"fmt"
+
+	"github.com/icza/bitio"
+)
+
+func readGolombUnsigned(br *bitio.Reader) (uint32, error) {
+	// ref: ISO 14496-10, 9.1
+	leadingZeroBits := uint32(0)
+
+	for {
+		b, err := br.ReadBits(1)
+		if err != nil {
+			return 0, err
+		}
+
+		if b != 0 {
+			break
+		}
+
+		leadingZeroBits++
+		if leadingZeroBits >= 32 {
+			return 0, fmt.Errorf("invalid value")
+		}
+	}
+
+	if leadingZeroBits == 0 {
+		return 0, nil
+	}
+
+	codeNum, err := br.ReadBits(uint8(leadingZeroBits))
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32((uint64(1) << leadingZeroBits) - 1 + codeNum), nil
+}
+
+func readGolombSigned(br *bitio.Reader) (int32, error) {
+	// ref: ISO 14496-10, 9.1.1
+	v, err := readGolombUnsigned(br)
+	if err != nil {
+		return 0, err
+	}
+
+	vi := int32(v)
+	if (v & 0x01) != 0 {
+		return (vi + 1) / 2, nil
+	}
+	return -vi / 2, nil
+}
@@ -0,0 +1,332 @@
+package h264
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/icza/bitio"
+)
+
+type sliceHeader struct {
+	frameNum               uint32
+	fieldPicFlag           bool
+	picOrderCntLsb         uint32
+	deltaPicOrderCntBottom int32
+	deltaPicOrderCnt       [2]int32
+}
+
+func (h *sliceHeader) unmarshal(buf []byte, idr bool, sps *SPS, ppss map[uint32]*PPS) error {
+	// ref: ISO 14496-10, 7.3.3
+	br := bitio.NewReader(bytes.NewReader(AntiCompetitionRemove(buf[1:])))
+
+	_, err := readGolombUnsigned(br) // first_mb_in_slice
+	if err != nil {
+		return err
+	}
+
+	_, err = readGolombUnsigned(br) // slice_type
+	if err != nil {
+		return err
+	}
+
+	ppsID, err := readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	pps, ok := ppss[ppsID]
+	if !ok {
+		return fmt.Errorf("slice refers to an unknown PPS (%d)", ppsID)
+	}
+
+	if sps.SeparateColourPlaneFlag {
+		_, err := br.ReadBits(2) // colour_plane_id
+		if err != nil {
+			return err
+		}
+	}
+
+	tmp, err := br.ReadBits(uint8(sps.Log2MaxFrameNumMinus4 + 4))
+	if err != nil {
+		return err
+	}
+	h.frameNum = uint32(tmp)
+
+	if !sps.FrameMbsOnlyFlag {
+		h.fieldPicFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		if h.fieldPicFlag {
+			_, err := br.ReadBits(1) // bottom_field_flag
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if idr {
+		_, err := readGolombUnsigned(br) // idr_pic_id
+		if err != nil {
+			return err
+		}
+	}
+
+	switch sps.PicOrderCntType {
+	case 0:
+		tmp, err := br.ReadBits(uint8(sps.Log2MaxPicOrderCntLsbMinus4 + 4))
+		if err != nil {
+			return err
+		}
+		h.picOrderCntLsb = uint32(tmp)
+
+		if pps.BottomFieldPicOrderInFramePresentFlag && !h.fieldPicFlag {
+			h.deltaPicOrderCntBottom, err = readGolombSigned(br)
+			if err != nil {
+				return err
+			}
+		}
+
+	case 1:
+		if !sps.DeltaPicOrderAlwaysZeroFlag {
+			h.deltaPicOrderCnt[0], err = readGolombSigned(br)
+			if err != nil {
+				return err
+			}
+
+			if pps.BottomFieldPicOrderInFramePresentFlag && !h.fieldPicFlag {
+				h.deltaPicOrderCnt[1], err = readGolombSigned(br)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// POCExtractor computes the picture order count (POC) of H264 access units,
+// that is, their position in display order inside their IDR period.
+type POCExtractor struct {
+	spsRaw     []byte
+	sps        *SPS
+	spsChanged bool
+
+	// PPSs, indexed by ID
+	ppsRaw map[uint32][]byte
+	ppss   map[uint32]*PPS
+
+	// number of frames decoded since the last IDR
+	decodeCount int64
+
+	prevPicOrderCntMsb int32
+	prevPicOrderCntLsb int32
+	prevFrameNum       uint32
+	prevFrameNumOffset int32
+}
+
+// NewPOCExtractor allocates a POCExtractor.
+func NewPOCExtractor() *POCExtractor {
+	return &POCExtractor{
+		ppsRaw: make(map[uint32][]byte),
+		ppss:   make(map[uint32]*PPS),
+	}
+}
+
+// SetParams provides SPS and PPS that are transmitted out of band
+// (for instance, in the SDP) and are used until the stream contains other ones.
+func (e *POCExtractor) SetParams(sps []byte, pps []byte) error {
+	return e.updateParams([][]byte{sps, pps})
+}
+
+func (e *POCExtractor) updateParams(au [][]byte) error {
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		switch NALUType(nalu[0] & 0x1F) {
+		case NALUTypeSPS:
+			if bytes.Equal(nalu, e.spsRaw) {
+				continue
+			}
+
+			var sps SPS
+			err := sps.Unmarshal(nalu)
+			if err != nil {
+				return fmt.Errorf("invalid SPS: %v", err)
+			}
+
+			e.spsRaw = append([]byte(nil), nalu...)
+			e.sps = &sps
+			e.spsChanged = true
+
+		case NALUTypePPS:
+			var pps PPS
+			err := pps.Unmarshal(nalu)
+			if err != nil {
+				return fmt.Errorf("invalid PPS: %v", err)
+			}
+
+			if bytes.Equal(nalu, e.ppsRaw[pps.ID]) {
+				continue
+			}
+
+			e.ppsRaw[pps.ID] = append([]byte(nil), nalu...)
+			e.ppss[pps.ID] = &pps
+		}
+	}
+
+	return nil
+}
+
+// findSlice returns the first slice of an access unit and whether it belongs to a IDR.
+func findSlice(au [][]byte) ([]byte, bool) {
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := NALUType(nalu[0] & 0x1F)
+		if typ == NALUTypeIDR || typ == NALUTypeNonIDR {
+			return nalu, (typ == NALUTypeIDR)
+		}
+	}
+
+	return nil, false
+}
+
+func (e *POCExtractor) extractSlice(slice []byte, idr bool) (int32, error) {
+	var h sliceHeader
+	err := h.unmarshal(slice, idr, e.sps, e.ppss)
+	if err != nil {
+		return 0, fmt.Errorf("invalid slice header: %v", err)
+	}
+
+	if idr {
+		e.decodeCount = 0
+	}
+
+	poc := e.picOrderCnt(idr, (slice[0]>>5)&0x03, &h)
+	e.decodeCount++
+
+	return poc, nil
+}
+
+func (e *POCExtractor) picOrderCnt(idr bool, refIdc uint8, h *sliceHeader) int32 {
+	switch e.sps.PicOrderCntType {
+	case 0:
+		// ref: ISO 14496-10, 8.2.1.1
+		if idr {
+			e.prevPicOrderCntMsb = 0
+			e.prevPicOrderCntLsb = 0
+		}
+
+		maxPicOrderCntLsb := int32(1) << (e.sps.Log2MaxPicOrderCntLsbMinus4 + 4)
+		lsb := int32(h.picOrderCntLsb)
+
+		var msb int32
+		switch {
+		case lsb < e.prevPicOrderCntLsb && (e.prevPicOrderCntLsb-lsb) >= (maxPicOrderCntLsb/2):
+			msb = e.prevPicOrderCntMsb + maxPicOrderCntLsb
+
+		case lsb > e.prevPicOrderCntLsb && (lsb-e.prevPicOrderCntLsb) > (maxPicOrderCntLsb/2):
+			msb = e.prevPicOrderCntMsb - maxPicOrderCntLsb
+
+		default:
+			msb = e.prevPicOrderCntMsb
+		}
+
+		if refIdc != 0 {
+			e.prevPicOrderCntMsb = msb
+			e.prevPicOrderCntLsb = lsb
+		}
+
+		return msb + lsb
+
+	case 1:
+		// ref: ISO 14496-10, 8.2.1.2
+		maxFrameNum := int32(1) << (e.sps.Log2MaxFrameNumMinus4 + 4)
+
+		var frameNumOffset int32
+		switch {
+		case idr:
+			frameNumOffset = 0
+
+		case e.prevFrameNum > h.frameNum:
+			frameNumOffset = e.prevFrameNumOffset + maxFrameNum
+
+		default:
+			frameNumOffset = e.prevFrameNumOffset
+		}
+
+		e.prevFrameNum = h.frameNum
+		e.prevFrameNumOffset = frameNumOffset
+
+		numRefFramesInPicOrderCntCycle := int32(len(e.sps.OffsetForRefFrames))
+
+		absFrameNum := int32(0)
+		if numRefFramesInPicOrderCntCycle != 0 {
+			absFrameNum = frameNumOffset + int32(h.frameNum)
+		}
+
+		if refIdc == 0 && absFrameNum > 0 {
+			absFrameNum--
+		}
+
+		expectedPicOrderCnt := int32(0)
+		if absFrameNum > 0 {
+			picOrderCntCycleCnt := (absFrameNum - 1) / numRefFramesInPicOrderCntCycle
+			frameNumInPicOrderCntCycle := (absFrameNum - 1) % numRefFramesInPicOrderCntCycle
+
+			expectedDeltaPerPicOrderCntCycle := int32(0)
+			for _, v := range e.sps.OffsetForRefFrames {
+				expectedDeltaPerPicOrderCntCycle += v
+			}
+
+			expectedPicOrderCnt = picOrderCntCycleCnt * expectedDeltaPerPicOrderCntCycle
+			for i := int32(0); i <= frameNumInPicOrderCntCycle; i++ {
+				expectedPicOrderCnt += e.sps.OffsetForRefFrames[i]
+			}
+		}
+
+		if refIdc == 0 {
+			expectedPicOrderCnt += e.sps.OffsetForNonRefPic
+		}
+
+		return expectedPicOrderCnt + h.deltaPicOrderCnt[0]
+
+	default:
+		// ref: ISO 14496-10, 8.2.1.3
+		// POC is proportional to the decoding order
+		return int32(e.decodeCount) * 2
+	}
+}
+
+// Extract returns the POC of an access unit and whether the access unit is a IDR.
+// SPS and PPS can be either contained into the access unit or
+// provided in a previous call.
+func (e *POCExtractor) Extract(au [][]byte) (int32, bool, error) {
+	err := e.updateParams(au)
+	if err != nil {
+		return 0, false, err
+	}
+
+	slice, idr := findSlice(au)
+	if slice == nil {
+		return 0, false, fmt.Errorf("access unit doesn't contain a slice")
+	}
+
+	if e.sps == nil || len(e.ppss) == 0 {
+		return 0, false, fmt.Errorf("SPS or PPS not received yet")
+	}
+
+	poc, err := e.extractSlice(slice, idr)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return poc, idr, nil
+}
@@ -0,0 +1,78 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPOCExtractor(t *testing.T) {
+	e := NewPOCExtractor()
+	err := e.SetParams(testDTSSPS, testDTSPPS)
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		idr      bool
+		refIdc   uint8
+		frameNum uint32
+		pocLsb   uint32
+		poc      int32
+	}{
+		{true, 3, 0, 0, 0},
+		{false, 2, 1, 8, 8},
+		{false, 2, 2, 4, 4},
+		{false, 0, 3, 2, 2},
+		{false, 2, 3, 30, 30},
+		{false, 2, 4, 50, 50},
+		// pic_order_cnt_lsb wraps around
+		{false, 2, 5, 10, 74},
+		{true, 3, 0, 0, 0},
+	} {
+		poc, idr, err := e.Extract([][]byte{{}, testSlice(ca.idr, ca.refIdc, ca.frameNum, ca.pocLsb)})
+		require.NoError(t, err)
+		require.Equal(t, ca.idr, idr)
+		require.Equal(t, ca.poc, poc)
+	}
+}
+
+func TestPOCExtractorMultiplePPS(t *testing.T) {
+	// same as testDTSPPS, with ID 1
+	pps1 := []byte{0x68, 0x5b, 0x8f, 0x20}
+
+	var pps PPS
+	err := pps.Unmarshal(pps1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), pps.ID)
+
+	e := NewPOCExtractor()
+	err = e.SetParams(testDTSSPS, testDTSPPS)
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		au  [][]byte
+		poc int32
+	}{
+		{[][]byte{pps1, testSliceWithPPS(1, true, 3, 0, 0)}, 0},
+		{[][]byte{testSliceWithPPS(0, false, 2, 1, 4)}, 4},
+		{[][]byte{testSliceWithPPS(1, false, 0, 2, 2)}, 2},
+		{[][]byte{testSliceWithPPS(0, false, 2, 2, 8)}, 8},
+	} {
+		poc, _, err := e.Extract(ca.au)
+		require.NoError(t, err)
+		require.Equal(t, ca.poc, poc)
+	}
+
+	_, _, err = e.Extract([][]byte{testSliceWithPPS(2, false, 2, 3, 12)})
+	require.EqualError(t, err, "invalid slice header: slice refers to an unknown PPS (2)")
+}
+
+func TestPOCExtractorErrors(t *testing.T) {
+	e := NewPOCExtractor()
+
+	_, _, err := e.Extract([][]byte{testDTSSPS, testDTSPPS})
+	require.EqualError(t, err, "access unit doesn't contain a slice")
+
+	e = NewPOCExtractor()
+	_, _, err = e.Extract([][]byte{testSlice(true, 3, 0, 0)})
+	require.EqualError(t, err, "SPS or PPS not received yet")
+}
@@ -0,0 +1,230 @@
+package h264
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/icza/bitio"
+)
+
+// PPS is a H264 picture parameter set.
+// Fields that follow redundant_pic_cnt_present_flag are not decoded,
+// since their syntax depends on the SPS.
+type PPS struct {
+	ID                                    uint32
+	SPSID                                 uint32
+	EntropyCodingModeFlag                 bool
+	BottomFieldPicOrderInFramePresentFlag bool
+	NumSliceGroupsMinus1                  uint32
+
+	// if NumSliceGroupsMinus1 > 0
+	SliceGroupMapType uint32
+
+	// if SliceGroupMapType == 0
+	RunLengthMinus1 []uint32
+
+	// if SliceGroupMapType == 2
+	TopLeft     []uint32
+	BottomRight []uint32
+
+	// if SliceGroupMapType == 3, 4 or 5
+	SliceGroupChangeDirectionFlag bool
+	SliceGroupChangeRateMinus1    uint32
+
+	// if SliceGroupMapType == 6
+	SliceGroupID []uint32
+
+	NumRefIdxL0DefaultActiveMinus1     uint32
+	NumRefIdxL1DefaultActiveMinus1     uint32
+	WeightedPredFlag                   bool
+	WeightedBipredIdc                  uint8
+	PicInitQpMinus26                   int32
+	PicInitQsMinus26                   int32
+	ChromaQpIndexOffset                int32
+	DeblockingFilterControlPresentFlag bool
+	ConstrainedIntraPredFlag           bool
+	RedundantPicCntPresentFlag         bool
+}
+
+func (p *PPS) unmarshalSliceGroups(br *bitio.Reader) error {
+	var err error
+	p.SliceGroupMapType, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	switch p.SliceGroupMapType {
+	case 0:
+		p.RunLengthMinus1 = make([]uint32, p.NumSliceGroupsMinus1+1)
+		for i := range p.RunLengthMinus1 {
+			p.RunLengthMinus1[i], err = readGolombUnsigned(br)
+			if err != nil {
+				return err
+			}
+		}
+
+	case 1:
+
+	case 2:
+		p.TopLeft = make([]uint32, p.NumSliceGroupsMinus1)
+		p.BottomRight = make([]uint32, p.NumSliceGroupsMinus1)
+		for i := range p.TopLeft {
+			p.TopLeft[i], err = readGolombUnsigned(br)
+			if err != nil {
+				return err
+			}
+
+			p.BottomRight[i], err = readGolombUnsigned(br)
+			if err != nil {
+				return err
+			}
+		}
+
+	case 3, 4, 5:
+		p.SliceGroupChangeDirectionFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		p.SliceGroupChangeRateMinus1, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+	case 6:
+		picSizeInMapUnitsMinus1, err := readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		if picSizeInMapUnitsMinus1 > 139264 { // maximum number of macroblocks
+			return fmt.Errorf("invalid pic_size_in_map_units_minus1 (%d)", picSizeInMapUnitsMinus1)
+		}
+
+		// Ceil(Log2(num_slice_groups_minus1 + 1))
+		bits := uint8(0)
+		for (uint32(1) << bits) < (p.NumSliceGroupsMinus1 + 1) {
+			bits++
+		}
+
+		p.SliceGroupID = make([]uint32, picSizeInMapUnitsMinus1+1)
+		for i := range p.SliceGroupID {
+			tmp, err := br.ReadBits(bits)
+			if err != nil {
+				return err
+			}
+			p.SliceGroupID[i] = uint32(tmp)
+		}
+
+	default:
+		return fmt.Errorf("invalid slice_group_map_type (%d)", p.SliceGroupMapType)
+	}
+
+	return nil
+}
+
+// Unmarshal decodes a PPS from bytes.
+func (p *PPS) Unmarshal(buf []byte) error {
+	// ref: ISO 14496-10, 7.3.2.2
+	if len(buf) < 2 {
+		return fmt.Errorf("buffer is too short")
+	}
+
+	typ := NALUType(buf[0] & 0x1F)
+	if typ != NALUTypePPS {
+		return fmt.Errorf("not a PPS")
+	}
+
+	buf = AntiCompetitionRemove(buf[1:])
+	br := bitio.NewReader(bytes.NewReader(buf))
+
+	var err error
+	p.ID, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.SPSID, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.EntropyCodingModeFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	p.BottomFieldPicOrderInFramePresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	p.NumSliceGroupsMinus1, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	if p.NumSliceGroupsMinus1 > 7 {
+		return fmt.Errorf("invalid num_slice_groups_minus1 (%d)", p.NumSliceGroupsMinus1)
+	}
+
+	if p.NumSliceGroupsMinus1 > 0 {
+		err := p.unmarshalSliceGroups(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.NumRefIdxL0DefaultActiveMinus1, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.NumRefIdxL1DefaultActiveMinus1, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.WeightedPredFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := br.ReadBits(2)
+	if err != nil {
+		return err
+	}
+	p.WeightedBipredIdc = uint8(tmp)
+
+	p.PicInitQpMinus26, err = readGolombSigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.PicInitQsMinus26, err = readGolombSigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.ChromaQpIndexOffset, err = readGolombSigned(br)
+	if err != nil {
+		return err
+	}
+
+	p.DeblockingFilterControlPresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	p.ConstrainedIntraPredFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	p.RedundantPicCntPresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
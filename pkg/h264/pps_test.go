@@ -0,0 +1,84 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPPSUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		pps  PPS
+	}{
+		{
+			"cabac",
+			[]byte{0x68, 0xee, 0x3c, 0x80},
+			PPS{
+				EntropyCodingModeFlag:              true,
+				DeblockingFilterControlPresentFlag: true,
+			},
+		},
+		{
+			"weighted bipred",
+			[]byte{0x68, 0xeb, 0xe3, 0xcb, 0x22, 0xc0},
+			PPS{
+				EntropyCodingModeFlag:              true,
+				NumRefIdxL0DefaultActiveMinus1:     2,
+				WeightedPredFlag:                   true,
+				WeightedBipredIdc:                  2,
+				PicInitQpMinus26:                   -3,
+				ChromaQpIndexOffset:                -2,
+				DeblockingFilterControlPresentFlag: true,
+			},
+		},
+		{
+			"slice groups",
+			[]byte{0x68, 0xc5, 0x4f, 0x1e, 0x40},
+			PPS{
+				NumSliceGroupsMinus1:               1,
+				SliceGroupMapType:                  0,
+				RunLengthMinus1:                    []uint32{1, 2},
+				DeblockingFilterControlPresentFlag: true,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var pps PPS
+			err := pps.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.pps, pps)
+		})
+	}
+}
+
+func TestPPSUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"too short",
+			[]byte{0x68},
+			"buffer is too short",
+		},
+		{
+			"not a PPS",
+			[]byte{0x67, 0xee},
+			"not a PPS",
+		},
+		{
+			"truncated",
+			[]byte{0x68, 0xee},
+			"EOF",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var pps PPS
+			err := pps.Unmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
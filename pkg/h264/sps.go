@@ -0,0 +1,808 @@
+package h264
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/icza/bitio"
+)
+
+// maximum decoded picture buffer size, in macroblocks, indexed by level_idc.
+// ref: ISO 14496-10, Table A-1
+var levelMaxDpbMbs = map[uint8]uint32{
+	9:  396,
+	10: 396,
+	11: 900,
+	12: 2376,
+	13: 2376,
+	20: 2376,
+	21: 4752,
+	22: 8100,
+	30: 8100,
+	31: 18000,
+	32: 20480,
+	40: 32768,
+	41: 32768,
+	42: 34816,
+	50: 110400,
+	51: 184320,
+	52: 184320,
+	60: 696320,
+	61: 696320,
+	62: 696320,
+}
+
+func readScalingList(br *bitio.Reader, size int) error {
+	// ref: ISO 14496-10, 7.3.2.1.1.1
+	lastScale := int32(8)
+	nextScale := int32(8)
+
+	for j := 0; j < size; j++ {
+		if nextScale != 0 {
+			deltaScale, err := readGolombSigned(br)
+			if err != nil {
+				return err
+			}
+
+			nextScale = (lastScale + deltaScale + 256) % 256
+		}
+
+		if nextScale != 0 {
+			lastScale = nextScale
+		}
+	}
+
+	return nil
+}
+
+// SPSHRD are the hypothetical reference decoder parameters of a SPS.
+type SPSHRD struct {
+	CpbCntMinus1                       uint32
+	BitRateScale                       uint8
+	CpbSizeScale                       uint8
+	BitRateValueMinus1                 []uint32
+	CpbSizeValueMinus1                 []uint32
+	CbrFlag                            []bool
+	InitialCpbRemovalDelayLengthMinus1 uint8
+	CpbRemovalDelayLengthMinus1        uint8
+	DpbOutputDelayLengthMinus1         uint8
+	TimeOffsetLength                   uint8
+}
+
+func (h *SPSHRD) unmarshal(br *bitio.Reader) error {
+	// ref: ISO 14496-10, E.1.2
+	var err error
+	h.CpbCntMinus1, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	if h.CpbCntMinus1 > 31 {
+		return fmt.Errorf("invalid cpb_cnt_minus1 (%d)", h.CpbCntMinus1)
+	}
+
+	tmp, err := br.ReadBits(8)
+	if err != nil {
+		return err
+	}
+	h.BitRateScale = uint8(tmp >> 4)
+	h.CpbSizeScale = uint8(tmp & 0x0F)
+
+	for i := uint32(0); i <= h.CpbCntMinus1; i++ {
+		v, err := readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+		h.BitRateValueMinus1 = append(h.BitRateValueMinus1, v)
+
+		v, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+		h.CpbSizeValueMinus1 = append(h.CpbSizeValueMinus1, v)
+
+		cbrFlag, err := br.ReadBool()
+		if err != nil {
+			return err
+		}
+		h.CbrFlag = append(h.CbrFlag, cbrFlag)
+	}
+
+	tmp, err = br.ReadBits(20)
+	if err != nil {
+		return err
+	}
+	h.InitialCpbRemovalDelayLengthMinus1 = uint8(tmp >> 15)
+	h.CpbRemovalDelayLengthMinus1 = uint8((tmp >> 10) & 0x1F)
+	h.DpbOutputDelayLengthMinus1 = uint8((tmp >> 5) & 0x1F)
+	h.TimeOffsetLength = uint8(tmp & 0x1F)
+
+	return nil
+}
+
+// SPSTimingInfo is the timing information of a SPS.
+type SPSTimingInfo struct {
+	NumUnitsInTick     uint32
+	TimeScale          uint32
+	FixedFrameRateFlag bool
+}
+
+func (t *SPSTimingInfo) unmarshal(br *bitio.Reader) error {
+	tmp, err := br.ReadBits(32)
+	if err != nil {
+		return err
+	}
+	t.NumUnitsInTick = uint32(tmp)
+
+	tmp, err = br.ReadBits(32)
+	if err != nil {
+		return err
+	}
+	t.TimeScale = uint32(tmp)
+
+	t.FixedFrameRateFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SPSBitstreamRestriction are the bitstream restrictions of a SPS.
+type SPSBitstreamRestriction struct {
+	MotionVectorsOverPicBoundariesFlag bool
+	MaxBytesPerPicDenom                uint32
+	MaxBitsPerMbDenom                  uint32
+	Log2MaxMvLengthHorizontal          uint32
+	Log2MaxMvLengthVertical            uint32
+	MaxNumReorderFrames                uint32
+	MaxDecFrameBuffering               uint32
+}
+
+func (r *SPSBitstreamRestriction) unmarshal(br *bitio.Reader) error {
+	var err error
+	r.MotionVectorsOverPicBoundariesFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	for _, dest := range []*uint32{
+		&r.MaxBytesPerPicDenom,
+		&r.MaxBitsPerMbDenom,
+		&r.Log2MaxMvLengthHorizontal,
+		&r.Log2MaxMvLengthVertical,
+		&r.MaxNumReorderFrames,
+		&r.MaxDecFrameBuffering,
+	} {
+		*dest, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SPSVUI are the video usability information of a SPS.
+type SPSVUI struct {
+	AspectRatioInfoPresentFlag bool
+
+	// if AspectRatioInfoPresentFlag == true
+	AspectRatioIdc uint8
+	SarWidth       uint16
+	SarHeight      uint16
+
+	OverscanInfoPresentFlag bool
+
+	// if OverscanInfoPresentFlag == true
+	OverscanAppropriateFlag    bool
+	VideoSignalTypePresentFlag bool
+
+	// if VideoSignalTypePresentFlag == true
+	VideoFormat                  uint8
+	VideoFullRangeFlag           bool
+	ColourDescriptionPresentFlag bool
+
+	// if ColourDescriptionPresentFlag == true
+	ColourPrimaries         uint8
+	TransferCharacteristics uint8
+	MatrixCoefficients      uint8
+
+	ChromaLocInfoPresentFlag bool
+
+	// if ChromaLocInfoPresentFlag == true
+	ChromaSampleLocTypeTopField    uint32
+	ChromaSampleLocTypeBottomField uint32
+
+	TimingInfo *SPSTimingInfo
+	NalHRD     *SPSHRD
+	VclHRD     *SPSHRD
+
+	// if NalHRD != nil || VclHRD != nil
+	LowDelayHrdFlag bool
+
+	PicStructPresentFlag bool
+	BitstreamRestriction *SPSBitstreamRestriction
+}
+
+func (v *SPSVUI) unmarshal(br *bitio.Reader) error {
+	// ref: ISO 14496-10, E.1.1
+	var err error
+	v.AspectRatioInfoPresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if v.AspectRatioInfoPresentFlag {
+		tmp, err := br.ReadBits(8)
+		if err != nil {
+			return err
+		}
+		v.AspectRatioIdc = uint8(tmp)
+
+		if v.AspectRatioIdc == 255 { // Extended_SAR
+			tmp, err := br.ReadBits(32)
+			if err != nil {
+				return err
+			}
+			v.SarWidth = uint16(tmp >> 16)
+			v.SarHeight = uint16(tmp)
+		}
+	}
+
+	v.OverscanInfoPresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if v.OverscanInfoPresentFlag {
+		v.OverscanAppropriateFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+	}
+
+	v.VideoSignalTypePresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if v.VideoSignalTypePresentFlag {
+		tmp, err := br.ReadBits(3)
+		if err != nil {
+			return err
+		}
+		v.VideoFormat = uint8(tmp)
+
+		v.VideoFullRangeFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		v.ColourDescriptionPresentFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		if v.ColourDescriptionPresentFlag {
+			tmp, err := br.ReadBits(24)
+			if err != nil {
+				return err
+			}
+			v.ColourPrimaries = uint8(tmp >> 16)
+			v.TransferCharacteristics = uint8(tmp >> 8)
+			v.MatrixCoefficients = uint8(tmp)
+		}
+	}
+
+	v.ChromaLocInfoPresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if v.ChromaLocInfoPresentFlag {
+		v.ChromaSampleLocTypeTopField, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		v.ChromaSampleLocTypeBottomField, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	timingInfoPresentFlag, err := br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if timingInfoPresentFlag {
+		v.TimingInfo = &SPSTimingInfo{}
+		err := v.TimingInfo.unmarshal(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	nalHrdParametersPresentFlag, err := br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if nalHrdParametersPresentFlag {
+		v.NalHRD = &SPSHRD{}
+		err := v.NalHRD.unmarshal(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	vclHrdParametersPresentFlag, err := br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if vclHrdParametersPresentFlag {
+		v.VclHRD = &SPSHRD{}
+		err := v.VclHRD.unmarshal(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	if nalHrdParametersPresentFlag || vclHrdParametersPresentFlag {
+		v.LowDelayHrdFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+	}
+
+	v.PicStructPresentFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	bitstreamRestrictionFlag, err := br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if bitstreamRestrictionFlag {
+		v.BitstreamRestriction = &SPSBitstreamRestriction{}
+		err := v.BitstreamRestriction.unmarshal(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SPSFrameCropping is the frame cropping part of a SPS.
+type SPSFrameCropping struct {
+	LeftOffset   uint32
+	RightOffset  uint32
+	TopOffset    uint32
+	BottomOffset uint32
+}
+
+func (c *SPSFrameCropping) unmarshal(br *bitio.Reader) error {
+	var err error
+	for _, dest := range []*uint32{
+		&c.LeftOffset,
+		&c.RightOffset,
+		&c.TopOffset,
+		&c.BottomOffset,
+	} {
+		*dest, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SPS is a H264 sequence parameter set.
+type SPS struct {
+	ProfileIdc         uint8
+	ConstraintSet0Flag bool
+	ConstraintSet1Flag bool
+	ConstraintSet2Flag bool
+	ConstraintSet3Flag bool
+	ConstraintSet4Flag bool
+	ConstraintSet5Flag bool
+	LevelIdc           uint8
+	ID                 uint32
+
+	// only for selected ProfileIdcs
+	ChromaFormatIdc                 uint32
+	SeparateColourPlaneFlag         bool
+	BitDepthLumaMinus8              uint32
+	BitDepthChromaMinus8            uint32
+	QpprimeYZeroTransformBypassFlag bool
+	SeqScalingMatrixPresentFlag     bool
+
+	Log2MaxFrameNumMinus4 uint32
+
+	// pic order count
+	PicOrderCntType uint32
+
+	// if PicOrderCntType == 0
+	Log2MaxPicOrderCntLsbMinus4 uint32
+
+	// if PicOrderCntType == 1
+	DeltaPicOrderAlwaysZeroFlag bool
+	OffsetForNonRefPic          int32
+	OffsetForTopToBottomField   int32
+	OffsetForRefFrames          []int32
+
+	MaxNumRefFrames                uint32
+	GapsInFrameNumValueAllowedFlag bool
+	PicWidthInMbsMinus1            uint32
+	PicHeightInMapUnitsMinus1      uint32
+	FrameMbsOnlyFlag               bool
+
+	// if FrameMbsOnlyFlag == false
+	MbAdaptiveFrameFieldFlag bool
+
+	Direct8x8InferenceFlag bool
+	FrameCropping          *SPSFrameCropping
+	VUI                    *SPSVUI
+}
+
+// Unmarshal decodes a SPS from bytes.
+func (s *SPS) Unmarshal(buf []byte) error {
+	// ref: ISO 14496-10, 7.3.2.1.1
+	if len(buf) < 4 {
+		return fmt.Errorf("buffer is too short")
+	}
+
+	typ := NALUType(buf[0] & 0x1F)
+	if typ != NALUTypeSPS {
+		return fmt.Errorf("not a SPS")
+	}
+
+	buf = AntiCompetitionRemove(buf[1:])
+
+	s.ProfileIdc = buf[0]
+	s.ConstraintSet0Flag = (buf[1] >> 7) == 1
+	s.ConstraintSet1Flag = (buf[1] >> 6 & 0x01) == 1
+	s.ConstraintSet2Flag = (buf[1] >> 5 & 0x01) == 1
+	s.ConstraintSet3Flag = (buf[1] >> 4 & 0x01) == 1
+	s.ConstraintSet4Flag = (buf[1] >> 3 & 0x01) == 1
+	s.ConstraintSet5Flag = (buf[1] >> 2 & 0x01) == 1
+	s.LevelIdc = buf[2]
+
+	br := bitio.NewReader(bytes.NewReader(buf[3:]))
+
+	var err error
+	s.ID, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	switch s.ProfileIdc {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		s.ChromaFormatIdc, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		if s.ChromaFormatIdc > 3 {
+			return fmt.Errorf("invalid chroma_format_idc (%d)", s.ChromaFormatIdc)
+		}
+
+		if s.ChromaFormatIdc == 3 {
+			s.SeparateColourPlaneFlag, err = br.ReadBool()
+			if err != nil {
+				return err
+			}
+		}
+
+		s.BitDepthLumaMinus8, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		s.BitDepthChromaMinus8, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		s.QpprimeYZeroTransformBypassFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		s.SeqScalingMatrixPresentFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		if s.SeqScalingMatrixPresentFlag {
+			n := 8
+			if s.ChromaFormatIdc == 3 {
+				n = 12
+			}
+
+			for i := 0; i < n; i++ {
+				seqScalingListPresentFlag, err := br.ReadBool()
+				if err != nil {
+					return err
+				}
+
+				if seqScalingListPresentFlag {
+					size := 16
+					if i >= 6 {
+						size = 64
+					}
+
+					err := readScalingList(br, size)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+	default:
+		s.ChromaFormatIdc = 1
+		s.SeparateColourPlaneFlag = false
+		s.BitDepthLumaMinus8 = 0
+		s.BitDepthChromaMinus8 = 0
+		s.QpprimeYZeroTransformBypassFlag = false
+		s.SeqScalingMatrixPresentFlag = false
+	}
+
+	s.Log2MaxFrameNumMinus4, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	s.PicOrderCntType, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	switch s.PicOrderCntType {
+	case 0:
+		s.Log2MaxPicOrderCntLsbMinus4, err = readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		s.DeltaPicOrderAlwaysZeroFlag = false
+		s.OffsetForNonRefPic = 0
+		s.OffsetForTopToBottomField = 0
+		s.OffsetForRefFrames = nil
+
+	case 1:
+		s.Log2MaxPicOrderCntLsbMinus4 = 0
+
+		s.DeltaPicOrderAlwaysZeroFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+
+		s.OffsetForNonRefPic, err = readGolombSigned(br)
+		if err != nil {
+			return err
+		}
+
+		s.OffsetForTopToBottomField, err = readGolombSigned(br)
+		if err != nil {
+			return err
+		}
+
+		numRefFramesInPicOrderCntCycle, err := readGolombUnsigned(br)
+		if err != nil {
+			return err
+		}
+
+		if numRefFramesInPicOrderCntCycle > 255 {
+			return fmt.Errorf("invalid num_ref_frames_in_pic_order_cnt_cycle (%d)",
+				numRefFramesInPicOrderCntCycle)
+		}
+
+		s.OffsetForRefFrames = make([]int32, numRefFramesInPicOrderCntCycle)
+		for i := uint32(0); i < numRefFramesInPicOrderCntCycle; i++ {
+			v, err := readGolombSigned(br)
+			if err != nil {
+				return err
+			}
+
+			s.OffsetForRefFrames[i] = v
+		}
+
+	case 2:
+		s.Log2MaxPicOrderCntLsbMinus4 = 0
+		s.DeltaPicOrderAlwaysZeroFlag = false
+		s.OffsetForNonRefPic = 0
+		s.OffsetForTopToBottomField = 0
+		s.OffsetForRefFrames = nil
+
+	default:
+		return fmt.Errorf("invalid pic_order_cnt_type (%d)", s.PicOrderCntType)
+	}
+
+	s.MaxNumRefFrames, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	s.GapsInFrameNumValueAllowedFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	s.PicWidthInMbsMinus1, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	s.PicHeightInMapUnitsMinus1, err = readGolombUnsigned(br)
+	if err != nil {
+		return err
+	}
+
+	s.FrameMbsOnlyFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if !s.FrameMbsOnlyFlag {
+		s.MbAdaptiveFrameFieldFlag, err = br.ReadBool()
+		if err != nil {
+			return err
+		}
+	} else {
+		s.MbAdaptiveFrameFieldFlag = false
+	}
+
+	s.Direct8x8InferenceFlag, err = br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	frameCroppingFlag, err := br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if frameCroppingFlag {
+		s.FrameCropping = &SPSFrameCropping{}
+		err := s.FrameCropping.unmarshal(br)
+		if err != nil {
+			return err
+		}
+	} else {
+		s.FrameCropping = nil
+	}
+
+	vuiParametersPresentFlag, err := br.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if vuiParametersPresentFlag {
+		s.VUI = &SPSVUI{}
+		err := s.VUI.unmarshal(br)
+		if err != nil {
+			return err
+		}
+	} else {
+		s.VUI = nil
+	}
+
+	return nil
+}
+
+func (s SPS) chromaArrayType() uint32 {
+	if s.SeparateColourPlaneFlag {
+		return 0
+	}
+	return s.ChromaFormatIdc
+}
+
+func (s SPS) cropUnits() (int, int) {
+	// ref: ISO 14496-10, 7.4.2.1.1
+	frameMbsOnly := 0
+	if s.FrameMbsOnlyFlag {
+		frameMbsOnly = 1
+	}
+
+	switch s.chromaArrayType() {
+	case 0:
+		return 1, 2 - frameMbsOnly
+
+	case 1: // 4:2:0
+		return 2, 2 * (2 - frameMbsOnly)
+
+	case 2: // 4:2:2
+		return 2, 2 - frameMbsOnly
+
+	default: // 4:4:4
+		return 1, 2 - frameMbsOnly
+	}
+}
+
+// Width returns the video width.
+func (s SPS) Width() int {
+	w := int(s.PicWidthInMbsMinus1+1) * 16
+
+	if s.FrameCropping != nil {
+		cropUnitX, _ := s.cropUnits()
+		w -= int(s.FrameCropping.LeftOffset+s.FrameCropping.RightOffset) * cropUnitX
+	}
+
+	return w
+}
+
+// Height returns the video height.
+func (s SPS) Height() int {
+	frameMbsOnly := 0
+	if s.FrameMbsOnlyFlag {
+		frameMbsOnly = 1
+	}
+
+	h := (2 - frameMbsOnly) * int(s.PicHeightInMapUnitsMinus1+1) * 16
+
+	if s.FrameCropping != nil {
+		_, cropUnitY := s.cropUnits()
+		h -= int(s.FrameCropping.TopOffset+s.FrameCropping.BottomOffset) * cropUnitY
+	}
+
+	return h
+}
+
+// FPS returns the frame rate of the video.
+// It returns zero if the frame rate is not available.
+func (s SPS) FPS() float64 {
+	if s.VUI == nil || s.VUI.TimingInfo == nil || s.VUI.TimingInfo.NumUnitsInTick == 0 {
+		return 0
+	}
+
+	return float64(s.VUI.TimingInfo.TimeScale) / (2 * float64(s.VUI.TimingInfo.NumUnitsInTick))
+}
+
+// MaxNumReorderFrames returns the maximum number of frames that precede
+// any frame in decoding order and follow it in output order.
+func (s SPS) MaxNumReorderFrames() uint32 {
+	if s.VUI != nil && s.VUI.BitstreamRestriction != nil {
+		return s.VUI.BitstreamRestriction.MaxNumReorderFrames
+	}
+
+	// ref: ISO 14496-10, E.2.1
+	switch s.ProfileIdc {
+	case 44, 86, 100, 110, 122, 244:
+		if s.ConstraintSet3Flag {
+			return 0
+		}
+	}
+
+	return s.maxDpbFrames()
+}
+
+func (s SPS) maxDpbFrames() uint32 {
+	// ref: ISO 14496-10, A.3.1
+	maxDpbMbs, ok := levelMaxDpbMbs[s.LevelIdc]
+	if !ok {
+		return 16
+	}
+
+	frameMbsOnly := uint32(0)
+	if s.FrameMbsOnlyFlag {
+		frameMbsOnly = 1
+	}
+
+	picWidthInMbs := s.PicWidthInMbsMinus1 + 1
+	frameHeightInMbs := (2 - frameMbsOnly) * (s.PicHeightInMapUnitsMinus1 + 1)
+
+	v := maxDpbMbs / (picWidthInMbs * frameHeightInMbs)
+	if v > 16 {
+		return 16
+	}
+	return v
+}
@@ -0,0 +1,206 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSPSUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name                string
+		byts                []byte
+		sps                 SPS
+		width               int
+		height              int
+		fps                 float64
+		maxNumReorderFrames uint32
+	}{
+		{
+			"352x288",
+			[]byte{
+				0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b, 0x50, 0xb0,
+				0x4b, 0x42, 0x00, 0x00, 0x03, 0x00, 0x02, 0x00,
+				0x00, 0x03, 0x00, 0x3d, 0x08,
+			},
+			SPS{
+				ProfileIdc:                     100,
+				LevelIdc:                       12,
+				ChromaFormatIdc:                1,
+				Log2MaxFrameNumMinus4:          6,
+				PicOrderCntType:                2,
+				MaxNumRefFrames:                1,
+				GapsInFrameNumValueAllowedFlag: true,
+				PicWidthInMbsMinus1:            21,
+				PicHeightInMapUnitsMinus1:      17,
+				FrameMbsOnlyFlag:               true,
+				Direct8x8InferenceFlag:         true,
+				VUI: &SPSVUI{
+					TimingInfo: &SPSTimingInfo{
+						NumUnitsInTick:     1,
+						TimeScale:          30,
+						FixedFrameRateFlag: true,
+					},
+				},
+			},
+			352,
+			288,
+			15,
+			6,
+		},
+		{
+			"1280x720",
+			[]byte{
+				0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50,
+				0x05, 0xbb, 0x01, 0x6c, 0x80, 0x00, 0x00, 0x03,
+				0x00, 0x80, 0x00, 0x00, 0x1e, 0x07, 0x8c, 0x18,
+				0xcb,
+			},
+			SPS{
+				ProfileIdc:                  100,
+				LevelIdc:                    31,
+				ChromaFormatIdc:             1,
+				Log2MaxPicOrderCntLsbMinus4: 2,
+				MaxNumRefFrames:             4,
+				PicWidthInMbsMinus1:         79,
+				PicHeightInMapUnitsMinus1:   44,
+				FrameMbsOnlyFlag:            true,
+				Direct8x8InferenceFlag:      true,
+				VUI: &SPSVUI{
+					AspectRatioInfoPresentFlag: true,
+					AspectRatioIdc:             1,
+					VideoSignalTypePresentFlag: true,
+					VideoFormat:                5,
+					VideoFullRangeFlag:         true,
+					TimingInfo: &SPSTimingInfo{
+						NumUnitsInTick: 1,
+						TimeScale:      60,
+					},
+					BitstreamRestriction: &SPSBitstreamRestriction{
+						MotionVectorsOverPicBoundariesFlag: true,
+						Log2MaxMvLengthHorizontal:          11,
+						Log2MaxMvLengthVertical:            11,
+						MaxNumReorderFrames:                2,
+						MaxDecFrameBuffering:               4,
+					},
+				},
+			},
+			1280,
+			720,
+			30,
+			2,
+		},
+		{
+			"1920x1080 baseline with cropping",
+			[]byte{
+				0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08,
+				0x9f, 0x95,
+			},
+			SPS{
+				ProfileIdc:                0x42,
+				ConstraintSet0Flag:        true,
+				ConstraintSet1Flag:        true,
+				LevelIdc:                  40,
+				ChromaFormatIdc:           1,
+				PicOrderCntType:           2,
+				MaxNumRefFrames:           1,
+				PicWidthInMbsMinus1:       119,
+				PicHeightInMapUnitsMinus1: 67,
+				FrameMbsOnlyFlag:          true,
+				Direct8x8InferenceFlag:    true,
+				FrameCropping: &SPSFrameCropping{
+					BottomOffset: 4,
+				},
+			},
+			1920,
+			1080,
+			0,
+			4,
+		},
+		{
+			"720x576 interlaced",
+			[]byte{
+				0x67, 0x4d, 0x40, 0x1e, 0xd1, 0x37, 0xb0, 0x2d,
+				0x09, 0x37, 0xfe, 0x00, 0x20, 0x00, 0x16, 0x20,
+				0x00, 0x00, 0x03, 0x00, 0x20, 0x00, 0x00, 0x06,
+				0x50, 0x80,
+			},
+			SPS{
+				ProfileIdc:                0x4d,
+				ConstraintSet1Flag:        true,
+				LevelIdc:                  30,
+				ChromaFormatIdc:           1,
+				PicOrderCntType:           1,
+				OffsetForNonRefPic:        1,
+				OffsetForTopToBottomField: -1,
+				OffsetForRefFrames:        []int32{0, 0},
+				MaxNumRefFrames:           2,
+				PicWidthInMbsMinus1:       44,
+				PicHeightInMapUnitsMinus1: 17,
+				MbAdaptiveFrameFieldFlag:  true,
+				Direct8x8InferenceFlag:    true,
+				VUI: &SPSVUI{
+					AspectRatioInfoPresentFlag: true,
+					AspectRatioIdc:             255,
+					SarWidth:                   16,
+					SarHeight:                  11,
+					TimingInfo: &SPSTimingInfo{
+						NumUnitsInTick:     1,
+						TimeScale:          50,
+						FixedFrameRateFlag: true,
+					},
+				},
+			},
+			720,
+			576,
+			25,
+			5,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sps SPS
+			err := sps.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.sps, sps)
+			require.Equal(t, ca.width, sps.Width())
+			require.Equal(t, ca.height, sps.Height())
+			require.Equal(t, ca.fps, sps.FPS())
+			require.Equal(t, ca.maxNumReorderFrames, sps.MaxNumReorderFrames())
+		})
+	}
+}
+
+func TestSPSUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"too short",
+			[]byte{0x67, 0x64},
+			"buffer is too short",
+		},
+		{
+			"not a SPS",
+			[]byte{0x68, 0x64, 0x00, 0x0c},
+			"not a SPS",
+		},
+		{
+			"truncated",
+			[]byte{0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b},
+			"EOF",
+		},
+		{
+			"invalid golomb",
+			[]byte{0x67, 0x64, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x01},
+			"invalid value",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sps SPS
+			err := sps.Unmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package h265 contains utilities to work with the H265 codec.
+package h265
@@ -0,0 +1,78 @@
+package h265
+
+import (
+	"fmt"
+)
+
+// NALUType is the type of a NALU.
+type NALUType uint8
+
+// standard NALU types.
+const (
+	NALUTypeTrailN         NALUType = 0
+	NALUTypeTrailR         NALUType = 1
+	NALUTypeTSAN           NALUType = 2
+	NALUTypeTSAR           NALUType = 3
+	NALUTypeSTSAN          NALUType = 4
+	NALUTypeSTSAR          NALUType = 5
+	NALUTypeRADLN          NALUType = 6
+	NALUTypeRADLR          NALUType = 7
+	NALUTypeRASLN          NALUType = 8
+	NALUTypeRASLR          NALUType = 9
+	NALUTypeBLAWLP         NALUType = 16
+	NALUTypeBLAWRADL       NALUType = 17
+	NALUTypeBLANLP         NALUType = 18
+	NALUTypeIDRWRADL       NALUType = 19
+	NALUTypeIDRNLP         NALUType = 20
+	NALUTypeCRA            NALUType = 21
+	NALUTypeVPS            NALUType = 32
+	NALUTypeSPS            NALUType = 33
+	NALUTypePPS            NALUType = 34
+	NALUTypeAUD            NALUType = 35
+	NALUTypeEndOfSequence  NALUType = 36
+	NALUTypeEndOfBitstream NALUType = 37
+	NALUTypeFillerData     NALUType = 38
+	NALUTypePrefixSEI      NALUType = 39
+	NALUTypeSuffixSEI      NALUType = 40
+)
+
+var naluTypelabels = map[NALUType]string{
+	NALUTypeTrailN:         "TrailN",
+	NALUTypeTrailR:         "TrailR",
+	NALUTypeTSAN:           "TSAN",
+	NALUTypeTSAR:           "TSAR",
+	NALUTypeSTSAN:          "STSAN",
+	NALUTypeSTSAR:          "STSAR",
+	NALUTypeRADLN:          "RADLN",
+	NALUTypeRADLR:          "RADLR",
+	NALUTypeRASLN:          "RASLN",
+	NALUTypeRASLR:          "RASLR",
+	NALUTypeBLAWLP:         "BLAWLP",
+	NALUTypeBLAWRADL:       "BLAWRADL",
+	NALUTypeBLANLP:         "BLANLP",
+	NALUTypeIDRWRADL:       "IDRWRADL",
+	NALUTypeIDRNLP:         "IDRNLP",
+	NALUTypeCRA:            "CRA",
+	NALUTypeVPS:            "VPS",
+	NALUTypeSPS:            "SPS",
+	NALUTypePPS:            "PPS",
+	NALUTypeAUD:            "AUD",
+	NALUTypeEndOfSequence:  "EndOfSequence",
+	NALUTypeEndOfBitstream: "EndOfBitstream",
+	NALUTypeFillerData:     "FillerData",
+	NALUTypePrefixSEI:      "PrefixSEI",
+	NALUTypeSuffixSEI:      "SuffixSEI",
+}
+
+// String implements fmt.Stringer.
+func (nt NALUType) String() string {
+	if l, ok := naluTypelabels[nt]; ok {
+		return l
+	}
+	return fmt.Sprintf("unknown (%d)", nt)
+}
+
+// IsIRAP checks whether the NALU type is an intra random access point.
+func (nt NALUType) IsIRAP() bool {
+	return nt >= NALUTypeBLAWLP && nt <= 23
+}
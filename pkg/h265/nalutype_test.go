@@ -0,0 +1,20 @@
+package h265
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNALUType(t *testing.T) {
+	require.NotEqual(t, true, strings.HasPrefix(NALUType(33).String(), "unknown"))
+	require.Equal(t, true, strings.HasPrefix(NALUType(50).String(), "unknown"))
+}
+
+func TestNALUTypeIsIRAP(t *testing.T) {
+	require.Equal(t, true, NALUTypeIDRWRADL.IsIRAP())
+	require.Equal(t, true, NALUTypeCRA.IsIRAP())
+	require.Equal(t, false, NALUTypeTrailR.IsIRAP())
+	require.Equal(t, false, NALUTypeSPS.IsIRAP())
+}
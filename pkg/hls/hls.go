@@ -0,0 +1,39 @@
+// Package hls contains a HLS muxer, that converts RTSP streams into
+// HLS playlists that can be played by browsers.
+package hls
+
+// SegmentFormat is the format of HLS segments.
+type SegmentFormat int
+
+// supported segment formats.
+const (
+	SegmentFormatMPEGTS SegmentFormat = iota
+	SegmentFormatFMP4
+)
+
+var segmentFormatLabels = map[SegmentFormat]string{
+	SegmentFormatMPEGTS: "MPEG-TS",
+	SegmentFormatFMP4:   "fMP4",
+}
+
+// String implements fmt.Stringer.
+func (f SegmentFormat) String() string {
+	if l, ok := segmentFormatLabels[f]; ok {
+		return l
+	}
+	return "unknown"
+}
+
+func (f SegmentFormat) extension() string {
+	if f == SegmentFormatFMP4 {
+		return ".mp4"
+	}
+	return ".ts"
+}
+
+func (f SegmentFormat) contentType() string {
+	if f == SegmentFormatFMP4 {
+		return "video/mp4"
+	}
+	return "video/MP2T"
+}
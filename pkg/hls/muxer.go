@@ -0,0 +1,393 @@
+package hls
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib"
+	"github.com/aler9/gortsplib/pkg/fmp4"
+	"github.com/aler9/gortsplib/pkg/h264"
+	"github.com/aler9/gortsplib/pkg/mpegts"
+	"github.com/aler9/gortsplib/pkg/rtpaac"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+const (
+	playlistName = "index.m3u8"
+	initName     = "init.mp4"
+)
+
+// Muxer is a HLS muxer.
+// It converts the H264 and AAC tracks of a stream into a sliding-window
+// HLS playlist, whose segments are cut on IDR boundaries.
+// Playlist and segments are served through ServeHTTP.
+type Muxer struct {
+	format          SegmentFormat
+	segmentCount    int
+	segmentDuration time.Duration
+
+	h264TrackID int
+	h264Conf    *gortsplib.TrackConfigH264
+	h264Decoder *rtph264.Decoder
+	aacTrackID  int
+	aacConf     *gortsplib.TrackConfigAAC
+	aacDecoder  *rtpaac.Decoder
+	tsEncoder   *mpegts.TimestampEncoder
+	fmp4Writer  *fmp4.Writer
+
+	mutex    sync.Mutex
+	init     []byte
+	cur      *segment
+	segments []*segment
+	nextID   uint64
+}
+
+// NewMuxer allocates a Muxer.
+// tracks are the tracks of the stream; they must contain a H264 track
+// and can contain an AAC track.
+// segmentCount is the number of segments kept in the playlist.
+// segmentDuration is the minimum duration of a segment; segments are cut
+// on the first IDR after this duration.
+func NewMuxer(
+	format SegmentFormat,
+	segmentCount int,
+	segmentDuration time.Duration,
+	tracks gortsplib.Tracks) (*Muxer, error) {
+	if segmentCount < 1 {
+		return nil, fmt.Errorf("invalid segment count (%d)", segmentCount)
+	}
+
+	if segmentDuration <= 0 {
+		return nil, fmt.Errorf("invalid segment duration (%v)", segmentDuration)
+	}
+
+	m := &Muxer{
+		format:          format,
+		segmentCount:    segmentCount,
+		segmentDuration: segmentDuration,
+		h264TrackID:     -1,
+		aacTrackID:      -1,
+	}
+
+	for i, t := range tracks {
+		switch {
+		case t.IsH264() && m.h264TrackID < 0:
+			conf, err := t.ExtractConfigH264()
+			if err != nil {
+				return nil, err
+			}
+
+			m.h264TrackID = i
+			m.h264Conf = conf
+
+		case t.IsAAC() && m.aacTrackID < 0:
+			conf, err := t.ExtractConfigAAC()
+			if err != nil {
+				return nil, err
+			}
+
+			m.aacTrackID = i
+			m.aacConf = conf
+		}
+	}
+
+	if m.h264TrackID < 0 {
+		return nil, fmt.Errorf("the stream doesn't contain a H264 track")
+	}
+
+	m.h264Decoder = rtph264.NewDecoder()
+
+	if m.aacTrackID >= 0 {
+		m.aacDecoder = rtpaac.NewDecoder(m.aacConf.SampleRate)
+	}
+
+	switch format {
+	case SegmentFormatMPEGTS:
+		var err error
+		m.tsEncoder, err = mpegts.NewTimestampEncoder(
+			&mpegts.VideoTrack{SPS: m.h264Conf.SPS, PPS: m.h264Conf.PPS})
+		if err != nil {
+			return nil, err
+		}
+
+	case SegmentFormatFMP4:
+		var audioTrack *fmp4.AudioTrack
+		if m.aacTrackID >= 0 {
+			audioTrack = &fmp4.AudioTrack{Config: m.aacConf.MPEG4AudioConfig()}
+		}
+
+		var err error
+		m.fmp4Writer, err = fmp4.NewWriter(
+			muxerWriter{m},
+			&fmp4.VideoTrack{SPS: m.h264Conf.SPS, PPS: m.h264Conf.PPS},
+			audioTrack)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid segment format (%d)", format)
+	}
+
+	return m, nil
+}
+
+// muxerWriter routes the output of the fMP4 writer.
+// The first write is the initialization segment, the next ones are fragments.
+type muxerWriter struct {
+	m *Muxer
+}
+
+func (w muxerWriter) Write(p []byte) (int, error) {
+	if w.m.cur == nil {
+		w.m.init = append(w.m.init, p...)
+	} else {
+		w.m.cur.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// WritePacketRTP writes a RTP packet.
+// It can be called by Client.OnPacketRTP.
+func (m *Muxer) WritePacketRTP(trackID int, payload []byte) error {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	switch trackID {
+	case m.h264TrackID:
+		nalus, pts, err := m.h264Decoder.DecodeUntilMarker(&pkt)
+		if err != nil {
+			if err == rtph264.ErrMorePacketsNeeded ||
+				err == rtph264.ErrNonStartingPacketAndNoPrevious {
+				return nil
+			}
+			return err
+		}
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		return m.writeH264(pts, nalus)
+
+	case m.aacTrackID:
+		aus, pts, err := m.aacDecoder.Decode(&pkt)
+		if err != nil {
+			if err == rtpaac.ErrMorePacketsNeeded {
+				return nil
+			}
+			return err
+		}
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		return m.writeAAC(pts, aus)
+	}
+
+	return nil
+}
+
+func (m *Muxer) newSegment(pts time.Duration) error {
+	s := &segment{
+		id:       m.nextID,
+		startPTS: pts,
+	}
+	m.nextID++
+
+	if m.format == SegmentFormatMPEGTS {
+		var audioTrack *mpegts.AudioTrack
+		if m.aacTrackID >= 0 {
+			audioTrack = &mpegts.AudioTrack{Config: m.aacConf.MPEG4AudioConfig()}
+		}
+
+		var err error
+		s.mpegtsMuxer, err = mpegts.NewMuxer(&s.buf,
+			&mpegts.VideoTrack{SPS: m.h264Conf.SPS, PPS: m.h264Conf.PPS},
+			audioTrack)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.cur = s
+	return nil
+}
+
+func (m *Muxer) finalizeSegment(pts time.Duration) {
+	m.cur.duration = pts - m.cur.startPTS
+	m.cur.mpegtsMuxer = nil
+
+	m.segments = append(m.segments, m.cur)
+	if len(m.segments) > m.segmentCount {
+		m.segments = m.segments[1:]
+	}
+
+	m.cur = nil
+}
+
+func (m *Muxer) writeH264(pts time.Duration, nalus [][]byte) error {
+	idr := false
+	for _, nalu := range nalus {
+		if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeIDR {
+			idr = true
+			break
+		}
+	}
+
+	if m.cur == nil {
+		// wait for the first IDR
+		if !idr {
+			return nil
+		}
+
+		err := m.newSegment(pts)
+		if err != nil {
+			return err
+		}
+	}
+
+	cut := idr && (pts-m.cur.startPTS) >= m.segmentDuration
+
+	switch m.format {
+	case SegmentFormatMPEGTS:
+		if cut {
+			m.finalizeSegment(pts)
+			err := m.newSegment(pts)
+			if err != nil {
+				return err
+			}
+		}
+
+		pts, dts, err := m.tsEncoder.EncodeH264(pts, nalus)
+		if err != nil {
+			return err
+		}
+
+		return m.cur.mpegtsMuxer.WriteH264(pts, dts, nalus)
+
+	default:
+		// the fMP4 writer writes the previous GOP when it receives an IDR,
+		// therefore the segment must be cut after writing.
+		err := m.fmp4Writer.WriteH264(pts, nalus)
+		if err != nil {
+			return err
+		}
+
+		if cut {
+			m.finalizeSegment(pts)
+			return m.newSegment(pts)
+		}
+
+		return nil
+	}
+}
+
+func (m *Muxer) writeAAC(pts time.Duration, aus [][]byte) error {
+	// wait for the first IDR
+	if m.cur == nil {
+		return nil
+	}
+
+	switch m.format {
+	case SegmentFormatMPEGTS:
+		return m.cur.mpegtsMuxer.WriteAAC(m.tsEncoder.EncodeAAC(pts), aus)
+
+	default:
+		for i, au := range aus {
+			err := m.fmp4Writer.WriteAAC(
+				pts+time.Duration(i)*1024*time.Second/time.Duration(m.aacConf.SampleRate), au)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (m *Muxer) generatePlaylist() []byte {
+	targetDuration := 0
+	for _, s := range m.segments {
+		v := int(math.Ceil(s.duration.Seconds()))
+		if v > targetDuration {
+			targetDuration = v
+		}
+	}
+
+	version := "3"
+	if m.format == SegmentFormatFMP4 {
+		version = "7"
+	}
+
+	cnt := "#EXTM3U\n" +
+		"#EXT-X-VERSION:" + version + "\n" +
+		"#EXT-X-TARGETDURATION:" + strconv.FormatInt(int64(targetDuration), 10) + "\n" +
+		"#EXT-X-MEDIA-SEQUENCE:" + strconv.FormatUint(m.segments[0].id, 10) + "\n"
+
+	if m.format == SegmentFormatFMP4 {
+		cnt += "#EXT-X-MAP:URI=\"" + initName + "\"\n"
+	}
+
+	for _, s := range m.segments {
+		cnt += "#EXTINF:" + strconv.FormatFloat(s.duration.Seconds(), 'f', 3, 64) + ",\n" +
+			s.name(m.format) + "\n"
+	}
+
+	return []byte(cnt)
+}
+
+// file returns the content type and the content of a file.
+func (m *Muxer) file(name string) (string, []byte) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	switch {
+	case name == playlistName:
+		// the playlist is available after the first segment has been completed
+		if len(m.segments) == 0 {
+			return "", nil
+		}
+		return "application/x-mpegURL", m.generatePlaylist()
+
+	case name == initName && m.format == SegmentFormatFMP4:
+		return "video/mp4", m.init
+
+	case strings.HasPrefix(name, "seg"):
+		for _, s := range m.segments {
+			if s.name(m.format) == name {
+				// completed segments are not modified anymore
+				return m.format.contentType(), s.buf.Bytes()
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// ServeHTTP implements http.Handler.
+// It serves the playlist (index.m3u8), the initialization segment (init.mp4)
+// and segments. Only the last element of the request path is considered,
+// therefore the Muxer can be mounted under any prefix.
+func (m *Muxer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, cnt := m.file(path.Base(r.URL.Path))
+	if cnt == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(cnt)
+}
@@ -0,0 +1,279 @@
+package hls
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib"
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/mpegts"
+	"github.com/aler9/gortsplib/pkg/rtpaac"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+var (
+	testSPS = []byte{0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95}
+	testPPS = []byte{0x68, 0xce, 0x3c, 0x80}
+	testIDR = []byte{0x65, 0x88, 0x86}
+	testP   = []byte{0x41, 0x9a, 0x30}
+)
+
+type testServerHandler struct {
+	stream *gortsplib.ServerStream
+}
+
+func (sh *testServerHandler) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (
+	*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.stream, nil
+}
+
+func (sh *testServerHandler) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (
+	*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.stream, nil
+}
+
+func (sh *testServerHandler) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func get(m *Muxer, name string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mystream/"+name, nil))
+	return w
+}
+
+func TestMuxer(t *testing.T) {
+	for _, format := range []SegmentFormat{
+		SegmentFormatMPEGTS,
+		SegmentFormatFMP4,
+	} {
+		t.Run(format.String(), func(t *testing.T) {
+			videoTrack, err := gortsplib.NewTrackH264(96, &gortsplib.TrackConfigH264{SPS: testSPS, PPS: testPPS})
+			require.NoError(t, err)
+
+			audioTrack, err := gortsplib.NewTrackAAC(97, &gortsplib.TrackConfigAAC{
+				Type:         2,
+				SampleRate:   48000,
+				ChannelCount: 2,
+			})
+			require.NoError(t, err)
+
+			stream := gortsplib.NewServerStream(gortsplib.Tracks{videoTrack, audioTrack})
+			defer stream.Close()
+
+			s := &gortsplib.Server{
+				Handler:     &testServerHandler{stream: stream},
+				RTSPAddress: "localhost:8557",
+			}
+			err = s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			u, err := base.ParseURL("rtsp://localhost:8557/mystream")
+			require.NoError(t, err)
+
+			transport := gortsplib.TransportTCP
+			c := gortsplib.Client{
+				Transport: &transport,
+			}
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
+
+			tracks, baseURL, _, err := c.Describe(u)
+			require.NoError(t, err)
+
+			m, err := NewMuxer(format, 2, time.Second, tracks)
+			require.NoError(t, err)
+
+			c.OnPacketRTP = func(trackID int, payload []byte) {
+				err := m.WritePacketRTP(trackID, payload)
+				require.NoError(t, err)
+			}
+
+			for _, track := range tracks {
+				_, err := c.Setup(true, baseURL, track, 0, 0)
+				require.NoError(t, err)
+			}
+
+			_, err = c.Play(nil)
+			require.NoError(t, err)
+
+			// the playlist is not available until the first segment is completed
+			require.Equal(t, http.StatusNotFound, get(m, "index.m3u8").Code)
+
+			videoEnc := rtph264.NewEncoder(96, nil, nil, nil)
+			audioEnc := rtpaac.NewEncoder(97, 48000, nil, nil, nil)
+			audioPTS := time.Duration(0)
+
+			// 3 GOPs of 1 second, followed by an IDR that completes the last segment
+			for i := 0; i <= 75; i++ {
+				pts := time.Duration(i) * 40 * time.Millisecond
+
+				nalu := testP
+				if (i % 25) == 0 {
+					nalu = testIDR
+				}
+
+				pkts, err := videoEnc.Encode([][]byte{nalu}, pts)
+				require.NoError(t, err)
+				for _, pkt := range pkts {
+					byts, _ := pkt.Marshal()
+					stream.WritePacketRTP(0, byts)
+				}
+
+				for audioPTS <= pts {
+					pkts, err := audioEnc.Encode([][]byte{{0x01, 0x02, 0x03, 0x04}}, audioPTS)
+					require.NoError(t, err)
+					for _, pkt := range pkts {
+						byts, _ := pkt.Marshal()
+						stream.WritePacketRTP(1, byts)
+					}
+					audioPTS += 1024 * time.Second / 48000
+				}
+			}
+
+			var playlist string
+			for i := 0; i < 50; i++ {
+				res := get(m, "index.m3u8")
+				if res.Code == http.StatusOK {
+					playlist = res.Body.String()
+					if bytes.Contains(res.Body.Bytes(), []byte("seg2")) {
+						require.Equal(t, "application/x-mpegURL", res.Header().Get("Content-Type"))
+						break
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			ext := ".ts"
+			mapTag := ""
+			version := "3"
+			if format == SegmentFormatFMP4 {
+				ext = ".mp4"
+				mapTag = "#EXT-X-MAP:URI=\"init.mp4\"\n"
+				version = "7"
+			}
+
+			require.Equal(t, "#EXTM3U\n"+
+				"#EXT-X-VERSION:"+version+"\n"+
+				"#EXT-X-TARGETDURATION:1\n"+
+				"#EXT-X-MEDIA-SEQUENCE:1\n"+
+				mapTag+
+				"#EXTINF:1.000,\n"+
+				"seg1"+ext+"\n"+
+				"#EXTINF:1.000,\n"+
+				"seg2"+ext+"\n", playlist)
+
+			// segments outside the window are not served anymore
+			require.Equal(t, http.StatusNotFound, get(m, "seg0"+ext).Code)
+
+			res := get(m, "seg1"+ext)
+			require.Equal(t, http.StatusOK, res.Code)
+			seg := res.Body.Bytes()
+
+			if format == SegmentFormatMPEGTS {
+				require.Equal(t, "video/MP2T", res.Header().Get("Content-Type"))
+
+				d := mpegts.NewDemuxer()
+				var frames []*mpegts.Frame
+				for len(seg) > 0 {
+					addFrames, err := d.Decode(seg[:mpegts.PacketSize])
+					require.NoError(t, err)
+					frames = append(frames, addFrames...)
+					seg = seg[mpegts.PacketSize:]
+				}
+				frames = append(frames, d.Flush()...)
+
+				videoCount := 0
+				audioCount := 0
+				for _, f := range frames {
+					if f.Type == mpegts.StreamTypeH264 {
+						videoCount++
+					} else {
+						audioCount++
+					}
+				}
+				require.Equal(t, 25, videoCount)
+				require.NotEqual(t, 0, audioCount)
+			} else {
+				require.Equal(t, "video/mp4", res.Header().Get("Content-Type"))
+				require.Equal(t, []byte("moof"), seg[4:8])
+
+				res = get(m, "init.mp4")
+				require.Equal(t, http.StatusOK, res.Code)
+				require.Equal(t, []byte("ftyp"), res.Body.Bytes()[4:8])
+			}
+		})
+	}
+}
+
+func TestMuxerErrors(t *testing.T) {
+	videoTrack, err := gortsplib.NewTrackH264(96, &gortsplib.TrackConfigH264{SPS: testSPS, PPS: testPPS})
+	require.NoError(t, err)
+
+	audioTrack, err := gortsplib.NewTrackAAC(97, &gortsplib.TrackConfigAAC{
+		Type:         2,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	})
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name            string
+		format          SegmentFormat
+		segmentCount    int
+		segmentDuration time.Duration
+		tracks          gortsplib.Tracks
+		err             string
+	}{
+		{
+			"invalid segment count",
+			SegmentFormatMPEGTS,
+			0,
+			time.Second,
+			gortsplib.Tracks{videoTrack},
+			"invalid segment count (0)",
+		},
+		{
+			"invalid segment duration",
+			SegmentFormatMPEGTS,
+			3,
+			0,
+			gortsplib.Tracks{videoTrack},
+			"invalid segment duration (0s)",
+		},
+		{
+			"missing H264",
+			SegmentFormatMPEGTS,
+			3,
+			time.Second,
+			gortsplib.Tracks{audioTrack},
+			"the stream doesn't contain a H264 track",
+		},
+		{
+			"invalid format",
+			SegmentFormat(15),
+			3,
+			time.Second,
+			gortsplib.Tracks{videoTrack},
+			"invalid segment format (15)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := NewMuxer(ca.format, ca.segmentCount, ca.segmentDuration, ca.tracks)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
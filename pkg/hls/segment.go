@@ -0,0 +1,23 @@
+package hls
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/mpegts"
+)
+
+type segment struct {
+	id       uint64
+	startPTS time.Duration
+	duration time.Duration
+	buf      bytes.Buffer
+
+	// only with SegmentFormatMPEGTS
+	mpegtsMuxer *mpegts.Muxer
+}
+
+func (s *segment) name(format SegmentFormat) string {
+	return "seg" + strconv.FormatUint(s.id, 10) + format.extension()
+}
@@ -0,0 +1,187 @@
+// Package jitterbuffer contains a buffer that reorders RTP packets by sequence number.
+package jitterbuffer
+
+import (
+	"time"
+)
+
+const (
+	// maximum number of packets that can be buffered.
+	// packets whose sequence number is farther than this
+	// from the expected one cause the buffer to be reset.
+	bufferSize = 1024
+)
+
+type entry struct {
+	payload []byte
+	recv    time.Time
+	present bool
+}
+
+// JitterBuffer reorders RTP packets by sequence number.
+// Packets are held until the missing packets that precede them arrive,
+// or until the oldest buffered packet waited longer than the latency;
+// in the latter case, missing packets are skipped and counted as lost.
+type JitterBuffer struct {
+	latency time.Duration
+
+	initialized bool
+	expected    uint16
+	entries     [bufferSize]entry
+	count       int
+	span        int // distance between expected and the farthest buffered packet, plus one
+}
+
+// New allocates a JitterBuffer.
+func New(latency time.Duration) *JitterBuffer {
+	return &JitterBuffer{
+		latency: latency,
+	}
+}
+
+// Push adds a packet to the buffer.
+// It returns the packets that are ready to be delivered, in order,
+// and the number of packets that have been considered lost.
+// The payload is copied, therefore it can be reused after the call.
+func (j *JitterBuffer) Push(now time.Time, seq uint16, payload []byte) ([][]byte, int) {
+	if !j.initialized {
+		j.initialized = true
+		j.expected = seq
+	}
+
+	diff := int(int16(seq - j.expected))
+
+	var out [][]byte
+	lost := 0
+
+	switch {
+	case diff < -bufferSize:
+		// the sequence number went back in time.
+		// this happens when the stream is restarted.
+		out, _ = j.flush()
+		j.expected = seq
+		diff = 0
+
+	case diff < 0:
+		// packet is late or duplicate
+		return nil, 0
+
+	case diff >= bufferSize:
+		// the sequence number jumped forward: skip everything in between.
+		out, lost = j.flush()
+		lost += int(uint16(seq - j.expected))
+		j.expected = seq
+		diff = 0
+	}
+
+	e := &j.entries[seq%bufferSize]
+	if e.present {
+		// duplicate
+		return out, lost
+	}
+
+	e.payload = append([]byte(nil), payload...)
+	e.recv = now
+	e.present = true
+	j.count++
+
+	if (diff + 1) > j.span {
+		j.span = diff + 1
+	}
+
+	out2, lost2 := j.drain(now)
+	return append(out, out2...), lost + lost2
+}
+
+// Expire returns the packets that can be delivered since the
+// latency expired, and the number of packets that have been considered lost.
+func (j *JitterBuffer) Expire(now time.Time) ([][]byte, int) {
+	return j.drain(now)
+}
+
+// NextDeadline returns the time when Expire must be called in order
+// to deliver buffered packets.
+// It returns false when there are no buffered packets.
+func (j *JitterBuffer) NextDeadline() (time.Time, bool) {
+	oldest, ok := j.oldest()
+	if !ok {
+		return time.Time{}, false
+	}
+	return oldest.Add(j.latency), true
+}
+
+func (j *JitterBuffer) oldest() (time.Time, bool) {
+	if j.count == 0 {
+		return time.Time{}, false
+	}
+
+	var ret time.Time
+	for i := 0; i < j.span; i++ {
+		e := &j.entries[(j.expected+uint16(i))%bufferSize]
+		if e.present && (ret.IsZero() || e.recv.Before(ret)) {
+			ret = e.recv
+		}
+	}
+	return ret, true
+}
+
+func (j *JitterBuffer) pop() []byte {
+	e := &j.entries[j.expected%bufferSize]
+	ret := e.payload
+	*e = entry{}
+	j.count--
+	j.expected++
+	j.span--
+	return ret
+}
+
+func (j *JitterBuffer) skip() {
+	j.expected++
+	j.span--
+}
+
+func (j *JitterBuffer) drain(now time.Time) ([][]byte, int) {
+	var out [][]byte
+	lost := 0
+
+	for j.count > 0 {
+		if j.entries[j.expected%bufferSize].present {
+			out = append(out, j.pop())
+			continue
+		}
+
+		oldest, _ := j.oldest()
+		if now.Sub(oldest) < j.latency {
+			break
+		}
+
+		// the missing packet didn't arrive in time
+		for !j.entries[j.expected%bufferSize].present {
+			j.skip()
+			lost++
+		}
+	}
+
+	if j.count == 0 {
+		j.span = 0
+	}
+
+	return out, lost
+}
+
+func (j *JitterBuffer) flush() ([][]byte, int) {
+	var out [][]byte
+	lost := 0
+
+	for j.count > 0 {
+		if j.entries[j.expected%bufferSize].present {
+			out = append(out, j.pop())
+		} else {
+			j.skip()
+			lost++
+		}
+	}
+	j.span = 0
+
+	return out, lost
+}
@@ -0,0 +1,104 @@
+package jitterbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorder(t *testing.T) {
+	j := New(100 * time.Millisecond)
+	now := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	out, lost := j.Push(now, 65534, []byte{0x01})
+	require.Equal(t, [][]byte{{0x01}}, out)
+	require.Equal(t, 0, lost)
+
+	out, lost = j.Push(now, 0, []byte{0x03})
+	require.Equal(t, [][]byte(nil), out)
+	require.Equal(t, 0, lost)
+
+	out, lost = j.Push(now, 1, []byte{0x04})
+	require.Equal(t, [][]byte(nil), out)
+	require.Equal(t, 0, lost)
+
+	deadline, ok := j.NextDeadline()
+	require.Equal(t, true, ok)
+	require.Equal(t, now.Add(100*time.Millisecond), deadline)
+
+	out, lost = j.Push(now, 65535, []byte{0x02})
+	require.Equal(t, [][]byte{{0x02}, {0x03}, {0x04}}, out)
+	require.Equal(t, 0, lost)
+
+	_, ok = j.NextDeadline()
+	require.Equal(t, false, ok)
+
+	// late packet
+	out, lost = j.Push(now, 65535, []byte{0x02})
+	require.Equal(t, [][]byte(nil), out)
+	require.Equal(t, 0, lost)
+}
+
+func TestLost(t *testing.T) {
+	j := New(100 * time.Millisecond)
+	now := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	j.Push(now, 100, []byte{0x01})
+
+	out, lost := j.Push(now, 103, []byte{0x04})
+	require.Equal(t, [][]byte(nil), out)
+	require.Equal(t, 0, lost)
+
+	out, lost = j.Push(now.Add(50*time.Millisecond), 105, []byte{0x06})
+	require.Equal(t, [][]byte(nil), out)
+	require.Equal(t, 0, lost)
+
+	out, lost = j.Expire(now.Add(99 * time.Millisecond))
+	require.Equal(t, [][]byte(nil), out)
+	require.Equal(t, 0, lost)
+
+	out, lost = j.Expire(now.Add(100 * time.Millisecond))
+	require.Equal(t, [][]byte{{0x04}}, out)
+	require.Equal(t, 2, lost)
+
+	deadline, ok := j.NextDeadline()
+	require.Equal(t, true, ok)
+	require.Equal(t, now.Add(150*time.Millisecond), deadline)
+
+	out, lost = j.Expire(now.Add(150 * time.Millisecond))
+	require.Equal(t, [][]byte{{0x06}}, out)
+	require.Equal(t, 1, lost)
+}
+
+func TestReset(t *testing.T) {
+	j := New(100 * time.Millisecond)
+	now := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	j.Push(now, 100, []byte{0x01})
+	j.Push(now, 102, []byte{0x03})
+
+	// jump forward
+	out, lost := j.Push(now, 5000, []byte{0x04})
+	require.Equal(t, [][]byte{{0x03}, {0x04}}, out)
+	require.Equal(t, 4898, lost)
+
+	// jump backward
+	out, lost = j.Push(now, 10, []byte{0x05})
+	require.Equal(t, [][]byte{{0x05}}, out)
+	require.Equal(t, 0, lost)
+}
+
+func TestPayloadCopy(t *testing.T) {
+	j := New(100 * time.Millisecond)
+	now := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	j.Push(now, 100, []byte{0x01})
+
+	buf := []byte{0x03}
+	j.Push(now, 102, buf)
+	buf[0] = 0xFF
+
+	out, _ := j.Push(now, 101, []byte{0x02})
+	require.Equal(t, [][]byte{{0x02}, {0x03}}, out)
+}
@@ -0,0 +1,132 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// EBML element IDs.
+// ref: https://www.matroska.org/technical/elements.html
+const (
+	idEBML               = 0x1A45DFA3
+	idEBMLVersion        = 0x4286
+	idEBMLReadVersion    = 0x42F7
+	idEBMLMaxIDLength    = 0x42F2
+	idEBMLMaxSizeLength  = 0x42F3
+	idDocType            = 0x4282
+	idDocTypeVersion     = 0x4287
+	idDocTypeReadVersion = 0x4285
+
+	idSegment       = 0x18538067
+	idSeekHead      = 0x114D9B74
+	idSeek          = 0x4DBB
+	idSeekID        = 0x53AB
+	idSeekPosition  = 0x53AC
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idMuxingApp     = 0x4D80
+	idWritingApp    = 0x5741
+	idDuration      = 0x4489
+
+	idTracks            = 0x1654AE6B
+	idTrackEntry        = 0xAE
+	idTrackNumber       = 0xD7
+	idTrackUID          = 0x73C5
+	idTrackType         = 0x83
+	idFlagLacing        = 0x9C
+	idCodecID           = 0x86
+	idCodecPrivate      = 0x63A2
+	idCodecDelay        = 0x56AA
+	idSeekPreRoll       = 0x56BB
+	idVideo             = 0xE0
+	idPixelWidth        = 0xB0
+	idPixelHeight       = 0xBA
+	idAudio             = 0xE1
+	idSamplingFrequency = 0xB5
+	idChannels          = 0x9F
+
+	idCluster     = 0x1F43B675
+	idTimecode    = 0xE7
+	idSimpleBlock = 0xA3
+
+	idCues               = 0x1C53BB6B
+	idCuePoint           = 0xBB
+	idCueTime            = 0xB3
+	idCueTrackPositions  = 0xB7
+	idCueTrack           = 0xF7
+	idCueClusterPosition = 0xF1
+
+	idVoid = 0xEC
+)
+
+// size of elements whose size is not known in advance.
+var unknownSize = []byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+func encodeID(id uint32) []byte {
+	switch {
+	case id >= 0x1000000:
+		return []byte{byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}
+	case id >= 0x10000:
+		return []byte{byte(id >> 16), byte(id >> 8), byte(id)}
+	case id >= 0x100:
+		return []byte{byte(id >> 8), byte(id)}
+	default:
+		return []byte{byte(id)}
+	}
+}
+
+// encodeSize encodes a size with the shortest variable-length integer.
+func encodeSize(v uint64) []byte {
+	n := 1
+	// all ones is reserved for unknown sizes
+	for n < 8 && v >= (uint64(1)<<(7*uint(n)))-1 {
+		n++
+	}
+
+	ret := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		ret[i] = byte(v)
+		v >>= 8
+	}
+	ret[0] |= 0x80 >> uint(n-1)
+	return ret
+}
+
+// element generates an element with the given ID and content.
+func element(id uint32, content ...[]byte) []byte {
+	size := 0
+	for _, c := range content {
+		size += len(c)
+	}
+
+	ret := encodeID(id)
+	ret = append(ret, encodeSize(uint64(size))...)
+	for _, c := range content {
+		ret = append(ret, c...)
+	}
+	return ret
+}
+
+func elementUint(id uint32, v uint64) []byte {
+	n := 1
+	for n < 8 && (v>>(8*uint(n))) != 0 {
+		n++
+	}
+
+	buf := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		buf[i] = byte(v)
+		v >>= 8
+	}
+	return element(id, buf)
+}
+
+func elementFloat(id uint32, v float64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, math.Float64bits(v))
+	return element(id, buf)
+}
+
+func elementString(id uint32, v string) []byte {
+	return element(id, []byte(v))
+}
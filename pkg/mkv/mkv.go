@@ -0,0 +1,219 @@
+// Package mkv contains a Matroska (MKV) and WebM writer.
+package mkv
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+const (
+	trackTypeVideo = 1
+	trackTypeAudio = 2
+
+	// Opus always uses a 48khz clock.
+	opusSampleRate = 48000
+
+	// pre-skip and seek pre-roll recommended by RFC 7845.
+	opusPreSkip     = 3840
+	opusSeekPreRoll = 80000000
+)
+
+// Track is a track of a Matroska file. It can be
+// - TrackH264
+// - TrackVP8
+// - TrackVP9
+// - TrackOpus
+// - TrackAAC
+type Track interface {
+	isVideo() bool
+	webmCompatible() bool
+	entry(number int) ([]byte, error)
+}
+
+// TrackH264 is a H264 track.
+type TrackH264 struct {
+	SPS []byte
+	PPS []byte
+}
+
+func (t *TrackH264) isVideo() bool {
+	return true
+}
+
+func (t *TrackH264) webmCompatible() bool {
+	return false
+}
+
+func (t *TrackH264) entry(number int) ([]byte, error) {
+	var sps h264.SPS
+	err := sps.Unmarshal(t.SPS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPS: %v", err)
+	}
+
+	if len(t.PPS) == 0 {
+		return nil, fmt.Errorf("PPS is missing")
+	}
+
+	// ref: ISO 14496-15, 5.3.3.1
+	avcc := []byte{
+		1,           // configuration version
+		t.SPS[1],    // profile
+		t.SPS[2],    // profile compatibility
+		t.SPS[3],    // level
+		0xFC | 0x03, // length size minus one = 3
+		0xE0 | 0x01, // number of SPS
+		byte(len(t.SPS) >> 8), byte(len(t.SPS)),
+	}
+	avcc = append(avcc, t.SPS...)
+	avcc = append(avcc, 1, byte(len(t.PPS)>>8), byte(len(t.PPS)))
+	avcc = append(avcc, t.PPS...)
+
+	return generateTrackEntry(number, trackTypeVideo, "V_MPEG4/ISO/AVC", avcc,
+		generateVideo(sps.Width(), sps.Height())), nil
+}
+
+// TrackVP8 is a VP8 track.
+type TrackVP8 struct {
+	// size of frames.
+	// When not provided, it is not written into the file.
+	Width  int
+	Height int
+}
+
+func (t *TrackVP8) isVideo() bool {
+	return true
+}
+
+func (t *TrackVP8) webmCompatible() bool {
+	return true
+}
+
+func (t *TrackVP8) entry(number int) ([]byte, error) {
+	return generateTrackEntry(number, trackTypeVideo, "V_VP8", nil,
+		generateVideo(t.Width, t.Height)), nil
+}
+
+// TrackVP9 is a VP9 track.
+type TrackVP9 struct {
+	// size of frames.
+	// When not provided, it is not written into the file.
+	Width  int
+	Height int
+}
+
+func (t *TrackVP9) isVideo() bool {
+	return true
+}
+
+func (t *TrackVP9) webmCompatible() bool {
+	return true
+}
+
+func (t *TrackVP9) entry(number int) ([]byte, error) {
+	return generateTrackEntry(number, trackTypeVideo, "V_VP9", nil,
+		generateVideo(t.Width, t.Height)), nil
+}
+
+// TrackOpus is an Opus track.
+type TrackOpus struct {
+	SampleRate   int
+	ChannelCount int
+}
+
+func (t *TrackOpus) isVideo() bool {
+	return false
+}
+
+func (t *TrackOpus) webmCompatible() bool {
+	return true
+}
+
+func (t *TrackOpus) entry(number int) ([]byte, error) {
+	if t.ChannelCount < 1 || t.ChannelCount > 2 {
+		return nil, fmt.Errorf("unsupported channel count (%d)", t.ChannelCount)
+	}
+
+	// ref: RFC 7845, 5.1
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = byte(t.ChannelCount)
+	binary.LittleEndian.PutUint16(head[10:], opusPreSkip)
+	binary.LittleEndian.PutUint32(head[12:], uint32(t.SampleRate))
+
+	return generateTrackEntry(number, trackTypeAudio, "A_OPUS", head,
+		elementUint(idCodecDelay, uint64(opusPreSkip)*1000000000/opusSampleRate),
+		elementUint(idSeekPreRoll, opusSeekPreRoll),
+		generateAudio(opusSampleRate, t.ChannelCount)), nil
+}
+
+// TrackAAC is an AAC track.
+type TrackAAC struct {
+	Config *aac.MPEG4AudioConfig
+}
+
+func (t *TrackAAC) isVideo() bool {
+	return false
+}
+
+func (t *TrackAAC) webmCompatible() bool {
+	return false
+}
+
+func (t *TrackAAC) entry(number int) ([]byte, error) {
+	if t.Config == nil {
+		return nil, fmt.Errorf("AAC configuration is missing")
+	}
+
+	config, err := t.Config.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return generateTrackEntry(number, trackTypeAudio, "A_AAC", config,
+		generateAudio(t.Config.SampleRate, t.Config.ChannelCount)), nil
+}
+
+func generateVideo(width int, height int) []byte {
+	if width == 0 || height == 0 {
+		return nil
+	}
+
+	return element(idVideo,
+		elementUint(idPixelWidth, uint64(width)),
+		elementUint(idPixelHeight, uint64(height)))
+}
+
+func generateAudio(sampleRate int, channelCount int) []byte {
+	return element(idAudio,
+		elementFloat(idSamplingFrequency, float64(sampleRate)),
+		elementUint(idChannels, uint64(channelCount)))
+}
+
+func generateTrackEntry(
+	number int,
+	typ int,
+	codecID string,
+	codecPrivate []byte,
+	additional ...[]byte,
+) []byte {
+	content := [][]byte{
+		elementUint(idTrackNumber, uint64(number)),
+		elementUint(idTrackUID, uint64(number)),
+		elementUint(idTrackType, uint64(typ)),
+		elementUint(idFlagLacing, 0),
+		elementString(idCodecID, codecID),
+	}
+
+	if codecPrivate != nil {
+		content = append(content, element(idCodecPrivate, codecPrivate))
+	}
+
+	content = append(content, additional...)
+
+	return element(idTrackEntry, content...)
+}
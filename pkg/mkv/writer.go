@@ -0,0 +1,488 @@
+package mkv
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+const (
+	// timestamps are expressed in milliseconds.
+	timecodeScale = 1000000
+
+	// duration of clusters when there's no video track.
+	audioClusterDuration = 5 * time.Second
+
+	// space reserved after the segment info for the seek head and the duration.
+	seekHeadReservedSize = 128
+)
+
+type cue struct {
+	time     uint64
+	track    int
+	position uint64
+}
+
+// Writer is a Matroska writer.
+// It writes a header, followed by clusters that are cut on keyframes
+// of the first video track, and by cues when the writer is closed.
+// Segment and clusters are written with an unknown size, therefore
+// files remain readable even if the writer is not closed.
+// When the underlying writer is an io.WriteSeeker, space reserved
+// after the header is filled with a seek head and the duration when
+// the writer is closed.
+// When all tracks are VP8, VP9 or Opus, the file is a WebM file.
+// Writer is safe for concurrent use.
+type Writer struct {
+	w        io.Writer
+	tracks   []Track
+	refTrack int
+
+	mutex sync.Mutex
+
+	// bytes written after the start of the segment
+	position uint64
+	// position of the tracks, relative to the start of the segment
+	tracksPosition uint64
+
+	started         bool
+	startPTS        time.Duration
+	lastTimecode    int64
+	trackStarted    []bool
+	clusterStarted  bool
+	clusterTimecode int64
+	cues            []cue
+	closed          bool
+}
+
+// NewWriter allocates a Writer and writes the header.
+func NewWriter(w io.Writer, tracks []Track) (*Writer, error) {
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("at least one track must be provided")
+	}
+
+	if len(tracks) > 126 {
+		return nil, fmt.Errorf("too many tracks (%d)", len(tracks))
+	}
+
+	wr := &Writer{
+		w:            w,
+		tracks:       tracks,
+		refTrack:     -1,
+		trackStarted: make([]bool, len(tracks)),
+	}
+
+	docType := "webm"
+	entries := make([][]byte, len(tracks))
+
+	for i, t := range tracks {
+		if wr.refTrack < 0 && t.isVideo() {
+			wr.refTrack = i
+		}
+
+		if !t.webmCompatible() {
+			docType = "matroska"
+		}
+
+		var err error
+		entries[i], err = t.entry(i + 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	header := element(idEBML,
+		elementUint(idEBMLVersion, 1),
+		elementUint(idEBMLReadVersion, 1),
+		elementUint(idEBMLMaxIDLength, 4),
+		elementUint(idEBMLMaxSizeLength, 8),
+		elementString(idDocType, docType),
+		elementUint(idDocTypeVersion, 4),
+		elementUint(idDocTypeReadVersion, 2))
+
+	header = append(header, encodeID(idSegment)...)
+	header = append(header, unknownSize...)
+
+	segmentStart := len(header)
+
+	header = append(header, infoElement()...)
+	header = append(header, voidElement(seekHeadReservedSize)...)
+
+	wr.tracksPosition = uint64(len(header) - segmentStart)
+
+	header = append(header, element(idTracks, entries...)...)
+
+	_, err := w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+
+	wr.position = uint64(len(header) - segmentStart)
+
+	return wr, nil
+}
+
+func infoElement(children ...[]byte) []byte {
+	return element(idInfo, append([][]byte{
+		elementUint(idTimecodeScale, timecodeScale),
+		elementString(idMuxingApp, "gortsplib"),
+		elementString(idWritingApp, "gortsplib"),
+	}, children...)...)
+}
+
+// voidElement generates a Void element whose total size is the given one.
+// It returns nil if there's no such element.
+func voidElement(size int) []byte {
+	for n := size - 2; n >= 0; n-- {
+		if 1+len(encodeSize(uint64(n)))+n == size {
+			return element(idVoid, make([]byte, n))
+		}
+	}
+	return nil
+}
+
+func seekElement(id uint32, position uint64) []byte {
+	return element(idSeek,
+		element(idSeekID, encodeID(id)),
+		elementUint(idSeekPosition, position))
+}
+
+// Close writes the cues and, when the underlying writer is an io.WriteSeeker,
+// the seek head and the duration.
+// It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var cuesPosition uint64
+
+	if len(w.cues) != 0 {
+		points := make([][]byte, len(w.cues))
+		for i, c := range w.cues {
+			points[i] = element(idCuePoint,
+				elementUint(idCueTime, c.time),
+				element(idCueTrackPositions,
+					elementUint(idCueTrack, uint64(c.track)),
+					elementUint(idCueClusterPosition, c.position)))
+		}
+
+		w.cues = nil
+		cuesPosition = w.position
+
+		err := w.write(element(idCues, points...))
+		if err != nil {
+			return err
+		}
+	}
+
+	ws, ok := w.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+
+	return w.writeSeekHead(ws, cuesPosition)
+}
+
+// writeSeekHead replaces the space reserved after the header
+// with a seek head, the segment info with the duration, and a Void element.
+func (w *Writer) writeSeekHead(ws io.WriteSeeker, cuesPosition uint64) error {
+	var info []byte
+	if w.started {
+		info = infoElement(elementFloat(idDuration, float64(w.lastTimecode)))
+	} else {
+		info = infoElement()
+	}
+
+	// the segment info is placed after the seek head, whose size
+	// depends on the position of the segment info.
+	var seekHead []byte
+	for {
+		seeks := [][]byte{
+			seekElement(idInfo, uint64(len(seekHead))),
+			seekElement(idTracks, w.tracksPosition),
+		}
+		if cuesPosition != 0 {
+			seeks = append(seeks, seekElement(idCues, cuesPosition))
+		}
+
+		sh := element(idSeekHead, seeks...)
+		done := len(sh) == len(seekHead)
+		seekHead = sh
+		if done {
+			break
+		}
+	}
+
+	head := append(seekHead, info...)
+
+	padding := int(w.tracksPosition) - len(head)
+	if padding != 0 {
+		void := voidElement(padding)
+		if void == nil {
+			return fmt.Errorf("not enough space for the seek head")
+		}
+		head = append(head, void...)
+	}
+
+	cur, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	_, err = ws.Seek(cur-int64(w.position), io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	_, err = ws.Write(head)
+	if err != nil {
+		return err
+	}
+
+	_, err = ws.Seek(cur, io.SeekStart)
+	return err
+}
+
+func (w *Writer) write(byts []byte) error {
+	_, err := w.w.Write(byts)
+	w.position += uint64(len(byts))
+	return err
+}
+
+func (w *Writer) track(trackID int) (Track, error) {
+	if trackID < 0 || trackID >= len(w.tracks) {
+		return nil, fmt.Errorf("invalid track ID (%d)", trackID)
+	}
+	return w.tracks[trackID], nil
+}
+
+func (w *Writer) writeBlock(trackID int, pts time.Duration, keyframe bool, data []byte) error {
+	if !w.trackStarted[trackID] {
+		// wait for the first keyframe of each track
+		if !keyframe {
+			return nil
+		}
+
+		if !w.started {
+			// wait for the first keyframe of the reference track
+			if w.refTrack >= 0 && trackID != w.refTrack {
+				return nil
+			}
+
+			w.started = true
+			w.startPTS = pts
+		}
+
+		w.trackStarted[trackID] = true
+	}
+
+	timecode := int64((pts - w.startPTS) / time.Millisecond)
+	rel := timecode - w.clusterTimecode
+
+	if timecode > w.lastTimecode {
+		w.lastTimecode = timecode
+	}
+
+	newCluster := !w.clusterStarted ||
+		rel > math.MaxInt16 || rel < math.MinInt16
+
+	if w.refTrack >= 0 {
+		if trackID == w.refTrack && keyframe {
+			newCluster = true
+		}
+	} else if time.Duration(rel)*time.Millisecond >= audioClusterDuration {
+		newCluster = true
+	}
+
+	if newCluster {
+		w.clusterTimecode = timecode
+		if w.clusterTimecode < 0 {
+			w.clusterTimecode = 0
+		}
+		rel = timecode - w.clusterTimecode
+
+		if keyframe && (w.refTrack < 0 || trackID == w.refTrack) {
+			w.cues = append(w.cues, cue{
+				time:     uint64(w.clusterTimecode),
+				track:    trackID + 1,
+				position: w.position,
+			})
+		}
+
+		cluster := append(encodeID(idCluster), unknownSize...)
+		cluster = append(cluster, elementUint(idTimecode, uint64(w.clusterTimecode))...)
+
+		err := w.write(cluster)
+		if err != nil {
+			return err
+		}
+
+		w.clusterStarted = true
+	}
+
+	// ref: https://www.matroska.org/technical/basics.html#simpleblock-structure
+	header := []byte{
+		0x80 | byte(trackID+1), // track number
+		byte(uint16(int16(rel)) >> 8),
+		byte(uint16(int16(rel))),
+		0,
+	}
+	if keyframe {
+		header[3] |= 0x80
+	}
+
+	return w.write(element(idSimpleBlock, header, data))
+}
+
+// WriteH264 writes a H264 access unit.
+func (w *Writer) WriteH264(trackID int, pts time.Duration, au [][]byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t, err := w.track(trackID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.(*TrackH264); !ok {
+		return fmt.Errorf("track %d is not a H264 track", trackID)
+	}
+
+	var filtered [][]byte
+	idr := false
+
+	for _, nalu := range au {
+		typ := h264.NALUType(nalu[0] & 0x1F)
+		switch typ {
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+
+		case h264.NALUTypeIDR:
+			idr = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if filtered == nil {
+		return nil
+	}
+
+	avcc, err := h264.EncodeAVCC(filtered)
+	if err != nil {
+		return err
+	}
+
+	return w.writeBlock(trackID, pts, idr, avcc)
+}
+
+// WriteVP8 writes a VP8 frame.
+func (w *Writer) WriteVP8(trackID int, pts time.Duration, frame []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t, err := w.track(trackID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.(*TrackVP8); !ok {
+		return fmt.Errorf("track %d is not a VP8 track", trackID)
+	}
+
+	if len(frame) == 0 {
+		return fmt.Errorf("frame is empty")
+	}
+
+	// ref: RFC 6386, 9.1
+	keyframe := (frame[0] & 0x01) == 0
+
+	return w.writeBlock(trackID, pts, keyframe, frame)
+}
+
+// WriteVP9 writes a VP9 frame.
+func (w *Writer) WriteVP9(trackID int, pts time.Duration, frame []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t, err := w.track(trackID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.(*TrackVP9); !ok {
+		return fmt.Errorf("track %d is not a VP9 track", trackID)
+	}
+
+	if len(frame) == 0 {
+		return fmt.Errorf("frame is empty")
+	}
+
+	return w.writeBlock(trackID, pts, vp9IsKeyframe(frame[0]), frame)
+}
+
+// vp9IsKeyframe checks whether a VP9 frame is a keyframe,
+// by reading the first byte of its uncompressed header.
+func vp9IsKeyframe(b byte) bool {
+	// ref: VP9 Bitstream Specification, 6.2
+	if (b >> 6) != 0x02 { // frame marker
+		return false
+	}
+
+	profile := ((b >> 5) & 0x01) | ((b>>4)&0x01)<<1
+	shift := uint(3)
+	if profile == 3 {
+		shift-- // reserved zero
+	}
+
+	showExistingFrame := (b>>shift)&0x01 != 0
+	if showExistingFrame {
+		return false
+	}
+
+	frameType := (b >> (shift - 1)) & 0x01
+	return frameType == 0
+}
+
+// WriteOpus writes an Opus packet.
+func (w *Writer) WriteOpus(trackID int, pts time.Duration, packet []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t, err := w.track(trackID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.(*TrackOpus); !ok {
+		return fmt.Errorf("track %d is not an Opus track", trackID)
+	}
+
+	return w.writeBlock(trackID, pts, true, packet)
+}
+
+// WriteAAC writes an AAC access unit.
+func (w *Writer) WriteAAC(trackID int, pts time.Duration, au []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t, err := w.track(trackID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.(*TrackAAC); !ok {
+		return fmt.Errorf("track %d is not an AAC track", trackID)
+	}
+
+	return w.writeBlock(trackID, pts, true, au)
+}
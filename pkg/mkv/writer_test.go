@@ -0,0 +1,460 @@
+package mkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+var testH264Track = &TrackH264{
+	SPS: []byte{0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95},
+	PPS: []byte{0x68, 0xce, 0x3c, 0x80},
+}
+
+var testAACTrack = &TrackAAC{
+	Config: &aac.MPEG4AudioConfig{
+		Type:         aac.MPEG4AudioTypeAACLC,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	},
+}
+
+type testElement struct {
+	id       uint32
+	content  []byte
+	children []*testElement
+}
+
+var testMasterElements = map[uint32]struct{}{
+	idEBML:              {},
+	idSegment:           {},
+	idSeekHead:          {},
+	idSeek:              {},
+	idInfo:              {},
+	idTracks:            {},
+	idTrackEntry:        {},
+	idVideo:             {},
+	idAudio:             {},
+	idCluster:           {},
+	idCues:              {},
+	idCuePoint:          {},
+	idCueTrackPositions: {},
+}
+
+func readTestVint(t *testing.T, buf []byte, keepMarker bool) (uint64, int, bool) {
+	require.NotEqual(t, 0, len(buf))
+
+	n := 1
+	for n <= 8 && (buf[0]&(0x80>>uint(n-1))) == 0 {
+		n++
+	}
+	require.LessOrEqual(t, n, 8)
+	require.GreaterOrEqual(t, len(buf), n)
+
+	v := uint64(buf[0])
+	if !keepMarker {
+		v &= uint64(0xFF >> uint(n))
+	}
+	allOnes := v == uint64(0xFF>>uint(n))
+
+	for i := 1; i < n; i++ {
+		v = v<<8 | uint64(buf[i])
+		if buf[i] != 0xFF {
+			allOnes = false
+		}
+	}
+
+	return v, n, allOnes
+}
+
+// parseElements parses elements. Elements with an unknown size
+// extend until the end of the parent or until a top-level element.
+func parseElements(t *testing.T, buf []byte, stopAtTopLevel bool) ([]*testElement, int) {
+	var ret []*testElement
+	pos := 0
+
+	for pos < len(buf) {
+		id, idLen, _ := readTestVint(t, buf[pos:], true)
+
+		if stopAtTopLevel && (id == idCluster || id == idCues) {
+			break
+		}
+
+		size, sizeLen, unknown := readTestVint(t, buf[pos+idLen:], false)
+		start := pos + idLen + sizeLen
+
+		e := &testElement{id: uint32(id)}
+
+		if unknown {
+			var n int
+			e.children, n = parseElements(t, buf[start:], id == idCluster)
+			e.content = buf[start : start+n]
+			pos = start + n
+		} else {
+			require.LessOrEqual(t, start+int(size), len(buf))
+			e.content = buf[start : start+int(size)]
+			if _, ok := testMasterElements[e.id]; ok {
+				e.children, _ = parseElements(t, e.content, false)
+			}
+			pos = start + int(size)
+		}
+
+		ret = append(ret, e)
+	}
+
+	return ret, pos
+}
+
+func findElements(elems []*testElement, id uint32) []*testElement {
+	var ret []*testElement
+	for _, e := range elems {
+		if e.id == id {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func findElement(elems []*testElement, path ...uint32) *testElement {
+	for _, e := range elems {
+		if e.id == path[0] {
+			if len(path) == 1 {
+				return e
+			}
+			return findElement(e.children, path[1:]...)
+		}
+	}
+	return nil
+}
+
+func elementUintValue(e *testElement) uint64 {
+	v := uint64(0)
+	for _, b := range e.content {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+type testBlock struct {
+	track    int
+	rel      int16
+	keyframe bool
+	data     []byte
+}
+
+func parseBlock(e *testElement) testBlock {
+	return testBlock{
+		track:    int(e.content[0] & 0x7F),
+		rel:      int16(binary.BigEndian.Uint16(e.content[1:3])),
+		keyframe: (e.content[3] & 0x80) != 0,
+		data:     e.content[4:],
+	}
+}
+
+func TestEncodeSize(t *testing.T) {
+	for _, ca := range []struct {
+		v   uint64
+		enc []byte
+	}{
+		{0, []byte{0x80}},
+		{126, []byte{0xFE}},
+		{127, []byte{0x40, 0x7F}},
+		{16382, []byte{0x7F, 0xFE}},
+		{16383, []byte{0x20, 0x3F, 0xFF}},
+	} {
+		require.Equal(t, ca.enc, encodeSize(ca.v))
+	}
+}
+
+func TestWriterH264AAC(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, []Track{testH264Track, testAACTrack})
+	require.NoError(t, err)
+
+	idr := []byte{0x65, 0x88, 0x86}
+	p := []byte{0x41, 0x9a, 0x30}
+	audioAU := []byte{0x01, 0x02, 0x03, 0x04}
+	start := 2 * time.Second
+
+	// frames that precede the first IDR are discarded
+	err = w.WriteAAC(1, start-10*time.Millisecond, audioAU)
+	require.NoError(t, err)
+	err = w.WriteH264(0, start-40*time.Millisecond, [][]byte{p})
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		pts := start + time.Duration(i)*40*time.Millisecond
+
+		au := [][]byte{{byte(h264.NALUTypeAccessUnitDelimiter), 0xf0}, p}
+		if (i % 25) == 0 {
+			au = [][]byte{{byte(h264.NALUTypeAccessUnitDelimiter), 0xf0}, idr}
+		}
+
+		err = w.WriteH264(0, pts, au)
+		require.NoError(t, err)
+
+		err = w.WriteAAC(1, pts+10*time.Millisecond, audioAU)
+		require.NoError(t, err)
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	elems, _ := parseElements(t, buf.Bytes(), false)
+	require.Equal(t, 2, len(elems))
+
+	require.Equal(t, "matroska", string(findElement(elems, idEBML, idDocType).content))
+
+	segment := elems[1]
+	require.Equal(t, uint32(idSegment), segment.id)
+
+	entries := findElements(findElement(segment.children, idTracks).children, idTrackEntry)
+	require.Equal(t, 2, len(entries))
+
+	require.Equal(t, "V_MPEG4/ISO/AVC", string(findElement(entries[0].children, idCodecID).content))
+	require.Equal(t, []byte{
+		0x01, 0x42, 0xc0, 0x28, 0xff, 0xe1, 0x00, 0x0a,
+		0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08,
+		0x9f, 0x95, 0x01, 0x00, 0x04, 0x68, 0xce, 0x3c,
+		0x80,
+	}, findElement(entries[0].children, idCodecPrivate).content)
+	require.Equal(t, uint64(1920), elementUintValue(findElement(entries[0].children, idVideo, idPixelWidth)))
+	require.Equal(t, uint64(1080), elementUintValue(findElement(entries[0].children, idVideo, idPixelHeight)))
+
+	require.Equal(t, "A_AAC", string(findElement(entries[1].children, idCodecID).content))
+	require.Equal(t, []byte{0x11, 0x90}, findElement(entries[1].children, idCodecPrivate).content)
+
+	clusters := findElements(segment.children, idCluster)
+	require.Equal(t, 2, len(clusters))
+
+	for i, cluster := range clusters {
+		require.Equal(t, uint64(i*1000), elementUintValue(findElement(cluster.children, idTimecode)))
+
+		blocks := findElements(cluster.children, idSimpleBlock)
+		require.Equal(t, 50, len(blocks))
+
+		b := parseBlock(blocks[0])
+		require.Equal(t, testBlock{1, 0, true, []byte{0x00, 0x00, 0x00, 0x03, 0x65, 0x88, 0x86}}, b)
+
+		b = parseBlock(blocks[1])
+		require.Equal(t, testBlock{2, 10, true, audioAU}, b)
+
+		b = parseBlock(blocks[2])
+		require.Equal(t, testBlock{1, 40, false, []byte{0x00, 0x00, 0x00, 0x03, 0x41, 0x9a, 0x30}}, b)
+	}
+
+	points := findElements(findElement(segment.children, idCues).children, idCuePoint)
+	require.Equal(t, 2, len(points))
+
+	for i, point := range points {
+		require.Equal(t, uint64(i*1000), elementUintValue(findElement(point.children, idCueTime)))
+		require.Equal(t, uint64(1), elementUintValue(findElement(point.children, idCueTrackPositions, idCueTrack)))
+
+		pos := elementUintValue(findElement(point.children, idCueTrackPositions, idCueClusterPosition))
+		// cluster position is relative to the start of the segment data
+		segmentStart := uint64(len(buf.Bytes()) - len(segment.content))
+		require.Equal(t, encodeID(idCluster), buf.Bytes()[segmentStart+pos:segmentStart+pos+4])
+	}
+}
+
+func TestWriterVP8Opus(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, []Track{
+		&TrackVP8{Width: 640, Height: 480},
+		&TrackOpus{SampleRate: 48000, ChannelCount: 2},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		pts := time.Duration(i) * 40 * time.Millisecond
+
+		frame := []byte{0x11, 0x02}
+		if i == 0 || i == 5 {
+			frame = []byte{0x10, 0x02}
+		}
+
+		err = w.WriteVP8(0, pts, frame)
+		require.NoError(t, err)
+
+		err = w.WriteOpus(1, pts, []byte{0xfc, 0x01})
+		require.NoError(t, err)
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	elems, _ := parseElements(t, buf.Bytes(), false)
+	require.Equal(t, "webm", string(findElement(elems, idEBML, idDocType).content))
+
+	segment := elems[1]
+	entries := findElements(findElement(segment.children, idTracks).children, idTrackEntry)
+	require.Equal(t, "V_VP8", string(findElement(entries[0].children, idCodecID).content))
+	require.Nil(t, findElement(entries[0].children, idCodecPrivate))
+	require.Equal(t, "A_OPUS", string(findElement(entries[1].children, idCodecID).content))
+	require.Equal(t, []byte{
+		'O', 'p', 'u', 's', 'H', 'e', 'a', 'd',
+		0x01, 0x02, 0x00, 0x0f, 0x80, 0xbb, 0x00, 0x00,
+		0x00, 0x00, 0x00,
+	}, findElement(entries[1].children, idCodecPrivate).content)
+	require.Equal(t, uint64(80000000), elementUintValue(findElement(entries[1].children, idCodecDelay)))
+
+	clusters := findElements(segment.children, idCluster)
+	require.Equal(t, 2, len(clusters))
+	require.Equal(t, uint64(200), elementUintValue(findElement(clusters[1].children, idTimecode)))
+
+	blocks := findElements(clusters[0].children, idSimpleBlock)
+	require.Equal(t, 10, len(blocks))
+	require.Equal(t, true, parseBlock(blocks[0]).keyframe)
+	require.Equal(t, false, parseBlock(blocks[2]).keyframe)
+}
+
+func TestWriterAudioOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, []Track{&TrackOpus{SampleRate: 48000, ChannelCount: 2}})
+	require.NoError(t, err)
+
+	for i := 0; i < 600; i++ {
+		err = w.WriteOpus(0, time.Duration(i)*20*time.Millisecond, []byte{0xfc, 0x01})
+		require.NoError(t, err)
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	elems, _ := parseElements(t, buf.Bytes(), false)
+	segment := elems[1]
+
+	// 12 seconds of audio, clusters are cut every 5 seconds
+	clusters := findElements(segment.children, idCluster)
+	require.Equal(t, 3, len(clusters))
+	require.Equal(t, uint64(5000), elementUintValue(findElement(clusters[1].children, idTimecode)))
+
+	points := findElements(findElement(segment.children, idCues).children, idCuePoint)
+	require.Equal(t, 3, len(points))
+}
+
+func TestWriterSeekHead(t *testing.T) {
+	f, err := ioutil.TempFile("", "gortsplib-mkv")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w, err := NewWriter(f, []Track{testH264Track})
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		nalu := []byte{0x41, 0x9a, 0x30}
+		if (i % 25) == 0 {
+			nalu = []byte{0x65, 0x88, 0x86}
+		}
+
+		err = w.WriteH264(0, time.Duration(i)*40*time.Millisecond, [][]byte{nalu})
+		require.NoError(t, err)
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	byts, err := ioutil.ReadFile(f.Name())
+	require.NoError(t, err)
+
+	elems, _ := parseElements(t, byts, false)
+	segment := elems[1]
+	segmentStart := uint64(len(byts) - len(segment.content))
+
+	// the seek head is the first element of the segment
+	require.Equal(t, uint32(idSeekHead), segment.children[0].id)
+
+	seeks := findElements(segment.children[0].children, idSeek)
+	require.Equal(t, 3, len(seeks))
+
+	for i, id := range []uint32{idInfo, idTracks, idCues} {
+		require.Equal(t, encodeID(id), findElement(seeks[i].children, idSeekID).content)
+
+		pos := elementUintValue(findElement(seeks[i].children, idSeekPosition))
+		require.Equal(t, encodeID(id), byts[segmentStart+pos:segmentStart+pos+uint64(len(encodeID(id)))])
+	}
+
+	duration := findElement(segment.children, idInfo, idDuration)
+	require.Equal(t, float64(49*40), math.Float64frombits(binary.BigEndian.Uint64(duration.content)))
+
+	require.Equal(t, 2, len(findElements(segment.children, idCluster)))
+}
+
+func TestWriterConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, []Track{testH264Track, testAACTrack})
+	require.NoError(t, err)
+
+	err = w.WriteH264(0, 0, [][]byte{{0x65, 0x88, 0x86}})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 1; i < 100; i++ {
+			w.WriteH264(0, time.Duration(i)*40*time.Millisecond, [][]byte{{0x41, 0x9a, 0x30}})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			w.WriteAAC(1, time.Duration(i)*20*time.Millisecond, []byte{0x01, 0x02})
+		}
+	}()
+
+	wg.Wait()
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	elems, _ := parseElements(t, buf.Bytes(), false)
+	blocks := findElements(findElements(elems[1].children, idCluster)[0].children, idSimpleBlock)
+	require.Equal(t, 200, len(blocks))
+}
+
+func TestVP9IsKeyframe(t *testing.T) {
+	require.Equal(t, true, vp9IsKeyframe(0x82))  // profile 0, keyframe
+	require.Equal(t, false, vp9IsKeyframe(0x86)) // profile 0, non-keyframe
+	require.Equal(t, false, vp9IsKeyframe(0x88)) // profile 0, show existing frame
+	require.Equal(t, true, vp9IsKeyframe(0xb0))  // profile 3, keyframe
+	require.Equal(t, false, vp9IsKeyframe(0xb2)) // profile 3, non-keyframe
+	require.Equal(t, false, vp9IsKeyframe(0x00)) // invalid frame marker
+}
+
+func TestWriterErrors(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, nil)
+	require.EqualError(t, err, "at least one track must be provided")
+
+	_, err = NewWriter(&bytes.Buffer{}, []Track{&TrackH264{SPS: []byte{0x67}, PPS: []byte{0x68}}})
+	require.EqualError(t, err, "invalid SPS: buffer is too short")
+
+	_, err = NewWriter(&bytes.Buffer{}, []Track{&TrackAAC{}})
+	require.EqualError(t, err, "AAC configuration is missing")
+
+	w, err := NewWriter(&bytes.Buffer{}, []Track{testH264Track})
+	require.NoError(t, err)
+
+	err = w.WriteAAC(0, 0, []byte{0x01})
+	require.EqualError(t, err, "track 0 is not an AAC track")
+
+	err = w.WriteH264(1, 0, [][]byte{{0x65}})
+	require.EqualError(t, err, "invalid track ID (1)")
+}
@@ -0,0 +1,123 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// fields is a reader of the fields of a box.
+// After a read past the end of the buffer, all readers return zero
+// and err() returns an error.
+type fields struct {
+	buf       []byte
+	exhausted bool
+}
+
+func (f *fields) next(n int) []byte {
+	if f.exhausted || n > len(f.buf) {
+		f.exhausted = true
+		f.buf = nil
+		return nil
+	}
+	ret := f.buf[:n]
+	f.buf = f.buf[n:]
+	return ret
+}
+
+func (f *fields) uint8() uint8 {
+	b := f.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (f *fields) uint16() uint16 {
+	b := f.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (f *fields) uint24() uint32 {
+	b := f.next(3)
+	if b == nil {
+		return 0
+	}
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+func (f *fields) uint32() uint32 {
+	b := f.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (f *fields) uint64() uint64 {
+	b := f.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (f *fields) bytes(n int) []byte {
+	return f.next(n)
+}
+
+func (f *fields) skip(n int) {
+	f.next(n)
+}
+
+// fullBoxHeader reads the version and flags of a full box.
+func (f *fields) fullBoxHeader() (uint8, uint32) {
+	return f.uint8(), f.uint24()
+}
+
+func (f *fields) err(typ string) error {
+	if f.exhausted {
+		return fmt.Errorf("invalid %s box: buffer is too short", typ)
+	}
+	return nil
+}
+
+// readBoxes calls cb for each box contained into buf.
+func readBoxes(buf []byte, cb func(typ string, content []byte) error) error {
+	for len(buf) > 0 {
+		if len(buf) < 8 {
+			return fmt.Errorf("invalid box header")
+		}
+
+		size := uint64(binary.BigEndian.Uint32(buf))
+		typ := string(buf[4:8])
+		headerSize := uint64(8)
+
+		switch size {
+		case 0:
+			size = uint64(len(buf))
+
+		case 1:
+			if len(buf) < 16 {
+				return fmt.Errorf("invalid box header")
+			}
+			size = binary.BigEndian.Uint64(buf[8:])
+			headerSize = 16
+		}
+
+		if size < headerSize || size > uint64(len(buf)) {
+			return fmt.Errorf("invalid size of box %s (%d)", typ, size)
+		}
+
+		err := cb(typ, buf[headerSize:size])
+		if err != nil {
+			return err
+		}
+
+		buf = buf[size:]
+	}
+
+	return nil
+}
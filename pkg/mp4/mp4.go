@@ -0,0 +1,61 @@
+// Package mp4 contains a MP4 reader.
+package mp4
+
+import (
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+// Sample is a sample of a track.
+type Sample struct {
+	// decode timestamp, relative to the start of the presentation.
+	// It is negative when the sample is decoded before the presentation starts.
+	DTS time.Duration
+
+	// presentation timestamp, relative to the start of the presentation.
+	PTS time.Duration
+
+	// whether the sample can be decoded without previous samples.
+	IsSync bool
+
+	// position and size of the sample inside the file.
+	Offset int64
+	Size   uint32
+}
+
+// Track is a track of a MP4 file.
+type Track struct {
+	// ID of the track.
+	ID uint32
+
+	// duration of the presentation of the track.
+	Duration time.Duration
+
+	// parameters of H264 tracks.
+	SPS []byte
+	PPS []byte
+
+	// configuration of AAC tracks.
+	AACConfig *aac.MPEG4AudioConfig
+
+	// samples of the track, in decode order.
+	Samples []*Sample
+}
+
+// IsH264 checks whether the track is a H264 track.
+func (t *Track) IsH264() bool {
+	return t.SPS != nil
+}
+
+// IsAAC checks whether the track is an AAC track.
+func (t *Track) IsAAC() bool {
+	return t.AACConfig != nil
+}
+
+func ticksToDuration(v int64, timescale uint32) time.Duration {
+	// avoid overflows of v * time.Second
+	secs := v / int64(timescale)
+	dec := v % int64(timescale)
+	return time.Duration(secs)*time.Second + time.Duration(dec)*time.Second/time.Duration(timescale)
+}
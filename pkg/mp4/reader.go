@@ -0,0 +1,816 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+const (
+	// maximum size of a moov or moof box.
+	maxMetadataBoxSize = 64 * 1024 * 1024
+
+	sampleFlagsNonSync = 0x10000
+
+	tfhdBaseDataOffsetPresent         = 0x01
+	tfhdSampleDescriptionIndexPresent = 0x02
+	tfhdDefaultSampleDurationPresent  = 0x08
+	tfhdDefaultSampleSizePresent      = 0x10
+	tfhdDefaultSampleFlagsPresent     = 0x20
+
+	trunDataOffsetPresent                  = 0x01
+	trunFirstSampleFlagsPresent            = 0x04
+	trunSampleDurationPresent              = 0x100
+	trunSampleSizePresent                  = 0x200
+	trunSampleFlagsPresent                 = 0x400
+	trunSampleCompositionTimeOffsetPresent = 0x800
+)
+
+type sttsEntry struct {
+	count uint32
+	delta uint32
+}
+
+type cttsEntry struct {
+	count  uint32
+	offset int32
+}
+
+type stscEntry struct {
+	firstChunk      uint32
+	samplesPerChunk uint32
+}
+
+// trackState contains the state of a track while the file is being read.
+type trackState struct {
+	track     *Track
+	timescale uint32
+	isVideo   bool
+	nextDTS   int64
+
+	// media time at which the presentation starts, from the edit list
+	mediaTime int64
+
+	// end of the last presented sample
+	endPTS int64
+
+	// sample table of progressive files
+	stts         []sttsEntry
+	ctts         []cttsEntry
+	stss         map[uint32]struct{}
+	stsc         []stscEntry
+	sampleSizes  []uint32
+	chunkOffsets []uint64
+
+	// defaults of fragmented files
+	defaultSampleDuration uint32
+	defaultSampleSize     uint32
+	defaultSampleFlags    uint32
+}
+
+func (ts *trackState) appendSample(dts int64, cto int32, duration uint32, isSync bool, offset int64, size uint32) {
+	if end := dts + int64(cto) + int64(duration); end > ts.endPTS {
+		ts.endPTS = end
+	}
+
+	ts.track.Samples = append(ts.track.Samples, &Sample{
+		DTS:    ticksToDuration(dts-ts.mediaTime, ts.timescale),
+		PTS:    ticksToDuration(dts+int64(cto)-ts.mediaTime, ts.timescale),
+		IsSync: isSync || !ts.isVideo,
+		Offset: offset,
+		Size:   size,
+	})
+}
+
+// buildSamples fills the samples of a progressive file from the sample table.
+func (ts *trackState) buildSamples() error {
+	sampleCount := len(ts.sampleSizes)
+	if sampleCount == 0 {
+		return nil
+	}
+
+	// decode timestamps
+	dtss := make([]int64, 0, sampleCount)
+	dts := int64(0)
+	for _, e := range ts.stts {
+		for i := uint32(0); i < e.count && len(dtss) < sampleCount; i++ {
+			dtss = append(dtss, dts)
+			dts += int64(e.delta)
+		}
+	}
+	if len(dtss) != sampleCount {
+		return fmt.Errorf("invalid stts box: sample count doesn't match")
+	}
+	ts.nextDTS = dts
+
+	// composition time offsets
+	ctos := make([]int32, sampleCount)
+	i := 0
+	for _, e := range ts.ctts {
+		for j := uint32(0); j < e.count && i < sampleCount; j++ {
+			ctos[i] = e.offset
+			i++
+		}
+	}
+
+	// sample offsets
+	offsets := make([]int64, 0, sampleCount)
+	for n, e := range ts.stsc {
+		lastChunk := uint32(len(ts.chunkOffsets))
+		if n < (len(ts.stsc) - 1) {
+			lastChunk = ts.stsc[n+1].firstChunk - 1
+		}
+
+		if e.firstChunk == 0 || lastChunk > uint32(len(ts.chunkOffsets)) {
+			return fmt.Errorf("invalid stsc box: chunk doesn't exist")
+		}
+
+		for chunk := e.firstChunk; chunk <= lastChunk; chunk++ {
+			offset := int64(ts.chunkOffsets[chunk-1])
+			for j := uint32(0); j < e.samplesPerChunk && len(offsets) < sampleCount; j++ {
+				offsets = append(offsets, offset)
+				offset += int64(ts.sampleSizes[len(offsets)-1])
+			}
+		}
+	}
+	if len(offsets) != sampleCount {
+		return fmt.Errorf("invalid stsc box: sample count doesn't match")
+	}
+
+	for i := 0; i < sampleCount; i++ {
+		isSync := true
+		if ts.stss != nil {
+			_, isSync = ts.stss[uint32(i+1)]
+		}
+		duration := ts.nextDTS - dtss[i]
+		if i < (sampleCount - 1) {
+			duration = dtss[i+1] - dtss[i]
+		}
+		ts.appendSample(dtss[i], ctos[i], uint32(duration), isSync, offsets[i], ts.sampleSizes[i])
+	}
+
+	return nil
+}
+
+func readAvcC(t *Track, content []byte) error {
+	// ref: ISO 14496-15, 5.3.3.1
+	f := fields{buf: content}
+	f.skip(4) // version, profile, profile compatibility, level
+
+	lengthSize := (f.uint8() & 0x03) + 1
+	if lengthSize != 4 {
+		return fmt.Errorf("unsupported NALU length size (%d)", lengthSize)
+	}
+
+	spsCount := int(f.uint8() & 0x1F)
+	for i := 0; i < spsCount; i++ {
+		sps := f.bytes(int(f.uint16()))
+		if t.SPS == nil && len(sps) > 0 {
+			t.SPS = append([]byte(nil), sps...)
+		}
+	}
+
+	ppsCount := int(f.uint8())
+	for i := 0; i < ppsCount; i++ {
+		pps := f.bytes(int(f.uint16()))
+		if t.PPS == nil && len(pps) > 0 {
+			t.PPS = append([]byte(nil), pps...)
+		}
+	}
+
+	err := f.err("avcC")
+	if err != nil {
+		return err
+	}
+
+	if t.SPS == nil || t.PPS == nil {
+		return fmt.Errorf("SPS or PPS are missing")
+	}
+
+	return nil
+}
+
+func readDescriptor(f *fields) (uint8, []byte) {
+	// ref: ISO 14496-1, 8.3.3
+	tag := f.uint8()
+
+	size := 0
+	for i := 0; i < 4; i++ {
+		b := f.uint8()
+		size = size<<7 | int(b&0x7F)
+		if (b & 0x80) == 0 {
+			break
+		}
+	}
+
+	return tag, f.bytes(size)
+}
+
+func readEsds(t *Track, content []byte) error {
+	f := fields{buf: content}
+	f.fullBoxHeader()
+
+	tag, es := readDescriptor(&f)
+	if err := f.err("esds"); err != nil {
+		return err
+	}
+	if tag != 0x03 {
+		return fmt.Errorf("ES_Descriptor not found")
+	}
+
+	// ref: ISO 14496-1, 7.2.6.5
+	f = fields{buf: es}
+	f.skip(2) // ES ID
+	flags := f.uint8()
+	if (flags & 0x80) != 0 {
+		f.skip(2) // depends on ES ID
+	}
+	if (flags & 0x40) != 0 {
+		f.skip(int(f.uint8())) // URL
+	}
+	if (flags & 0x20) != 0 {
+		f.skip(2) // OCR ES ID
+	}
+
+	tag, dcd := readDescriptor(&f)
+	if err := f.err("esds"); err != nil {
+		return err
+	}
+	if tag != 0x04 {
+		return fmt.Errorf("DecoderConfigDescriptor not found")
+	}
+
+	// ref: ISO 14496-1, 7.2.6.6
+	f = fields{buf: dcd}
+	objectType := f.uint8()
+	f.skip(12) // stream type, buffer size, max bitrate, average bitrate
+
+	// not MPEG-4 audio
+	if objectType != 0x40 {
+		return nil
+	}
+
+	tag, dsi := readDescriptor(&f)
+	if err := f.err("esds"); err != nil {
+		return err
+	}
+	if tag != 0x05 {
+		return fmt.Errorf("DecoderSpecificInfo not found")
+	}
+
+	var conf aac.MPEG4AudioConfig
+	err := conf.Decode(dsi)
+	if err != nil {
+		return fmt.Errorf("invalid AAC configuration: %v", err)
+	}
+
+	t.AACConfig = &conf
+	return nil
+}
+
+func readStsd(ts *trackState, content []byte) error {
+	f := fields{buf: content}
+	f.fullBoxHeader()
+	f.skip(4) // entry count
+	if err := f.err("stsd"); err != nil {
+		return err
+	}
+
+	// read the first sample entry only
+	return readBoxes(f.buf, func(typ string, content []byte) error {
+		if ts.track.SPS != nil || ts.track.AACConfig != nil {
+			return nil
+		}
+
+		switch typ {
+		case "avc1", "avc3":
+			// ref: ISO 14496-12, 12.1.3
+			if len(content) < 78 {
+				return fmt.Errorf("invalid %s box: buffer is too short", typ)
+			}
+
+			return readBoxes(content[78:], func(typ string, content []byte) error {
+				if typ == "avcC" {
+					return readAvcC(ts.track, content)
+				}
+				return nil
+			})
+
+		case "mp4a":
+			// ref: ISO 14496-12, 12.2.3
+			if len(content) < 28 {
+				return fmt.Errorf("invalid mp4a box: buffer is too short")
+			}
+
+			// QuickTime sound sample descriptions have additional fields
+			skip := 28
+			switch binary.BigEndian.Uint16(content[8:]) {
+			case 1:
+				skip += 16
+			case 2:
+				skip += 36
+			}
+			if len(content) < skip {
+				return fmt.Errorf("invalid mp4a box: buffer is too short")
+			}
+
+			return readBoxes(content[skip:], func(typ string, content []byte) error {
+				if typ == "esds" {
+					return readEsds(ts.track, content)
+				}
+				return nil
+			})
+		}
+
+		return nil
+	})
+}
+
+func readStbl(ts *trackState, content []byte) error {
+	return readBoxes(content, func(typ string, content []byte) error {
+		f := fields{buf: content}
+
+		switch typ {
+		case "stsd":
+			return readStsd(ts, content)
+
+		case "stts":
+			f.fullBoxHeader()
+			count := f.uint32()
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				ts.stts = append(ts.stts, sttsEntry{
+					count: f.uint32(),
+					delta: f.uint32(),
+				})
+			}
+
+		case "ctts":
+			f.fullBoxHeader()
+			count := f.uint32()
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				ts.ctts = append(ts.ctts, cttsEntry{
+					count:  f.uint32(),
+					offset: int32(f.uint32()),
+				})
+			}
+
+		case "stss":
+			f.fullBoxHeader()
+			count := f.uint32()
+			ts.stss = make(map[uint32]struct{})
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				ts.stss[f.uint32()] = struct{}{}
+			}
+
+		case "stsc":
+			f.fullBoxHeader()
+			count := f.uint32()
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				ts.stsc = append(ts.stsc, stscEntry{
+					firstChunk:      f.uint32(),
+					samplesPerChunk: f.uint32(),
+				})
+				f.skip(4) // sample description index
+			}
+
+		case "stsz":
+			f.fullBoxHeader()
+			sampleSize := f.uint32()
+			count := f.uint32()
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				if sampleSize != 0 {
+					ts.sampleSizes = append(ts.sampleSizes, sampleSize)
+				} else {
+					ts.sampleSizes = append(ts.sampleSizes, f.uint32())
+				}
+			}
+
+		case "stco":
+			f.fullBoxHeader()
+			count := f.uint32()
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				ts.chunkOffsets = append(ts.chunkOffsets, uint64(f.uint32()))
+			}
+
+		case "co64":
+			f.fullBoxHeader()
+			count := f.uint32()
+			for i := uint32(0); i < count && !f.exhausted; i++ {
+				ts.chunkOffsets = append(ts.chunkOffsets, f.uint64())
+			}
+		}
+
+		return f.err(typ)
+	})
+}
+
+// readElst reads the edit list of a track.
+// Only the media time of the first non-empty edit is used, in order to
+// remove the initial composition offset of files with B-frames.
+func readElst(ts *trackState, content []byte) error {
+	f := fields{buf: content}
+	version, _ := f.fullBoxHeader()
+	count := f.uint32()
+
+	for i := uint32(0); i < count; i++ {
+		var mediaTime int64
+		if version == 1 {
+			f.skip(8) // segment duration
+			mediaTime = int64(f.uint64())
+		} else {
+			f.skip(4)
+			mediaTime = int64(int32(f.uint32()))
+		}
+		f.skip(4) // media rate
+
+		if f.exhausted {
+			break
+		}
+
+		// -1 means an empty edit
+		if mediaTime >= 0 {
+			ts.mediaTime = mediaTime
+			break
+		}
+	}
+
+	return f.err("elst")
+}
+
+func readTrak(content []byte) (*trackState, error) {
+	ts := &trackState{
+		track: &Track{},
+	}
+	var handler string
+
+	err := readBoxes(content, func(typ string, content []byte) error {
+		switch typ {
+		case "tkhd":
+			f := fields{buf: content}
+			version, _ := f.fullBoxHeader()
+			if version == 1 {
+				f.skip(16) // creation time, modification time
+			} else {
+				f.skip(8)
+			}
+			ts.track.ID = f.uint32()
+			return f.err(typ)
+
+		case "edts":
+			return readBoxes(content, func(typ string, content []byte) error {
+				if typ == "elst" {
+					return readElst(ts, content)
+				}
+				return nil
+			})
+
+		case "mdia":
+			return readBoxes(content, func(typ string, content []byte) error {
+				f := fields{buf: content}
+
+				switch typ {
+				case "mdhd":
+					version, _ := f.fullBoxHeader()
+					if version == 1 {
+						f.skip(16)
+					} else {
+						f.skip(8)
+					}
+					ts.timescale = f.uint32()
+					return f.err(typ)
+
+				case "hdlr":
+					f.fullBoxHeader()
+					f.skip(4) // pre-defined
+					handler = string(f.bytes(4))
+					return f.err(typ)
+
+				case "minf":
+					return readBoxes(content, func(typ string, content []byte) error {
+						if typ == "stbl" {
+							return readStbl(ts, content)
+						}
+						return nil
+					})
+				}
+
+				return nil
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case handler == "vide" && ts.track.IsH264():
+		ts.isVideo = true
+
+	case handler == "soun" && ts.track.IsAAC():
+
+	default:
+		// unsupported track
+		return nil, nil
+	}
+
+	if ts.timescale == 0 {
+		return nil, fmt.Errorf("invalid timescale of track %d", ts.track.ID)
+	}
+
+	err = ts.buildSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func readMoov(content []byte) ([]*trackState, error) {
+	var states []*trackState
+
+	err := readBoxes(content, func(typ string, content []byte) error {
+		switch typ {
+		case "trak":
+			ts, err := readTrak(content)
+			if err != nil {
+				return err
+			}
+			if ts != nil {
+				states = append(states, ts)
+			}
+
+		case "mvex":
+			return readBoxes(content, func(typ string, content []byte) error {
+				if typ != "trex" {
+					return nil
+				}
+
+				f := fields{buf: content}
+				f.fullBoxHeader()
+				trackID := f.uint32()
+				f.skip(4) // default sample description index
+				defaultSampleDuration := f.uint32()
+				defaultSampleSize := f.uint32()
+				defaultSampleFlags := f.uint32()
+
+				// trex boxes are placed after trak boxes
+				for _, ts := range states {
+					if ts.track.ID == trackID {
+						ts.defaultSampleDuration = defaultSampleDuration
+						ts.defaultSampleSize = defaultSampleSize
+						ts.defaultSampleFlags = defaultSampleFlags
+					}
+				}
+
+				return f.err(typ)
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
+
+func readTraf(states []*trackState, content []byte, moofOffset int64) error {
+	var ts *trackState
+	var baseOffset int64
+	var dataPos int64
+	var defaultSampleDuration uint32
+	var defaultSampleSize uint32
+	var defaultSampleFlags uint32
+
+	return readBoxes(content, func(typ string, content []byte) error {
+		f := fields{buf: content}
+
+		switch typ {
+		case "tfhd":
+			_, flags := f.fullBoxHeader()
+			trackID := f.uint32()
+
+			ts = nil
+			for _, s := range states {
+				if s.track.ID == trackID {
+					ts = s
+				}
+			}
+			if ts == nil {
+				// unsupported track
+				return nil
+			}
+
+			baseOffset = moofOffset
+			if (flags & tfhdBaseDataOffsetPresent) != 0 {
+				baseOffset = int64(f.uint64())
+			}
+			dataPos = baseOffset
+
+			if (flags & tfhdSampleDescriptionIndexPresent) != 0 {
+				f.skip(4)
+			}
+
+			defaultSampleDuration = ts.defaultSampleDuration
+			if (flags & tfhdDefaultSampleDurationPresent) != 0 {
+				defaultSampleDuration = f.uint32()
+			}
+
+			defaultSampleSize = ts.defaultSampleSize
+			if (flags & tfhdDefaultSampleSizePresent) != 0 {
+				defaultSampleSize = f.uint32()
+			}
+
+			defaultSampleFlags = ts.defaultSampleFlags
+			if (flags & tfhdDefaultSampleFlagsPresent) != 0 {
+				defaultSampleFlags = f.uint32()
+			}
+
+		case "tfdt":
+			if ts == nil {
+				return nil
+			}
+
+			version, _ := f.fullBoxHeader()
+			if version == 1 {
+				ts.nextDTS = int64(f.uint64())
+			} else {
+				ts.nextDTS = int64(f.uint32())
+			}
+
+		case "trun":
+			if ts == nil {
+				return nil
+			}
+
+			_, flags := f.fullBoxHeader()
+			count := f.uint32()
+
+			if (flags & trunDataOffsetPresent) != 0 {
+				dataPos = baseOffset + int64(int32(f.uint32()))
+			}
+
+			firstSampleFlags := defaultSampleFlags
+			if (flags & trunFirstSampleFlagsPresent) != 0 {
+				firstSampleFlags = f.uint32()
+			}
+
+			for i := uint32(0); i < count; i++ {
+				duration := defaultSampleDuration
+				if (flags & trunSampleDurationPresent) != 0 {
+					duration = f.uint32()
+				}
+
+				size := defaultSampleSize
+				if (flags & trunSampleSizePresent) != 0 {
+					size = f.uint32()
+				}
+
+				sampleFlags := defaultSampleFlags
+				if (flags & trunSampleFlagsPresent) != 0 {
+					sampleFlags = f.uint32()
+				} else if i == 0 {
+					sampleFlags = firstSampleFlags
+				}
+
+				cto := int32(0)
+				if (flags & trunSampleCompositionTimeOffsetPresent) != 0 {
+					cto = int32(f.uint32())
+				}
+
+				if f.exhausted {
+					break
+				}
+
+				ts.appendSample(ts.nextDTS, cto, duration, (sampleFlags&sampleFlagsNonSync) == 0, dataPos, size)
+				ts.nextDTS += int64(duration)
+				dataPos += int64(size)
+			}
+		}
+
+		return f.err(typ)
+	})
+}
+
+func readMoof(states []*trackState, content []byte, moofOffset int64) error {
+	return readBoxes(content, func(typ string, content []byte) error {
+		if typ == "traf" {
+			return readTraf(states, content, moofOffset)
+		}
+		return nil
+	})
+}
+
+// ReadTracks reads the H264 and AAC tracks of a MP4 file.
+// Both progressive and fragmented files are supported.
+// Sample data is not read; it can be read from the file by using
+// the offset and size of each sample.
+func ReadTracks(r io.ReadSeeker) ([]*Track, error) {
+	var states []*trackState
+	moovFound := false
+	pos := int64(0)
+
+	for {
+		_, err := r.Seek(pos, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		header := make([]byte, 16)
+		_, err = io.ReadFull(r, header[:8])
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			// box extends to the end of the file
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			size = end - pos
+
+			_, err = r.Seek(pos+8, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+
+		case 1:
+			_, err = io.ReadFull(r, header[8:])
+			if err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+
+		if size < headerSize {
+			return nil, fmt.Errorf("invalid size of box %s (%d)", typ, size)
+		}
+
+		switch typ {
+		case "moov", "moof":
+			if size > maxMetadataBoxSize {
+				return nil, fmt.Errorf("%s box is too big (%d)", typ, size)
+			}
+
+			content := make([]byte, size-headerSize)
+			_, err := io.ReadFull(r, content)
+			if err != nil {
+				return nil, err
+			}
+
+			if typ == "moov" {
+				if moovFound {
+					return nil, fmt.Errorf("multiple moov boxes found")
+				}
+				moovFound = true
+
+				states, err = readMoov(content)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				if !moovFound {
+					return nil, fmt.Errorf("moof box found before moov box")
+				}
+
+				err := readMoof(states, content, pos)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		pos += size
+	}
+
+	if !moovFound {
+		return nil, fmt.Errorf("moov box not found")
+	}
+
+	if len(states) == 0 {
+		return nil, fmt.Errorf("no supported tracks found")
+	}
+
+	tracks := make([]*Track, len(states))
+	for i, ts := range states {
+		ts.track.Duration = ticksToDuration(ts.endPTS-ts.mediaTime, ts.timescale)
+		tracks[i] = ts.track
+	}
+
+	return tracks, nil
+}
@@ -0,0 +1,254 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/fmp4"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+var (
+	testSPS = []byte{0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95}
+	testPPS = []byte{0x68, 0xce, 0x3c, 0x80}
+	testIDR = []byte{0x65, 0x88, 0x86}
+	testP   = []byte{0x41, 0x9a, 0x30}
+
+	testAACConfig = &aac.MPEG4AudioConfig{
+		Type:         aac.MPEG4AudioTypeAACLC,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	}
+)
+
+func testBox(typ string, content ...[]byte) []byte {
+	size := 8
+	for _, c := range content {
+		size += len(c)
+	}
+
+	ret := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(ret, uint32(size))
+	copy(ret[4:], typ)
+
+	for _, c := range content {
+		ret = append(ret, c...)
+	}
+
+	return ret
+}
+
+func testUint32s(vals ...uint32) []byte {
+	ret := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint32(ret[i*4:], v)
+	}
+	return ret
+}
+
+func TestReadTracksFragmented(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := fmp4.NewWriter(&buf,
+		&fmp4.VideoTrack{SPS: testSPS, PPS: testPPS},
+		&fmp4.AudioTrack{Config: testAACConfig})
+	require.NoError(t, err)
+
+	audioAU := []byte{0x01, 0x02, 0x03, 0x04}
+	audioDuration := ticksToDuration(1024, 48000)
+	audioCount := 0
+
+	for i := 0; i < 6; i++ {
+		pts := time.Duration(i) * 40 * time.Millisecond
+
+		au := [][]byte{testP}
+		if (i % 3) == 0 {
+			au = [][]byte{testIDR}
+		}
+
+		err = w.WriteH264(pts, au)
+		require.NoError(t, err)
+
+		for time.Duration(audioCount)*audioDuration < pts+40*time.Millisecond {
+			err = w.WriteAAC(time.Duration(audioCount)*audioDuration, audioAU)
+			require.NoError(t, err)
+			audioCount++
+		}
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	byts := buf.Bytes()
+
+	tracks, err := ReadTracks(bytes.NewReader(byts))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(tracks))
+
+	video := tracks[0]
+	require.Equal(t, uint32(1), video.ID)
+	require.Equal(t, true, video.IsH264())
+	require.Equal(t, testSPS, video.SPS)
+	require.Equal(t, testPPS, video.PPS)
+	require.Equal(t, 6, len(video.Samples))
+	require.Equal(t, 240*time.Millisecond, video.Duration)
+
+	for i, s := range video.Samples {
+		require.Equal(t, time.Duration(i)*40*time.Millisecond, s.DTS)
+		require.Equal(t, s.DTS, s.PTS)
+		require.Equal(t, (i%3) == 0, s.IsSync)
+
+		nalus, err := h264.DecodeAVCC(byts[s.Offset : s.Offset+int64(s.Size)])
+		require.NoError(t, err)
+		if s.IsSync {
+			require.Equal(t, [][]byte{testIDR}, nalus)
+		} else {
+			require.Equal(t, [][]byte{testP}, nalus)
+		}
+	}
+
+	audio := tracks[1]
+	require.Equal(t, uint32(2), audio.ID)
+	require.Equal(t, true, audio.IsAAC())
+	require.Equal(t, testAACConfig, audio.AACConfig)
+	require.Equal(t, audioCount, len(audio.Samples))
+
+	for i, s := range audio.Samples {
+		require.Equal(t, ticksToDuration(int64(i)*1024, 48000), s.DTS)
+		require.Equal(t, true, s.IsSync)
+		require.Equal(t, audioAU, byts[s.Offset:s.Offset+int64(s.Size)])
+	}
+}
+
+func TestReadTracksProgressive(t *testing.T) {
+	ftyp := testBox("ftyp", []byte("isom"), testUint32s(0x200), []byte("isom"))
+
+	samples := [][]byte{
+		{0x00, 0x00, 0x00, 0x03, 0x65, 0x88, 0x86},
+		{0x00, 0x00, 0x00, 0x03, 0x41, 0x9a, 0x30},
+		{0x00, 0x00, 0x00, 0x03, 0x41, 0x9a, 0x50},
+	}
+	mdat := testBox("mdat", samples...)
+	dataStart := uint32(len(ftyp) + 8)
+
+	avcC := []byte{1, 0x42, 0xc0, 0x28, 0xFF, 0xE1, 0x00, byte(len(testSPS))}
+	avcC = append(avcC, testSPS...)
+	avcC = append(avcC, 1, 0x00, byte(len(testPPS)))
+	avcC = append(avcC, testPPS...)
+
+	videoTrak := testBox("trak",
+		testBox("tkhd", testUint32s(3, 0, 0, 5)),
+		// an empty edit followed by an edit that removes the initial composition offset
+		testBox("edts",
+			testBox("elst", testUint32s(0, 2, 0, 0xFFFFFFFF, 0x10000, 100, 3000, 0x10000))),
+		testBox("mdia",
+			testBox("mdhd", testUint32s(0, 0, 0, 90000, 0, 0)),
+			testBox("hdlr", testUint32s(0, 0), []byte("vide"), make([]byte, 13)),
+			testBox("minf",
+				testBox("stbl",
+					testBox("stsd", testUint32s(0, 1),
+						testBox("avc1", make([]byte, 78), testBox("avcC", avcC))),
+					testBox("stts", testUint32s(0, 1, 3, 3000)),
+					testBox("ctts", testUint32s(0, 3, 1, 3000, 1, 6000, 1, 0)),
+					testBox("stss", testUint32s(0, 1, 1)),
+					testBox("stsc", testUint32s(0, 2, 1, 2, 1, 2, 1, 1)),
+					testBox("stsz", testUint32s(0, 0, 3, 7, 7, 7)),
+					testBox("stco", testUint32s(0, 2, dataStart, dataStart+14)),
+				),
+			),
+		),
+	)
+
+	// unsupported tracks are skipped
+	textTrak := testBox("trak",
+		testBox("tkhd", testUint32s(3, 0, 0, 6)),
+		testBox("mdia",
+			testBox("mdhd", testUint32s(0, 0, 0, 1000, 0, 0)),
+			testBox("hdlr", testUint32s(0, 0), []byte("text"), make([]byte, 13)),
+		),
+	)
+
+	moov := testBox("moov", testBox("mvhd", make([]byte, 100)), videoTrak, textTrak)
+
+	var byts []byte
+	byts = append(byts, ftyp...)
+	byts = append(byts, mdat...)
+	byts = append(byts, moov...)
+
+	tracks, err := ReadTracks(bytes.NewReader(byts))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tracks))
+
+	track := tracks[0]
+	require.Equal(t, uint32(5), track.ID)
+	require.Equal(t, testSPS, track.SPS)
+	require.Equal(t, testPPS, track.PPS)
+	require.Equal(t, 100*time.Millisecond, track.Duration)
+
+	require.Equal(t, []*Sample{
+		{
+			DTS:    -1000000 * 100 / 3 * time.Nanosecond,
+			PTS:    0,
+			IsSync: true,
+			Offset: int64(dataStart),
+			Size:   7,
+		},
+		{
+			DTS:    0,
+			PTS:    1000000 * 200 / 3 * time.Nanosecond,
+			IsSync: false,
+			Offset: int64(dataStart + 7),
+			Size:   7,
+		},
+		{
+			DTS:    1000000 * 100 / 3 * time.Nanosecond,
+			PTS:    1000000 * 100 / 3 * time.Nanosecond,
+			IsSync: false,
+			Offset: int64(dataStart + 14),
+			Size:   7,
+		},
+	}, track.Samples)
+
+	for i, s := range track.Samples {
+		require.Equal(t, samples[i], byts[s.Offset:s.Offset+int64(s.Size)])
+	}
+}
+
+func TestReadTracksErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"moov box not found",
+		},
+		{
+			"invalid size",
+			[]byte{0x00, 0x00, 0x00, 0x04, 'f', 't', 'y', 'p'},
+			"invalid size of box ftyp (4)",
+		},
+		{
+			"moof before moov",
+			testBox("moof"),
+			"moof box found before moov box",
+		},
+		{
+			"no tracks",
+			testBox("moov", testBox("mvhd", make([]byte, 100))),
+			"no supported tracks found",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := ReadTracks(bytes.NewReader(ca.byts))
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package mpegaudio
+
+import (
+	"fmt"
+)
+
+// Version is a MPEG version.
+type Version int
+
+// versions.
+const (
+	Version1  Version = 1
+	Version2  Version = 2
+	Version25 Version = 3 // MPEG-2.5
+)
+
+var bitrates = map[Version]map[int][]int{
+	Version1: {
+		1: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		2: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		3: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	Version2: {
+		1: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		2: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		3: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+var sampleRates = map[Version][]int{
+	Version1:  {44100, 48000, 32000},
+	Version2:  {22050, 24000, 16000},
+	Version25: {11025, 12000, 8000},
+}
+
+// FrameHeader is the header of a MPEG-1/2 audio frame.
+type FrameHeader struct {
+	Version      Version
+	Layer        int
+	Bitrate      int // in bit/s
+	SampleRate   int
+	Padding      bool
+	ChannelCount int
+}
+
+// Unmarshal decodes a FrameHeader.
+func (h *FrameHeader) Unmarshal(buf []byte) error {
+	// ref: ISO 11172-3, 2.4.1.3
+	if len(buf) < 4 {
+		return fmt.Errorf("not enough bytes")
+	}
+
+	if buf[0] != 0xFF || (buf[1]&0xE0) != 0xE0 {
+		return fmt.Errorf("invalid sync word")
+	}
+
+	switch (buf[1] >> 3) & 0x03 {
+	case 0:
+		h.Version = Version25
+	case 2:
+		h.Version = Version2
+	case 3:
+		h.Version = Version1
+	default:
+		return fmt.Errorf("invalid version")
+	}
+
+	h.Layer = 4 - int((buf[1]>>1)&0x03)
+	if h.Layer == 4 {
+		return fmt.Errorf("invalid layer")
+	}
+
+	bitrateIndex := int(buf[2] >> 4)
+	if bitrateIndex == 0 {
+		return fmt.Errorf("free bitrate is not supported")
+	}
+	if bitrateIndex == 15 {
+		return fmt.Errorf("invalid bitrate")
+	}
+
+	bitrateVersion := h.Version
+	if bitrateVersion == Version25 {
+		bitrateVersion = Version2
+	}
+	h.Bitrate = bitrates[bitrateVersion][h.Layer][bitrateIndex] * 1000
+
+	sampleRateIndex := int((buf[2] >> 2) & 0x03)
+	if sampleRateIndex == 3 {
+		return fmt.Errorf("invalid sample rate")
+	}
+	h.SampleRate = sampleRates[h.Version][sampleRateIndex]
+
+	h.Padding = ((buf[2] >> 1) & 0x01) != 0
+
+	if (buf[3] >> 6) == 3 {
+		h.ChannelCount = 1
+	} else {
+		h.ChannelCount = 2
+	}
+
+	return nil
+}
+
+// SampleCount returns the number of samples contained into the frame.
+func (h FrameHeader) SampleCount() int {
+	switch {
+	case h.Layer == 1:
+		return 384
+
+	case h.Layer == 3 && h.Version != Version1:
+		return 576
+
+	default:
+		return 1152
+	}
+}
+
+// FrameLen returns the size of the frame, header included.
+func (h FrameHeader) FrameLen() int {
+	padding := 0
+	if h.Padding {
+		padding = 1
+	}
+
+	if h.Layer == 1 {
+		return (12*h.Bitrate/h.SampleRate + padding) * 4
+	}
+
+	return h.SampleCount()/8*h.Bitrate/h.SampleRate + padding
+}
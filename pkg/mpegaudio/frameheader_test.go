@@ -0,0 +1,115 @@
+package mpegaudio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameHeaderUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name        string
+		byts        []byte
+		h           FrameHeader
+		frameLen    int
+		sampleCount int
+	}{
+		{
+			"mpeg-1 layer 3",
+			[]byte{0xff, 0xfb, 0x90, 0x64},
+			FrameHeader{
+				Version:      Version1,
+				Layer:        3,
+				Bitrate:      128000,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			417,
+			1152,
+		},
+		{
+			"mpeg-1 layer 2 padding mono",
+			[]byte{0xff, 0xfd, 0x66, 0xc4},
+			FrameHeader{
+				Version:      Version1,
+				Layer:        2,
+				Bitrate:      96000,
+				SampleRate:   48000,
+				Padding:      true,
+				ChannelCount: 1,
+			},
+			289,
+			1152,
+		},
+		{
+			"mpeg-2 layer 3",
+			[]byte{0xff, 0xf3, 0x84, 0x44},
+			FrameHeader{
+				Version:      Version2,
+				Layer:        3,
+				Bitrate:      64000,
+				SampleRate:   24000,
+				ChannelCount: 2,
+			},
+			192,
+			576,
+		},
+		{
+			"mpeg-1 layer 1",
+			[]byte{0xff, 0xff, 0xc0, 0x00},
+			FrameHeader{
+				Version:      Version1,
+				Layer:        1,
+				Bitrate:      384000,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			416,
+			384,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h FrameHeader
+			err := h.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+			require.Equal(t, ca.frameLen, h.FrameLen())
+			require.Equal(t, ca.sampleCount, h.SampleCount())
+		})
+	}
+}
+
+func TestFrameHeaderUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"too short",
+			[]byte{0xff, 0xfb},
+			"not enough bytes",
+		},
+		{
+			"invalid sync word",
+			[]byte{0xff, 0x1b, 0x90, 0x64},
+			"invalid sync word",
+		},
+		{
+			"free bitrate",
+			[]byte{0xff, 0xfb, 0x00, 0x64},
+			"free bitrate is not supported",
+		},
+		{
+			"invalid sample rate",
+			[]byte{0xff, 0xfb, 0x9c, 0x64},
+			"invalid sample rate",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h FrameHeader
+			err := h.Unmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
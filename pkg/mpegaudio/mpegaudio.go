@@ -0,0 +1,2 @@
+// Package mpegaudio contains utilities to work with MPEG-1/2 audio frames.
+package mpegaudio
@@ -0,0 +1,20 @@
+package mpegts
+
+// crc32MPEG2 computes the CRC32 of PSI sections.
+// ref: ISO 13818-1, Annex A
+func crc32MPEG2(byts []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+
+	for _, b := range byts {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if (crc & 0x80000000) != 0 {
+				crc = (crc << 1) ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,422 @@
+package mpegts
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	clockRate = 90000
+
+	// maximum difference between two consecutive PCRs.
+	// Bigger differences are considered discontinuities.
+	pcrMaxDiff = 2 * clockRate
+)
+
+func decodeTimestamp(byts []byte) uint64 {
+	return uint64(byts[0]>>1&0x07)<<30 |
+		uint64(byts[1])<<22 |
+		uint64(byts[2]>>1)<<15 |
+		uint64(byts[3])<<7 |
+		uint64(byts[4]>>1)
+}
+
+// timestampDiff returns the signed difference between two 33-bit timestamps.
+func timestampDiff(a uint64, b uint64) int64 {
+	diff := int64((a - b) & 0x1FFFFFFFF)
+	if diff >= 0x100000000 {
+		diff -= 0x200000000
+	}
+	return diff
+}
+
+// Frame is a PES packet extracted from a MPEG-TS stream.
+type Frame struct {
+	// PID of the elementary stream.
+	PID uint16
+
+	// type of the elementary stream.
+	Type StreamType
+
+	// presentation and decode timestamps.
+	// They are relative to the first PCR and continuous across PCR discontinuities.
+	PTS time.Duration
+	DTS time.Duration
+
+	// content of the PES packet.
+	// With StreamTypeH264, it's an Annex-B stream that can be decoded with h264.DecodeAnnexB().
+	// With StreamTypeAAC, it's a sequence of ADTS packets that can be decoded with aac.DecodeADTS().
+	Data []byte
+}
+
+type demuxerStream struct {
+	typ StreamType
+
+	ccReceived bool
+	cc         uint8
+
+	pesStarted bool
+	pesLength  int
+	pts        time.Duration
+	dts        time.Duration
+	data       []byte
+}
+
+// Demuxer is a MPEG-TS demuxer.
+// It extracts the elementary streams described in the PMT.
+type Demuxer struct {
+	pmtPIDs map[uint16]struct{}
+	pcrPID  uint16
+	streams map[uint16]*demuxerStream
+
+	timingInitialized bool
+	lastPCR           uint64
+	timeline          int64
+}
+
+// NewDemuxer allocates a Demuxer.
+func NewDemuxer() *Demuxer {
+	return &Demuxer{
+		pmtPIDs: make(map[uint16]struct{}),
+		streams: make(map[uint16]*demuxerStream),
+	}
+}
+
+// Streams returns the type of the elementary streams that have been
+// found in the PMT, indexed by PID.
+func (d *Demuxer) Streams() map[uint16]StreamType {
+	ret := make(map[uint16]StreamType, len(d.streams))
+	for pid, s := range d.streams {
+		ret[pid] = s.typ
+	}
+	return ret
+}
+
+func (d *Demuxer) processPCR(pcr uint64, discontinuity bool) {
+	if !d.timingInitialized {
+		d.timingInitialized = true
+		d.lastPCR = pcr
+		d.timeline = 0
+		return
+	}
+
+	diff := timestampDiff(pcr, d.lastPCR)
+
+	// in case of discontinuities, keep the timeline continuous
+	if discontinuity || diff < 0 || diff > pcrMaxDiff {
+		diff = 0
+	}
+
+	d.timeline += diff
+	d.lastPCR = pcr
+}
+
+func (d *Demuxer) convertTimestamp(ts uint64) time.Duration {
+	// no PCR has been received yet: use the first timestamp as reference.
+	if !d.timingInitialized {
+		d.timingInitialized = true
+		d.lastPCR = ts
+		d.timeline = 0
+	}
+
+	v := d.timeline + timestampDiff(ts, d.lastPCR)
+	return time.Duration(v) * time.Second / clockRate
+}
+
+// Decode decodes a MPEG-TS packet.
+// It returns the PES packets that have been completed by the packet.
+func (d *Demuxer) Decode(pkt []byte) ([]*Frame, error) {
+	if len(pkt) != PacketSize {
+		return nil, fmt.Errorf("invalid packet size (%d)", len(pkt))
+	}
+
+	if pkt[0] != 0x47 {
+		return nil, fmt.Errorf("sync byte not found")
+	}
+
+	if (pkt[1] >> 7) == 1 {
+		return nil, fmt.Errorf("transport error indicator is set")
+	}
+
+	pusi := ((pkt[1] >> 6) & 0x01) == 1
+	pid := uint16(pkt[1]&0x1F)<<8 | uint16(pkt[2])
+	afc := (pkt[3] >> 4) & 0x03
+	cc := pkt[3] & 0x0F
+
+	if pid == 0x1FFF { // null packet
+		return nil, nil
+	}
+
+	pos := 4
+	discontinuity := false
+
+	// adaptation field
+	if (afc & 0x02) != 0 {
+		afLen := int(pkt[4])
+		pos = 5 + afLen
+		if pos > PacketSize {
+			return nil, fmt.Errorf("invalid adaptation field length (%d)", afLen)
+		}
+
+		if afLen > 0 {
+			discontinuity = (pkt[5] >> 7) == 1
+			pcrFlag := ((pkt[5] >> 4) & 0x01) == 1
+
+			if pcrFlag {
+				if afLen < 7 {
+					return nil, fmt.Errorf("invalid adaptation field length (%d)", afLen)
+				}
+
+				if pid == d.pcrPID {
+					pcr := uint64(pkt[6])<<25 |
+						uint64(pkt[7])<<17 |
+						uint64(pkt[8])<<9 |
+						uint64(pkt[9])<<1 |
+						uint64(pkt[10]>>7)
+					d.processPCR(pcr, discontinuity)
+				}
+			}
+		}
+	}
+
+	if (afc & 0x01) == 0 { // no payload
+		return nil, nil
+	}
+
+	payload := pkt[pos:]
+
+	if pid == 0 {
+		return nil, d.decodePAT(pusi, payload)
+	}
+
+	if _, ok := d.pmtPIDs[pid]; ok {
+		return nil, d.decodePMT(pusi, payload)
+	}
+
+	s, ok := d.streams[pid]
+	if !ok {
+		return nil, nil
+	}
+
+	// check continuity
+	if s.ccReceived && !discontinuity {
+		if cc == s.cc { // duplicate packet
+			return nil, nil
+		}
+
+		if cc != ((s.cc + 1) & 0x0F) {
+			s.cc = cc
+			s.pesStarted = false
+			s.data = nil
+
+			if !pusi {
+				return nil, fmt.Errorf("discarding PES since a TS packet is missing (PID %d)", pid)
+			}
+		}
+	}
+	s.ccReceived = true
+	s.cc = cc
+
+	var ret []*Frame
+
+	if pusi {
+		if s.pesStarted {
+			ret = append(ret, s.flush(pid))
+		}
+
+		err := d.startPES(s, payload)
+		if err != nil {
+			return ret, err
+		}
+	} else {
+		if !s.pesStarted {
+			return nil, nil
+		}
+
+		s.data = append(s.data, payload...)
+	}
+
+	if s.pesLength > 0 && len(s.data) >= s.pesLength {
+		s.data = s.data[:s.pesLength]
+		ret = append(ret, s.flush(pid))
+	}
+
+	return ret, nil
+}
+
+// Flush returns the PES packets that are still being assembled.
+// It must be called when the stream ends, since PES packets with unbounded
+// length are completed only by the next PES packet of the same elementary stream.
+func (d *Demuxer) Flush() []*Frame {
+	var ret []*Frame
+
+	for pid, s := range d.streams {
+		if s.pesStarted {
+			ret = append(ret, s.flush(pid))
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].PID < ret[j].PID
+	})
+
+	return ret
+}
+
+func (d *Demuxer) startPES(s *demuxerStream, payload []byte) error {
+	// ref: ISO 13818-1, 2.4.3.6
+	if len(payload) < 9 || payload[0] != 0 || payload[1] != 0 || payload[2] != 1 {
+		return fmt.Errorf("invalid PES header")
+	}
+
+	pesPacketLength := int(payload[4])<<8 | int(payload[5])
+
+	if (payload[6] >> 6) != 0x02 {
+		return fmt.Errorf("PES optional header is missing")
+	}
+
+	ptsDTSIndicator := payload[7] >> 6
+	headerLength := int(payload[8])
+
+	if len(payload) < (9+headerLength) || (pesPacketLength != 0 && pesPacketLength < (3+headerLength)) {
+		return fmt.Errorf("invalid PES header")
+	}
+
+	switch ptsDTSIndicator {
+	case 2:
+		if headerLength < 5 {
+			return fmt.Errorf("invalid PES header")
+		}
+		s.pts = d.convertTimestamp(decodeTimestamp(payload[9:]))
+		s.dts = s.pts
+
+	case 3:
+		if headerLength < 10 {
+			return fmt.Errorf("invalid PES header")
+		}
+		s.pts = d.convertTimestamp(decodeTimestamp(payload[9:]))
+		s.dts = d.convertTimestamp(decodeTimestamp(payload[14:]))
+
+	default:
+		return fmt.Errorf("PTS is missing")
+	}
+
+	s.pesStarted = true
+	s.pesLength = 0
+	if pesPacketLength != 0 {
+		s.pesLength = pesPacketLength - 3 - headerLength
+	}
+	s.data = append([]byte(nil), payload[9+headerLength:]...)
+
+	return nil
+}
+
+func (s *demuxerStream) flush(pid uint16) *Frame {
+	f := &Frame{
+		PID:  pid,
+		Type: s.typ,
+		PTS:  s.pts,
+		DTS:  s.dts,
+		Data: s.data,
+	}
+
+	s.pesStarted = false
+	s.data = nil
+
+	return f
+}
+
+// readSection returns the content of a PSI section, without the CRC.
+func readSection(pusi bool, payload []byte, tableID uint8) ([]byte, error) {
+	if !pusi {
+		return nil, fmt.Errorf("PSI sections that span multiple packets are not supported")
+	}
+
+	if len(payload) < 1 {
+		return nil, fmt.Errorf("invalid PSI section")
+	}
+
+	pointer := int(payload[0])
+	if len(payload) < (1 + pointer + 3) {
+		return nil, fmt.Errorf("invalid PSI section")
+	}
+	payload = payload[1+pointer:]
+
+	if payload[0] != tableID {
+		return nil, fmt.Errorf("invalid table ID (%d)", payload[0])
+	}
+
+	sectionLength := int(payload[1]&0x0F)<<8 | int(payload[2])
+	if sectionLength < 9 || len(payload) < (3+sectionLength) {
+		return nil, fmt.Errorf("invalid PSI section")
+	}
+
+	return payload[3 : 3+sectionLength-4], nil
+}
+
+func (d *Demuxer) decodePAT(pusi bool, payload []byte) error {
+	sec, err := readSection(pusi, payload, 0x00)
+	if err != nil {
+		return err
+	}
+
+	// skip transport_stream_id, version, section_number, last_section_number
+	sec = sec[5:]
+
+	if (len(sec) % 4) != 0 {
+		return fmt.Errorf("invalid PAT")
+	}
+
+	for i := 0; i < len(sec); i += 4 {
+		programNumber := uint16(sec[i])<<8 | uint16(sec[i+1])
+		pid := uint16(sec[i+2]&0x1F)<<8 | uint16(sec[i+3])
+
+		if programNumber != 0 {
+			d.pmtPIDs[pid] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+func (d *Demuxer) decodePMT(pusi bool, payload []byte) error {
+	sec, err := readSection(pusi, payload, 0x02)
+	if err != nil {
+		return err
+	}
+
+	// skip program_number, version, section_number, last_section_number
+	sec = sec[5:]
+
+	if len(sec) < 4 {
+		return fmt.Errorf("invalid PMT")
+	}
+
+	d.pcrPID = uint16(sec[0]&0x1F)<<8 | uint16(sec[1])
+	programInfoLength := int(sec[2]&0x0F)<<8 | int(sec[3])
+	if len(sec) < (4 + programInfoLength) {
+		return fmt.Errorf("invalid PMT")
+	}
+	sec = sec[4+programInfoLength:]
+
+	for len(sec) > 0 {
+		if len(sec) < 5 {
+			return fmt.Errorf("invalid PMT")
+		}
+
+		typ := StreamType(sec[0])
+		pid := uint16(sec[1]&0x1F)<<8 | uint16(sec[2])
+		esInfoLength := int(sec[3]&0x0F)<<8 | int(sec[4])
+		if len(sec) < (5 + esInfoLength) {
+			return fmt.Errorf("invalid PMT")
+		}
+		sec = sec[5+esInfoLength:]
+
+		if s, ok := d.streams[pid]; !ok || s.typ != typ {
+			d.streams[pid] = &demuxerStream{typ: typ}
+		}
+	}
+
+	return nil
+}
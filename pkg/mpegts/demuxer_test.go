@@ -0,0 +1,300 @@
+package mpegts
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astits"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+type testData struct {
+	pid           uint16
+	pcr           *int64
+	discontinuity bool
+	pts           int64
+	dts           *int64
+	data          []byte
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func testMux(t *testing.T, data []testData) [][]byte {
+	var buf bytes.Buffer
+	mux := astits.NewMuxer(context.Background(), &buf)
+
+	err := mux.AddElementaryStream(astits.PMTElementaryStream{
+		ElementaryPID: 256,
+		StreamType:    astits.StreamTypeH264Video,
+	})
+	require.NoError(t, err)
+
+	err = mux.AddElementaryStream(astits.PMTElementaryStream{
+		ElementaryPID: 257,
+		StreamType:    astits.StreamTypeAACAudio,
+	})
+	require.NoError(t, err)
+
+	mux.SetPCRPID(256)
+
+	for _, d := range data {
+		af := &astits.PacketAdaptationField{
+			DiscontinuityIndicator: d.discontinuity,
+		}
+		if d.pcr != nil {
+			af.HasPCR = true
+			af.PCR = &astits.ClockReference{Base: *d.pcr}
+		}
+
+		oh := &astits.PESOptionalHeader{
+			MarkerBits: 2,
+			PTS:        &astits.ClockReference{Base: d.pts},
+		}
+		streamID := uint8(192)
+
+		if d.pid == 256 {
+			streamID = 224
+		}
+
+		if d.dts != nil {
+			oh.PTSDTSIndicator = astits.PTSDTSIndicatorBothPresent
+			oh.DTS = &astits.ClockReference{Base: *d.dts}
+		} else {
+			oh.PTSDTSIndicator = astits.PTSDTSIndicatorOnlyPTS
+		}
+
+		_, err := mux.WriteData(&astits.MuxerData{
+			PID:             d.pid,
+			AdaptationField: af,
+			PES: &astits.PESData{
+				Header: &astits.PESHeader{
+					OptionalHeader: oh,
+					StreamID:       streamID,
+				},
+				Data: d.data,
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	byts := buf.Bytes()
+	require.Equal(t, 0, len(byts)%PacketSize)
+
+	var pkts [][]byte
+	for len(byts) > 0 {
+		pkts = append(pkts, byts[:PacketSize])
+		byts = byts[PacketSize:]
+	}
+	return pkts
+}
+
+func testDemux(t *testing.T, pkts [][]byte) []*Frame {
+	d := NewDemuxer()
+	var frames []*Frame
+
+	for _, pkt := range pkts {
+		addFrames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		frames = append(frames, addFrames...)
+	}
+
+	return append(frames, d.Flush()...)
+}
+
+func TestDemuxer(t *testing.T) {
+	h264Data, err := h264.EncodeAnnexB([][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+		{0x05, 0x01, 0x02, 0x03},
+		bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 200),
+	})
+	require.NoError(t, err)
+
+	aacData, err := aac.EncodeADTS([]*aac.ADTSPacket{
+		{
+			Type:         2,
+			SampleRate:   44100,
+			ChannelCount: 2,
+			AU:           []byte{0x01, 0x02, 0x03, 0x04},
+		},
+	})
+	require.NoError(t, err)
+
+	pkts := testMux(t, []testData{
+		{
+			pid:  256,
+			pcr:  int64Ptr(90000),
+			pts:  90000 + 9000,
+			dts:  int64Ptr(90000),
+			data: h264Data,
+		},
+		{
+			pid:  257,
+			pts:  90000 + 4500,
+			data: aacData,
+		},
+		{
+			pid:  256,
+			pcr:  int64Ptr(90000 + 3000),
+			pts:  90000 + 3000,
+			data: h264Data,
+		},
+	})
+
+	frames := testDemux(t, pkts)
+	require.Equal(t, 3, len(frames))
+
+	// PES packets are returned in order of completion
+	require.Equal(t, &Frame{
+		PID:  257,
+		Type: StreamTypeAAC,
+		PTS:  50 * time.Millisecond,
+		DTS:  50 * time.Millisecond,
+		Data: aacData,
+	}, frames[0])
+
+	require.Equal(t, &Frame{
+		PID:  256,
+		Type: StreamTypeH264,
+		PTS:  100 * time.Millisecond,
+		DTS:  0,
+		Data: h264Data,
+	}, frames[1])
+
+	require.Equal(t, &Frame{
+		PID:  256,
+		Type: StreamTypeH264,
+		PTS:  33333333 * time.Nanosecond,
+		DTS:  33333333 * time.Nanosecond,
+		Data: h264Data,
+	}, frames[2])
+
+	nalus, err := h264.DecodeAnnexB(frames[1].Data)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(nalus))
+
+	adtsPkts, err := aac.DecodeADTS(frames[0].Data)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, adtsPkts[0].AU)
+}
+
+func TestDemuxerPCRDiscontinuity(t *testing.T) {
+	pkts := testMux(t, []testData{
+		{
+			pid:  256,
+			pcr:  int64Ptr(90000),
+			pts:  90000,
+			data: []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0},
+		},
+		{
+			pid:  256,
+			pcr:  int64Ptr(90000 + 9000),
+			pts:  90000 + 9000,
+			data: []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0},
+		},
+		{
+			pid:           256,
+			pcr:           int64Ptr(900000000),
+			discontinuity: true,
+			pts:           900000000 + 9000,
+			data:          []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0},
+		},
+		{
+			// jump without discontinuity indicator
+			pid:  256,
+			pcr:  int64Ptr(0x1FFFFFFFF - 4500),
+			pts:  0x1FFFFFFFF - 4500,
+			data: []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0},
+		},
+		{
+			// wraparound
+			pid:  256,
+			pcr:  int64Ptr(4500),
+			pts:  4500,
+			data: []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0},
+		},
+	})
+
+	frames := testDemux(t, pkts)
+	require.Equal(t, 5, len(frames))
+
+	var ptss []time.Duration
+	for _, f := range frames {
+		ptss = append(ptss, f.PTS)
+	}
+
+	require.Equal(t, []time.Duration{
+		0,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		100 * time.Millisecond,
+		200*time.Millisecond + 11111*time.Nanosecond,
+	}, ptss)
+}
+
+func TestDemuxerContinuity(t *testing.T) {
+	pkts := testMux(t, []testData{
+		{
+			pid:  256,
+			pcr:  int64Ptr(90000),
+			pts:  90000,
+			data: bytes.Repeat([]byte{0x01}, 400),
+		},
+		{
+			pid:  256,
+			pcr:  int64Ptr(90000 + 9000),
+			pts:  90000 + 9000,
+			data: bytes.Repeat([]byte{0x02}, 400),
+		},
+	})
+
+	d := NewDemuxer()
+	var lastErr error
+
+	for i, pkt := range pkts {
+		// skip the second packet of the first PES
+		if i == 3 {
+			continue
+		}
+
+		// duplicate packets are discarded
+		for j := 0; j < 2; j++ {
+			_, err := d.Decode(pkt)
+			if err != nil {
+				lastErr = err
+			}
+		}
+	}
+
+	require.EqualError(t, lastErr, "discarding PES since a TS packet is missing (PID 256)")
+}
+
+func TestDemuxerErrors(t *testing.T) {
+	d := NewDemuxer()
+
+	_, err := d.Decode([]byte{0x47, 0x00})
+	require.EqualError(t, err, "invalid packet size (2)")
+
+	_, err = d.Decode(make([]byte, PacketSize))
+	require.EqualError(t, err, "sync byte not found")
+
+	// PAT packet whose adaptation field fills the entire packet
+	pkt := make([]byte, PacketSize)
+	pkt[0] = 0x47
+	pkt[1] = 0x40
+	pkt[3] = 0x30
+	pkt[4] = 183
+	_, err = d.Decode(pkt)
+	require.EqualError(t, err, "invalid PSI section")
+}
+
+func TestStreamType(t *testing.T) {
+	require.Equal(t, "H264", StreamTypeH264.String())
+	require.Equal(t, "unknown (255)", StreamType(255).String())
+}
@@ -0,0 +1,5 @@
+// Package mpegts contains MPEG-TS utilities.
+package mpegts
+
+// PacketSize is the size of a MPEG-TS packet.
+const PacketSize = 188
@@ -0,0 +1,414 @@
+package mpegts
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+const (
+	muxerPMTPID        = 0x1000
+	muxerVideoPID      = 256
+	muxerAudioPID      = 257
+	muxerProgramNumber = 1
+
+	// interval between PAT/PMT when there's no video track.
+	// With a video track, tables are written before every IDR.
+	muxerTablesInterval = 1 * time.Second
+
+	streamIDVideo = 0xE0
+	streamIDAudio = 0xC0
+
+	aacSamplesPerAU = 1024
+
+	// maximum size of the data of an audio PES packet,
+	// whose PES_packet_length can't be unbounded.
+	muxerAudioPESMaxDataSize = 0xFFFF - 8
+)
+
+// VideoTrack is a H264 track.
+type VideoTrack struct {
+	// SPS and PPS are inserted before every IDR that doesn't contain them.
+	SPS []byte
+	PPS []byte
+}
+
+// AudioTrack is an AAC track.
+type AudioTrack struct {
+	Config *aac.MPEG4AudioConfig
+}
+
+func durationToTimestamp(v time.Duration) uint64 {
+	// avoid overflows of v * clockRate
+	secs := v / time.Second
+	dec := v % time.Second
+	ts := int64(secs)*clockRate + int64(dec)*clockRate/int64(time.Second)
+	return uint64(ts) & 0x1FFFFFFFF
+}
+
+func encodeTimestamp(prefix uint8, ts uint64) []byte {
+	return []byte{
+		prefix<<4 | byte(ts>>29)&0x0E | 0x01,
+		byte(ts >> 22),
+		byte(ts>>14)&0xFE | 0x01,
+		byte(ts >> 7),
+		byte(ts<<1)&0xFE | 0x01,
+	}
+}
+
+func encodePCR(pcr uint64) []byte {
+	return []byte{
+		byte(pcr >> 25),
+		byte(pcr >> 17),
+		byte(pcr >> 9),
+		byte(pcr >> 1),
+		byte(pcr<<7) | 0x7E, // reserved bits, extension = 0
+		0x00,
+	}
+}
+
+// Muxer is a MPEG-TS muxer.
+// It writes a single program that contains an optional H264 track
+// and an optional AAC track.
+type Muxer struct {
+	w          io.Writer
+	videoTrack *VideoTrack
+	audioTrack *AudioTrack
+	pcrPID     uint16
+
+	ccs           map[uint16]uint8
+	tablesWritten bool
+	lastTablesPTS time.Duration
+	buf           []byte
+}
+
+// NewMuxer allocates a Muxer.
+// At least one track must be provided.
+func NewMuxer(w io.Writer, videoTrack *VideoTrack, audioTrack *AudioTrack) (*Muxer, error) {
+	if videoTrack == nil && audioTrack == nil {
+		return nil, fmt.Errorf("at least one track must be provided")
+	}
+
+	if audioTrack != nil && audioTrack.Config == nil {
+		return nil, fmt.Errorf("AAC configuration is missing")
+	}
+
+	m := &Muxer{
+		w:          w,
+		videoTrack: videoTrack,
+		audioTrack: audioTrack,
+		ccs:        make(map[uint16]uint8),
+	}
+
+	if videoTrack != nil {
+		m.pcrPID = muxerVideoPID
+	} else {
+		m.pcrPID = muxerAudioPID
+	}
+
+	return m, nil
+}
+
+// WriteH264 writes a H264 access unit.
+// DTS can be computed with h264.DTSExtractor or h264.DTSEstimator.
+// An AUD is prepended to the access unit, replacing existing ones.
+func (m *Muxer) WriteH264(pts time.Duration, dts time.Duration, au [][]byte) error {
+	if m.videoTrack == nil {
+		return fmt.Errorf("video track is not present")
+	}
+
+	idr := false
+	spsPresent := false
+	ppsPresent := false
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeIDR:
+			idr = true
+
+		case h264.NALUTypeSPS:
+			spsPresent = true
+
+		case h264.NALUTypePPS:
+			ppsPresent = true
+		}
+	}
+
+	filteredNALUs := [][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+	}
+
+	if idr {
+		if !spsPresent && m.videoTrack.SPS != nil {
+			filteredNALUs = append(filteredNALUs, m.videoTrack.SPS)
+		}
+		if !ppsPresent && m.videoTrack.PPS != nil {
+			filteredNALUs = append(filteredNALUs, m.videoTrack.PPS)
+		}
+	}
+
+	for _, nalu := range au {
+		if len(nalu) == 0 || h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeAccessUnitDelimiter {
+			continue
+		}
+		filteredNALUs = append(filteredNALUs, nalu)
+	}
+
+	enc, err := h264.EncodeAnnexB(filteredNALUs)
+	if err != nil {
+		return err
+	}
+
+	if idr || !m.tablesWritten {
+		m.writeTables(pts)
+	}
+
+	m.writePES(muxerVideoPID, streamIDVideo, idr, pts, &dts, enc)
+
+	return m.flush()
+}
+
+// WriteAAC writes AAC AUs, that are grouped into a single PES packet.
+// When the PES packet would be too big, AUs are split into multiple PES packets.
+func (m *Muxer) WriteAAC(pts time.Duration, aus [][]byte) error {
+	if m.audioTrack == nil {
+		return fmt.Errorf("audio track is not present")
+	}
+
+	encs := make([][]byte, len(aus))
+	for i, au := range aus {
+		enc, err := aac.EncodeADTS([]*aac.ADTSPacket{{
+			Type:         int(m.audioTrack.Config.Type),
+			SampleRate:   m.audioTrack.Config.SampleRate,
+			ChannelCount: m.audioTrack.Config.ChannelCount,
+			AU:           au,
+		}})
+		if err != nil {
+			return err
+		}
+
+		if len(enc) > muxerAudioPESMaxDataSize {
+			return fmt.Errorf("AU is too big (%d)", len(au))
+		}
+
+		encs[i] = enc
+	}
+
+	if !m.tablesWritten ||
+		(m.videoTrack == nil && (pts-m.lastTablesPTS) >= muxerTablesInterval) {
+		m.writeTables(pts)
+	}
+
+	var data []byte
+	dataPTS := pts
+
+	for i, enc := range encs {
+		if (len(data) + len(enc)) > muxerAudioPESMaxDataSize {
+			m.writePES(muxerAudioPID, streamIDAudio, m.videoTrack == nil, dataPTS, nil, data)
+			data = nil
+		}
+
+		if data == nil {
+			dataPTS = pts + time.Duration(i)*aacSamplesPerAU*time.Second/
+				time.Duration(m.audioTrack.Config.SampleRate)
+		}
+
+		data = append(data, enc...)
+	}
+
+	m.writePES(muxerAudioPID, streamIDAudio, m.videoTrack == nil, dataPTS, nil, data)
+
+	return m.flush()
+}
+
+func (m *Muxer) flush() error {
+	_, err := m.w.Write(m.buf)
+	m.buf = m.buf[:0]
+	return err
+}
+
+func (m *Muxer) nextCC(pid uint16) uint8 {
+	cc := m.ccs[pid]
+	m.ccs[pid] = (cc + 1) & 0x0F
+	return cc
+}
+
+// writePacket appends a TS packet to the buffer.
+// When the payload is shorter than the available space, the adaptation field is
+// filled with stuffing bytes.
+func (m *Muxer) writePacket(pid uint16, pusi bool, af []byte, payload []byte) int {
+	afPresent := af != nil
+	avail := PacketSize - 4
+	if afPresent {
+		avail -= 1 + len(af)
+	}
+
+	if len(payload) < avail {
+		stuffing := avail - len(payload)
+
+		if !afPresent {
+			afPresent = true
+			stuffing--
+
+			if stuffing > 0 {
+				af = []byte{0x00} // flags
+				stuffing--
+			}
+		}
+
+		for i := 0; i < stuffing; i++ {
+			af = append(af, 0xFF)
+		}
+		avail = len(payload)
+	}
+
+	afc := uint8(0x01)
+	if afPresent {
+		afc |= 0x02
+	}
+
+	var pusiBit uint8
+	if pusi {
+		pusiBit = 0x40
+	}
+
+	m.buf = append(m.buf,
+		0x47,
+		pusiBit|byte(pid>>8)&0x1F,
+		byte(pid),
+		afc<<4|m.nextCC(pid))
+
+	if afPresent {
+		m.buf = append(m.buf, byte(len(af)))
+		m.buf = append(m.buf, af...)
+	}
+
+	m.buf = append(m.buf, payload[:avail]...)
+
+	return avail
+}
+
+func (m *Muxer) writeSection(pid uint16, section []byte) {
+	section = append(section, 0, 0, 0, 0)
+	crc := crc32MPEG2(section[:len(section)-4])
+	section[len(section)-4] = byte(crc >> 24)
+	section[len(section)-3] = byte(crc >> 16)
+	section[len(section)-2] = byte(crc >> 8)
+	section[len(section)-1] = byte(crc)
+
+	// pointer field
+	payload := append([]byte{0x00}, section...)
+
+	// PSI sections are padded with 0xFF instead of using the adaptation field
+	for len(payload) < (PacketSize - 4) {
+		payload = append(payload, 0xFF)
+	}
+
+	m.writePacket(pid, true, nil, payload)
+}
+
+func (m *Muxer) writeTables(pts time.Duration) {
+	m.tablesWritten = true
+	m.lastTablesPTS = pts
+
+	// PAT
+	// ref: ISO 13818-1, 2.4.4.3
+	pat := []byte{
+		0x00,       // table_id
+		0xB0, 0x0D, // section_syntax_indicator, section_length
+		0x00, 0x01, // transport_stream_id
+		0xC1,       // version_number, current_next_indicator
+		0x00, 0x00, // section_number, last_section_number
+		byte(muxerProgramNumber >> 8), byte(muxerProgramNumber & 0xFF),
+		0xE0 | byte(muxerPMTPID>>8), byte(muxerPMTPID & 0xFF),
+	}
+	m.writeSection(0, pat)
+
+	// PMT
+	// ref: ISO 13818-1, 2.4.4.8
+	var streams []byte
+	if m.videoTrack != nil {
+		streams = append(streams, byte(StreamTypeH264),
+			0xE0|byte(muxerVideoPID>>8), byte(muxerVideoPID&0xFF),
+			0xF0, 0x00)
+	}
+	if m.audioTrack != nil {
+		streams = append(streams, byte(StreamTypeAAC),
+			0xE0|byte(muxerAudioPID>>8), byte(muxerAudioPID&0xFF),
+			0xF0, 0x00)
+	}
+
+	sectionLength := 9 + len(streams) + 4
+	pmt := []byte{
+		0x02, // table_id
+		0xB0 | byte(sectionLength>>8), byte(sectionLength),
+		byte(muxerProgramNumber >> 8), byte(muxerProgramNumber & 0xFF),
+		0xC1,       // version_number, current_next_indicator
+		0x00, 0x00, // section_number, last_section_number
+		0xE0 | byte(m.pcrPID>>8), byte(m.pcrPID),
+		0xF0, 0x00, // program_info_length
+	}
+	pmt = append(pmt, streams...)
+	m.writeSection(muxerPMTPID, pmt)
+}
+
+func (m *Muxer) writePES(pid uint16, streamID uint8, randomAccess bool,
+	pts time.Duration, dts *time.Duration, data []byte) {
+	// PES header
+	// ref: ISO 13818-1, 2.4.3.6
+	var optionalHeader []byte
+	if dts != nil && *dts != pts {
+		optionalHeader = append([]byte{0x80, 0xC0, 10},
+			encodeTimestamp(0x03, durationToTimestamp(pts))...)
+		optionalHeader = append(optionalHeader,
+			encodeTimestamp(0x01, durationToTimestamp(*dts))...)
+	} else {
+		optionalHeader = append([]byte{0x80, 0x80, 5},
+			encodeTimestamp(0x02, durationToTimestamp(pts))...)
+	}
+
+	// an unbounded length is allowed with video streams only
+	pesLength := 0
+	if streamID != streamIDVideo {
+		pesLength = len(optionalHeader) + len(data)
+	}
+
+	pes := []byte{0x00, 0x00, 0x01, streamID, byte(pesLength >> 8), byte(pesLength)}
+	pes = append(pes, optionalHeader...)
+	pes = append(pes, data...)
+
+	// adaptation field of the first packet
+	var af []byte
+	var afFlags uint8
+	if randomAccess {
+		afFlags |= 0x40
+	}
+	if pid == m.pcrPID {
+		afFlags |= 0x10
+	}
+	if afFlags != 0 {
+		af = []byte{afFlags}
+		if pid == m.pcrPID {
+			pcr := pts
+			if dts != nil {
+				pcr = *dts
+			}
+			af = append(af, encodePCR(durationToTimestamp(pcr))...)
+		}
+	}
+
+	n := m.writePacket(pid, true, af, pes)
+	pes = pes[n:]
+
+	for len(pes) > 0 {
+		n := m.writePacket(pid, false, nil, pes)
+		pes = pes[n:]
+	}
+}
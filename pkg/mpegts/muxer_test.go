@@ -0,0 +1,325 @@
+package mpegts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astits"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+var testVideoTrack = &VideoTrack{
+	SPS: []byte{0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95},
+	PPS: []byte{0x68, 0xce, 0x3c, 0x80},
+}
+
+var testAudioTrack = &AudioTrack{
+	Config: &aac.MPEG4AudioConfig{
+		Type:         aac.MPEG4AudioTypeAACLC,
+		SampleRate:   44100,
+		ChannelCount: 2,
+	},
+}
+
+func splitPackets(byts []byte) [][]byte {
+	var pkts [][]byte
+	for len(byts) > 0 {
+		pkts = append(pkts, byts[:PacketSize])
+		byts = byts[PacketSize:]
+	}
+	return pkts
+}
+
+func TestMuxer(t *testing.T) {
+	var buf bytes.Buffer
+
+	m, err := NewMuxer(&buf, testVideoTrack, testAudioTrack)
+	require.NoError(t, err)
+
+	idr := []byte{0x65, 0x88, 0x84, 0x00, 0x33, 0xff}
+	nonIDR := append([]byte{0x41, 0x9a}, bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 100)...)
+
+	// SPS and PPS are inserted before the IDR
+	err = m.WriteH264(2*time.Second, 1900*time.Millisecond, [][]byte{idr})
+	require.NoError(t, err)
+
+	err = m.WriteAAC(1950*time.Millisecond, [][]byte{{0x01, 0x02}, {0x03, 0x04}})
+	require.NoError(t, err)
+
+	// existing AUDs are replaced
+	err = m.WriteH264(2100*time.Millisecond, 1940*time.Millisecond, [][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 0x10},
+		nonIDR,
+	})
+	require.NoError(t, err)
+
+	// PTS equal to DTS
+	err = m.WriteH264(2200*time.Millisecond, 2200*time.Millisecond, [][]byte{nonIDR})
+	require.NoError(t, err)
+
+	pkts := splitPackets(buf.Bytes())
+
+	// PAT, PMT, video, audio, video (3 packets), video (3 packets)
+	require.Equal(t, 10, len(pkts))
+
+	require.Equal(t, []byte{
+		0x47, 0x40, 0x00, 0x10, 0x00,
+		0x00, 0xb0, 0x0d, 0x00, 0x01, 0xc1, 0x00, 0x00,
+		0x00, 0x01, 0xf0, 0x00,
+	}, pkts[0][:17])
+
+	// random access indicator and PCR
+	require.Equal(t, []byte{0x47, 0x41, 0x00, 0x30}, pkts[2][:4])
+	require.Equal(t, byte(0x50), pkts[2][5])
+
+	d := NewDemuxer()
+	var frames []*Frame
+	for _, pkt := range pkts {
+		addFrames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		frames = append(frames, addFrames...)
+	}
+	frames = append(frames, d.Flush()...)
+
+	require.Equal(t, map[uint16]StreamType{
+		256: StreamTypeH264,
+		257: StreamTypeAAC,
+	}, d.Streams())
+
+	// PES packets are returned in order of completion
+	require.Equal(t, 4, len(frames))
+
+	enc, err := h264.EncodeAnnexB([][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+		testVideoTrack.SPS,
+		testVideoTrack.PPS,
+		idr,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, &Frame{
+		PID:  256,
+		Type: StreamTypeH264,
+		PTS:  100 * time.Millisecond,
+		DTS:  0,
+		Data: enc,
+	}, frames[1])
+
+	aacEnc, err := aac.EncodeADTS([]*aac.ADTSPacket{
+		{
+			Type:         2,
+			SampleRate:   44100,
+			ChannelCount: 2,
+			AU:           []byte{0x01, 0x02},
+		},
+		{
+			Type:         2,
+			SampleRate:   44100,
+			ChannelCount: 2,
+			AU:           []byte{0x03, 0x04},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, &Frame{
+		PID:  257,
+		Type: StreamTypeAAC,
+		PTS:  50 * time.Millisecond,
+		DTS:  50 * time.Millisecond,
+		Data: aacEnc,
+	}, frames[0])
+
+	enc, err = h264.EncodeAnnexB([][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+		nonIDR,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, &Frame{
+		PID:  256,
+		Type: StreamTypeH264,
+		PTS:  200 * time.Millisecond,
+		DTS:  40 * time.Millisecond,
+		Data: enc,
+	}, frames[2])
+
+	require.Equal(t, &Frame{
+		PID:  256,
+		Type: StreamTypeH264,
+		PTS:  300 * time.Millisecond,
+		DTS:  300 * time.Millisecond,
+		Data: enc,
+	}, frames[3])
+
+	// check compatibility with a third-party demuxer, that verifies CRCs
+	dem := astits.NewDemuxer(context.Background(), bytes.NewReader(buf.Bytes()))
+	var pesCount int
+	for {
+		data, err := dem.NextData()
+		if errors.Is(err, astits.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+
+		switch {
+		case data.PAT != nil:
+			require.Equal(t, []*astits.PATProgram{{
+				ProgramMapID:  0x1000,
+				ProgramNumber: 1,
+			}}, data.PAT.Programs)
+
+		case data.PMT != nil:
+			require.Equal(t, uint16(256), data.PMT.PCRPID)
+			require.Equal(t, 2, len(data.PMT.ElementaryStreams))
+
+		case data.PES != nil:
+			pesCount++
+		}
+	}
+	require.Equal(t, 4, pesCount)
+}
+
+func TestMuxerAudioOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	m, err := NewMuxer(&buf, nil, testAudioTrack)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = m.WriteAAC(time.Duration(i)*600*time.Millisecond, [][]byte{{0x01, 0x02, 0x03, 0x04}})
+		require.NoError(t, err)
+	}
+
+	pkts := splitPackets(buf.Bytes())
+
+	// PAT, PMT, audio, audio, PAT, PMT, audio
+	require.Equal(t, 7, len(pkts))
+
+	for _, i := range []int{0, 4} {
+		require.Equal(t, []byte{0x47, 0x40, 0x00}, pkts[i][:3])
+	}
+
+	// continuity counter of PAT
+	require.Equal(t, byte(0x11), pkts[4][3])
+
+	// PCR is carried by the audio track
+	require.Equal(t, []byte{0x47, 0x41, 0x01, 0x30}, pkts[2][:4])
+	require.Equal(t, byte(0x50), pkts[2][5])
+
+	d := NewDemuxer()
+	var frames []*Frame
+	for _, pkt := range pkts {
+		addFrames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		frames = append(frames, addFrames...)
+	}
+
+	require.Equal(t, 3, len(frames))
+	require.Equal(t, 1200*time.Millisecond, frames[2].PTS)
+}
+
+func TestMuxerAudioSplit(t *testing.T) {
+	var buf bytes.Buffer
+
+	m, err := NewMuxer(&buf, nil, testAudioTrack)
+	require.NoError(t, err)
+
+	// 80 KiB of AUs, that do not fit into a single PES packet
+	aus := make([][]byte, 20)
+	for i := range aus {
+		aus[i] = bytes.Repeat([]byte{byte(i)}, 4096)
+	}
+
+	err = m.WriteAAC(0, aus)
+	require.NoError(t, err)
+
+	d := NewDemuxer()
+	var frames []*Frame
+	for _, pkt := range splitPackets(buf.Bytes()) {
+		addFrames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		frames = append(frames, addFrames...)
+	}
+	frames = append(frames, d.Flush()...)
+
+	require.Equal(t, 2, len(frames))
+	require.Equal(t, time.Duration(0), frames[0].PTS)
+	require.InDelta(t, float64(15*1024*time.Second/44100), float64(frames[1].PTS), float64(time.Millisecond))
+
+	var decoded [][]byte
+	for _, f := range frames {
+		require.LessOrEqual(t, len(f.Data), 0xFFFF)
+
+		pkts, err := aac.DecodeADTS(f.Data)
+		require.NoError(t, err)
+		for _, pkt := range pkts {
+			decoded = append(decoded, pkt.AU)
+		}
+	}
+	require.Equal(t, aus, decoded)
+}
+
+func TestMuxerEmptyNALUs(t *testing.T) {
+	var buf bytes.Buffer
+
+	m, err := NewMuxer(&buf, testVideoTrack, nil)
+	require.NoError(t, err)
+
+	idr := []byte{0x65, 0x88, 0x84, 0x00, 0x33, 0xff}
+
+	err = m.WriteH264(2*time.Second, 2*time.Second, [][]byte{{}, idr, {}})
+	require.NoError(t, err)
+
+	d := NewDemuxer()
+	var frames []*Frame
+	for _, pkt := range splitPackets(buf.Bytes()) {
+		addFrames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		frames = append(frames, addFrames...)
+	}
+	frames = append(frames, d.Flush()...)
+
+	require.Equal(t, 1, len(frames))
+
+	au, err := h264.DecodeAnnexB(frames[0].Data)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+		testVideoTrack.SPS,
+		testVideoTrack.PPS,
+		idr,
+	}, au)
+}
+
+func TestMuxerErrors(t *testing.T) {
+	_, err := NewMuxer(nil, nil, nil)
+	require.EqualError(t, err, "at least one track must be provided")
+
+	_, err = NewMuxer(nil, nil, &AudioTrack{})
+	require.EqualError(t, err, "AAC configuration is missing")
+
+	m, err := NewMuxer(nil, nil, testAudioTrack)
+	require.NoError(t, err)
+	err = m.WriteH264(0, 0, [][]byte{{0x65}})
+	require.EqualError(t, err, "video track is not present")
+
+	m, err = NewMuxer(nil, testVideoTrack, nil)
+	require.NoError(t, err)
+	err = m.WriteAAC(0, [][]byte{{0x01}})
+	require.EqualError(t, err, "audio track is not present")
+
+	m, err = NewMuxer(nil, nil, testAudioTrack)
+	require.NoError(t, err)
+	err = m.WriteAAC(0, [][]byte{make([]byte, 70000)})
+	require.EqualError(t, err, "AU is too big (70000)")
+}
+
+func TestCRC32MPEG2(t *testing.T) {
+	require.Equal(t, uint32(0x0376e6e7), crc32MPEG2([]byte("123456789")))
+}
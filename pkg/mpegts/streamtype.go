@@ -0,0 +1,39 @@
+package mpegts
+
+import (
+	"fmt"
+)
+
+// StreamType is the type of an elementary stream.
+type StreamType uint8
+
+// standard stream types.
+const (
+	StreamTypeMPEG1Video StreamType = 0x01
+	StreamTypeMPEG2Video StreamType = 0x02
+	StreamTypeMPEG1Audio StreamType = 0x03
+	StreamTypeMPEG2Audio StreamType = 0x04
+	StreamTypeAAC        StreamType = 0x0F
+	StreamTypeMPEG4Video StreamType = 0x10
+	StreamTypeH264       StreamType = 0x1B
+	StreamTypeH265       StreamType = 0x24
+)
+
+var streamTypeLabels = map[StreamType]string{
+	StreamTypeMPEG1Video: "MPEG1Video",
+	StreamTypeMPEG2Video: "MPEG2Video",
+	StreamTypeMPEG1Audio: "MPEG1Audio",
+	StreamTypeMPEG2Audio: "MPEG2Audio",
+	StreamTypeAAC:        "AAC",
+	StreamTypeMPEG4Video: "MPEG4Video",
+	StreamTypeH264:       "H264",
+	StreamTypeH265:       "H265",
+}
+
+// String implements fmt.Stringer.
+func (st StreamType) String() string {
+	if l, ok := streamTypeLabels[st]; ok {
+		return l
+	}
+	return fmt.Sprintf("unknown (%d)", st)
+}
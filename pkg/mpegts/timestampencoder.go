@@ -0,0 +1,51 @@
+package mpegts
+
+import (
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
+// TimestampEncoderOffset is the offset added to timestamps by TimestampEncoder,
+// since the DTS of the first frames can be lower than the PTS of the first frame,
+// and MPEG-TS timestamps can't be negative.
+const TimestampEncoderOffset = 2 * time.Second
+
+// TimestampEncoder computes the timestamps to be passed to Muxer,
+// starting from the PTS of H264 access units and AAC AUs.
+// DTS of H264 access units are computed with a h264.DTSExtractor,
+// that is provided with the SPS and PPS of the video track.
+type TimestampEncoder struct {
+	dtsExtractor *h264.DTSExtractor
+}
+
+// NewTimestampEncoder allocates a TimestampEncoder.
+func NewTimestampEncoder(videoTrack *VideoTrack) (*TimestampEncoder, error) {
+	e := &TimestampEncoder{
+		dtsExtractor: h264.NewDTSExtractor(),
+	}
+
+	if videoTrack != nil {
+		err := e.dtsExtractor.SetParams(videoTrack.SPS, videoTrack.PPS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// EncodeH264 returns the PTS and DTS of a H264 access unit.
+func (e *TimestampEncoder) EncodeH264(pts time.Duration, au [][]byte) (time.Duration, time.Duration, error) {
+	dts, err := e.dtsExtractor.Extract(au, pts)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return pts + TimestampEncoderOffset, dts + TimestampEncoderOffset, nil
+}
+
+// EncodeAAC returns the PTS of AAC AUs.
+func (e *TimestampEncoder) EncodeAAC(pts time.Duration) time.Duration {
+	return pts + TimestampEncoderOffset
+}
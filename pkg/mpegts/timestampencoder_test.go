@@ -0,0 +1,31 @@
+package mpegts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampEncoder(t *testing.T) {
+	// SPS with max_num_reorder_frames = 2
+	sps := []byte{
+		0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50,
+		0x05, 0xbb, 0x01, 0x6c, 0x80, 0x00, 0x00, 0x03,
+		0x00, 0x80, 0x00, 0x00, 0x1e, 0x07, 0x8c, 0x18,
+		0xcb,
+	}
+	pps := []byte{0x68, 0xee, 0x3c, 0x80}
+
+	e, err := NewTimestampEncoder(&VideoTrack{SPS: sps, PPS: pps})
+	require.NoError(t, err)
+
+	// IDR slice, without SPS and PPS
+	pts, dts, err := e.EncodeH264(0, [][]byte{{0x65, 0x88, 0x84, 0x80}})
+	require.NoError(t, err)
+	require.Equal(t, TimestampEncoderOffset, pts)
+	require.Equal(t, TimestampEncoderOffset-2*time.Second/30, dts)
+	require.Less(t, int64(0), int64(dts))
+
+	require.Equal(t, TimestampEncoderOffset+time.Second, e.EncodeAAC(time.Second))
+}
@@ -0,0 +1,86 @@
+// Package ntpsync contains a utility to compute the absolute (NTP) time of RTP packets.
+package ntpsync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+// seconds between 1900-01-01 (NTP epoch) and 1970-01-01 (Unix epoch).
+const ntpEpochOffset = 2208988800
+
+func ntpTimeToTime(v uint64) time.Time {
+	secs := int64(v>>32) - ntpEpochOffset
+	nanos := int64((v & 0xFFFFFFFF) * uint64(time.Second) >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// Synchronizer maps RTP timestamps of a track to absolute (NTP) time,
+// by using the RTCP sender reports sent by the source of the track.
+type Synchronizer struct {
+	clockRate float64
+	mutex     sync.Mutex
+
+	// data from the last sender report
+	senderReportReceived bool
+	senderReportNTP      time.Time
+	senderReportRTP      uint32
+}
+
+// New allocates a Synchronizer.
+func New(clockRate int) *Synchronizer {
+	return &Synchronizer{
+		clockRate: float64(clockRate),
+	}
+}
+
+// ProcessPacketRTCP extracts the needed data from RTCP packets.
+func (s *Synchronizer) ProcessPacketRTCP(payload []byte) {
+	packets, err := rtcp.Unmarshal(payload)
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, pkt := range packets {
+		if sr, ok := pkt.(*rtcp.SenderReport); ok {
+			s.senderReportReceived = true
+			s.senderReportNTP = ntpTimeToTime(sr.NTPTime)
+			s.senderReportRTP = sr.RTPTime
+		}
+	}
+}
+
+// NTPTime returns the absolute time of a RTP timestamp.
+// It returns false when no sender report has been received yet.
+func (s *Synchronizer) NTPTime(rtpTime uint32) (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.senderReportReceived {
+		return time.Time{}, false
+	}
+
+	// the difference is computed with signed 32-bit arithmetic,
+	// therefore timestamp wraparounds are handled.
+	diff := int32(rtpTime - s.senderReportRTP)
+
+	return s.senderReportNTP.Add(time.Duration(float64(diff) * float64(time.Second) / s.clockRate)), true
+}
+
+// PacketNTPTime returns the absolute time of a RTP packet.
+// It returns false when no sender report has been received yet
+// or when the packet is invalid.
+func (s *Synchronizer) PacketNTPTime(payload []byte) (time.Time, bool) {
+	// do not parse the entire packet, extract only the fields we need
+	if len(payload) < 8 {
+		return time.Time{}, false
+	}
+
+	rtpTime := uint32(payload[4])<<24 | uint32(payload[5])<<16 | uint32(payload[6])<<8 | uint32(payload[7])
+	return s.NTPTime(rtpTime)
+}
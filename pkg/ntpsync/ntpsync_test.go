@@ -0,0 +1,71 @@
+package ntpsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynchronizer(t *testing.T) {
+	s := New(90000)
+
+	_, ok := s.NTPTime(0xafb45733)
+	require.Equal(t, false, ok)
+
+	srPkt := rtcp.SenderReport{
+		SSRC:        0xba9da416,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     0xafb45733,
+		PacketCount: 714,
+		OctetCount:  859127,
+	}
+	byts, _ := srPkt.Marshal()
+	s.ProcessPacketRTCP(byts)
+
+	srTime := time.Unix(1605962234, 92999999)
+
+	ts, ok := s.NTPTime(0xafb45733)
+	require.Equal(t, true, ok)
+	require.Equal(t, srTime.UnixNano(), ts.UnixNano())
+
+	ts, _ = s.NTPTime(0xafb45733 + 90000)
+	require.Equal(t, srTime.Add(1*time.Second).UnixNano(), ts.UnixNano())
+
+	ts, _ = s.NTPTime(0xafb45733 - 45000)
+	require.Equal(t, srTime.Add(-500*time.Millisecond).UnixNano(), ts.UnixNano())
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      0xafb45733 + 9000,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ = rtpPkt.Marshal()
+	ts, ok = s.PacketNTPTime(byts)
+	require.Equal(t, true, ok)
+	require.Equal(t, srTime.Add(100*time.Millisecond).UnixNano(), ts.UnixNano())
+}
+
+func TestSynchronizerWraparound(t *testing.T) {
+	s := New(90000)
+
+	srPkt := rtcp.SenderReport{
+		SSRC:    0xba9da416,
+		NTPTime: uint64(ntpEpochOffset+1000) << 32,
+		RTPTime: 0xFFFFFFFF - 90000 + 1,
+	}
+	byts, _ := srPkt.Marshal()
+	s.ProcessPacketRTCP(byts)
+
+	ts, ok := s.NTPTime(90000)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Unix(1002, 0).UnixNano(), ts.UnixNano())
+}
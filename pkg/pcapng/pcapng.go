@@ -0,0 +1,39 @@
+// Package pcapng contains a pcapng writer and reader.
+package pcapng
+
+import (
+	"time"
+)
+
+// ref: https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-05.html
+const (
+	blockTypeSectionHeader     = 0x0A0D0D0A
+	blockTypeInterface         = 0x00000001
+	blockTypeSimplePacket      = 0x00000003
+	blockTypeEnhancedPacket    = 0x00000006
+	byteOrderMagic             = 0x1A2B3C4D
+	optionEndOfOpt             = 0
+	optionInterfaceTSResol     = 9
+	maxBlockSize               = 16 * 1024 * 1024
+	defaultTimestampResolution = time.Microsecond
+)
+
+// LinkType is the link type of an interface.
+type LinkType uint16
+
+// standard link types.
+const (
+	LinkTypeEthernet LinkType = 1
+	LinkTypeRaw      LinkType = 101 // raw IPv4 or IPv6 packets
+)
+
+// Packet is a captured packet.
+type Packet struct {
+	Time     time.Time
+	LinkType LinkType
+	Data     []byte
+}
+
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
@@ -0,0 +1,137 @@
+package pcapng
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, LinkTypeRaw)
+	require.NoError(t, err)
+
+	t1 := time.Date(2021, 5, 3, 10, 15, 20, 123456000, time.UTC)
+	err = w.WritePacket(t1, []byte{0x01, 0x02, 0x03})
+	require.NoError(t, err)
+
+	t2 := t1.Add(40 * time.Millisecond)
+	err = w.WritePacket(t2, []byte{0x04, 0x05, 0x06, 0x07, 0x08})
+	require.NoError(t, err)
+
+	// blocks are aligned to 32 bits
+	require.Equal(t, 0, buf.Len()%4)
+
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+
+	pkt, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, LinkTypeRaw, pkt.LinkType)
+	require.Equal(t, t1.UnixNano(), pkt.Time.UnixNano())
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, pkt.Data)
+
+	pkt, err = r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, t2.UnixNano(), pkt.Time.UnixNano())
+	require.Equal(t, []byte{0x04, 0x05, 0x06, 0x07, 0x08}, pkt.Data)
+
+	_, err = r.ReadPacket()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReadBigEndian(t *testing.T) {
+	byts := []byte{
+		// section header
+		0x0a, 0x0d, 0x0d, 0x0a, 0x00, 0x00, 0x00, 0x1c,
+		0x1a, 0x2b, 0x3c, 0x4d, 0x00, 0x01, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0x1c,
+		// interface description with if_tsresol = 9 (nanoseconds)
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x20,
+		0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x09, 0x00, 0x01, 0x09, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20,
+		// unknown block
+		0x00, 0x00, 0x0b, 0xad, 0x00, 0x00, 0x00, 0x0c,
+		0x00, 0x00, 0x00, 0x0c,
+		// enhanced packet
+		0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x24,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x3b, 0x9a, 0xca, 0x05, 0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x02, 0xaa, 0xbb, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x24,
+	}
+
+	r, err := NewReader(bytes.NewReader(byts))
+	require.NoError(t, err)
+
+	pkt, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, &Packet{
+		Time:     time.Unix(1, 5),
+		LinkType: LinkTypeEthernet,
+		Data:     []byte{0xaa, 0xbb},
+	}, pkt)
+
+	_, err = r.ReadPacket()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"section header not found",
+		},
+		{
+			"missing section header",
+			[]byte{
+				0x01, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x14, 0x00, 0x00, 0x00,
+			},
+			"section header not found",
+		},
+		{
+			"invalid magic",
+			[]byte{
+				0x0a, 0x0d, 0x0d, 0x0a, 0x1c, 0x00, 0x00, 0x00,
+				0x01, 0x02, 0x03, 0x04,
+			},
+			"invalid byte order magic",
+		},
+		{
+			"invalid length",
+			[]byte{
+				0x0a, 0x0d, 0x0d, 0x0a, 0x1d, 0x00, 0x00, 0x00,
+				0x4d, 0x3c, 0x2b, 0x1a,
+			},
+			"invalid block length (29)",
+		},
+		{
+			"unsupported version",
+			[]byte{
+				0x0a, 0x0d, 0x0d, 0x0a, 0x1c, 0x00, 0x00, 0x00,
+				0x4d, 0x3c, 0x2b, 0x1a, 0x02, 0x00, 0x00, 0x00,
+				0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+				0x1c, 0x00, 0x00, 0x00,
+			},
+			"unsupported version (2)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := NewReader(bytes.NewReader(ca.byts))
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
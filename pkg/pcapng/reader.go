@@ -0,0 +1,249 @@
+package pcapng
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+type readerInterface struct {
+	linkType       LinkType
+	snapLen        uint32
+	ticksPerSecond uint64
+}
+
+// Reader is a pcapng reader.
+// Packets of all sections and interfaces are returned.
+type Reader struct {
+	r          io.Reader
+	order      binary.ByteOrder
+	interfaces []*readerInterface
+}
+
+// NewReader allocates a Reader and reads the first section header.
+func NewReader(r io.Reader) (*Reader, error) {
+	rd := &Reader{
+		r: r,
+	}
+
+	typ, body, err := rd.readBlock()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("section header not found")
+		}
+		return nil, err
+	}
+
+	if typ != blockTypeSectionHeader {
+		return nil, fmt.Errorf("section header not found")
+	}
+
+	err = rd.readSectionHeader(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return rd, nil
+}
+
+func (r *Reader) readBlock() (uint32, []byte, error) {
+	var header [12]byte
+	_, err := io.ReadFull(r.r, header[:8])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// the block type of section headers is a palindrome,
+	// therefore it can be read before knowing the byte order.
+	if binary.LittleEndian.Uint32(header[0:]) == blockTypeSectionHeader {
+		_, err := io.ReadFull(r.r, header[8:12])
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch {
+		case binary.LittleEndian.Uint32(header[8:]) == byteOrderMagic:
+			r.order = binary.LittleEndian
+
+		case binary.BigEndian.Uint32(header[8:]) == byteOrderMagic:
+			r.order = binary.BigEndian
+
+		default:
+			return 0, nil, fmt.Errorf("invalid byte order magic")
+		}
+	} else if r.order == nil {
+		return 0, nil, fmt.Errorf("section header not found")
+	}
+
+	typ := r.order.Uint32(header[0:])
+	l := r.order.Uint32(header[4:])
+
+	headerLen := uint32(8)
+	if typ == blockTypeSectionHeader {
+		headerLen = 12
+	}
+
+	if l < headerLen+4 || (l%4) != 0 || l > maxBlockSize {
+		return 0, nil, fmt.Errorf("invalid block length (%d)", l)
+	}
+
+	buf := make([]byte, l-8)
+	copy(buf, header[8:headerLen])
+
+	_, err = io.ReadFull(r.r, buf[headerLen-8:])
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+
+	if r.order.Uint32(buf[len(buf)-4:]) != l {
+		return 0, nil, fmt.Errorf("block lengths do not match")
+	}
+
+	return typ, buf[:len(buf)-4], nil
+}
+
+func (r *Reader) readSectionHeader(body []byte) error {
+	if len(body) < 16 {
+		return fmt.Errorf("invalid section header")
+	}
+
+	major := r.order.Uint16(body[4:])
+	if major != 1 {
+		return fmt.Errorf("unsupported version (%d)", major)
+	}
+
+	r.interfaces = nil
+	return nil
+}
+
+func (r *Reader) readInterface(body []byte) error {
+	if len(body) < 8 {
+		return fmt.Errorf("invalid interface description")
+	}
+
+	intf := &readerInterface{
+		linkType:       LinkType(r.order.Uint16(body[0:])),
+		snapLen:        r.order.Uint32(body[4:]),
+		ticksPerSecond: uint64(time.Second / defaultTimestampResolution),
+	}
+
+	opts := body[8:]
+	for len(opts) >= 4 {
+		code := r.order.Uint16(opts[0:])
+		l := int(r.order.Uint16(opts[2:]))
+		opts = opts[4:]
+
+		if code == optionEndOfOpt {
+			break
+		}
+
+		if len(opts) < l {
+			return fmt.Errorf("invalid option length")
+		}
+
+		if code == optionInterfaceTSResol && l >= 1 {
+			v := opts[0]
+			exp := uint(v & 0x7F)
+			if exp > 63 || ((v&0x80) == 0 && exp > 19) {
+				return fmt.Errorf("unsupported timestamp resolution (%d)", v)
+			}
+
+			if (v & 0x80) != 0 {
+				intf.ticksPerSecond = 1 << exp
+			} else {
+				intf.ticksPerSecond = 1
+				for i := uint(0); i < exp; i++ {
+					intf.ticksPerSecond *= 10
+				}
+			}
+		}
+
+		opts = opts[l+pad4(l):]
+	}
+
+	r.interfaces = append(r.interfaces, intf)
+	return nil
+}
+
+func (r *Reader) interfaceByID(id uint32) (*readerInterface, error) {
+	if int(id) >= len(r.interfaces) {
+		return nil, fmt.Errorf("invalid interface ID (%d)", id)
+	}
+	return r.interfaces[id], nil
+}
+
+// ReadPacket reads the next packet.
+// It returns io.EOF when there are no more packets.
+func (r *Reader) ReadPacket() (*Packet, error) {
+	for {
+		typ, body, err := r.readBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		switch typ {
+		case blockTypeSectionHeader:
+			err := r.readSectionHeader(body)
+			if err != nil {
+				return nil, err
+			}
+
+		case blockTypeInterface:
+			err := r.readInterface(body)
+			if err != nil {
+				return nil, err
+			}
+
+		case blockTypeEnhancedPacket:
+			if len(body) < 20 {
+				return nil, fmt.Errorf("invalid enhanced packet")
+			}
+
+			intf, err := r.interfaceByID(r.order.Uint32(body[0:]))
+			if err != nil {
+				return nil, err
+			}
+
+			ts := uint64(r.order.Uint32(body[4:]))<<32 | uint64(r.order.Uint32(body[8:]))
+			capLen := r.order.Uint32(body[12:])
+			if uint32(len(body)-20) < capLen {
+				return nil, fmt.Errorf("invalid captured length (%d)", capLen)
+			}
+
+			return &Packet{
+				Time: time.Unix(int64(ts/intf.ticksPerSecond),
+					int64(float64(ts%intf.ticksPerSecond)*float64(time.Second)/float64(intf.ticksPerSecond))),
+				LinkType: intf.linkType,
+				Data:     body[20 : 20+capLen],
+			}, nil
+
+		case blockTypeSimplePacket:
+			if len(body) < 4 {
+				return nil, fmt.Errorf("invalid simple packet")
+			}
+
+			intf, err := r.interfaceByID(0)
+			if err != nil {
+				return nil, err
+			}
+
+			capLen := r.order.Uint32(body[0:])
+			if intf.snapLen != 0 && capLen > intf.snapLen {
+				capLen = intf.snapLen
+			}
+			if uint32(len(body)-4) < capLen {
+				return nil, fmt.Errorf("invalid packet length (%d)", capLen)
+			}
+
+			// simple packets don't have timestamps
+			return &Packet{
+				LinkType: intf.linkType,
+				Data:     body[4 : 4+capLen],
+			}, nil
+		}
+	}
+}
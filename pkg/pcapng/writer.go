@@ -0,0 +1,72 @@
+package pcapng
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Writer is a pcapng writer.
+// It writes a single section with a single interface.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter allocates a Writer and writes the section header
+// and the interface description.
+func NewWriter(w io.Writer, linkType LinkType) (*Writer, error) {
+	wr := &Writer{
+		w: w,
+	}
+
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1) // major version
+	binary.LittleEndian.PutUint16(shb[6:], 0) // minor version
+	binary.LittleEndian.PutUint64(shb[8:], 0xFFFFFFFFFFFFFFFF)
+
+	err := wr.writeBlock(blockTypeSectionHeader, shb)
+	if err != nil {
+		return nil, err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:], uint16(linkType))
+	binary.LittleEndian.PutUint32(idb[4:], 0) // snap length
+
+	err = wr.writeBlock(blockTypeInterface, idb)
+	if err != nil {
+		return nil, err
+	}
+
+	return wr, nil
+}
+
+func (w *Writer) writeBlock(typ uint32, body []byte) error {
+	padding := pad4(len(body))
+	l := 12 + len(body) + padding
+
+	buf := make([]byte, l)
+	binary.LittleEndian.PutUint32(buf[0:], typ)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(l))
+	copy(buf[8:], body)
+	binary.LittleEndian.PutUint32(buf[l-4:], uint32(l))
+
+	_, err := w.w.Write(buf)
+	return err
+}
+
+// WritePacket writes a packet.
+func (w *Writer) WritePacket(t time.Time, data []byte) error {
+	ts := uint64(t.UnixNano() / int64(defaultTimestampResolution))
+
+	epb := make([]byte, 20+len(data))
+	binary.LittleEndian.PutUint32(epb[0:], 0) // interface ID
+	binary.LittleEndian.PutUint32(epb[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(epb[16:], uint32(len(data)))
+	copy(epb[20:], data)
+
+	return w.writeBlock(blockTypeEnhancedPacket, epb)
+}
@@ -0,0 +1,153 @@
+// Package rtcpnacker contains a utility to generate RTCP Generic NACKs.
+package rtcpnacker
+
+import (
+	"crypto/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// missing packets older than this (in sequence numbers) are not requested anymore.
+	maxMissingAge = 512
+
+	// maximum number of times a missing packet is requested.
+	maxRequests = 3
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// RTCPNacker is a utility to generate RTCP Generic NACKs (RFC 4585),
+// that allow to request the retransmission of lost RTP packets.
+type RTCPNacker struct {
+	senderSSRC uint32
+	mutex      sync.Mutex
+
+	// data from rtp packets
+	firstRTPReceived   bool
+	mediaSSRC          uint32
+	lastSequenceNumber uint16
+	missing            map[uint16]int // sequence number -> number of requests
+}
+
+// New allocates a RTCPNacker.
+func New(senderSSRC *uint32) *RTCPNacker {
+	return &RTCPNacker{
+		senderSSRC: func() uint32 {
+			if senderSSRC == nil {
+				return randUint32()
+			}
+			return *senderSSRC
+		}(),
+		missing: make(map[uint16]int),
+	}
+}
+
+// ProcessPacketRTP extracts the needed data from RTP packets.
+func (rn *RTCPNacker) ProcessPacketRTP(ts time.Time, payload []byte) {
+	rn.mutex.Lock()
+	defer rn.mutex.Unlock()
+
+	// do not parse the entire packet, extract only the fields we need
+	if len(payload) < 12 {
+		return
+	}
+
+	sequenceNumber := uint16(payload[2])<<8 | uint16(payload[3])
+	ssrc := uint32(payload[8])<<24 | uint32(payload[9])<<16 | uint32(payload[10])<<8 | uint32(payload[11])
+
+	// first frame or SSRC change
+	if !rn.firstRTPReceived || ssrc != rn.mediaSSRC {
+		rn.firstRTPReceived = true
+		rn.mediaSSRC = ssrc
+		rn.lastSequenceNumber = sequenceNumber
+		rn.missing = make(map[uint16]int)
+		return
+	}
+
+	diff := int16(sequenceNumber - rn.lastSequenceNumber)
+
+	switch {
+	case diff > 0:
+		// detect lost frames
+		if diff <= maxMissingAge {
+			for seq := rn.lastSequenceNumber + 1; seq != sequenceNumber; seq++ {
+				rn.missing[seq] = 0
+			}
+		} else {
+			// the gap is too big to be recovered
+			rn.missing = make(map[uint16]int)
+		}
+
+		rn.lastSequenceNumber = sequenceNumber
+
+		for seq := range rn.missing {
+			if (rn.lastSequenceNumber - seq) > maxMissingAge {
+				delete(rn.missing, seq)
+			}
+		}
+
+	case diff < 0:
+		// a reordered or retransmitted frame arrived
+		delete(rn.missing, sequenceNumber)
+	}
+}
+
+// Report generates a RTCP Generic NACK that contains the missing packets.
+// It returns nil if there are no missing packets.
+func (rn *RTCPNacker) Report(ts time.Time) []byte {
+	rn.mutex.Lock()
+	defer rn.mutex.Unlock()
+
+	if len(rn.missing) == 0 {
+		return nil
+	}
+
+	seqs := make([]uint16, 0, len(rn.missing))
+	for seq, requests := range rn.missing {
+		seqs = append(seqs, seq)
+
+		if (requests + 1) >= maxRequests {
+			delete(rn.missing, seq)
+		} else {
+			rn.missing[seq] = requests + 1
+		}
+	}
+
+	// sort from the oldest to the newest
+	sort.Slice(seqs, func(a, b int) bool {
+		return (rn.lastSequenceNumber - seqs[a]) > (rn.lastSequenceNumber - seqs[b])
+	})
+
+	pkt := &rtcp.TransportLayerNack{
+		SenderSSRC: rn.senderSSRC,
+		MediaSSRC:  rn.mediaSSRC,
+	}
+
+	for _, seq := range seqs {
+		if len(pkt.Nacks) != 0 {
+			cur := &pkt.Nacks[len(pkt.Nacks)-1]
+			d := seq - cur.PacketID
+			if d >= 1 && d <= 16 {
+				cur.LostPackets |= 1 << (d - 1)
+				continue
+			}
+		}
+
+		pkt.Nacks = append(pkt.Nacks, rtcp.NackPair{PacketID: seq})
+	}
+
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return byts
+}
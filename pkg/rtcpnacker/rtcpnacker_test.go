@@ -0,0 +1,105 @@
+package rtcpnacker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func processSequenceNumber(rn *RTCPNacker, ts time.Time, seq uint16) {
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: seq,
+			Timestamp:      0xafb45733,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ := rtpPkt.Marshal()
+	rn.ProcessPacketRTP(ts, byts)
+}
+
+func TestRTCPNackerBase(t *testing.T) {
+	v := uint32(0x65f83afb)
+	rn := New(&v)
+
+	ts := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	processSequenceNumber(rn, ts, 65530)
+	processSequenceNumber(rn, ts, 65531)
+
+	require.Equal(t, []byte(nil), rn.Report(ts))
+
+	processSequenceNumber(rn, ts, 65534)
+	processSequenceNumber(rn, ts, 2)
+	processSequenceNumber(rn, ts, 25)
+
+	// reordered packet
+	processSequenceNumber(rn, ts, 0)
+
+	expectedPkt := rtcp.TransportLayerNack{
+		SenderSSRC: 0x65f83afb,
+		MediaSSRC:  0xba9da416,
+		Nacks: []rtcp.NackPair{
+			{
+				PacketID:    65532,
+				LostPackets: 0xffd5,
+			},
+			{
+				PacketID:    13,
+				LostPackets: 0x07ff,
+			},
+		},
+	}
+	expected, _ := expectedPkt.Marshal()
+
+	for i := 0; i < maxRequests; i++ {
+		require.Equal(t, expected, rn.Report(ts))
+	}
+
+	require.Equal(t, []byte(nil), rn.Report(ts))
+}
+
+func TestRTCPNackerRetransmission(t *testing.T) {
+	v := uint32(0x65f83afb)
+	rn := New(&v)
+
+	ts := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	processSequenceNumber(rn, ts, 100)
+	processSequenceNumber(rn, ts, 102)
+
+	expectedPkt := rtcp.TransportLayerNack{
+		SenderSSRC: 0x65f83afb,
+		MediaSSRC:  0xba9da416,
+		Nacks: []rtcp.NackPair{
+			{
+				PacketID: 101,
+			},
+		},
+	}
+	expected, _ := expectedPkt.Marshal()
+	require.Equal(t, expected, rn.Report(ts))
+
+	processSequenceNumber(rn, ts, 101)
+	require.Equal(t, []byte(nil), rn.Report(ts))
+}
+
+func TestRTCPNackerBigGap(t *testing.T) {
+	v := uint32(0x65f83afb)
+	rn := New(&v)
+
+	ts := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	processSequenceNumber(rn, ts, 100)
+	processSequenceNumber(rn, ts, 102)
+	processSequenceNumber(rn, ts, 2000)
+
+	require.Equal(t, []byte(nil), rn.Report(ts))
+}
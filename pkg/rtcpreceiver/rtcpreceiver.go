@@ -22,6 +22,7 @@ type RTCPReceiver struct {
 	mutex        sync.Mutex
 
 	// data from rtp packets
+	rtpSSRC              uint32
 	firstRTPReceived     bool
 	sequenceNumberCycles uint16
 	lastSequenceNumber   uint16
@@ -36,6 +37,9 @@ type RTCPReceiver struct {
 	senderSSRC           uint32
 	lastSenderReport     uint32
 	lastSenderReportTime time.Time
+
+	// keyframe requests
+	firSequenceNumber uint8
 }
 
 // New allocates a RTCPReceiver.
@@ -94,6 +98,10 @@ func (rr *RTCPReceiver) ProcessPacketRTP(ts time.Time, payload []byte) {
 
 	// do not parse the entire packet, extract only the fields we need
 	if len(payload) >= 8 {
+		if len(payload) >= 12 {
+			rr.rtpSSRC = uint32(payload[8])<<24 | uint32(payload[9])<<16 | uint32(payload[10])<<8 | uint32(payload[11])
+		}
+
 		sequenceNumber := uint16(payload[2])<<8 | uint16(payload[3])
 		rtpTime := uint32(payload[4])<<24 | uint32(payload[5])<<16 | uint32(payload[6])<<8 | uint32(payload[7])
 
@@ -167,3 +175,57 @@ func (rr *RTCPReceiver) ProcessPacketRTCP(ts time.Time, payload []byte) {
 		}
 	}
 }
+
+// mediaSSRC returns the SSRC of the sender, that is taken from
+// sender reports or, if no sender report has been received yet, from RTP packets.
+// It is used by keyframe requests only, receiver reports keep using
+// the SSRC of sender reports.
+func (rr *RTCPReceiver) mediaSSRC() uint32 {
+	if !rr.lastSenderReportTime.IsZero() {
+		return rr.senderSSRC
+	}
+	return rr.rtpSSRC
+}
+
+// PictureLossIndication generates a RTCP Picture Loss Indication (RFC 4585),
+// that asks the sender to send a keyframe.
+func (rr *RTCPReceiver) PictureLossIndication() []byte {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	pkt := &rtcp.PictureLossIndication{
+		SenderSSRC: rr.receiverSSRC,
+		MediaSSRC:  rr.mediaSSRC(),
+	}
+
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return byts
+}
+
+// FullIntraRequest generates a RTCP Full Intra Request (RFC 5104),
+// that asks the sender to send a keyframe.
+func (rr *RTCPReceiver) FullIntraRequest() []byte {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	pkt := &rtcp.FullIntraRequest{
+		SenderSSRC: rr.receiverSSRC,
+		MediaSSRC:  rr.mediaSSRC(),
+		FIR: []rtcp.FIREntry{{
+			SSRC:           rr.mediaSSRC(),
+			SequenceNumber: rr.firSequenceNumber,
+		}},
+	}
+	rr.firSequenceNumber++
+
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return byts
+}
@@ -385,3 +385,64 @@ func TestRTCPReceiverJitter(t *testing.T) {
 	ts = time.Date(2008, 0o5, 20, 22, 15, 22, 0, time.UTC)
 	require.Equal(t, expected, rr.Report(ts))
 }
+
+func TestRTCPReceiverKeyframeRequest(t *testing.T) {
+	v := uint32(0x65f83afb)
+	rr := New(&v, 90000)
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      0xafb45733,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ := rtpPkt.Marshal()
+	ts := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+	rr.ProcessPacketRTP(ts, byts)
+
+	// receiver reports don't use the SSRC of RTP packets
+	var report rtcp.ReceiverReport
+	err := report.Unmarshal(rr.Report(ts))
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), report.Reports[0].SSRC)
+
+	// keyframe requests use the SSRC of RTP packets until a sender report is received
+	expectedPLI := rtcp.PictureLossIndication{
+		SenderSSRC: 0x65f83afb,
+		MediaSSRC:  0xba9da416,
+	}
+	expected, _ := expectedPLI.Marshal()
+	require.Equal(t, expected, rr.PictureLossIndication())
+
+	for i := 0; i < 2; i++ {
+		expectedFIR := rtcp.FullIntraRequest{
+			SenderSSRC: 0x65f83afb,
+			MediaSSRC:  0xba9da416,
+			FIR: []rtcp.FIREntry{{
+				SSRC:           0xba9da416,
+				SequenceNumber: uint8(i),
+			}},
+		}
+		expected, _ = expectedFIR.Marshal()
+		require.Equal(t, expected, rr.FullIntraRequest())
+	}
+
+	srPkt := rtcp.SenderReport{
+		SSRC:    0x1234abcd,
+		NTPTime: 0xe363887a17ced916,
+	}
+	byts, _ = srPkt.Marshal()
+	rr.ProcessPacketRTCP(ts, byts)
+
+	expectedPLI = rtcp.PictureLossIndication{
+		SenderSSRC: 0x65f83afb,
+		MediaSSRC:  0x1234abcd,
+	}
+	expected, _ = expectedPLI.Marshal()
+	require.Equal(t, expected, rr.PictureLossIndication())
+}
@@ -0,0 +1,106 @@
+package rtpaaclatm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/MP4A-LATM decoder.
+type Decoder struct {
+	clockRate    time.Duration
+	cpresent     bool
+	config       *aac.StreamMuxConfig
+	initialTs    uint32
+	initialTsSet bool
+
+	// for Decode()
+	isDecodingFragmented bool
+	fragmentedTimestamp  uint32
+	fragmentedBuf        []byte
+}
+
+// NewDecoder allocates a Decoder.
+// cpresent tells whether the StreamMuxConfig is transmitted in-band.
+// config is the StreamMuxConfig provided out-of-band by the fmtp attribute.
+// When cpresent is true, it can be nil.
+func NewDecoder(clockRate int, cpresent bool, config *aac.StreamMuxConfig) *Decoder {
+	return &Decoder{
+		clockRate: time.Duration(clockRate),
+		cpresent:  cpresent,
+		config:    config,
+	}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / d.clockRate
+}
+
+// Config returns the configuration of the audio stream.
+// It returns nil if the StreamMuxConfig has not been received yet.
+func (d *Decoder) Config() *aac.MPEG4AudioConfig {
+	if d.config == nil {
+		return nil
+	}
+	return d.config.Config
+}
+
+// Decode decodes AUs from a RTP/MP4A-LATM packet.
+// It returns the AUs and the PTS of the first AU.
+// The PTS of subsequent AUs can be calculated by adding time.Second*1024/clockRate.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	if len(pkt.Payload) == 0 {
+		d.isDecodingFragmented = false
+		d.fragmentedBuf = nil
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	var buf []byte
+
+	if !d.isDecodingFragmented {
+		if !pkt.Header.Marker {
+			d.isDecodingFragmented = true
+			d.fragmentedTimestamp = pkt.Timestamp
+			d.fragmentedBuf = append([]byte(nil), pkt.Payload...)
+			return nil, 0, ErrMorePacketsNeeded
+		}
+
+		buf = pkt.Payload
+	} else {
+		if pkt.Timestamp != d.fragmentedTimestamp {
+			d.isDecodingFragmented = false
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("received a packet with a different timestamp")
+		}
+
+		d.fragmentedBuf = append(d.fragmentedBuf, pkt.Payload...)
+
+		if !pkt.Header.Marker {
+			return nil, 0, ErrMorePacketsNeeded
+		}
+
+		buf = d.fragmentedBuf
+		d.isDecodingFragmented = false
+		d.fragmentedBuf = nil
+	}
+
+	config, aus, err := aac.DecodeAudioMuxElements(buf, d.cpresent, d.config)
+	if err != nil {
+		return nil, 0, err
+	}
+	d.config = config
+
+	return aus, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,125 @@
+package rtpaaclatm
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	samplesPerAU      = 1024
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/MP4A-LATM encoder.
+// The StreamMuxConfig is not transmitted in-band (cpresent=0)
+// and must have NumSubFrames equal to zero.
+type Encoder struct {
+	payloadType    uint8
+	sampleRate     float64
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sampleRate int,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sampleRate:  float64(sampleRate),
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*e.sampleRate)
+}
+
+// Encode encodes AUs into RTP/MP4A-LATM packets.
+// Each AU is put into a dedicated AudioMuxElement,
+// that is fragmented into multiple packets if needed.
+func (e *Encoder) Encode(aus [][]byte, firstPTS time.Duration) ([]*rtp.Packet, error) {
+	if len(aus) == 0 {
+		return nil, fmt.Errorf("there are no AUs")
+	}
+
+	var rets []*rtp.Packet
+	ts := e.encodeTimestamp(firstPTS)
+
+	for _, au := range aus {
+		element, err := aac.EncodeAudioMuxElement([][]byte{au})
+		if err != nil {
+			return nil, err
+		}
+
+		rets = append(rets, e.writeElement(element, ts)...)
+		ts += samplesPerAU
+	}
+
+	return rets, nil
+}
+
+func (e *Encoder) writeElement(element []byte, ts uint32) []*rtp.Packet {
+	packetCount := len(element) / rtpPayloadMaxSize
+	lastPacketSize := len(element) % rtpPayloadMaxSize
+	if lastPacketSize > 0 {
+		packetCount++
+	}
+
+	ret := make([]*rtp.Packet, packetCount)
+	for i := range ret {
+		le := rtpPayloadMaxSize
+		if i == (packetCount-1) && lastPacketSize > 0 {
+			le = lastPacketSize
+		}
+
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      ts,
+				SSRC:           e.ssrc,
+				Marker:         (i == (packetCount - 1)),
+			},
+			Payload: element[:le],
+		}
+		element = element[le:]
+
+		e.sequenceNumber++
+	}
+
+	return ret
+}
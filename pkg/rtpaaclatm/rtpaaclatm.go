@@ -0,0 +1,2 @@
+// Package rtpaaclatm contains a RTP/MP4A-LATM decoder and encoder.
+package rtpaaclatm
@@ -0,0 +1,281 @@
+package rtpaaclatm
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var testConfig = &aac.StreamMuxConfig{
+	Config: &aac.MPEG4AudioConfig{
+		Type:         aac.MPEG4AudioTypeAACLC,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	},
+	LatmBufferFullness: 255,
+}
+
+var cases = []struct {
+	name string
+	aus  [][]byte
+	pts  time.Duration
+	enc  [][]byte
+}{
+	{
+		"single",
+		[][]byte{
+			{0x01, 0x02, 0x03, 0x04},
+		},
+		0,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x04, 0x01, 0x02, 0x03,
+				0x04,
+			},
+		},
+	},
+	{
+		"multiple",
+		[][]byte{
+			{0x01, 0x02, 0x03, 0x04},
+			{0x05, 0x06, 0x07, 0x08},
+		},
+		20 * time.Millisecond,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x04, 0x01, 0x02, 0x03,
+				0x04,
+			},
+			{
+				0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6e, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x04, 0x05, 0x06, 0x07,
+				0x08,
+			},
+		},
+	},
+	{
+		"fragmented",
+		[][]byte{
+			bytes.Repeat([]byte{0x01}, 2000),
+		},
+		0,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+					0x9d, 0xbb, 0x78, 0x12, 0xff, 0xff, 0xff, 0xff,
+					0xff, 0xff, 0xff, 0xd7,
+				},
+				bytes.Repeat([]byte{0x01}, 1452),
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				bytes.Repeat([]byte{0x01}, 548),
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(48000, false, testConfig)
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x01, 0xaa,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var aus [][]byte
+			expPTS := ca.pts
+
+			for _, byts := range ca.enc {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				addAUs, pts, err := d.Decode(&pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, expPTS, pts)
+				aus = append(aus, addAUs...)
+				expPTS += time.Duration(len(addAUs)) * 1024 * time.Second / 48000
+			}
+
+			require.Equal(t, ca.aus, aus)
+			require.Equal(t, testConfig.Config, d.Config())
+		})
+	}
+}
+
+func TestDecodeInBandConfig(t *testing.T) {
+	d := NewDecoder(48000, true, nil)
+	require.Equal(t, (*aac.MPEG4AudioConfig)(nil), d.Config())
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12,
+		// useSameStreamMux = 0, StreamMuxConfig, PayloadLengthInfo, PayloadMux
+		0x20, 0x00, 0x11, 0x90, 0x1f, 0xe0, 0x10, 0x08,
+		0x10,
+	})
+	require.NoError(t, err)
+
+	aus, pts, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x01, 0x02}}, aus)
+	require.Equal(t, time.Duration(0), pts)
+	require.Equal(t, testConfig.Config, d.Config())
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x55,
+		0x9d, 0xbb, 0x78, 0x12,
+		// useSameStreamMux = 1, PayloadLengthInfo, PayloadMux
+		0x81, 0x01, 0x81, 0x80,
+	})
+	require.NoError(t, err)
+
+	aus, pts, err = d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x03, 0x03}}, aus)
+	require.Equal(t, 1024*time.Second/48000, pts)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkts [][]byte
+		err  string
+	}{
+		{
+			"missing payload",
+			[][]byte{
+				{
+					0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+			},
+			"payload is empty",
+		},
+		{
+			"missing au",
+			[][]byte{
+				{
+					0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x04, 0x01,
+				},
+			},
+			"EOF",
+		},
+		{
+			"fragmented with different timestamp",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x04, 0x01,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x16,
+					0x9d, 0xbb, 0x78, 0x12, 0x02, 0x03, 0x04,
+				},
+			},
+			"received a packet with a different timestamp",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(48000, false, testConfig)
+			var lastErr error
+			for _, byts := range ca.pkts {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+				_, _, lastErr = d.Decode(&pkt)
+			}
+			require.EqualError(t, lastErr, ca.err)
+		})
+	}
+}
+
+func TestDecodeMissingConfig(t *testing.T) {
+	d := NewDecoder(48000, true, nil)
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+		0x9d, 0xbb, 0x78, 0x12, 0x80, 0x80,
+	})
+	require.NoError(t, err)
+
+	_, _, err = d.Decode(&pkt)
+	require.EqualError(t, err, "StreamMuxConfig has not been received yet")
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, 48000, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.aus, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(96, 48000, nil, nil, nil)
+	_, err := e.Encode(nil, 0)
+	require.EqualError(t, err, "there are no AUs")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(96, 48000, nil, nil, nil)
+}
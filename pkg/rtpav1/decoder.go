@@ -0,0 +1,164 @@
+package rtpav1
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/av1"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we decoded a non-starting
+// packet of a fragmented OBU and we didn't received anything before.
+// It's normal to receive this when we are decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"decoded a non-starting fragmented packet without any previous starting packet")
+
+// IsNewCodedVideoSequence checks whether a RTP/AV1 packet is the first packet
+// of a coded video sequence (N flag of the aggregation header).
+func IsNewCodedVideoSequence(pkt *rtp.Packet) bool {
+	return len(pkt.Payload) >= 1 && ((pkt.Payload[0]>>3)&0x01) == 1
+}
+
+// Decoder is a RTP/AV1 decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	// for Decode()
+	startingPacketReceived bool
+	isDecodingFragmented   bool
+	fragmentedBuffer       []byte
+	lastSequenceNumber     uint16
+
+	// for DecodeUntilMarker()
+	obuBuffer [][]byte
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes OBUs from a RTP/AV1 packet.
+// It returns the OBUs that have been completed by the packet.
+// OBUs are returned without the obu_size field.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	// aggregation header
+	// ref: https://aomediacodec.github.io/av1-rtp-spec/#44-av1-aggregation-header
+	if len(pkt.Payload) < 2 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	z := (pkt.Payload[0] >> 7) & 0x01
+	y := (pkt.Payload[0] >> 6) & 0x01
+	w := int((pkt.Payload[0] >> 4) & 0x03)
+	payload := pkt.Payload[1:]
+
+	var elements [][]byte
+
+	for i := 0; len(payload) > 0; i++ {
+		if w != 0 && i == (w-1) {
+			// last element, without length field
+			elements = append(elements, payload)
+			break
+		}
+
+		le, n, err := av1.DecodeLEB128(payload)
+		if err != nil {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("invalid OBU element length: %s", err)
+		}
+		payload = payload[n:]
+
+		if le == 0 || uint(len(payload)) < le {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("invalid OBU element length (%d)", le)
+		}
+
+		elements = append(elements, payload[:le])
+		payload = payload[le:]
+	}
+
+	if len(elements) == 0 || (w != 0 && len(elements) != w) {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("invalid OBU element count")
+	}
+
+	if z == 1 {
+		if !d.isDecodingFragmented {
+			if !d.startingPacketReceived {
+				return nil, 0, ErrNonStartingPacketAndNoPrevious
+			}
+			return nil, 0, fmt.Errorf("received a continuation packet without previous starting packet")
+		}
+
+		if pkt.SequenceNumber != (d.lastSequenceNumber + 1) {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("discarding OBU since a RTP packet is missing")
+		}
+
+		d.fragmentedBuffer = append(d.fragmentedBuffer, elements[0]...)
+		elements[0] = d.fragmentedBuffer
+	} else {
+		if d.isDecodingFragmented {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("received a starting packet while a fragmented OBU was pending")
+		}
+
+		if !d.initialTsSet {
+			d.initialTsSet = true
+			d.initialTs = pkt.Timestamp
+		}
+	}
+
+	d.startingPacketReceived = true
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if y == 1 {
+		d.fragmentedBuffer = append([]byte(nil), elements[len(elements)-1]...)
+		d.isDecodingFragmented = true
+		elements = elements[:len(elements)-1]
+
+		if len(elements) == 0 {
+			return nil, 0, ErrMorePacketsNeeded
+		}
+	} else {
+		d.isDecodingFragmented = false
+	}
+
+	return elements, d.decodeTimestamp(pkt.Timestamp), nil
+}
+
+// DecodeUntilMarker decodes OBUs from a RTP/AV1 packet and puts them in a buffer.
+// When a packet has the marker flag (meaning that all the OBUs of a temporal unit
+// have been received), the buffer is returned.
+func (d *Decoder) DecodeUntilMarker(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	obus, pts, err := d.Decode(pkt)
+	if err != nil && err != ErrMorePacketsNeeded {
+		d.obuBuffer = d.obuBuffer[:0]
+		return nil, 0, err
+	}
+
+	d.obuBuffer = append(d.obuBuffer, obus...)
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	ret := d.obuBuffer
+	d.obuBuffer = nil
+
+	return ret, pts, nil
+}
@@ -0,0 +1,193 @@
+package rtpav1
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/av1"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // av1 always uses 90khz
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// removeSizeField removes the obu_size field from an OBU, if present.
+func removeSizeField(obu []byte) ([]byte, error) {
+	hasSize := (obu[0] >> 1) & 0x01
+	if hasSize == 0 {
+		return obu, nil
+	}
+
+	headerLen := 1
+	if ((obu[0] >> 2) & 0x01) == 1 {
+		headerLen = 2
+	}
+
+	if len(obu) < headerLen {
+		return nil, fmt.Errorf("invalid OBU")
+	}
+
+	le, n, err := av1.DecodeLEB128(obu[headerLen:])
+	if err != nil {
+		return nil, err
+	}
+
+	if uint(len(obu)-headerLen-n) < le {
+		return nil, fmt.Errorf("invalid OBU size")
+	}
+
+	ret := make([]byte, headerLen+int(le))
+	copy(ret, obu[:headerLen])
+	ret[0] &^= 0x02
+	copy(ret[headerLen:], obu[headerLen+n:headerLen+n+int(le)])
+	return ret, nil
+}
+
+// Encoder is a RTP/AV1 encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*rtpClockRate)
+}
+
+// Encode encodes the OBUs of a temporal unit into RTP/AV1 packets.
+// Temporal delimiters and tile lists are dropped, and obu_size fields are removed.
+// The N flag is set when the temporal unit contains a sequence header.
+func (e *Encoder) Encode(obus [][]byte, pts time.Duration) ([]*rtp.Packet, error) {
+	var filtered [][]byte
+	newSequence := false
+
+	for _, obu := range obus {
+		if len(obu) == 0 {
+			return nil, fmt.Errorf("empty OBU")
+		}
+
+		typ := av1.OBUType((obu[0] >> 3) & 0x0F)
+
+		switch typ {
+		case av1.OBUTypeTemporalDelimiter, av1.OBUTypeTileList:
+			continue
+
+		case av1.OBUTypeSequenceHeader:
+			newSequence = true
+		}
+
+		obu, err := removeSizeField(obu)
+		if err != nil {
+			return nil, err
+		}
+
+		filtered = append(filtered, obu)
+	}
+
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("temporal unit doesn't contain any OBU")
+	}
+
+	var payloads [][]byte
+	cur := []byte{0}
+	z := uint8(0)
+
+	flush := func(y uint8) {
+		n := uint8(0)
+		if newSequence && len(payloads) == 0 {
+			n = 1
+		}
+		cur[0] = (z << 7) | (y << 6) | (n << 3)
+		payloads = append(payloads, cur)
+		cur = []byte{0}
+		z = y
+	}
+
+	for _, obu := range filtered {
+		for len(obu) > 0 {
+			avail := rtpPayloadMaxSize - len(cur)
+
+			// the whole OBU fits into the current packet
+			if len(av1.EncodeLEB128(uint(len(obu))))+len(obu) <= avail {
+				cur = append(cur, av1.EncodeLEB128(uint(len(obu)))...)
+				cur = append(cur, obu...)
+				break
+			}
+
+			// write a fragment that fills the current packet
+			le := avail - len(av1.EncodeLEB128(uint(avail)))
+			if le <= 0 {
+				flush(0)
+				continue
+			}
+
+			cur = append(cur, av1.EncodeLEB128(uint(le))...)
+			cur = append(cur, obu[:le]...)
+			obu = obu[le:]
+			flush(1)
+		}
+	}
+
+	flush(0)
+
+	ret := make([]*rtp.Packet, len(payloads))
+	encPTS := e.encodeTimestamp(pts)
+
+	for i, payload := range payloads {
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      encPTS,
+				SSRC:           e.ssrc,
+				Marker:         (i == len(payloads)-1),
+			},
+			Payload: payload,
+		}
+
+		e.sequenceNumber++
+	}
+
+	return ret, nil
+}
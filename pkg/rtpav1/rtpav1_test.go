@@ -0,0 +1,314 @@
+package rtpav1
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var fragmentedOBU = mergeBytes(
+	[]byte{0x30},
+	bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 375),
+)
+
+var cases = []struct {
+	name string
+	obus [][]byte
+	pts  time.Duration
+	enc  [][]byte
+}{
+	{
+		"aggregated",
+		[][]byte{
+			{0x08, 0xaa, 0xbb, 0xcc},
+			{0x30, 0xdd, 0xee},
+		},
+		25 * time.Millisecond,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6f, 0x1f,
+				0x9d, 0xbb, 0x78, 0x12, 0x08, 0x04, 0x08, 0xaa,
+				0xbb, 0xcc, 0x03, 0x30, 0xdd, 0xee,
+			},
+		},
+	},
+	{
+		"fragmented",
+		[][]byte{
+			fragmentedOBU,
+		},
+		55 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x40, 0xb1, 0x0b,
+				},
+				fragmentedOBU[:1457],
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xee, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0xc0, 0xb1, 0x0b,
+				},
+				fragmentedOBU[1457:2914],
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0xe0, 0x44, 0xef, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x80, 0x57,
+				},
+				fragmentedOBU[2914:],
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0xe0, 0x44, 0xec, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x01, 0x30,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var obus [][]byte
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				addOBUs, pts, err := d.Decode(&pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+				obus = append(obus, addOBUs...)
+			}
+
+			require.Equal(t, ca.obus, obus)
+		})
+	}
+}
+
+func TestDecodeWithElementCount(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x60, 0x02, 0x08, 0xaa,
+		0x30, 0xbb,
+	})
+	require.NoError(t, err)
+	obus, _, err := d.DecodeUntilMarker(&pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+	require.Equal(t, [][]byte(nil), obus)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x90, 0xcc, 0xdd,
+	})
+	require.NoError(t, err)
+	obus, _, err = d.DecodeUntilMarker(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{0x08, 0xaa},
+		{0x30, 0xbb, 0xcc, 0xdd},
+	}, obus)
+}
+
+func TestDecodePartOfFragmentedBeforeSingle(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x90, 0x01,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x10, 0x30, 0x01,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkts [][]byte
+		err  string
+	}{
+		{
+			"missing payload",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00,
+			}},
+			"payload is too short",
+		},
+		{
+			"invalid element length",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x05, 0x30,
+			}},
+			"invalid OBU element length (5)",
+		},
+		{
+			"invalid element count",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x30, 0x01, 0x30,
+			}},
+			"invalid OBU element count",
+		},
+		{
+			"missing packet",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x50, 0x30,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xef, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x90, 0x01,
+				},
+			},
+			"discarding OBU since a RTP packet is missing",
+		},
+		{
+			"continuation without start",
+			[][]byte{
+				{
+					0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x10, 0x30,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x90, 0x01,
+				},
+			},
+			"received a continuation packet without previous starting packet",
+		},
+		{
+			"start while fragmented",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x50, 0x30,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x10, 0x30,
+				},
+			},
+			"received a starting packet while a fragmented OBU was pending",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			var lastErr error
+			for _, byts := range ca.pkts {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+				_, _, lastErr = d.Decode(&pkt)
+			}
+			require.EqualError(t, lastErr, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.obus, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeFilterAndRemoveSize(t *testing.T) {
+	sequenceNumber := uint16(0x44ed)
+	ssrc := uint32(0x9dbb7812)
+	initialTs := uint32(0x88776655)
+	e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+
+	enc, err := e.Encode([][]byte{
+		{0x12, 0x00},                   // temporal delimiter
+		{0x0a, 0x03, 0xaa, 0xbb, 0xcc}, // sequence header with size
+		{0x32, 0x02, 0xdd, 0xee},       // frame with size
+	}, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(enc))
+	require.Equal(t, true, IsNewCodedVideoSequence(enc[0]))
+	require.Equal(t, []byte{
+		0x08, 0x04, 0x08, 0xaa, 0xbb, 0xcc, 0x03, 0x30,
+		0xdd, 0xee,
+	}, enc[0].Payload)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(96, nil, nil, nil)
+
+	_, err := e.Encode([][]byte{{0x12, 0x00}}, 0)
+	require.EqualError(t, err, "temporal unit doesn't contain any OBU")
+
+	_, err = e.Encode([][]byte{{0x32, 0x05, 0x01}}, 0)
+	require.EqualError(t, err, "invalid OBU size")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(96, nil, nil, nil)
+}
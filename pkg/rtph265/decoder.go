@@ -0,0 +1,206 @@
+package rtph265
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we decoded a non-starting
+// packet of a fragmented NALU and we didn't received anything before.
+// It's normal to receive this when we are decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"decoded a non-starting fragmented packet without any previous starting packet")
+
+// Decoder is a RTP/H265 decoder.
+type Decoder struct {
+	maxDONDiff   int
+	initialTs    uint32
+	initialTsSet bool
+
+	// for Decode()
+	startingPacketReceived bool
+	isDecodingFragmented   bool
+	fragmentedBuffer       []byte
+
+	// for DecodeUntilMarker()
+	naluBuffer [][]byte
+}
+
+// NewDecoder allocates a Decoder.
+// maxDONDiff is the value of the sprop-max-don-diff parameter of the track;
+// when it is greater than zero, packets contain the DONL / DOND fields.
+func NewDecoder(maxDONDiff int) *Decoder {
+	return &Decoder{
+		maxDONDiff: maxDONDiff,
+	}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes NALUs from a RTP/H265 packet.
+// When DONL fields are present, they are removed and NALUs are returned
+// in transmission order.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	if !d.isDecodingFragmented {
+		if !d.initialTsSet {
+			d.initialTsSet = true
+			d.initialTs = pkt.Timestamp
+		}
+
+		if len(pkt.Payload) < 2 {
+			return nil, 0, fmt.Errorf("payload is too short")
+		}
+
+		typ := naluType((pkt.Payload[0] >> 1) & 0b111111)
+
+		switch typ {
+		case naluTypeAP:
+			var nalus [][]byte
+			pkt.Payload = pkt.Payload[2:]
+			first := true
+
+			for len(pkt.Payload) > 0 {
+				if d.maxDONDiff > 0 {
+					// DONL for the first NALU, DOND for the others
+					le := 1
+					if first {
+						le = 2
+					}
+
+					if len(pkt.Payload) < le {
+						return nil, 0, fmt.Errorf("invalid aggregation packet (invalid size)")
+					}
+					pkt.Payload = pkt.Payload[le:]
+				}
+				first = false
+
+				if len(pkt.Payload) < 2 {
+					return nil, 0, fmt.Errorf("invalid aggregation packet (invalid size)")
+				}
+
+				size := binary.BigEndian.Uint16(pkt.Payload)
+				pkt.Payload = pkt.Payload[2:]
+
+				if size == 0 || int(size) > len(pkt.Payload) {
+					return nil, 0, fmt.Errorf("invalid aggregation packet (invalid size)")
+				}
+
+				nalus = append(nalus, pkt.Payload[:size])
+				pkt.Payload = pkt.Payload[size:]
+			}
+
+			if len(nalus) == 0 {
+				return nil, 0, fmt.Errorf("aggregation packet doesn't contain any NALU")
+			}
+
+			d.startingPacketReceived = true
+			return nalus, d.decodeTimestamp(pkt.Timestamp), nil
+
+		case naluTypeFU: // first packet of a fragmented NALU
+			if len(pkt.Payload) < 3 {
+				return nil, 0, fmt.Errorf("invalid fragmentation unit (invalid size)")
+			}
+
+			start := pkt.Payload[2] >> 7
+			if start != 1 {
+				if !d.startingPacketReceived {
+					return nil, 0, ErrNonStartingPacketAndNoPrevious
+				}
+				return nil, 0, fmt.Errorf("invalid fragmentation unit (non-starting)")
+			}
+
+			head := uint16(pkt.Payload[0]&0b10000001)<<8 | uint16(pkt.Payload[1])
+			head |= uint16(pkt.Payload[2]&0b111111) << 9
+			pkt.Payload = pkt.Payload[3:]
+
+			if d.maxDONDiff > 0 {
+				if len(pkt.Payload) < 2 {
+					return nil, 0, fmt.Errorf("invalid fragmentation unit (invalid size)")
+				}
+				pkt.Payload = pkt.Payload[2:]
+			}
+
+			d.fragmentedBuffer = append([]byte{byte(head >> 8), byte(head)}, pkt.Payload...)
+
+			d.isDecodingFragmented = true
+			d.startingPacketReceived = true
+			return nil, 0, ErrMorePacketsNeeded
+
+		case naluTypePACI:
+			return nil, 0, fmt.Errorf("packet type not supported (%v)", typ)
+		}
+
+		nalu := pkt.Payload
+		if d.maxDONDiff > 0 {
+			if len(pkt.Payload) < 4 {
+				return nil, 0, fmt.Errorf("payload is too short")
+			}
+			nalu = append([]byte{pkt.Payload[0], pkt.Payload[1]}, pkt.Payload[4:]...)
+		}
+
+		d.startingPacketReceived = true
+		return [][]byte{nalu}, d.decodeTimestamp(pkt.Timestamp), nil
+	}
+
+	// we are decoding a fragmented NALU
+
+	if len(pkt.Payload) < 3 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("invalid fragmentation unit (invalid size)")
+	}
+
+	typ := naluType((pkt.Payload[0] >> 1) & 0b111111)
+	if typ != naluTypeFU {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("expected fragmentation unit, got another type")
+	}
+
+	start := pkt.Payload[2] >> 7
+	end := (pkt.Payload[2] >> 6) & 0x01
+
+	if start == 1 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("invalid fragmentation unit (decoded two starting packets in a row)")
+	}
+
+	d.fragmentedBuffer = append(d.fragmentedBuffer, pkt.Payload[3:]...)
+
+	if end != 1 {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	d.isDecodingFragmented = false
+	d.startingPacketReceived = true
+	return [][]byte{d.fragmentedBuffer}, d.decodeTimestamp(pkt.Timestamp), nil
+}
+
+// DecodeUntilMarker decodes NALUs from a RTP/H265 packet and puts them in a buffer.
+// When a packet has the marker flag (meaning that all the NALUs with the same PTS have
+// been received), the buffer is returned.
+func (d *Decoder) DecodeUntilMarker(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	nalus, pts, err := d.Decode(pkt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	d.naluBuffer = append(d.naluBuffer, nalus...)
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	ret := d.naluBuffer
+	d.naluBuffer = d.naluBuffer[:0]
+
+	return ret, pts, nil
+}
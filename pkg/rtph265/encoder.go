@@ -0,0 +1,248 @@
+package rtph265
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // h265 always uses 90khz
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/H265 encoder.
+// It never writes DONL fields (sprop-max-don-diff=0).
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*rtpClockRate)
+}
+
+// Encode encodes NALUs into RTP/H265 packets.
+func (e *Encoder) Encode(nalus [][]byte, pts time.Duration) ([]*rtp.Packet, error) {
+	var rets []*rtp.Packet
+	var batch [][]byte
+
+	// split NALUs into batches
+	for _, nalu := range nalus {
+		if e.lenAggregated(batch, nalu) <= rtpPayloadMaxSize {
+			// add to existing batch
+			batch = append(batch, nalu)
+		} else {
+			// write batch
+			if batch != nil {
+				pkts, err := e.writeBatch(batch, pts, false)
+				if err != nil {
+					return nil, err
+				}
+				rets = append(rets, pkts...)
+			}
+
+			// initialize new batch
+			batch = [][]byte{nalu}
+		}
+	}
+
+	// write final batch
+	// marker is used to indicate when all NALUs with same PTS have been sent
+	pkts, err := e.writeBatch(batch, pts, true)
+	if err != nil {
+		return nil, err
+	}
+	rets = append(rets, pkts...)
+
+	return rets, nil
+}
+
+func (e *Encoder) writeBatch(nalus [][]byte, pts time.Duration, marker bool) ([]*rtp.Packet, error) {
+	if len(nalus) == 1 {
+		// the NALU fits into a single RTP packet
+		if len(nalus[0]) < rtpPayloadMaxSize {
+			return e.writeSingle(nalus[0], pts, marker)
+		}
+
+		// split the NALU into multiple fragmentation packet
+		return e.writeFragmented(nalus[0], pts, marker)
+	}
+
+	return e.writeAggregated(nalus, pts, marker)
+}
+
+func (e *Encoder) writeSingle(nalu []byte, pts time.Duration, marker bool) ([]*rtp.Packet, error) {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      e.encodeTimestamp(pts),
+			SSRC:           e.ssrc,
+			Marker:         marker,
+		},
+		Payload: nalu,
+	}
+
+	e.sequenceNumber++
+
+	return []*rtp.Packet{pkt}, nil
+}
+
+func (e *Encoder) writeFragmented(nalu []byte, pts time.Duration, marker bool) ([]*rtp.Packet, error) {
+	packetCount := (len(nalu) - 2) / (rtpPayloadMaxSize - 3)
+	lastPacketSize := (len(nalu) - 2) % (rtpPayloadMaxSize - 3)
+	if lastPacketSize > 0 {
+		packetCount++
+	}
+
+	ret := make([]*rtp.Packet, packetCount)
+	encPTS := e.encodeTimestamp(pts)
+
+	// payload header: same as the NALU header, with type replaced
+	head0 := (nalu[0] & 0b10000001) | (uint8(naluTypeFU) << 1)
+	head1 := nalu[1]
+	typ := (nalu[0] >> 1) & 0b111111
+	nalu = nalu[2:] // remove header
+
+	for i := range ret {
+		start := uint8(0)
+		if i == 0 {
+			start = 1
+		}
+		end := uint8(0)
+		le := rtpPayloadMaxSize - 3
+		if i == (packetCount - 1) {
+			end = 1
+			le = lastPacketSize
+		}
+
+		data := make([]byte, 3+le)
+		data[0] = head0
+		data[1] = head1
+		data[2] = (start << 7) | (end << 6) | typ
+		copy(data[3:], nalu[:le])
+		nalu = nalu[le:]
+
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      encPTS,
+				SSRC:           e.ssrc,
+				Marker:         (i == (packetCount-1) && marker),
+			},
+			Payload: data,
+		}
+
+		e.sequenceNumber++
+	}
+
+	return ret, nil
+}
+
+func (e *Encoder) lenAggregated(nalus [][]byte, addNALU []byte) int {
+	ret := 2 // header
+
+	for _, nalu := range nalus {
+		ret += 2         // size
+		ret += len(nalu) // nalu
+	}
+
+	if addNALU != nil {
+		ret += 2            // size
+		ret += len(addNALU) // nalu
+	}
+
+	return ret
+}
+
+func (e *Encoder) writeAggregated(nalus [][]byte, pts time.Duration, marker bool) ([]*rtp.Packet, error) {
+	payload := make([]byte, e.lenAggregated(nalus, nil))
+
+	// header
+	// F is the OR of all F bits, LayerId and TID are the lowest ones
+	f := uint8(0)
+	layerID := uint8(0b111111)
+	tid := uint8(0b111)
+	for _, nalu := range nalus {
+		f |= nalu[0] >> 7
+		if v := ((nalu[0] & 0x01) << 5) | (nalu[1] >> 3); v < layerID {
+			layerID = v
+		}
+		if v := nalu[1] & 0b111; v < tid {
+			tid = v
+		}
+	}
+	payload[0] = (f << 7) | (uint8(naluTypeAP) << 1) | (layerID >> 5)
+	payload[1] = (layerID << 3) | tid
+	pos := 2
+
+	for _, nalu := range nalus {
+		// size
+		naluLen := len(nalu)
+		binary.BigEndian.PutUint16(payload[pos:], uint16(naluLen))
+		pos += 2
+
+		// nalu
+		copy(payload[pos:], nalu)
+		pos += naluLen
+	}
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      e.encodeTimestamp(pts),
+			SSRC:           e.ssrc,
+			Marker:         marker,
+		},
+		Payload: payload,
+	}
+
+	e.sequenceNumber++
+
+	return []*rtp.Packet{pkt}, nil
+}
@@ -0,0 +1,397 @@
+package rtph265
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var fragmentedPayload = bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 256)
+
+var cases = []struct {
+	name  string
+	nalus [][]byte
+	pts   time.Duration
+	enc   [][]byte
+}{
+	{
+		"single",
+		[][]byte{
+			mergeBytes(
+				[]byte{0x26, 0x01},
+				bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 8),
+			),
+		},
+		25 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6f, 0x1f,
+					0x9d, 0xbb, 0x78, 0x12, 0x26, 0x01,
+				},
+				bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 8),
+			),
+		},
+	},
+	{
+		"aggregated",
+		[][]byte{
+			{0x40, 0x01, 0xaa, 0xbb},
+			{0x42, 0x01, 0xcc},
+			{0x44, 0x01, 0xdd},
+		},
+		0,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x60, 0x01, 0x00, 0x04,
+				0x40, 0x01, 0xaa, 0xbb, 0x00, 0x03, 0x42, 0x01,
+				0xcc, 0x00, 0x03, 0x44, 0x01, 0xdd,
+			},
+		},
+	},
+	{
+		"fragmented",
+		[][]byte{
+			mergeBytes(
+				[]byte{0x26, 0x01},
+				fragmentedPayload,
+			),
+		},
+		55 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x93,
+				},
+				fragmentedPayload[:1457],
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x53,
+				},
+				fragmentedPayload[1457:],
+			),
+		},
+	},
+	{
+		"aggregated followed by fragmented",
+		[][]byte{
+			{0x40, 0x01, 0xaa, 0xbb},
+			{0x42, 0x01, 0xcc},
+			mergeBytes(
+				[]byte{0x26, 0x01},
+				fragmentedPayload,
+			),
+		},
+		0,
+		[][]byte{
+			{
+				0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x60, 0x01, 0x00, 0x04,
+				0x40, 0x01, 0xaa, 0xbb, 0x00, 0x03, 0x42, 0x01,
+				0xcc,
+			},
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x93,
+				},
+				fragmentedPayload[:1457],
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0xe0, 0x44, 0xef, 0x88, 0x77, 0x66, 0x55,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x53,
+				},
+				fragmentedPayload[1457:],
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(0)
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x4e, 0x01, 0x00,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var nalus [][]byte
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				addNALUs, pts, err := d.Decode(&pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+				nalus = append(nalus, addNALUs...)
+			}
+
+			require.Equal(t, ca.nalus, nalus)
+		})
+	}
+}
+
+func TestDecodeUntilMarker(t *testing.T) {
+	d := NewDecoder(0)
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x40, 0x01, 0xaa,
+	})
+	require.NoError(t, err)
+	_, _, err = d.DecodeUntilMarker(&pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x26, 0x01, 0xbb,
+	})
+	require.NoError(t, err)
+	nalus, _, err := d.DecodeUntilMarker(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{0x40, 0x01, 0xaa},
+		{0x26, 0x01, 0xbb},
+	}, nalus)
+}
+
+func TestDecodeDONL(t *testing.T) {
+	d := NewDecoder(2)
+
+	// single NALU
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x26, 0x01, 0x00, 0x01,
+		0xaa, 0xbb,
+	})
+	require.NoError(t, err)
+	nalus, _, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x26, 0x01, 0xaa, 0xbb}}, nalus)
+
+	// aggregation packet
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x60, 0x01, 0x00, 0x02,
+		0x00, 0x03, 0x40, 0x01, 0xaa, 0x00, 0x00, 0x03,
+		0x42, 0x01, 0xbb,
+	})
+	require.NoError(t, err)
+	nalus, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{0x40, 0x01, 0xaa},
+		{0x42, 0x01, 0xbb},
+	}, nalus)
+
+	// fragmentation unit
+	err = pkt.Unmarshal([]byte{
+		0x80, 0x60, 0x44, 0xef, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x93, 0x00,
+		0x04, 0xaa,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xf0, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x53, 0xbb,
+	})
+	require.NoError(t, err)
+	nalus, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x26, 0x01, 0xaa, 0xbb}}, nalus)
+}
+
+func TestDecodePartOfFragmentedBeforeSingle(t *testing.T) {
+	d := NewDecoder(0)
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xef, 0x88, 0x77, 0x79, 0xab,
+		0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x53, 0x04,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xf0, 0x88, 0x77, 0x79, 0xab,
+		0x9d, 0xbb, 0x78, 0x12, 0x26, 0x01, 0x04,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkts [][]byte
+		err  string
+	}{
+		{
+			"missing payload",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12,
+			}},
+			"payload is too short",
+		},
+		{
+			"aggregation packet without NALUs",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x60, 0x01,
+			}},
+			"aggregation packet doesn't contain any NALU",
+		},
+		{
+			"aggregation packet with invalid size",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x60, 0x01, 0x00, 0x05,
+				0x40, 0x01,
+			}},
+			"invalid aggregation packet (invalid size)",
+		},
+		{
+			"fragmentation unit with invalid size",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01,
+			}},
+			"invalid fragmentation unit (invalid size)",
+		},
+		{
+			"fragmentation unit without start bit",
+			[][]byte{
+				{
+					0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x26, 0x01, 0xaa,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x13, 0xaa,
+				},
+			},
+			"invalid fragmentation unit (non-starting)",
+		},
+		{
+			"fragmentation unit with 2 starting packets",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x93, 0xaa,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x93, 0xaa,
+				},
+			},
+			"invalid fragmentation unit (decoded two starting packets in a row)",
+		},
+		{
+			"fragmentation unit followed by another type",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x62, 0x01, 0x93, 0xaa,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x26, 0x01, 0xaa,
+				},
+			},
+			"expected fragmentation unit, got another type",
+		},
+		{
+			"PACI",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x64, 0x01, 0xaa,
+			}},
+			"packet type not supported (PACI)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(0)
+			var lastErr error
+			for _, byts := range ca.pkts {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+				_, _, lastErr = d.Decode(&pkt)
+			}
+			require.EqualError(t, lastErr, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.nalus, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(96, nil, nil, nil)
+}
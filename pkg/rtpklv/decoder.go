@@ -0,0 +1,98 @@
+package rtpklv
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/KLV decoder.
+type Decoder struct {
+	clockRate time.Duration
+
+	initialTs    uint32
+	initialTsSet bool
+
+	sequenceNumberReceived bool
+	lastSequenceNumber     uint16
+	buffer                 []byte
+	bufferTs               uint32
+	discarding             bool
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder(clockRate int) *Decoder {
+	return &Decoder{
+		clockRate: time.Duration(clockRate),
+	}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / d.clockRate
+}
+
+// Decode decodes KLV packets from RTP/KLV packets.
+// A KLV unit is split into packets with the same timestamp,
+// and the last packet of a unit has the marker bit set.
+// It returns the KLV packets contained into a unit and their PTS.
+// When a packet is lost, the unit is discarded and
+// ErrMorePacketsNeeded is returned until the next unit.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	// ref: RFC 6597
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	if d.sequenceNumberReceived && pkt.SequenceNumber != (d.lastSequenceNumber+1) {
+		d.buffer = nil
+		d.discarding = true
+	}
+	d.sequenceNumberReceived = true
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if d.discarding {
+		if pkt.Marker {
+			d.discarding = false
+		}
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	// the previous unit was not terminated
+	if d.buffer != nil && pkt.Timestamp != d.bufferTs {
+		d.buffer = nil
+	}
+
+	if (len(d.buffer) + len(pkt.Payload)) > unitMaxSize {
+		d.buffer = nil
+		d.discarding = !pkt.Marker
+		return nil, 0, fmt.Errorf("KLV unit size exceeds maximum (%d)", unitMaxSize)
+	}
+
+	d.buffer = append(d.buffer, pkt.Payload...)
+	d.bufferTs = pkt.Timestamp
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	unit := d.buffer
+	d.buffer = nil
+
+	pkts, err := splitUnit(unit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(pkts) == 0 {
+		return nil, 0, fmt.Errorf("KLV unit is empty")
+	}
+
+	return pkts, d.decodeTimestamp(pkt.Timestamp), nil
+}
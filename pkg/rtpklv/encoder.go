@@ -0,0 +1,107 @@
+package rtpklv
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/KLV encoder.
+type Encoder struct {
+	payloadType    uint8
+	clockRate      float64
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	clockRate int,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		clockRate:   float64(clockRate),
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*e.clockRate)
+}
+
+// Encode encodes KLV packets, that are presented at the same time,
+// into RTP/KLV packets.
+func (e *Encoder) Encode(pkts [][]byte, pts time.Duration) ([]*rtp.Packet, error) {
+	var unit []byte
+	for _, pkt := range pkts {
+		unit = append(unit, pkt...)
+	}
+
+	if len(unit) == 0 {
+		return nil, fmt.Errorf("there are no KLV packets")
+	}
+
+	if len(unit) > unitMaxSize {
+		return nil, fmt.Errorf("KLV unit size exceeds maximum (%d)", unitMaxSize)
+	}
+
+	_, err := splitUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*rtp.Packet
+	encPTS := e.encodeTimestamp(pts)
+
+	for len(unit) > 0 {
+		n := rtpPayloadMaxSize
+		if n > len(unit) {
+			n = len(unit)
+		}
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      encPTS,
+				SSRC:           e.ssrc,
+				Marker:         n == len(unit),
+			},
+			Payload: unit[:n],
+		})
+
+		e.sequenceNumber++
+		unit = unit[n:]
+	}
+
+	return ret, nil
+}
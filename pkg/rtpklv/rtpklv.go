@@ -0,0 +1,62 @@
+// Package rtpklv contains a RTP/KLV (SMPTE 336M) decoder and encoder.
+package rtpklv
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+
+	// maximum size of a KLV unit.
+	unitMaxSize = 1 * 1024 * 1024
+)
+
+// prefix of SMPTE universal labels.
+var universalLabelPrefix = []byte{0x06, 0x0E, 0x2B, 0x34}
+
+// splitUnit splits a KLV unit into KLV packets.
+func splitUnit(unit []byte) ([][]byte, error) {
+	// ref: SMPTE 336M
+	var ret [][]byte
+
+	for len(unit) > 0 {
+		if len(unit) < 17 {
+			return nil, fmt.Errorf("invalid KLV packet")
+		}
+
+		if !bytes.Equal(unit[:4], universalLabelPrefix) {
+			return nil, fmt.Errorf("invalid universal label key")
+		}
+
+		// length is BER-encoded
+		pos := 16
+		l := uint64(unit[pos])
+		pos++
+
+		if (l & 0x80) != 0 {
+			n := int(l & 0x7F)
+			if n == 0 || n > 8 || len(unit) < (pos+n) {
+				return nil, fmt.Errorf("invalid KLV length")
+			}
+
+			l = 0
+			for _, b := range unit[pos : pos+n] {
+				l = l<<8 | uint64(b)
+			}
+			pos += n
+		}
+
+		if uint64(len(unit)-pos) < l {
+			return nil, fmt.Errorf("invalid KLV length")
+		}
+
+		end := pos + int(l)
+		ret = append(ret, unit[:end])
+		unit = unit[end:]
+	}
+
+	return ret, nil
+}
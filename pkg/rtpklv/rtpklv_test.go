@@ -0,0 +1,213 @@
+package rtpklv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+// UAS Datalink Local Set key
+var testKey = []byte{
+	0x06, 0x0e, 0x2b, 0x34, 0x02, 0x0b, 0x01, 0x01,
+	0x0e, 0x01, 0x03, 0x01, 0x01, 0x00, 0x00, 0x00,
+}
+
+var testShortPacket = mergeBytes(testKey, []byte{0x04, 0x01, 0x02, 0x03, 0x04})
+
+var testLongPacket = mergeBytes(testKey, []byte{0x82, 0x07, 0xd0}, bytes.Repeat([]byte{0x05}, 2000))
+
+var cases = []struct {
+	name string
+	pkts [][]byte
+	pts  time.Duration
+	enc  []*rtp.Packet
+}{
+	{
+		"single",
+		[][]byte{testShortPacket},
+		25 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    97,
+					SequenceNumber: 17645,
+					Timestamp:      2289528607,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: testShortPacket,
+			},
+		},
+	},
+	{
+		"fragmented",
+		[][]byte{testShortPacket, testLongPacket},
+		55 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    97,
+					SequenceNumber: 17645,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(testShortPacket, testLongPacket)[:1460],
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    97,
+					SequenceNumber: 17646,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(testShortPacket, testLongPacket)[1460:],
+			},
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(90000)
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			_, _, err := d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    97,
+					SequenceNumber: 17644,
+					Timestamp:      2289526357,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: testShortPacket,
+			})
+			require.NoError(t, err)
+
+			for i, pkt := range ca.enc {
+				pkts, pts, err := d.Decode(pkt)
+
+				if i != len(ca.enc)-1 {
+					require.Equal(t, ErrMorePacketsNeeded, err)
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+				require.Equal(t, ca.pkts, pkts)
+			}
+		})
+	}
+}
+
+func TestDecodePacketLoss(t *testing.T) {
+	d := NewDecoder(90000)
+
+	enc := cases[1].enc
+
+	// the first packet of the unit is lost
+	_, _, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    97,
+			SequenceNumber: 17643,
+			Timestamp:      2289526357,
+		},
+		Payload: testShortPacket,
+	})
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	_, _, err = d.Decode(enc[1])
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	pkts, _, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    97,
+			SequenceNumber: 17647,
+			Timestamp:      2289540307,
+		},
+		Payload: testShortPacket,
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{testShortPacket}, pkts)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		payload []byte
+		err     string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"KLV unit is empty",
+		},
+		{
+			"invalid key",
+			mergeBytes([]byte{0x01}, testShortPacket[1:]),
+			"invalid universal label key",
+		},
+		{
+			"truncated",
+			testLongPacket[:100],
+			"invalid KLV length",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(90000)
+			_, _, err := d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:     2,
+					Marker:      true,
+					PayloadType: 97,
+				},
+				Payload: ca.payload,
+			})
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(97, 90000, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.pkts, ca.pts)
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, enc)
+		})
+	}
+}
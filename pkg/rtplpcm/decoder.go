@@ -0,0 +1,50 @@
+package rtplpcm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/LPCM decoder.
+type Decoder struct {
+	sampleRate time.Duration
+	frameSize  int
+
+	initialTs    uint32
+	initialTsSet bool
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder(bitDepth int, sampleRate int, channelCount int) *Decoder {
+	return &Decoder{
+		sampleRate: time.Duration(sampleRate),
+		frameSize:  channelCount * bitDepth / 8,
+	}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / d.sampleRate
+}
+
+// Decode decodes audio samples from a RTP/LPCM packet.
+// Samples are interleaved and in network byte order (big endian).
+// It returns the samples and the PTS of the first sample.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	if len(pkt.Payload) == 0 {
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	if (len(pkt.Payload) % d.frameSize) != 0 {
+		return nil, 0, fmt.Errorf("payload size (%d) is not a multiple of the sample size (%d)",
+			len(pkt.Payload), d.frameSize)
+	}
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	return pkt.Payload, d.decodeTimestamp(pkt.Timestamp), nil
+}
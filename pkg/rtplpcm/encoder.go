@@ -0,0 +1,111 @@
+package rtplpcm
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/LPCM encoder.
+type Encoder struct {
+	payloadType    uint8
+	sampleRate     float64
+	frameSize      int
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	bitDepth int,
+	sampleRate int,
+	channelCount int,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sampleRate:  float64(sampleRate),
+		frameSize:   channelCount * bitDepth / 8,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*e.sampleRate)
+}
+
+// Encode encodes audio samples into RTP/LPCM packets.
+// Samples must be interleaved and in network byte order (big endian).
+// The timestamp of each packet is computed from the PTS of the first sample
+// and from the number of samples that precede the packet.
+func (e *Encoder) Encode(samples []byte, pts time.Duration) ([]*rtp.Packet, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("there are no samples")
+	}
+
+	if (len(samples) % e.frameSize) != 0 {
+		return nil, fmt.Errorf("buffer size (%d) is not a multiple of the sample size (%d)",
+			len(samples), e.frameSize)
+	}
+
+	maxPayloadSize := (rtpPayloadMaxSize / e.frameSize) * e.frameSize
+	var ret []*rtp.Packet
+	ts := e.encodeTimestamp(pts)
+
+	for len(samples) > 0 {
+		le := maxPayloadSize
+		if le > len(samples) {
+			le = len(samples)
+		}
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      ts,
+				SSRC:           e.ssrc,
+				Marker:         false,
+			},
+			Payload: samples[:le],
+		})
+
+		e.sequenceNumber++
+		ts += uint32(le / e.frameSize)
+		samples = samples[le:]
+	}
+
+	return ret, nil
+}
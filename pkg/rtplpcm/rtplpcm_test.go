@@ -0,0 +1,162 @@
+package rtplpcm
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var cases = []struct {
+	name    string
+	samples []byte
+	pts     time.Duration
+	enc     [][]byte
+}{
+	{
+		"single",
+		[]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+		20 * time.Millisecond,
+		[][]byte{
+			{
+				0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x69, 0xc7,
+				0x9d, 0xbb, 0x78, 0x12, 0x01, 0x02, 0x03, 0x04,
+				0x05, 0x06,
+			},
+		},
+	},
+	{
+		"splitted",
+		bytes.Repeat([]byte{0x41, 0x42, 0x43}, 680),
+		20 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x69, 0xc7,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				bytes.Repeat([]byte{0x41, 0x42, 0x43}, 486),
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xee, 0x88, 0x77, 0x6a, 0xba,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				bytes.Repeat([]byte{0x41, 0x42, 0x43}, 194),
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(24, 44100, 2)
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0x60, 0x44, 0xec, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x01, 0x02, 0x03, 0x04,
+				0x05, 0x06,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var samples []byte
+			expPTS := ca.pts
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				addSamples, pts, err := d.Decode(&pkt)
+				require.NoError(t, err)
+				require.Equal(t, expPTS, pts)
+				samples = append(samples, addSamples...)
+				expPTS += time.Duration(len(addSamples)/6) * time.Second / 44100
+			}
+
+			require.Equal(t, ca.samples, samples)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d := NewDecoder(16, 44100, 2)
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.EqualError(t, err, "payload is empty")
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x01, 0x02, 0x03,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.EqualError(t, err, "payload size (3) is not a multiple of the sample size (4)")
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, 24, 44100, 2, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.samples, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(96, 16, 44100, 2, nil, nil, nil)
+
+	_, err := e.Encode(nil, 0)
+	require.EqualError(t, err, "there are no samples")
+
+	_, err = e.Encode([]byte{0x01, 0x02, 0x03}, 0)
+	require.EqualError(t, err, "buffer size (3) is not a multiple of the sample size (4)")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(96, 16, 44100, 2, nil, nil, nil)
+}
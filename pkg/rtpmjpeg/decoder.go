@@ -0,0 +1,222 @@
+package rtpmjpeg
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we decoded a non-starting
+// packet of a fragmented image and we didn't received anything before.
+// It's normal to receive this when we are decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"decoded a non-starting fragmented packet without any previous starting packet")
+
+// Decoder is a RTP/M-JPEG decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	startingPacketReceived bool
+	isDecodingFragmented   bool
+	fragmentedHeader       frameHeader
+	fragmentedTimestamp    uint32
+	fragmentedBuffer       []byte
+	lastSequenceNumber     uint16
+
+	// quantization tables received in-band, indexed by Q factor
+	quantizationTables map[uint8][][]byte
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		quantizationTables: make(map[uint8][][]byte),
+	}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes a JPEG image from RTP/M-JPEG packets.
+// Since the RTP payload doesn't contain the JPEG headers, they are rebuilt
+// and the returned image is a complete JFIF file.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	// main JPEG header
+	// ref: RFC2435, section 3.1
+	if len(pkt.Payload) < 8 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	fragmentOffset := int(pkt.Payload[1])<<16 | int(pkt.Payload[2])<<8 | int(pkt.Payload[3])
+	typ := pkt.Payload[4]
+	q := pkt.Payload[5]
+	width := int(pkt.Payload[6]) * 8
+	height := int(pkt.Payload[7]) * 8
+	payload := pkt.Payload[8:]
+
+	switch typ {
+	case 0, 1, 64, 65:
+	default:
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("unsupported type (%d)", typ)
+	}
+
+	if width == 0 || height == 0 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("invalid size (%dx%d)", width, height)
+	}
+
+	var restartInterval uint16
+
+	// restart marker header
+	// ref: RFC2435, section 3.1.7
+	if typ >= 64 {
+		if len(payload) < 4 {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("payload is too short")
+		}
+
+		restartInterval = uint16(payload[0])<<8 | uint16(payload[1])
+		payload = payload[4:]
+	}
+
+	if fragmentOffset == 0 {
+		h := frameHeader{
+			typ:             typ,
+			width:           width,
+			height:          height,
+			restartInterval: restartInterval,
+		}
+
+		switch {
+		case q >= 1 && q <= 99:
+			h.quantizationTables = makeQuantizationTables(q)
+
+		case q >= 128:
+			// quantization table header
+			// ref: RFC2435, section 3.1.8
+			if len(payload) < 4 {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("payload is too short")
+			}
+
+			precision := payload[1]
+			le := int(payload[2])<<8 | int(payload[3])
+			payload = payload[4:]
+
+			// tables are not present in the packet: use the ones
+			// received previously with the same Q factor.
+			// Tables of Q factor 255 change in every frame and can't be reused.
+			// ref: RFC2435, section 3.1.8
+			if le == 0 {
+				if q == 255 {
+					d.isDecodingFragmented = false
+					return nil, 0, fmt.Errorf("quantization tables are mandatory with Q factor 255")
+				}
+
+				tables, ok := d.quantizationTables[q]
+				if !ok {
+					d.isDecodingFragmented = false
+					return nil, 0, fmt.Errorf("quantization tables not received yet (Q factor %d)", q)
+				}
+
+				h.quantizationTables = tables
+				break
+			}
+
+			if precision != 0 {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("16-bit quantization tables are not supported")
+			}
+
+			if le != 64 && le != 128 {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("invalid quantization table length (%d)", le)
+			}
+
+			if len(payload) < le {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("payload is too short")
+			}
+
+			for i := 0; i < le; i += 64 {
+				h.quantizationTables = append(h.quantizationTables,
+					append([]byte(nil), payload[i:i+64]...))
+			}
+			payload = payload[le:]
+
+			if q != 255 {
+				d.quantizationTables[q] = h.quantizationTables
+			}
+
+		default:
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("invalid Q factor (%d)", q)
+		}
+
+		if !d.initialTsSet {
+			d.initialTsSet = true
+			d.initialTs = pkt.Timestamp
+		}
+
+		d.startingPacketReceived = true
+		d.isDecodingFragmented = true
+		d.fragmentedHeader = h
+		d.fragmentedTimestamp = pkt.Timestamp
+		d.fragmentedBuffer = append([]byte(nil), payload...)
+	} else {
+		if !d.isDecodingFragmented {
+			if !d.startingPacketReceived {
+				return nil, 0, ErrNonStartingPacketAndNoPrevious
+			}
+			return nil, 0, fmt.Errorf("received a non-starting packet without previous starting packet")
+		}
+
+		if pkt.SequenceNumber != (d.lastSequenceNumber + 1) {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("discarding image since a RTP packet is missing")
+		}
+
+		if pkt.Timestamp != d.fragmentedTimestamp {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("received a packet with a different timestamp")
+		}
+
+		if fragmentOffset != len(d.fragmentedBuffer) {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("received a fragment with an invalid offset (%d)", fragmentOffset)
+		}
+
+		d.fragmentedBuffer = append(d.fragmentedBuffer, payload...)
+	}
+
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	d.isDecodingFragmented = false
+
+	image := d.fragmentedHeader.marshal()
+	image = append(image, d.fragmentedBuffer...)
+
+	// append the end of image marker, unless it's already present
+	n := len(d.fragmentedBuffer)
+	if n < 2 || d.fragmentedBuffer[n-2] != 0xFF || d.fragmentedBuffer[n-1] != markerEOI {
+		image = append(image, 0xFF, markerEOI)
+	}
+
+	d.fragmentedBuffer = nil
+
+	return image, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,248 @@
+package rtpmjpeg
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // m-jpeg always uses 90khz
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// parseImage extracts the parameters and the entropy-coded data
+// of a baseline JPEG image.
+func parseImage(image []byte) (*frameHeader, []byte, error) {
+	if len(image) < 2 || image[0] != 0xFF || image[1] != markerSOI {
+		return nil, nil, fmt.Errorf("SOI not found")
+	}
+	image = image[2:]
+
+	quantizationTables := make(map[uint8][]byte)
+	var h *frameHeader
+	var lumaTable uint8
+	var chromaTable uint8
+	var restartInterval uint16
+
+	for {
+		if len(image) < 4 || image[0] != 0xFF {
+			return nil, nil, fmt.Errorf("invalid marker")
+		}
+
+		marker := image[1]
+		le := int(image[2])<<8 | int(image[3])
+		if le < 2 || len(image) < (2+le) {
+			return nil, nil, fmt.Errorf("invalid marker length")
+		}
+		seg := image[4 : 2+le]
+		image = image[2+le:]
+
+		switch marker {
+		case markerDQT:
+			for len(seg) > 0 {
+				precision := seg[0] >> 4
+				id := seg[0] & 0x0F
+				if precision != 0 {
+					return nil, nil, fmt.Errorf("16-bit quantization tables are not supported")
+				}
+				if len(seg) < 65 {
+					return nil, nil, fmt.Errorf("invalid DQT")
+				}
+				quantizationTables[id] = seg[1:65]
+				seg = seg[65:]
+			}
+
+		case markerDRI:
+			if len(seg) != 2 {
+				return nil, nil, fmt.Errorf("invalid DRI")
+			}
+			restartInterval = uint16(seg[0])<<8 | uint16(seg[1])
+
+		case markerSOF0:
+			if len(seg) != 15 || seg[0] != 8 || seg[5] != 3 {
+				return nil, nil, fmt.Errorf("unsupported SOF: only 8-bit YUV images are supported")
+			}
+
+			height := int(seg[1])<<8 | int(seg[2])
+			width := int(seg[3])<<8 | int(seg[4])
+			if width == 0 || height == 0 || width > 2040 || height > 2040 ||
+				(width%8) != 0 || (height%8) != 0 {
+				return nil, nil, fmt.Errorf("unsupported size (%dx%d)", width, height)
+			}
+
+			var typ uint8
+			switch seg[7] {
+			case 0x21:
+				typ = 0
+			case 0x22:
+				typ = 1
+			default:
+				return nil, nil, fmt.Errorf("unsupported sampling factors (0x%.2x)", seg[7])
+			}
+
+			if seg[10] != 0x11 || seg[13] != 0x11 || seg[11] != seg[14] {
+				return nil, nil, fmt.Errorf("unsupported chroma components")
+			}
+
+			lumaTable = seg[8]
+			chromaTable = seg[11]
+
+			h = &frameHeader{
+				typ:    typ,
+				width:  width,
+				height: height,
+			}
+
+		case markerSOS:
+			if h == nil {
+				return nil, nil, fmt.Errorf("SOF not found")
+			}
+
+			luma, ok := quantizationTables[lumaTable]
+			if !ok {
+				return nil, nil, fmt.Errorf("quantization table %d not found", lumaTable)
+			}
+			chroma, ok := quantizationTables[chromaTable]
+			if !ok {
+				return nil, nil, fmt.Errorf("quantization table %d not found", chromaTable)
+			}
+			h.quantizationTables = [][]byte{luma, chroma}
+
+			if restartInterval != 0 {
+				h.typ += 64
+				h.restartInterval = restartInterval
+			}
+
+			// remove the end of image marker
+			if len(image) >= 2 && image[len(image)-2] == 0xFF && image[len(image)-1] == markerEOI {
+				image = image[:len(image)-2]
+			}
+
+			if len(image) == 0 {
+				return nil, nil, fmt.Errorf("image data is empty")
+			}
+
+			return h, image, nil
+
+		case 0xC1, 0xC2, 0xC3, 0xC5, 0xC6, 0xC7, 0xC9, 0xCA, 0xCB, 0xCD, 0xCE, 0xCF:
+			return nil, nil, fmt.Errorf("only baseline JPEG images are supported")
+		}
+	}
+}
+
+// Encoder is a RTP/M-JPEG encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*rtpClockRate)
+}
+
+// Encode encodes a baseline JPEG image into RTP/M-JPEG packets.
+// The image must use the default Huffman tables and 4:2:2 or 4:2:0 chroma subsampling.
+// Quantization tables are always sent in-band.
+func (e *Encoder) Encode(image []byte, pts time.Duration) ([]*rtp.Packet, error) {
+	h, data, err := parseImage(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*rtp.Packet
+	encPTS := e.encodeTimestamp(pts)
+	offset := 0
+
+	for len(data) > 0 {
+		// main JPEG header
+		// ref: RFC2435, section 3.1
+		payload := []byte{
+			0,
+			byte(offset >> 16), byte(offset >> 8), byte(offset),
+			h.typ,
+			255, // Q: quantization tables are in-band
+			byte(h.width / 8),
+			byte(h.height / 8),
+		}
+
+		// restart marker header
+		// the whole image is sent as a single chunk (restart count = 0x3FFF)
+		if h.restartInterval != 0 {
+			payload = append(payload,
+				byte(h.restartInterval>>8), byte(h.restartInterval),
+				0xFF, 0xFF)
+		}
+
+		// quantization table header
+		if offset == 0 {
+			payload = append(payload, 0, 0, 0, 128)
+			payload = append(payload, h.quantizationTables[0]...)
+			payload = append(payload, h.quantizationTables[1]...)
+		}
+
+		pl := rtpPayloadMaxSize - len(payload)
+		if pl > len(data) {
+			pl = len(data)
+		}
+
+		payload = append(payload, data[:pl]...)
+		data = data[pl:]
+		offset += pl
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      encPTS,
+				SSRC:           e.ssrc,
+				Marker:         len(data) == 0,
+			},
+			Payload: payload,
+		})
+
+		e.sequenceNumber++
+	}
+
+	return ret, nil
+}
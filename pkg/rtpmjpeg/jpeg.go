@@ -0,0 +1,210 @@
+package rtpmjpeg
+
+// JPEG markers.
+const (
+	markerSOF0 = 0xC0
+	markerDHT  = 0xC4
+	markerSOI  = 0xD8
+	markerEOI  = 0xD9
+	markerSOS  = 0xDA
+	markerDQT  = 0xDB
+	markerDRI  = 0xDD
+)
+
+// default quantization tables, in zig-zag order.
+// ref: RFC2435, Appendix A
+var (
+	defaultLumaQuantizer = [64]byte{
+		16, 11, 12, 14, 12, 10, 16, 14,
+		13, 14, 18, 17, 16, 19, 24, 40,
+		26, 24, 22, 22, 24, 49, 35, 37,
+		29, 40, 58, 51, 61, 60, 57, 51,
+		56, 55, 64, 72, 92, 78, 64, 68,
+		87, 69, 55, 56, 80, 109, 81, 87,
+		95, 98, 103, 104, 103, 62, 77, 113,
+		121, 112, 100, 120, 92, 101, 103, 99,
+	}
+
+	defaultChromaQuantizer = [64]byte{
+		17, 18, 18, 24, 21, 24, 47, 26,
+		26, 47, 99, 66, 56, 66, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	}
+)
+
+// default Huffman tables.
+// ref: RFC2435, Appendix B
+var (
+	lumDCCodeLens = []byte{0, 1, 5, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0}
+	lumDCSymbols  = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	lumACCodeLens = []byte{0, 2, 1, 3, 3, 2, 4, 3, 5, 5, 4, 4, 0, 0, 1, 0x7d}
+	lumACSymbols  = []byte{
+		0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12,
+		0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+		0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08,
+		0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+		0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16,
+		0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+		0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
+		0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+		0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59,
+		0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+		0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79,
+		0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+		0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98,
+		0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+		0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6,
+		0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+		0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4,
+		0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+		0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea,
+		0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+		0xf9, 0xfa,
+	}
+
+	chmDCCodeLens = []byte{0, 3, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0}
+	chmDCSymbols  = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	chmACCodeLens = []byte{0, 2, 1, 2, 4, 4, 3, 4, 7, 5, 4, 4, 0, 1, 2, 0x77}
+	chmACSymbols  = []byte{
+		0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21,
+		0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+		0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91,
+		0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+		0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34,
+		0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+		0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38,
+		0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+		0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58,
+		0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+		0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78,
+		0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+		0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96,
+		0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+		0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4,
+		0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+		0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2,
+		0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+		0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9,
+		0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+		0xf9, 0xfa,
+	}
+)
+
+// makeQuantizationTables computes the quantization tables that correspond
+// to a Q factor between 1 and 99.
+// ref: RFC2435, Appendix A
+func makeQuantizationTables(q uint8) [][]byte {
+	factor := int(q)
+	if factor < 1 {
+		factor = 1
+	} else if factor > 99 {
+		factor = 99
+	}
+
+	if factor < 50 {
+		factor = 5000 / factor
+	} else {
+		factor = 200 - factor*2
+	}
+
+	scale := func(in [64]byte) []byte {
+		out := make([]byte, 64)
+		for i, v := range in {
+			lq := (int(v)*factor + 50) / 100
+			if lq < 1 {
+				lq = 1
+			} else if lq > 255 {
+				lq = 255
+			}
+			out[i] = byte(lq)
+		}
+		return out
+	}
+
+	return [][]byte{
+		scale(defaultLumaQuantizer),
+		scale(defaultChromaQuantizer),
+	}
+}
+
+// frameHeader contains the parameters that are needed to rebuild
+// the headers of a JPEG image.
+type frameHeader struct {
+	typ                uint8
+	width              int
+	height             int
+	restartInterval    uint16
+	quantizationTables [][]byte
+}
+
+func writeHuffmanTable(buf []byte, class uint8, id uint8, codeLens []byte, symbols []byte) []byte {
+	le := 2 + 1 + len(codeLens) + len(symbols)
+	buf = append(buf, 0xFF, markerDHT, byte(le>>8), byte(le))
+	buf = append(buf, (class<<4)|id)
+	buf = append(buf, codeLens...)
+	buf = append(buf, symbols...)
+	return buf
+}
+
+// marshal writes the headers of a baseline JPEG image, up to the start of scan.
+// ref: RFC2435, Appendix B
+func (h frameHeader) marshal() []byte {
+	var buf []byte
+
+	buf = append(buf, 0xFF, markerSOI)
+
+	// quantization tables
+	le := 2 + len(h.quantizationTables)*(1+64)
+	buf = append(buf, 0xFF, markerDQT, byte(le>>8), byte(le))
+	for i, table := range h.quantizationTables {
+		buf = append(buf, byte(i))
+		buf = append(buf, table...)
+	}
+
+	// start of frame
+	lumaSampling := byte(0x21) // 4:2:2
+	if (h.typ & 0x3F) == 1 {
+		lumaSampling = 0x22 // 4:2:0
+	}
+	chromaTable := byte(1)
+	if len(h.quantizationTables) == 1 {
+		chromaTable = 0
+	}
+	buf = append(buf, 0xFF, markerSOF0, 0x00, 17,
+		8, // precision
+		byte(h.height>>8), byte(h.height),
+		byte(h.width>>8), byte(h.width),
+		3, // components
+		1, lumaSampling, 0,
+		2, 0x11, chromaTable,
+		3, 0x11, chromaTable)
+
+	// restart interval
+	if h.restartInterval != 0 {
+		buf = append(buf, 0xFF, markerDRI, 0x00, 4,
+			byte(h.restartInterval>>8), byte(h.restartInterval))
+	}
+
+	// Huffman tables
+	buf = writeHuffmanTable(buf, 0, 0, lumDCCodeLens, lumDCSymbols)
+	buf = writeHuffmanTable(buf, 1, 0, lumACCodeLens, lumACSymbols)
+	buf = writeHuffmanTable(buf, 0, 1, chmDCCodeLens, chmDCSymbols)
+	buf = writeHuffmanTable(buf, 1, 1, chmACCodeLens, chmACSymbols)
+
+	// start of scan
+	buf = append(buf, 0xFF, markerSOS, 0x00, 12,
+		3, // components
+		1, 0x00,
+		2, 0x11,
+		3, 0x11,
+		0, 63, 0)
+
+	return buf
+}
@@ -0,0 +1,499 @@
+package rtpmjpeg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var testQuantizationTables = makeQuantizationTables(75)
+
+func testImage(typ uint8, restartInterval uint16, data []byte) []byte {
+	return mergeBytes(
+		frameHeader{
+			typ:                typ,
+			width:              64,
+			height:             48,
+			restartInterval:    restartInterval,
+			quantizationTables: testQuantizationTables,
+		}.marshal(),
+		data,
+		[]byte{0xFF, markerEOI},
+	)
+}
+
+var fragmentedData = bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 256)
+
+var cases = []struct {
+	name  string
+	image []byte
+	pts   time.Duration
+	enc   [][]byte
+}{
+	{
+		"single",
+		testImage(1, 0, []byte{0xaa, 0xbb, 0xcc}),
+		25 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6f, 0x1f,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x01, 0xff, 0x08, 0x06, 0x00, 0x00, 0x00, 0x80,
+				},
+				testQuantizationTables[0],
+				testQuantizationTables[1],
+				[]byte{0xaa, 0xbb, 0xcc},
+			),
+		},
+	},
+	{
+		"restart markers",
+		testImage(64, 4, []byte{0xaa, 0xbb, 0xcc}),
+		25 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6f, 0x1f,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x40, 0xff, 0x08, 0x06, 0x00, 0x04, 0xff, 0xff,
+					0x00, 0x00, 0x00, 0x80,
+				},
+				testQuantizationTables[0],
+				testQuantizationTables[1],
+				[]byte{0xaa, 0xbb, 0xcc},
+			),
+		},
+	},
+	{
+		"fragmented",
+		testImage(0, 0, fragmentedData),
+		55 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x1a, 0x44, 0xed, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x00, 0xff, 0x08, 0x06, 0x00, 0x00, 0x00, 0x80,
+				},
+				testQuantizationTables[0],
+				testQuantizationTables[1],
+				fragmentedData[:1320],
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0x9a, 0x44, 0xee, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x05, 0x28,
+					0x00, 0xff, 0x08, 0x06,
+				},
+				fragmentedData[1320:],
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0x9a, 0x44, 0xec, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x32, 0x08, 0x06, 0xaa,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var image []byte
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				var pts time.Duration
+				image, pts, err = d.Decode(&pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+			}
+
+			require.Equal(t, ca.image, image)
+		})
+	}
+}
+
+func TestDecodeQFactor(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x4b, 0x08, 0x06, 0xaa, 0xbb, 0xcc,
+	})
+	require.NoError(t, err)
+	image, _, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, testImage(1, 0, []byte{0xaa, 0xbb, 0xcc}), image)
+}
+
+func TestDecodeCachedQuantizationTables(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(mergeBytes(
+		[]byte{
+			0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+			0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+			0x01, 0x80, 0x08, 0x06, 0x00, 0x00, 0x00, 0x80,
+		},
+		testQuantizationTables[0],
+		testQuantizationTables[1],
+		[]byte{0xaa, 0xbb, 0xcc},
+	))
+	require.NoError(t, err)
+	image, _, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, testImage(1, 0, []byte{0xaa, 0xbb, 0xcc}), image)
+
+	// the second image doesn't contain tables
+	err = pkt.Unmarshal([]byte{
+		0x80, 0x9a, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x80, 0x08, 0x06, 0x00, 0x00, 0x00, 0x00,
+		0xdd, 0xee, 0xff,
+	})
+	require.NoError(t, err)
+	image, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, testImage(1, 0, []byte{0xdd, 0xee, 0xff}), image)
+
+	// tables with Q factor 255 are not cached
+	err = pkt.Unmarshal(mergeBytes(
+		[]byte{
+			0x80, 0x9a, 0x44, 0xef, 0x88, 0x77, 0x66, 0x55,
+			0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+			0x01, 0xff, 0x08, 0x06, 0x00, 0x00, 0x00, 0x80,
+		},
+		testQuantizationTables[0],
+		testQuantizationTables[1],
+		[]byte{0xaa, 0xbb, 0xcc},
+	))
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+
+	_, ok := d.quantizationTables[255]
+	require.Equal(t, false, ok)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0x9a, 0x44, 0xf0, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0xff, 0x08, 0x06, 0x00, 0x00, 0x00, 0x00,
+		0xdd, 0xee, 0xff,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.EqualError(t, err, "quantization tables are mandatory with Q factor 255")
+}
+
+func TestDecodePartOfFragmentedBeforeSingle(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x10,
+		0x01, 0x32, 0x08, 0x06, 0xaa,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0x9a, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x32, 0x08, 0x06, 0xaa,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkts [][]byte
+		err  string
+	}{
+		{
+			"missing payload",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00,
+			}},
+			"payload is too short",
+		},
+		{
+			"unsupported type",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x32, 0x08, 0x06,
+			}},
+			"unsupported type (2)",
+		},
+		{
+			"invalid size",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x32, 0x00, 0x06,
+			}},
+			"invalid size (0x48)",
+		},
+		{
+			"invalid Q factor",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x64, 0x08, 0x06,
+			}},
+			"invalid Q factor (100)",
+		},
+		{
+			"16-bit quantization tables",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0xff, 0x08, 0x06, 0x00, 0x01, 0x01, 0x00,
+			}},
+			"16-bit quantization tables are not supported",
+		},
+		{
+			"invalid quantization table length",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0xff, 0x08, 0x06, 0x00, 0x00, 0x00, 0x20,
+			}},
+			"invalid quantization table length (32)",
+		},
+		{
+			"missing quantization tables",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x80, 0x08, 0x06, 0x00, 0x00, 0x00, 0x00,
+			}},
+			"quantization tables not received yet (Q factor 128)",
+		},
+		{
+			"missing quantization tables with Q factor 255",
+			[][]byte{{
+				0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0xff, 0x08, 0x06, 0x00, 0x00, 0x00, 0x00,
+			}},
+			"quantization tables are mandatory with Q factor 255",
+		},
+		{
+			"missing packet",
+			[][]byte{
+				{
+					0x80, 0x1a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x01, 0x32, 0x08, 0x06, 0xaa,
+				},
+				{
+					0x80, 0x9a, 0x44, 0xef, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x01,
+					0x01, 0x32, 0x08, 0x06, 0xbb,
+				},
+			},
+			"discarding image since a RTP packet is missing",
+		},
+		{
+			"different timestamp",
+			[][]byte{
+				{
+					0x80, 0x1a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x01, 0x32, 0x08, 0x06, 0xaa,
+				},
+				{
+					0x80, 0x9a, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x16,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x01,
+					0x01, 0x32, 0x08, 0x06, 0xbb,
+				},
+			},
+			"received a packet with a different timestamp",
+		},
+		{
+			"invalid offset",
+			[][]byte{
+				{
+					0x80, 0x1a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x01, 0x32, 0x08, 0x06, 0xaa,
+				},
+				{
+					0x80, 0x9a, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x05,
+					0x01, 0x32, 0x08, 0x06, 0xbb,
+				},
+			},
+			"received a fragment with an invalid offset (5)",
+		},
+		{
+			"non-starting packet",
+			[][]byte{
+				{
+					0x80, 0x9a, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x00,
+					0x01, 0x32, 0x08, 0x06, 0xaa,
+				},
+				{
+					0x80, 0x9a, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x00, 0x00, 0x01,
+					0x01, 0x32, 0x08, 0x06, 0xbb,
+				},
+			},
+			"received a non-starting packet without previous starting packet",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			var lastErr error
+			for _, byts := range ca.pkts {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+				_, _, lastErr = d.Decode(&pkt)
+			}
+			require.EqualError(t, lastErr, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(26, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.image, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(26, nil, nil, nil)
+
+	_, err := e.Encode([]byte{0x01, 0x02}, 0)
+	require.EqualError(t, err, "SOI not found")
+
+	_, err = e.Encode([]byte{
+		0xFF, markerSOI,
+		0xFF, 0xC2, 0x00, 0x02,
+	}, 0)
+	require.EqualError(t, err, "only baseline JPEG images are supported")
+
+	_, err = e.Encode([]byte{
+		0xFF, markerSOI,
+		0xFF, markerSOS, 0x00, 0x02,
+	}, 0)
+	require.EqualError(t, err, "SOF not found")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(26, nil, nil, nil)
+}
+
+func TestEncodeDecodeStandardImage(t *testing.T) {
+	img := image.NewYCbCr(image.Rect(0, 0, 320, 240), image.YCbCrSubsampleRatio420)
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			img.Y[img.YOffset(x, y)] = uint8((x*7 + y*13) % 256)
+			img.Cb[img.COffset(x, y)] = uint8((x * 3) % 256)
+			img.Cr[img.COffset(x, y)] = uint8((y * 5) % 256)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80})
+	require.NoError(t, err)
+
+	e := NewEncoder(26, nil, nil, nil)
+	pkts, err := e.Encode(buf.Bytes(), 0)
+	require.NoError(t, err)
+	require.Greater(t, len(pkts), 1)
+
+	d := NewDecoder()
+	var out []byte
+	for _, pkt := range pkts {
+		out, _, err = d.Decode(pkt)
+		if err == ErrMorePacketsNeeded {
+			continue
+		}
+		require.NoError(t, err)
+	}
+
+	expected, err := jpeg.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	decoded, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	require.Equal(t, expected.Bounds(), decoded.Bounds())
+
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			require.Equal(t,
+				color.YCbCrModel.Convert(expected.At(x, y)),
+				color.YCbCrModel.Convert(decoded.At(x, y)))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package rtpmpeg4video
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/MPEG-4 Video decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	sequenceNumberReceived bool
+	lastSequenceNumber     uint16
+	buffer                 []byte
+	bufferTs               uint32
+	discarding             bool
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes a frame from RTP/MPEG-4 Video packets.
+// A frame (a VOP, eventually preceded by configuration headers) is split
+// into packets with the same timestamp, and the last packet of a frame
+// has the marker bit set.
+// It returns the frame and its PTS. When a packet is lost, the frame
+// is discarded and ErrMorePacketsNeeded is returned until the next frame.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	// ref: RFC 6416, 5
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	if d.sequenceNumberReceived && pkt.SequenceNumber != (d.lastSequenceNumber+1) {
+		d.buffer = nil
+		d.discarding = true
+	}
+	d.sequenceNumberReceived = true
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if d.discarding {
+		if pkt.Marker {
+			d.discarding = false
+		}
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	// the previous frame was not terminated
+	if d.buffer != nil && pkt.Timestamp != d.bufferTs {
+		d.buffer = nil
+	}
+
+	if (len(d.buffer) + len(pkt.Payload)) > frameMaxSize {
+		d.buffer = nil
+		d.discarding = !pkt.Marker
+		return nil, 0, fmt.Errorf("frame size exceeds maximum (%d)", frameMaxSize)
+	}
+
+	d.buffer = append(d.buffer, pkt.Payload...)
+	d.bufferTs = pkt.Timestamp
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	frame := d.buffer
+	d.buffer = nil
+
+	if len(frame) == 0 {
+		return nil, 0, fmt.Errorf("frame is empty")
+	}
+
+	return frame, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,11 @@
+// Package rtpmpeg4video contains a RTP/MPEG-4 Video (MP4V-ES) decoder and encoder.
+package rtpmpeg4video
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // MPEG-4 Video always uses 90khz
+
+	// maximum size of a frame.
+	frameMaxSize = 8 * 1024 * 1024
+)
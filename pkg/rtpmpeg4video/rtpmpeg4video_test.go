@@ -0,0 +1,173 @@
+package rtpmpeg4video
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+// VOP start code, I-VOP
+var testFrame = []byte{0x00, 0x00, 0x01, 0xb6, 0x10, 0x60, 0x91, 0x82, 0x3d, 0xb7, 0xef}
+
+var cases = []struct {
+	name  string
+	frame []byte
+	pts   time.Duration
+	enc   []*rtp.Packet
+}{
+	{
+		"single",
+		testFrame,
+		25 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					Timestamp:      2289528607,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: testFrame,
+			},
+		},
+	},
+	{
+		"fragmented",
+		bytes.Repeat([]byte{0x41}, 3000),
+		55 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x41}, 1460),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x41}, 1460),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17647,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x41}, 80),
+			},
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			_, _, err := d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17644,
+					Timestamp:      2289526357,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: testFrame,
+			})
+			require.NoError(t, err)
+
+			for i, pkt := range ca.enc {
+				frame, pts, err := d.Decode(pkt)
+
+				if i != len(ca.enc)-1 {
+					require.Equal(t, ErrMorePacketsNeeded, err)
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+				require.Equal(t, ca.frame, frame)
+			}
+		})
+	}
+}
+
+func TestDecodePacketLoss(t *testing.T) {
+	d := NewDecoder()
+
+	pkt := func(seq uint16, ts uint32, marker bool, payload string) *rtp.Packet {
+		return &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         marker,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      ts,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte(payload),
+		}
+	}
+
+	_, _, err := d.Decode(pkt(100, 0, false, "a1"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	// the last packet of the first frame is lost
+	_, _, err = d.Decode(pkt(102, 9000, false, "b1"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	_, _, err = d.Decode(pkt(103, 9000, true, "b2"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	frame, pts, err := d.Decode(pkt(104, 18000, true, "c"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("c"), frame)
+	require.Equal(t, 200*time.Millisecond, pts)
+
+	// the marker of a frame is missing
+	_, _, err = d.Decode(pkt(105, 27000, false, "d1"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	frame, _, err = d.Decode(pkt(106, 36000, true, "e"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("e"), frame)
+
+	_, _, err = d.Decode(pkt(107, 45000, true, ""))
+	require.EqualError(t, err, "frame is empty")
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.frame, ca.pts)
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, enc)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package rtpmpegaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/mpegaudio"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/MPEG-1/2 audio decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	sequenceNumberReceived bool
+	lastSequenceNumber     uint16
+	fragments              []byte
+	fragmentsExpected      int
+	fragmentsTs            uint32
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes MPEG-1/2 audio frames from a RTP/MPEG-1/2 audio packet.
+// It returns the frames and the PTS of the first frame.
+// Frames that are split between RTP packets are reassembled; when a RTP
+// packet is missing, the fragmented frame is discarded.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	// ref: RFC 2250, 3.5
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	lost := d.sequenceNumberReceived && pkt.SequenceNumber != (d.lastSequenceNumber+1)
+	d.sequenceNumberReceived = true
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if len(pkt.Payload) < 4 {
+		d.fragments = nil
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	fragOffset := int(binary.BigEndian.Uint16(pkt.Payload[2:]))
+	payload := pkt.Payload[4:]
+
+	if fragOffset != 0 {
+		if d.fragments == nil || lost || pkt.Timestamp != d.fragmentsTs ||
+			fragOffset != len(d.fragments) {
+			d.fragments = nil
+			return nil, 0, ErrMorePacketsNeeded
+		}
+
+		d.fragments = append(d.fragments, payload...)
+
+		if len(d.fragments) < d.fragmentsExpected {
+			return nil, 0, ErrMorePacketsNeeded
+		}
+
+		frame := d.fragments
+		d.fragments = nil
+
+		if len(frame) != d.fragmentsExpected {
+			return nil, 0, fmt.Errorf("invalid fragmented frame size (%d instead of %d)",
+				len(frame), d.fragmentsExpected)
+		}
+
+		return [][]byte{frame}, d.decodeTimestamp(pkt.Timestamp), nil
+	}
+
+	d.fragments = nil
+
+	var frames [][]byte
+
+	for len(payload) > 0 {
+		var h mpegaudio.FrameHeader
+		err := h.Unmarshal(payload)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		l := h.FrameLen()
+
+		if l > len(payload) {
+			// the frame is fragmented
+			if frames == nil {
+				d.fragments = append([]byte(nil), payload...)
+				d.fragmentsExpected = l
+				d.fragmentsTs = pkt.Timestamp
+				return nil, 0, ErrMorePacketsNeeded
+			}
+
+			return nil, 0, fmt.Errorf("frame is incomplete")
+		}
+
+		frames = append(frames, payload[:l])
+		payload = payload[l:]
+	}
+
+	if frames == nil {
+		return nil, 0, fmt.Errorf("there are no frames")
+	}
+
+	return frames, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,138 @@
+package rtpmpegaudio
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/mpegaudio"
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/MPEG-1/2 audio encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+// The payload type is usually 14.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*rtpClockRate)
+}
+
+func (e *Encoder) writePacket(ret []*rtp.Packet, pts time.Duration,
+	fragOffset int, payload []byte) []*rtp.Packet {
+	buf := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint16(buf[2:], uint16(fragOffset))
+	copy(buf[4:], payload)
+
+	ret = append(ret, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      e.encodeTimestamp(pts),
+			SSRC:           e.ssrc,
+		},
+		Payload: buf,
+	})
+
+	e.sequenceNumber++
+	return ret
+}
+
+// Encode encodes MPEG-1/2 audio frames into RTP/MPEG-1/2 audio packets.
+// pts is the PTS of the first frame.
+func (e *Encoder) Encode(frames [][]byte, pts time.Duration) ([]*rtp.Packet, error) {
+	// ref: RFC 2250, 3.5
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("there are no frames")
+	}
+
+	var ret []*rtp.Packet
+	var payload []byte
+	payloadPTS := pts
+
+	for _, frame := range frames {
+		var h mpegaudio.FrameHeader
+		err := h.Unmarshal(frame)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(frame) != h.FrameLen() {
+			return nil, fmt.Errorf("invalid frame size (%d instead of %d)", len(frame), h.FrameLen())
+		}
+
+		maxSize := rtpPayloadMaxSize - 4
+
+		if payload != nil && (len(payload)+len(frame)) > maxSize {
+			ret = e.writePacket(ret, payloadPTS, 0, payload)
+			payload = nil
+		}
+
+		if payload == nil {
+			payloadPTS = pts
+		}
+
+		if len(frame) > maxSize {
+			// fragment the frame
+			for off := 0; off < len(frame); off += maxSize {
+				end := off + maxSize
+				if end > len(frame) {
+					end = len(frame)
+				}
+				ret = e.writePacket(ret, pts, off, frame[off:end])
+			}
+		} else {
+			payload = append(payload, frame...)
+		}
+
+		pts += time.Duration(h.SampleCount()) * time.Second / time.Duration(h.SampleRate)
+	}
+
+	if payload != nil {
+		ret = e.writePacket(ret, payloadPTS, 0, payload)
+	}
+
+	return ret, nil
+}
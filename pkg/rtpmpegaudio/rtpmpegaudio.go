@@ -0,0 +1,8 @@
+// Package rtpmpegaudio contains a RTP/MPEG-1/2 audio decoder and encoder.
+package rtpmpegaudio
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // MPEG-1/2 audio always uses 90khz
+)
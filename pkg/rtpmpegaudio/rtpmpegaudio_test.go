@@ -0,0 +1,229 @@
+package rtpmpegaudio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+// MPEG-1 layer 3, 128 kbit/s, 44100 Hz, 417 bytes, 1152 samples
+func testFrame(v byte) []byte {
+	return mergeBytes([]byte{0xff, 0xfb, 0x90, 0x64}, bytes.Repeat([]byte{v}, 413))
+}
+
+// MPEG-1 layer 2, 384 kbit/s, 32000 Hz, 1728 bytes
+var testBigFrame = mergeBytes([]byte{0xff, 0xfd, 0xe8, 0x04}, bytes.Repeat([]byte{0x05}, 1724))
+
+var cases = []struct {
+	name   string
+	frames [][]byte
+	pts    time.Duration
+	enc    []*rtp.Packet
+}{
+	{
+		"single",
+		[][]byte{testFrame(1)},
+		25 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    14,
+					SequenceNumber: 17645,
+					Timestamp:      2289528607,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes([]byte{0x00, 0x00, 0x00, 0x00}, testFrame(1)),
+			},
+		},
+	},
+	{
+		"aggregated",
+		[][]byte{testFrame(1), testFrame(2), testFrame(3), testFrame(4)},
+		25 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    14,
+					SequenceNumber: 17645,
+					Timestamp:      2289528607,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes([]byte{0x00, 0x00, 0x00, 0x00},
+					testFrame(1), testFrame(2), testFrame(3)),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    14,
+					SequenceNumber: 17646,
+					Timestamp:      2289528607 + 3*1152*90000/44100,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes([]byte{0x00, 0x00, 0x00, 0x00}, testFrame(4)),
+			},
+		},
+	},
+	{
+		"fragmented",
+		[][]byte{testBigFrame},
+		55 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    14,
+					SequenceNumber: 17645,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes([]byte{0x00, 0x00, 0x00, 0x00}, testBigFrame[:1456]),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    14,
+					SequenceNumber: 17646,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes([]byte{0x00, 0x00, 0x05, 0xb0}, testBigFrame[1456:]),
+			},
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			_, _, err := d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    14,
+					SequenceNumber: 17644,
+					Timestamp:      2289526357,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes([]byte{0x00, 0x00, 0x00, 0x00}, testFrame(0)),
+			})
+			require.NoError(t, err)
+
+			var frames [][]byte
+
+			for i, pkt := range ca.enc {
+				addFrames, pts, err := d.Decode(pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+				if i == 0 {
+					require.Equal(t, ca.pts, pts)
+				}
+				frames = append(frames, addFrames...)
+			}
+
+			require.Equal(t, ca.frames, frames)
+		})
+	}
+}
+
+func TestDecodePacketLoss(t *testing.T) {
+	d := NewDecoder()
+
+	enc := cases[2].enc
+
+	_, _, err := d.Decode(enc[0])
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	// the second fragment is received after a lost packet
+	pkt := *enc[1]
+	pkt.SequenceNumber++
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	frames, _, err := d.Decode(cases[0].enc[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{testFrame(1)}, frames)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		payload []byte
+		err     string
+	}{
+		{
+			"too short",
+			[]byte{0x00, 0x00},
+			"payload is too short",
+		},
+		{
+			"no frames",
+			[]byte{0x00, 0x00, 0x00, 0x00},
+			"there are no frames",
+		},
+		{
+			"invalid frame",
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04},
+			"invalid sync word",
+		},
+		{
+			"incomplete frame",
+			mergeBytes([]byte{0x00, 0x00, 0x00, 0x00}, testFrame(1), testFrame(2)[:100]),
+			"frame is incomplete",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			_, _, err := d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:     2,
+					PayloadType: 14,
+				},
+				Payload: ca.payload,
+			})
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(14, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.frames, ca.pts)
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, enc)
+		})
+	}
+}
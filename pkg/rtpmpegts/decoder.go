@@ -0,0 +1,84 @@
+package rtpmpegts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/mpegts"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+const syncByte = 0x47
+
+// Decoder is a RTP/MPEG-TS decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	sequenceNumberReceived bool
+	lastSequenceNumber     uint16
+	buffer                 []byte
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes MPEG-TS packets from a RTP/MPEG-TS packet.
+// It returns 188-byte MPEG-TS packets, aligned to the sync byte.
+// TS packets that are split between RTP packets are reassembled; when a RTP
+// packet is missing, the partial TS packet is discarded and the stream is resynchronized.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, time.Duration, error) {
+	if len(pkt.Payload) == 0 {
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	if d.sequenceNumberReceived && pkt.SequenceNumber != (d.lastSequenceNumber+1) {
+		d.buffer = nil
+	}
+	d.sequenceNumberReceived = true
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	buf := append(d.buffer, pkt.Payload...)
+	var ret [][]byte
+
+	for len(buf) >= mpegts.PacketSize {
+		if buf[0] != syncByte {
+			// resynchronize
+			i := bytes.IndexByte(buf[1:], syncByte)
+			if i < 0 {
+				buf = nil
+				break
+			}
+			buf = buf[1+i:]
+			continue
+		}
+
+		ret = append(ret, append([]byte(nil), buf[:mpegts.PacketSize]...))
+		buf = buf[mpegts.PacketSize:]
+	}
+
+	d.buffer = append([]byte(nil), buf...)
+
+	if len(ret) == 0 {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	return ret, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,109 @@
+package rtpmpegts
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/mpegts"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // mpeg-ts always uses 90khz
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/MPEG-TS encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*rtpClockRate)
+}
+
+// Encode encodes MPEG-TS packets into RTP/MPEG-TS packets.
+// Each RTP packet contains an integral number of MPEG-TS packets.
+func (e *Encoder) Encode(tsPackets [][]byte, pts time.Duration) ([]*rtp.Packet, error) {
+	if len(tsPackets) == 0 {
+		return nil, fmt.Errorf("there are no MPEG-TS packets")
+	}
+
+	for _, tsPacket := range tsPackets {
+		if len(tsPacket) != mpegts.PacketSize || tsPacket[0] != syncByte {
+			return nil, fmt.Errorf("invalid MPEG-TS packet")
+		}
+	}
+
+	perPacket := rtpPayloadMaxSize / mpegts.PacketSize
+	var ret []*rtp.Packet
+	encPTS := e.encodeTimestamp(pts)
+
+	for len(tsPackets) > 0 {
+		n := perPacket
+		if n > len(tsPackets) {
+			n = len(tsPackets)
+		}
+
+		payload := make([]byte, 0, n*mpegts.PacketSize)
+		for _, tsPacket := range tsPackets[:n] {
+			payload = append(payload, tsPacket...)
+		}
+		tsPackets = tsPackets[n:]
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      encPTS,
+				SSRC:           e.ssrc,
+			},
+			Payload: payload,
+		})
+
+		e.sequenceNumber++
+	}
+
+	return ret, nil
+}
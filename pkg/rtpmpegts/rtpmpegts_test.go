@@ -0,0 +1,218 @@
+package rtpmpegts
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+func tsPacket(v byte) []byte {
+	return mergeBytes([]byte{0x47}, bytes.Repeat([]byte{v}, 187))
+}
+
+var cases = []struct {
+	name      string
+	tsPackets [][]byte
+	pts       time.Duration
+	enc       [][]byte
+}{
+	{
+		"single",
+		[][]byte{tsPacket(1)},
+		25 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x21, 0x44, 0xed, 0x88, 0x77, 0x6f, 0x1f,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				tsPacket(1),
+			),
+		},
+	},
+	{
+		"multiple",
+		[][]byte{
+			tsPacket(1), tsPacket(2), tsPacket(3), tsPacket(4), tsPacket(5),
+			tsPacket(6), tsPacket(7), tsPacket(8), tsPacket(9),
+		},
+		55 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x21, 0x44, 0xed, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				tsPacket(1), tsPacket(2), tsPacket(3), tsPacket(4), tsPacket(5),
+				tsPacket(6), tsPacket(7),
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0x21, 0x44, 0xee, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				tsPacket(8), tsPacket(9),
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal(mergeBytes(
+				[]byte{
+					0x80, 0x21, 0x44, 0xec, 0x88, 0x77, 0x66, 0x55,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				tsPacket(0),
+			))
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var tsPackets [][]byte
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				addPackets, pts, err := d.Decode(&pkt)
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+				tsPackets = append(tsPackets, addPackets...)
+			}
+
+			require.Equal(t, ca.tsPackets, tsPackets)
+		})
+	}
+}
+
+func TestDecodeUnaligned(t *testing.T) {
+	d := NewDecoder()
+
+	// garbage before the first sync byte, TS packet split between RTP packets
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(mergeBytes(
+		[]byte{
+			0x80, 0x21, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+			0x9d, 0xbb, 0x78, 0x12, 0x01, 0x02,
+		},
+		tsPacket(1),
+		tsPacket(2)[:100],
+	))
+	require.NoError(t, err)
+	tsPackets, _, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{tsPacket(1)}, tsPackets)
+
+	err = pkt.Unmarshal(mergeBytes(
+		[]byte{
+			0x80, 0x21, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+			0x9d, 0xbb, 0x78, 0x12,
+		},
+		tsPacket(2)[100:],
+		tsPacket(3)[:50],
+	))
+	require.NoError(t, err)
+	tsPackets, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{tsPacket(2)}, tsPackets)
+
+	// a RTP packet is missing: the partial TS packet is discarded
+	err = pkt.Unmarshal(mergeBytes(
+		[]byte{
+			0x80, 0x21, 0x44, 0xf0, 0x88, 0x77, 0x66, 0x55,
+			0x9d, 0xbb, 0x78, 0x12,
+		},
+		tsPacket(4)[100:],
+		tsPacket(5),
+	))
+	require.NoError(t, err)
+	tsPackets, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{tsPacket(5)}, tsPackets)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x21, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.EqualError(t, err, "payload is empty")
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0x21, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x47, 0x01,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(33, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.tsPackets, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(33, nil, nil, nil)
+
+	_, err := e.Encode(nil, 0)
+	require.EqualError(t, err, "there are no MPEG-TS packets")
+
+	_, err = e.Encode([][]byte{{0x47, 0x01}}, 0)
+	require.EqualError(t, err, "invalid MPEG-TS packet")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(33, nil, nil, nil)
+}
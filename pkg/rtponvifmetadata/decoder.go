@@ -0,0 +1,86 @@
+package rtponvifmetadata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/ONVIF metadata decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	sequenceNumberReceived bool
+	lastSequenceNumber     uint16
+	buffer                 []byte
+	bufferTs               uint32
+	discarding             bool
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes a XML metadata document from RTP/ONVIF metadata packets.
+// A document is split into packets with the same timestamp,
+// and the last packet of a document has the marker bit set.
+// It returns the document and its PTS. When a packet is lost, the document
+// is discarded and ErrMorePacketsNeeded is returned until the next document.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	if d.sequenceNumberReceived && pkt.SequenceNumber != (d.lastSequenceNumber+1) {
+		d.buffer = nil
+		d.discarding = true
+	}
+	d.sequenceNumberReceived = true
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if d.discarding {
+		if pkt.Marker {
+			d.discarding = false
+		}
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	// the previous document was not terminated
+	if d.buffer != nil && pkt.Timestamp != d.bufferTs {
+		d.buffer = nil
+	}
+
+	if (len(d.buffer) + len(pkt.Payload)) > documentMaxSize {
+		d.buffer = nil
+		d.discarding = !pkt.Marker
+		return nil, 0, fmt.Errorf("document size exceeds maximum (%d)", documentMaxSize)
+	}
+
+	d.buffer = append(d.buffer, pkt.Payload...)
+	d.bufferTs = pkt.Timestamp
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	doc := d.buffer
+	d.buffer = nil
+
+	if len(doc) == 0 {
+		return nil, 0, fmt.Errorf("document is empty")
+	}
+
+	return doc, d.decodeTimestamp(pkt.Timestamp), nil
+}
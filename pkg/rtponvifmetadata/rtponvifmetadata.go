@@ -0,0 +1,11 @@
+// Package rtponvifmetadata contains a RTP/ONVIF metadata decoder and encoder.
+package rtponvifmetadata
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // ONVIF metadata always uses 90khz
+
+	// maximum size of a metadata document.
+	documentMaxSize = 1 * 1024 * 1024
+)
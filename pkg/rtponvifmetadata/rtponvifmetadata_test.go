@@ -0,0 +1,175 @@
+package rtponvifmetadata
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+var testDocument = []byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+	`<tt:MetadataStream xmlns:tt="http://www.onvif.org/ver10/schema">` +
+	`<tt:VideoAnalytics><tt:Frame UtcTime="2021-07-12T10:11:12.345Z"/></tt:VideoAnalytics>` +
+	`</tt:MetadataStream>`)
+
+var cases = []struct {
+	name string
+	doc  []byte
+	pts  time.Duration
+	enc  []*rtp.Packet
+}{
+	{
+		"single",
+		testDocument,
+		25 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    107,
+					SequenceNumber: 17645,
+					Timestamp:      2289528607,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: testDocument,
+			},
+		},
+	},
+	{
+		"fragmented",
+		bytes.Repeat([]byte{0x41}, 3000),
+		55 * time.Millisecond,
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    107,
+					SequenceNumber: 17645,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x41}, 1460),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    107,
+					SequenceNumber: 17646,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x41}, 1460),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    107,
+					SequenceNumber: 17647,
+					Timestamp:      2289531307,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x41}, 80),
+			},
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			_, _, err := d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    107,
+					SequenceNumber: 17644,
+					Timestamp:      2289526357,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: testDocument,
+			})
+			require.NoError(t, err)
+
+			for i, pkt := range ca.enc {
+				doc, pts, err := d.Decode(pkt)
+
+				if i != len(ca.enc)-1 {
+					require.Equal(t, ErrMorePacketsNeeded, err)
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+				require.Equal(t, ca.doc, doc)
+			}
+		})
+	}
+}
+
+func TestDecodePacketLoss(t *testing.T) {
+	d := NewDecoder()
+
+	pkt := func(seq uint16, ts uint32, marker bool, payload string) *rtp.Packet {
+		return &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         marker,
+				PayloadType:    107,
+				SequenceNumber: seq,
+				Timestamp:      ts,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte(payload),
+		}
+	}
+
+	_, _, err := d.Decode(pkt(100, 0, false, "<a>"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	// the last packet of the first document is lost
+	_, _, err = d.Decode(pkt(102, 9000, false, "<b>"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	_, _, err = d.Decode(pkt(103, 9000, true, "</b>"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	doc, pts, err := d.Decode(pkt(104, 18000, true, "<c/>"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("<c/>"), doc)
+	require.Equal(t, 200*time.Millisecond, pts)
+
+	// the marker of a document is missing
+	_, _, err = d.Decode(pkt(105, 27000, false, "<d>"))
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	doc, _, err = d.Decode(pkt(106, 36000, true, "<e/>"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("<e/>"), doc)
+
+	_, _, err = d.Decode(pkt(107, 45000, true, ""))
+	require.EqualError(t, err, "document is empty")
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(107, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.doc, ca.pts)
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, enc)
+		})
+	}
+}
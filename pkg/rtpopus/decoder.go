@@ -0,0 +1,45 @@
+package rtpopus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/Opus decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / clockRate
+}
+
+// Decode decodes an Opus packet from a RTP/Opus packet.
+// It returns the Opus packet and its PTS.
+// The PTS is computed from the RTP timestamp, therefore gaps caused by
+// discontinuous transmission (DTX) are preserved.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	if len(pkt.Payload) == 0 {
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	_, err := PacketDuration(pkt.Payload)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	return pkt.Payload, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,108 @@
+package rtpopus
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/Opus encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+
+	nextTs    uint32
+	nextTsSet bool
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*clockRate)
+}
+
+// Encode encodes Opus packets into RTP/Opus packets.
+// Each Opus packet is put into a dedicated RTP packet, whose timestamp is
+// increased by the duration of the packets that precede it.
+// In case of discontinuous transmission (DTX), the PTS of the packets
+// that follow the gap must be passed to a new Encode() call; the marker bit
+// is then set on the first packet, as suggested by RFC7587.
+func (e *Encoder) Encode(packets [][]byte, firstPTS time.Duration) ([]*rtp.Packet, error) {
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("there are no packets")
+	}
+
+	ret := make([]*rtp.Packet, len(packets))
+	ts := e.encodeTimestamp(firstPTS)
+
+	for i, pkt := range packets {
+		if len(pkt) > rtpPayloadMaxSize {
+			return nil, fmt.Errorf("packet is too big (%d)", len(pkt))
+		}
+
+		d, err := PacketDuration(pkt)
+		if err != nil {
+			return nil, err
+		}
+
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      ts,
+				SSRC:           e.ssrc,
+				Marker:         !e.nextTsSet || ts != e.nextTs,
+			},
+			Payload: pkt,
+		}
+
+		e.sequenceNumber++
+		ts += uint32(d * clockRate / time.Second)
+		e.nextTs = ts
+		e.nextTsSet = true
+	}
+
+	return ret, nil
+}
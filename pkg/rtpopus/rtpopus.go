@@ -0,0 +1,69 @@
+// Package rtpopus contains a RTP/Opus decoder and encoder.
+package rtpopus
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// the RTP clock rate of Opus is always 48khz, regardless of the sample rate
+	// of the encoded audio.
+	clockRate = 48000
+
+	maxPacketDuration = 120 * time.Millisecond
+)
+
+// duration of a frame, indexed by the configuration number of the TOC byte.
+// ref: RFC6716, 3.1
+var frameDurations = [32]time.Duration{
+	// SILK-only
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	// Hybrid
+	10 * time.Millisecond, 20 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond,
+	// CELT-only
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+}
+
+// PacketDuration returns the duration of an Opus packet,
+// computed from the TOC byte and, if present, the frame count byte.
+func PacketDuration(pkt []byte) (time.Duration, error) {
+	// ref: RFC6716, 3.1 and 3.2
+	if len(pkt) == 0 {
+		return 0, fmt.Errorf("packet is empty")
+	}
+
+	frameDuration := frameDurations[pkt[0]>>3]
+
+	var frameCount int
+	switch pkt[0] & 0x03 {
+	case 0:
+		frameCount = 1
+
+	case 1, 2:
+		frameCount = 2
+
+	case 3:
+		if len(pkt) < 2 {
+			return 0, fmt.Errorf("frame count byte is missing")
+		}
+
+		frameCount = int(pkt[1] & 0x3F)
+		if frameCount == 0 {
+			return 0, fmt.Errorf("frame count is zero")
+		}
+	}
+
+	d := time.Duration(frameCount) * frameDuration
+	if d > maxPacketDuration {
+		return 0, fmt.Errorf("packet duration (%v) is greater than %v", d, maxPacketDuration)
+	}
+
+	return d, nil
+}
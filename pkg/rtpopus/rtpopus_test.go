@@ -0,0 +1,279 @@
+package rtpopus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+var cases = []struct {
+	name    string
+	packets [][]byte
+	pts     time.Duration
+	enc     [][]byte
+}{
+	{
+		"single",
+		[][]byte{
+			{0xfc, 0x01, 0x02},
+		},
+		0,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0xfc, 0x01, 0x02,
+			},
+		},
+	},
+	{
+		"multiple",
+		[][]byte{
+			{0xfc, 0x01},
+			{0xfd, 0x02, 0x03},
+			{0x0b, 0x03, 0x04},
+			{0xfc, 0x05},
+		},
+		20 * time.Millisecond,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0xfc, 0x01,
+			},
+			{
+				0x80, 0x60, 0x44, 0xee, 0x88, 0x77, 0x6d, 0xd5,
+				0x9d, 0xbb, 0x78, 0x12, 0xfd, 0x02, 0x03,
+			},
+			{
+				0x80, 0x60, 0x44, 0xef, 0x88, 0x77, 0x75, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x0b, 0x03, 0x04,
+			},
+			{
+				0x80, 0x60, 0x44, 0xf0, 0x88, 0x77, 0x80, 0x95,
+				0x9d, 0xbb, 0x78, 0x12, 0xfc, 0x05,
+			},
+		},
+	},
+}
+
+func TestPacketDuration(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkt  []byte
+		dur  time.Duration
+	}{
+		{
+			"silk 10ms",
+			[]byte{0x00},
+			10 * time.Millisecond,
+		},
+		{
+			"silk 60ms",
+			[]byte{0x18},
+			60 * time.Millisecond,
+		},
+		{
+			"hybrid 20ms, 2 frames",
+			[]byte{0x79},
+			40 * time.Millisecond,
+		},
+		{
+			"celt 2.5ms",
+			[]byte{0x80},
+			2500 * time.Microsecond,
+		},
+		{
+			"celt 20ms, 2 frames with different size",
+			[]byte{0xfe},
+			40 * time.Millisecond,
+		},
+		{
+			"celt 2.5ms, arbitrary number of frames",
+			[]byte{0x83, 0x05},
+			12500 * time.Microsecond,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			dur, err := PacketDuration(ca.pkt)
+			require.NoError(t, err)
+			require.Equal(t, ca.dur, dur)
+		})
+	}
+}
+
+func TestPacketDurationErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkt  []byte
+		err  string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"packet is empty",
+		},
+		{
+			"missing frame count",
+			[]byte{0x03},
+			"frame count byte is missing",
+		},
+		{
+			"zero frame count",
+			[]byte{0x03, 0x00},
+			"frame count is zero",
+		},
+		{
+			"too long",
+			[]byte{0x1b, 0x03},
+			"packet duration (180ms) is greater than 120ms",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := PacketDuration(ca.pkt)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0xfc,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var packets [][]byte
+			expPTS := ca.pts
+
+			for _, byts := range ca.enc {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				packet, pts, err := d.Decode(&pkt)
+				require.NoError(t, err)
+				require.Equal(t, expPTS, pts)
+				packets = append(packets, packet)
+
+				dur, err := PacketDuration(packet)
+				require.NoError(t, err)
+				expPTS += dur
+			}
+
+			require.Equal(t, ca.packets, packets)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkt  []byte
+		err  string
+	}{
+		{
+			"missing payload",
+			[]byte{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12,
+			},
+			"payload is empty",
+		},
+		{
+			"invalid packet",
+			[]byte{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x03,
+			},
+			"frame count byte is missing",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			var pkt rtp.Packet
+			err := pkt.Unmarshal(ca.pkt)
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.packets, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeDecodeDTX(t *testing.T) {
+	sequenceNumber := uint16(0x44ed)
+	ssrc := uint32(0x9dbb7812)
+	initialTs := uint32(0x88776655)
+	e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+	d := NewDecoder()
+
+	for _, ca := range []struct {
+		pts    time.Duration
+		marker bool
+	}{
+		{0, true},
+		{20 * time.Millisecond, false},
+		// gap caused by DTX
+		{400 * time.Millisecond, true},
+		{420 * time.Millisecond, false},
+	} {
+		pkts, err := e.Encode([][]byte{{0xfc, 0x01}}, ca.pts)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(pkts))
+		require.Equal(t, ca.marker, pkts[0].Marker)
+
+		_, pts, err := d.Decode(pkts[0])
+		require.NoError(t, err)
+		require.Equal(t, ca.pts, pts)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(96, nil, nil, nil)
+
+	_, err := e.Encode(nil, 0)
+	require.EqualError(t, err, "there are no packets")
+
+	_, err = e.Encode([][]byte{{0x03}}, 0)
+	require.EqualError(t, err, "frame count byte is missing")
+
+	_, err = e.Encode([][]byte{make([]byte, 1461)}, 0)
+	require.EqualError(t, err, "packet is too big (1461)")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(96, nil, nil, nil)
+}
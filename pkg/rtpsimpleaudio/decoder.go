@@ -0,0 +1,42 @@
+package rtpsimpleaudio
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/simple audio decoder.
+type Decoder struct {
+	clockRate time.Duration
+
+	initialTs    uint32
+	initialTsSet bool
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder(clockRate int) *Decoder {
+	return &Decoder{
+		clockRate: time.Duration(clockRate),
+	}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / d.clockRate
+}
+
+// Decode decodes an audio frame from a RTP packet.
+// It returns the frame and the PTS of its first byte.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	if len(pkt.Payload) == 0 {
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	if !d.initialTsSet {
+		d.initialTsSet = true
+		d.initialTs = pkt.Timestamp
+	}
+
+	return pkt.Payload, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,3 @@
+// Package rtpsimpleaudio contains a RTP decoder and encoder for audio codecs
+// that use one byte per clock tick and don't need any framing, like G711 and G722.
+package rtpsimpleaudio
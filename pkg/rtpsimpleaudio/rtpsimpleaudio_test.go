@@ -0,0 +1,152 @@
+package rtpsimpleaudio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var cases = []struct {
+	name  string
+	frame []byte
+	pts   time.Duration
+	enc   [][]byte
+}{
+	{
+		"single",
+		bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 40),
+		20 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x00, 0x44, 0xed, 0x88, 0x77, 0x66, 0xf5,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 40),
+			),
+		},
+	},
+	{
+		"splitted",
+		bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 400),
+		20 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x00, 0x44, 0xed, 0x88, 0x77, 0x66, 0xf5,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 365),
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0x00, 0x44, 0xee, 0x88, 0x77, 0x6c, 0xa9,
+					0x9d, 0xbb, 0x78, 0x12,
+				},
+				bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 35),
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(8000)
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0x00, 0x44, 0xec, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x01,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var frame []byte
+			expPTS := ca.pts
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				addFrame, pts, err := d.Decode(&pkt)
+				require.NoError(t, err)
+				require.Equal(t, expPTS, pts)
+				frame = append(frame, addFrame...)
+				expPTS += time.Duration(len(addFrame)) * time.Second / 8000
+			}
+
+			require.Equal(t, ca.frame, frame)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d := NewDecoder(8000)
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0x00, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.EqualError(t, err, "payload is empty")
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(0, 8000, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.frame, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	e := NewEncoder(0, 8000, nil, nil, nil)
+
+	_, err := e.Encode(nil, 0)
+	require.EqualError(t, err, "frame is empty")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(0, 8000, nil, nil, nil)
+}
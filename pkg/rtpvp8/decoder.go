@@ -0,0 +1,164 @@
+package rtpvp8
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we decoded a non-starting
+// packet of a fragmented frame and we didn't received anything before.
+// It's normal to receive this when we are decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"decoded a non-starting fragmented packet without any previous starting packet")
+
+// IsKeyFrame checks whether a VP8 frame is a key frame.
+func IsKeyFrame(frame []byte) bool {
+	// ref: https://tools.ietf.org/html/rfc6386#section-9.1
+	return len(frame) >= 1 && (frame[0]&0x01) == 0
+}
+
+// Decoder is a RTP/VP8 decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	startingPacketReceived bool
+	isDecodingFragmented   bool
+	fragmentedBuffer       []byte
+	fragmentedTs           uint32
+	lastSequenceNumber     uint16
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+// Decode decodes a VP8 frame from a RTP/VP8 packet.
+// When the frame is split into multiple packets, it returns ErrMorePacketsNeeded
+// until the last packet (the one with the marker flag) is received.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	// payload descriptor
+	// ref: https://tools.ietf.org/html/rfc7741#section-4.2
+	payload := pkt.Payload
+
+	if len(payload) < 1 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	x := (payload[0] >> 7) & 0x01
+	s := (payload[0] >> 4) & 0x01
+	pid := payload[0] & 0x07
+	payload = payload[1:]
+
+	if x == 1 {
+		if len(payload) < 1 {
+			d.isDecodingFragmented = false
+			return nil, 0, fmt.Errorf("payload is too short")
+		}
+
+		i := (payload[0] >> 7) & 0x01
+		l := (payload[0] >> 6) & 0x01
+		t := (payload[0] >> 5) & 0x01
+		k := (payload[0] >> 4) & 0x01
+		payload = payload[1:]
+
+		if i == 1 {
+			if len(payload) < 1 {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("payload is too short")
+			}
+
+			// 15-bit picture ID
+			if (payload[0] >> 7) == 1 {
+				if len(payload) < 2 {
+					d.isDecodingFragmented = false
+					return nil, 0, fmt.Errorf("payload is too short")
+				}
+				payload = payload[2:]
+			} else {
+				payload = payload[1:]
+			}
+		}
+
+		if l == 1 {
+			if len(payload) < 1 {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("payload is too short")
+			}
+			payload = payload[1:]
+		}
+
+		if t == 1 || k == 1 {
+			if len(payload) < 1 {
+				d.isDecodingFragmented = false
+				return nil, 0, fmt.Errorf("payload is too short")
+			}
+			payload = payload[1:]
+		}
+	}
+
+	if len(payload) == 0 {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	if s == 1 && pid == 0 {
+		if !d.initialTsSet {
+			d.initialTsSet = true
+			d.initialTs = pkt.Timestamp
+		}
+
+		d.startingPacketReceived = true
+
+		if pkt.Marker {
+			d.isDecodingFragmented = false
+			return payload, d.decodeTimestamp(pkt.Timestamp), nil
+		}
+
+		d.fragmentedBuffer = append([]byte(nil), payload...)
+		d.fragmentedTs = pkt.Timestamp
+		d.lastSequenceNumber = pkt.SequenceNumber
+		d.isDecodingFragmented = true
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	if !d.isDecodingFragmented {
+		if !d.startingPacketReceived {
+			return nil, 0, ErrNonStartingPacketAndNoPrevious
+		}
+		return nil, 0, fmt.Errorf("received a non-starting packet without previous starting packet")
+	}
+
+	if pkt.SequenceNumber != (d.lastSequenceNumber + 1) {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("discarding frame since a RTP packet is missing")
+	}
+
+	if pkt.Timestamp != d.fragmentedTs {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("received a packet with a different timestamp")
+	}
+
+	d.fragmentedBuffer = append(d.fragmentedBuffer, payload...)
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if !pkt.Marker {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	d.isDecodingFragmented = false
+	return d.fragmentedBuffer, d.decodeTimestamp(pkt.Timestamp), nil
+}
@@ -0,0 +1,258 @@
+package rtpvp8
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var fragmentedFrame = mergeBytes(
+	[]byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a},
+	bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 256),
+)
+
+var cases = []struct {
+	name  string
+	frame []byte
+	pts   time.Duration
+	enc   [][]byte
+}{
+	{
+		"single",
+		[]byte{0x01, 0x02, 0x03, 0x04},
+		25 * time.Millisecond,
+		[][]byte{
+			{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6f, 0x1f,
+				0x9d, 0xbb, 0x78, 0x12, 0x10, 0x01, 0x02, 0x03,
+				0x04,
+			},
+		},
+	},
+	{
+		"fragmented",
+		fragmentedFrame,
+		55 * time.Millisecond,
+		[][]byte{
+			mergeBytes(
+				[]byte{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x10,
+				},
+				fragmentedFrame[:1459],
+			),
+			mergeBytes(
+				[]byte{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x79, 0xab,
+					0x9d, 0xbb, 0x78, 0x12, 0x00,
+				},
+				fragmentedFrame[1459:],
+			),
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+
+			// send an initial packet downstream
+			// in order to compute the right timestamp,
+			// that is relative to the initial packet
+			var pkt rtp.Packet
+			err := pkt.Unmarshal([]byte{
+				0x80, 0xe0, 0x44, 0xec, 0x88, 0x77, 0x66, 0x55,
+				0x9d, 0xbb, 0x78, 0x12, 0x10, 0x01,
+			})
+			require.NoError(t, err)
+			_, _, err = d.Decode(&pkt)
+			require.NoError(t, err)
+
+			var frame []byte
+
+			for _, byts := range ca.enc {
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+
+				var pts time.Duration
+				frame, pts, err = d.Decode(&pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, ca.pts, pts)
+			}
+
+			require.Equal(t, ca.frame, frame)
+		})
+	}
+}
+
+func TestDecodeExtendedDescriptor(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x90, 0xf0, 0x80, 0x01,
+		0x02, 0x03, 0xaa, 0xbb,
+	})
+	require.NoError(t, err)
+	frame, _, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xaa, 0xbb}, frame)
+}
+
+func TestDecodePartOfFragmentedBeforeSingle(t *testing.T) {
+	d := NewDecoder()
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x00, 0x01,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+
+	err = pkt.Unmarshal([]byte{
+		0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x66, 0x55,
+		0x9d, 0xbb, 0x78, 0x12, 0x10, 0x01,
+	})
+	require.NoError(t, err)
+	_, _, err = d.Decode(&pkt)
+	require.NoError(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		pkts [][]byte
+		err  string
+	}{
+		{
+			"missing payload",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12,
+			}},
+			"payload is too short",
+		},
+		{
+			"missing data",
+			[][]byte{{
+				0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+				0x9d, 0xbb, 0x78, 0x12, 0x90, 0x80,
+			}},
+			"payload is too short",
+		},
+		{
+			"missing packet",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x10, 0x01,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xef, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x01,
+				},
+			},
+			"discarding frame since a RTP packet is missing",
+		},
+		{
+			"different timestamp",
+			[][]byte{
+				{
+					0x80, 0x60, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x10, 0x01,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x16,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x01,
+				},
+			},
+			"received a packet with a different timestamp",
+		},
+		{
+			"non-starting packet",
+			[][]byte{
+				{
+					0x80, 0xe0, 0x44, 0xed, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x10, 0x01,
+				},
+				{
+					0x80, 0xe0, 0x44, 0xee, 0x88, 0x77, 0x6a, 0x15,
+					0x9d, 0xbb, 0x78, 0x12, 0x00, 0x01,
+				},
+			},
+			"received a non-starting packet without previous starting packet",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			var lastErr error
+			for _, byts := range ca.pkts {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(byts)
+				require.NoError(t, err)
+				_, _, lastErr = d.Decode(&pkt)
+			}
+			require.EqualError(t, lastErr, ca.err)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			sequenceNumber := uint16(0x44ed)
+			ssrc := uint32(0x9dbb7812)
+			initialTs := uint32(0x88776655)
+			e := NewEncoder(96, &sequenceNumber, &ssrc, &initialTs)
+
+			enc, err := e.Encode(ca.frame, ca.pts)
+			require.NoError(t, err)
+
+			var bytss [][]byte
+			for _, pkt := range enc {
+				byts, err := pkt.Marshal()
+				require.NoError(t, err)
+				bytss = append(bytss, byts)
+			}
+
+			require.Equal(t, ca.enc, bytss)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	NewEncoder(96, nil, nil, nil)
+}
+
+func TestIsKeyFrame(t *testing.T) {
+	require.Equal(t, true, IsKeyFrame([]byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a}))
+	require.Equal(t, false, IsKeyFrame([]byte{0x31, 0x02, 0x00}))
+	require.Equal(t, false, IsKeyFrame(nil))
+}
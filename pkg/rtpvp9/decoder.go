@@ -0,0 +1,247 @@
+package rtpvp9
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we decoded a non-starting
+// packet of a fragmented frame and we didn't received anything before.
+// It's normal to receive this when we are decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"decoded a non-starting fragmented packet without any previous starting packet")
+
+// IsKeyFrame checks whether a VP9 frame is a key frame.
+func IsKeyFrame(frame []byte) bool {
+	// ref: VP9 Bitstream & Decoding Process Specification, 6.2 Uncompressed header syntax
+	if len(frame) < 1 {
+		return false
+	}
+
+	// frame_marker
+	if (frame[0] >> 6) != 0x02 {
+		return false
+	}
+
+	profile := ((frame[0] >> 5) & 0x01) | (((frame[0] >> 4) & 0x01) << 1)
+	pos := uint(4)
+	if profile == 3 {
+		pos++ // reserved_zero
+	}
+
+	showExistingFrame := (frame[0] >> (7 - pos)) & 0x01
+	if showExistingFrame == 1 {
+		return false
+	}
+
+	frameType := (frame[0] >> (7 - pos - 1)) & 0x01
+	return frameType == 0
+}
+
+// Decoder is a RTP/VP9 decoder.
+type Decoder struct {
+	initialTs    uint32
+	initialTsSet bool
+
+	startingPacketReceived bool
+	isDecodingFragmented   bool
+	fragmentedBuffer       []byte
+	fragmentedTs           uint32
+	lastSequenceNumber     uint16
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) decodeTimestamp(ts uint32) time.Duration {
+	return (time.Duration(ts) - time.Duration(d.initialTs)) * time.Second / rtpClockRate
+}
+
+func skipPayloadDescriptor(payload []byte) ([]byte, bool, bool, error) {
+	// ref: https://datatracker.ietf.org/doc/html/draft-ietf-payload-vp9-16#section-4.2
+
+	if len(payload) < 1 {
+		return nil, false, false, fmt.Errorf("payload is too short")
+	}
+
+	i := (payload[0] >> 7) & 0x01
+	p := (payload[0] >> 6) & 0x01
+	l := (payload[0] >> 5) & 0x01
+	f := (payload[0] >> 4) & 0x01
+	b := (payload[0] >> 3) & 0x01
+	e := (payload[0] >> 2) & 0x01
+	v := (payload[0] >> 1) & 0x01
+	payload = payload[1:]
+
+	skip := func(n int) error {
+		if len(payload) < n {
+			return fmt.Errorf("payload is too short")
+		}
+		payload = payload[n:]
+		return nil
+	}
+
+	if i == 1 {
+		if len(payload) < 1 {
+			return nil, false, false, fmt.Errorf("payload is too short")
+		}
+
+		// 15-bit picture ID
+		n := 1
+		if (payload[0] >> 7) == 1 {
+			n = 2
+		}
+
+		err := skip(n)
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	if l == 1 {
+		// layer indices, plus TL0PICIDX in non-flexible mode
+		n := 1
+		if f == 0 {
+			n = 2
+		}
+
+		err := skip(n)
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	if f == 1 && p == 1 {
+		// reference indices
+		for k := 0; ; k++ {
+			if len(payload) < 1 {
+				return nil, false, false, fmt.Errorf("payload is too short")
+			}
+
+			more := payload[0] & 0x01
+			payload = payload[1:]
+
+			if more == 0 {
+				break
+			}
+
+			if k == 2 {
+				return nil, false, false, fmt.Errorf("too many reference indices")
+			}
+		}
+	}
+
+	if v == 1 {
+		// scalability structure
+		if len(payload) < 1 {
+			return nil, false, false, fmt.Errorf("payload is too short")
+		}
+
+		ns := int(payload[0]>>5) + 1
+		y := (payload[0] >> 4) & 0x01
+		g := (payload[0] >> 3) & 0x01
+		payload = payload[1:]
+
+		if y == 1 {
+			err := skip(ns * 4)
+			if err != nil {
+				return nil, false, false, err
+			}
+		}
+
+		if g == 1 {
+			if len(payload) < 1 {
+				return nil, false, false, fmt.Errorf("payload is too short")
+			}
+
+			ng := int(payload[0])
+			payload = payload[1:]
+
+			for k := 0; k < ng; k++ {
+				if len(payload) < 1 {
+					return nil, false, false, fmt.Errorf("payload is too short")
+				}
+
+				r := int((payload[0] >> 2) & 0x03)
+
+				err := skip(1 + r)
+				if err != nil {
+					return nil, false, false, err
+				}
+			}
+		}
+	}
+
+	if len(payload) == 0 {
+		return nil, false, false, fmt.Errorf("payload is too short")
+	}
+
+	return payload, b == 1, e == 1, nil
+}
+
+// Decode decodes a VP9 frame from a RTP/VP9 packet.
+// When the frame is split into multiple packets, it returns ErrMorePacketsNeeded
+// until the packet that ends the frame is received.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, time.Duration, error) {
+	payload, start, end, err := skipPayloadDescriptor(pkt.Payload)
+	if err != nil {
+		d.isDecodingFragmented = false
+		return nil, 0, err
+	}
+
+	if start {
+		if !d.initialTsSet {
+			d.initialTsSet = true
+			d.initialTs = pkt.Timestamp
+		}
+
+		d.startingPacketReceived = true
+
+		if end {
+			d.isDecodingFragmented = false
+			return payload, d.decodeTimestamp(pkt.Timestamp), nil
+		}
+
+		d.fragmentedBuffer = append([]byte(nil), payload...)
+		d.fragmentedTs = pkt.Timestamp
+		d.lastSequenceNumber = pkt.SequenceNumber
+		d.isDecodingFragmented = true
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	if !d.isDecodingFragmented {
+		if !d.startingPacketReceived {
+			return nil, 0, ErrNonStartingPacketAndNoPrevious
+		}
+		return nil, 0, fmt.Errorf("received a non-starting packet without previous starting packet")
+	}
+
+	if pkt.SequenceNumber != (d.lastSequenceNumber + 1) {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("discarding frame since a RTP packet is missing")
+	}
+
+	if pkt.Timestamp != d.fragmentedTs {
+		d.isDecodingFragmented = false
+		return nil, 0, fmt.Errorf("received a packet with a different timestamp")
+	}
+
+	d.fragmentedBuffer = append(d.fragmentedBuffer, payload...)
+	d.lastSequenceNumber = pkt.SequenceNumber
+
+	if !end {
+		return nil, 0, ErrMorePacketsNeeded
+	}
+
+	d.isDecodingFragmented = false
+	return d.fragmentedBuffer, d.decodeTimestamp(pkt.Timestamp), nil
+}
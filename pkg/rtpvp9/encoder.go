@@ -0,0 +1,135 @@
+package rtpvp9
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460  // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpClockRate      = 90000 // vp9 always uses 90khz
+)
+
+func randUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// Encoder is a RTP/VP9 encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	initialTs      uint32
+	pictureID      uint16
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8,
+	sequenceNumber *uint16,
+	ssrc *uint32,
+	initialTs *uint32,
+	initialPictureID *uint16) *Encoder {
+	return &Encoder{
+		payloadType: payloadType,
+		sequenceNumber: func() uint16 {
+			if sequenceNumber != nil {
+				return *sequenceNumber
+			}
+			return uint16(randUint32())
+		}(),
+		ssrc: func() uint32 {
+			if ssrc != nil {
+				return *ssrc
+			}
+			return randUint32()
+		}(),
+		initialTs: func() uint32 {
+			if initialTs != nil {
+				return *initialTs
+			}
+			return randUint32()
+		}(),
+		pictureID: func() uint16 {
+			if initialPictureID != nil {
+				return *initialPictureID & 0x7FFF
+			}
+			return uint16(randUint32()) & 0x7FFF
+		}(),
+	}
+}
+
+func (e *Encoder) encodeTimestamp(ts time.Duration) uint32 {
+	return e.initialTs + uint32(ts.Seconds()*rtpClockRate)
+}
+
+// Encode encodes a VP9 frame into RTP/VP9 packets.
+func (e *Encoder) Encode(frame []byte, pts time.Duration) ([]*rtp.Packet, error) {
+	if len(frame) == 0 {
+		return nil, fmt.Errorf("frame is empty")
+	}
+
+	le := rtpPayloadMaxSize - 3 // payload descriptor
+	packetCount := len(frame) / le
+	lastPacketSize := len(frame) % le
+	if lastPacketSize > 0 {
+		packetCount++
+	}
+
+	ret := make([]*rtp.Packet, packetCount)
+	encPTS := e.encodeTimestamp(pts)
+
+	p := uint8(1)
+	if IsKeyFrame(frame) {
+		p = 0
+	}
+
+	for i := range ret {
+		pl := le
+		if i == (packetCount-1) && lastPacketSize > 0 {
+			pl = lastPacketSize
+		}
+
+		b := uint8(0)
+		if i == 0 {
+			b = 1
+		}
+		end := uint8(0)
+		if i == (packetCount - 1) {
+			end = 1
+		}
+
+		data := make([]byte, 3+pl)
+
+		// payload descriptor with a 15-bit picture ID
+		data[0] = (1 << 7) | (p << 6) | (b << 3) | (end << 2)
+		data[1] = 0x80 | uint8(e.pictureID>>8)
+		data[2] = uint8(e.pictureID)
+
+		copy(data[3:], frame[:pl])
+		frame = frame[pl:]
+
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      encPTS,
+				SSRC:           e.ssrc,
+				Marker:         (i == (packetCount - 1)),
+			},
+			Payload: data,
+		}
+
+		e.sequenceNumber++
+	}
+
+	e.pictureID = (e.pictureID + 1) & 0x7FFF
+
+	return ret, nil
+}
@@ -114,6 +114,10 @@ type Server struct {
 	// This must be touched only when the server reports errors about buffer sizes.
 	// It defaults to 2048.
 	ReadBufferSize int
+	// if greater than zero, RTP packets published with UDP are reordered
+	// by sequence number, waiting at most this duration for missing packets.
+	// It defaults to zero (disabled).
+	JitterBufferLatency time.Duration
 
 	//
 	// system functions
@@ -125,6 +129,12 @@ type Server struct {
 	// It defaults to net.ListenPacket.
 	ListenPacket func(network, address string) (net.PacketConn, error)
 
+	//
+	// debugging
+	//
+	// if set, the traffic of the server is written into it.
+	Capture *Capture
+
 	//
 	// private
 	//
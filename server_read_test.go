@@ -6,10 +6,12 @@ import (
 	"crypto/tls"
 	"net"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	psdp "github.com/pion/sdp/v3"
 	"github.com/stretchr/testify/require"
@@ -796,6 +798,128 @@ func TestServerReadTCPResponseBeforeFrames(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestServerReadTCPResponseAfterFrames(t *testing.T) {
+	writerDone := make(chan struct{})
+	writerTerminate := make(chan struct{})
+
+	track, err := NewTrackH264(96, &TrackConfigH264{[]byte{0x01, 0x02, 0x03, 0x04}, []byte{0x01, 0x02, 0x03, 0x04}})
+	require.NoError(t, err)
+
+	stream := NewServerStream(Tracks{track})
+
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler: &testServerHandler{
+			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+				close(writerTerminate)
+				<-writerDone
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					defer close(writerDone)
+
+					for {
+						select {
+						case <-writerTerminate:
+							return
+						default:
+						}
+
+						stream.WritePacketRTP(0, bytes.Repeat([]byte{0x00}, 1000))
+					}
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn.Close()
+	bconn := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	res, err := writeReqReadRes(bconn, base.Request{
+		Method: base.Setup,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream/trackID=0"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+			"Transport": headers.Transport{
+				Protocol: headers.TransportProtocolTCP,
+				Delivery: func() *headers.TransportDelivery {
+					v := headers.TransportDeliveryUnicast
+					return &v
+				}(),
+				Mode: func() *headers.TransportMode {
+					v := headers.TransportModePlay
+					return &v
+				}(),
+				InterleavedIDs: &[2]int{0, 1},
+			}.Write(),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	session := res.Header["Session"]
+
+	res, err = writeReqReadRes(bconn, base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"2"},
+			"Session": session,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	err = base.Request{
+		Method: base.Pause,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"3"},
+			"Session": session,
+		},
+	}.Write(bconn.Writer)
+	require.NoError(t, err)
+
+	var fr base.InterleavedFrame
+	fr.Payload = make([]byte, 2048)
+
+	for {
+		what, err := base.ReadInterleavedFrameOrResponse(&fr, res, bconn.Reader)
+		require.NoError(t, err)
+
+		if _, ok := what.(*base.Response); ok {
+			break
+		}
+	}
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	// the response must be the last thing written before pausing
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	_, err = bconn.Reader.ReadByte()
+	require.Error(t, err)
+	require.True(t, err.(net.Error).Timeout())
+}
+
 func TestServerReadPlayPlay(t *testing.T) {
 	track, err := NewTrackH264(96, &TrackConfigH264{[]byte{0x01, 0x02, 0x03, 0x04}, []byte{0x01, 0x02, 0x03, 0x04}})
 	require.NoError(t, err)
@@ -1797,3 +1921,322 @@ func TestServerReadErrorUDPSamePorts(t *testing.T) {
 		require.Equal(t, base.StatusBadRequest, res.StatusCode)
 	}()
 }
+
+func TestServerReadRetransmission(t *testing.T) {
+	track, err := NewTrackH264(96, &TrackConfigH264{
+		SPS: testRecorderSPS,
+		PPS: testRecorderPPS,
+	})
+	require.NoError(t, err)
+
+	stream := NewServerStream(Tracks{track})
+	defer stream.Close()
+
+	require.Equal(t, true, stream.Tracks()[0].hasRTCPFeedback("nack"))
+
+	packet := func(seq uint16) []byte {
+		byts, err := (&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				SSRC:           0x38F27A2F,
+			},
+			Payload: []byte{0x01, 0x02, 0x03, 0x04},
+		}).Marshal()
+		require.NoError(t, err)
+		return byts
+	}
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					stream.WritePacketRTP(0, packet(100))
+
+					// simulate a packet that is lost on the network
+					stream.trackInfos[0].history.push(101, packet(101))
+
+					stream.WritePacketRTP(0, packet(102))
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
+		RTSPAddress:    "localhost:8554",
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	received := make(chan []byte, 10)
+
+	c := Client{
+		Transport: func() *Transport {
+			v := TransportUDP
+			return &v
+		}(),
+		OnPacketRTP: func(trackID int, payload []byte) {
+			received <- append([]byte(nil), payload...)
+		},
+	}
+
+	err = c.StartReading("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, seq := range []uint16{100, 102, 101} {
+		select {
+		case byts := <-received:
+			require.Equal(t, packet(seq), byts)
+		case <-time.After(2 * time.Second):
+			t.Errorf("packet %d not received", seq)
+			return
+		}
+	}
+}
+
+func TestServerReadRetransmissionAttributeNotDuplicated(t *testing.T) {
+	track, err := NewTrackH264(96, &TrackConfigH264{
+		SPS: testRecorderSPS,
+		PPS: testRecorderPPS,
+	})
+	require.NoError(t, err)
+
+	track.Media.Attributes = append(track.Media.Attributes, psdp.Attribute{
+		Key:   "rtcp-fb",
+		Value: "96 nack",
+	})
+
+	stream := NewServerStream(Tracks{track})
+	defer stream.Close()
+
+	n := 0
+	for _, attr := range stream.Tracks()[0].Media.Attributes {
+		if attr.Key == "rtcp-fb" {
+			n++
+		}
+	}
+	require.Equal(t, 1, n)
+}
+
+func TestServerReadSenderReports(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			track, err := NewTrackH264(96, &TrackConfigH264{
+				SPS: testRecorderSPS,
+				PPS: testRecorderPPS,
+			})
+			require.NoError(t, err)
+
+			stream := NewServerStream(Tracks{track})
+			stream.EnableSenderReports(500 * time.Millisecond)
+			defer stream.Close()
+
+			byts, _ := (&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 946,
+					Timestamp:      54352,
+					SSRC:           0x38F27A2F,
+				},
+				Payload: []byte{0x05, 0x02, 0x03, 0x04},
+			}).Marshal()
+			stream.WritePacketRTP(0, byts)
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress: "localhost:8554",
+			}
+
+			if transport == "udp" {
+				s.UDPRTPAddress = "127.0.0.1:8000"
+				s.UDPRTCPAddress = "127.0.0.1:8001"
+			}
+
+			err = s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			received := make(chan []rtcp.Packet, 10)
+
+			c := Client{
+				Transport: func() *Transport {
+					if transport == "udp" {
+						v := TransportUDP
+						return &v
+					}
+					v := TransportTCP
+					return &v
+				}(),
+				OnPacketRTCP: func(trackID int, payload []byte) {
+					pkts, err := rtcp.Unmarshal(payload)
+					require.NoError(t, err)
+					received <- pkts
+				},
+			}
+
+			err = c.StartReading("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+			defer c.Close()
+
+			recv := func() []rtcp.Packet {
+				for {
+					select {
+					case pkts := <-received:
+						// skip the packet sent to open the firewall
+						if _, ok := pkts[0].(*rtcp.ReceiverReport); ok {
+							continue
+						}
+						return pkts
+
+					case <-time.After(2 * time.Second):
+						t.Errorf("RTCP packet not received")
+						return nil
+					}
+				}
+			}
+
+			// sender report sent when the reader becomes active
+			pkts := recv()
+			require.Equal(t, uint32(0x38F27A2F), pkts[0].(*rtcp.SenderReport).SSRC)
+			require.Equal(t, uint32(1), pkts[0].(*rtcp.SenderReport).PacketCount)
+
+			// sender reports of the source are discarded
+			byts, _ = rtcp.Marshal([]rtcp.Packet{
+				&rtcp.SenderReport{
+					SSRC: 0x12345678,
+				},
+				&rtcp.SourceDescription{
+					Chunks: []rtcp.SourceDescriptionChunk{{
+						Source: 0x12345678,
+						Items: []rtcp.SourceDescriptionItem{{
+							Type: rtcp.SDESCNAME,
+							Text: "test",
+						}},
+					}},
+				},
+			})
+			stream.WritePacketRTCP(0, byts)
+
+			pkts = recv()
+			require.Equal(t, 1, len(pkts))
+			_, ok := pkts[0].(*rtcp.SourceDescription)
+			require.Equal(t, true, ok)
+
+			// periodic sender report
+			pkts = recv()
+			require.Equal(t, uint32(0x38F27A2F), pkts[0].(*rtcp.SenderReport).SSRC)
+		})
+	}
+}
+
+func TestServerReadSenderReportsConcurrentClose(t *testing.T) {
+	track, err := NewTrackH264(96, &TrackConfigH264{
+		SPS: testRecorderSPS,
+		PPS: testRecorderPPS,
+	})
+	require.NoError(t, err)
+
+	stream := NewServerStream(Tracks{track})
+	stream.EnableSenderReports(500 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestServerReadSenderReportsUnknownClockRate(t *testing.T) {
+	track1, err := NewTrackH264(96, &TrackConfigH264{
+		SPS: testRecorderSPS,
+		PPS: testRecorderPPS,
+	})
+	require.NoError(t, err)
+
+	track2 := &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "application",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"98"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "98 private/0",
+				},
+			},
+		},
+	}
+
+	stream := NewServerStream(Tracks{track1, track2})
+	defer stream.Close()
+
+	byts, _ := (&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    98,
+			SequenceNumber: 946,
+			SSRC:           0x38F27A2F,
+		},
+		Payload: []byte{0x01, 0x02},
+	}).Marshal()
+
+	// sender reports can be enabled while packets are being written
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			stream.WritePacketRTP(1, byts)
+		}
+	}()
+
+	stream.EnableSenderReports(500 * time.Millisecond)
+	<-done
+
+	// tracks with an unknown clock rate are skipped
+	require.NotNil(t, stream.rtcpSenders[0])
+	require.Nil(t, stream.rtcpSenders[1])
+}
@@ -12,6 +12,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	psdp "github.com/pion/sdp/v3"
 	"github.com/stretchr/testify/require"
 
@@ -50,6 +52,7 @@ type testServerHandler struct {
 	onPause        func(*ServerHandlerOnPauseCtx) (*base.Response, error)
 	onPacketRTP    func(*ServerHandlerOnPacketRTPCtx)
 	onPacketRTCP   func(*ServerHandlerOnPacketRTCPCtx)
+	onPacketsLost  func(*ServerHandlerOnPacketsLostCtx)
 	onSetParameter func(*ServerHandlerOnSetParameterCtx) (*base.Response, error)
 	onGetParameter func(*ServerHandlerOnGetParameterCtx) (*base.Response, error)
 }
@@ -132,6 +135,12 @@ func (sh *testServerHandler) OnPacketRTCP(ctx *ServerHandlerOnPacketRTCPCtx) {
 	}
 }
 
+func (sh *testServerHandler) OnPacketsLost(ctx *ServerHandlerOnPacketsLostCtx) {
+	if sh.onPacketsLost != nil {
+		sh.onPacketsLost(ctx)
+	}
+}
+
 func (sh *testServerHandler) OnSetParameter(ctx *ServerHandlerOnSetParameterCtx) (*base.Response, error) {
 	if sh.onSetParameter != nil {
 		return sh.onSetParameter(ctx)
@@ -1236,3 +1245,162 @@ func TestServerErrorInvalidPath(t *testing.T) {
 		})
 	}
 }
+
+func TestServerKeyframeRequest(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			var mutex sync.Mutex
+			var stream *ServerStream
+			var publisher *ServerSession
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						mutex.Lock()
+						defer mutex.Unlock()
+
+						stream = NewServerStream(ctx.Tracks)
+						publisher = ctx.Session
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						mutex.Lock()
+						defer mutex.Unlock()
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						mutex.Lock()
+						defer mutex.Unlock()
+
+						// keyframe requests of readers are forwarded to the publisher
+						// that returns the stream
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onPacketRTP: func(ctx *ServerHandlerOnPacketRTPCtx) {
+						mutex.Lock()
+						defer mutex.Unlock()
+
+						if ctx.Session == publisher {
+							stream.WritePacketRTP(ctx.TrackID, ctx.Payload)
+						}
+					},
+				},
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+				RTSPAddress:    "localhost:8554",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			track, err := NewTrackH264(96, &TrackConfigH264{
+				SPS: testRecorderSPS,
+				PPS: testRecorderPPS,
+			})
+			require.NoError(t, err)
+
+			keyframeRequest := make(chan int, 10)
+			requestPayload := make(chan []byte, 10)
+
+			pc := Client{
+				Transport: func() *Transport {
+					v := TransportTCP
+					return &v
+				}(),
+				OnKeyframeRequest: func(trackID int) {
+					keyframeRequest <- trackID
+				},
+				OnPacketRTCP: func(trackID int, payload []byte) {
+					requestPayload <- append([]byte(nil), payload...)
+				},
+			}
+
+			err = pc.StartPublishing("rtsp://localhost:8554/teststream", Tracks{track})
+			require.NoError(t, err)
+			defer pc.Close()
+
+			byts, err := (&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 946,
+					SSRC:           0x38F27A2F,
+				},
+				Payload: []byte{0x05, 0x02, 0x03, 0x04},
+			}).Marshal()
+			require.NoError(t, err)
+
+			err = pc.WritePacketRTP(0, byts)
+			require.NoError(t, err)
+
+			rc := Client{
+				Transport: func() *Transport {
+					if transport == "udp" {
+						v := TransportUDP
+						return &v
+					}
+					v := TransportTCP
+					return &v
+				}(),
+			}
+
+			err = rc.StartReading("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+			defer rc.Close()
+
+			err = rc.RequestKeyframe(1)
+			require.EqualError(t, err, "track 1 is not being read")
+
+			// make sure that the publisher SSRC is known
+			time.Sleep(100 * time.Millisecond)
+
+			// requests are rate-limited
+			for i := 0; i < 3; i++ {
+				err = rc.RequestKeyframe(0)
+				require.NoError(t, err)
+			}
+
+			select {
+			case trackID := <-keyframeRequest:
+				require.Equal(t, 0, trackID)
+			case <-time.After(2 * time.Second):
+				t.Errorf("keyframe request not received")
+				return
+			}
+
+			pkts, err := rtcp.Unmarshal(<-requestPayload)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(pkts))
+			require.Equal(t, uint32(0x38F27A2F), pkts[0].(*rtcp.PictureLossIndication).MediaSSRC)
+			require.Equal(t, uint32(0x38F27A2F), pkts[1].(*rtcp.FullIntraRequest).FIR[0].SSRC)
+
+			select {
+			case <-keyframeRequest:
+				t.Errorf("keyframe request was not rate-limited")
+			case <-time.After(200 * time.Millisecond):
+			}
+		})
+	}
+}
@@ -117,6 +117,10 @@ func (sc *ServerConn) run() {
 		return sc.nconn
 	}()
 
+	if sc.s.Capture != nil {
+		conn = sc.s.Capture.wrapConn(conn, false)
+	}
+
 	sc.br = bufio.NewReaderSize(conn, serverConnReadBufferSize)
 	sc.bw = bufio.NewWriterSize(conn, serverConnWriteBufferSize)
 	sc.sessions = make(map[string]*ServerSession)
@@ -256,6 +260,8 @@ func (sc *ServerConn) run() {
 
 func (sc *ServerConn) tcpProcessPlay(trackID int, isRTP bool, payload []byte) {
 	if !isRTP {
+		sc.tcpSession.setuppedStream.processReaderPacketRTCP(sc.tcpSession, trackID, payload)
+
 		if h, ok := sc.s.Handler.(ServerHandlerOnPacketRTCP); ok {
 			h.OnPacketRTCP(&ServerHandlerOnPacketRTCPCtx{
 				Session: sc.tcpSession,
@@ -533,11 +539,11 @@ func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 	case sc.tcpFrameSetEnabled != sc.tcpFrameEnabled:
 		sc.tcpFrameEnabled = sc.tcpFrameSetEnabled
 
-		// write response before frames
-		sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
-		res.Write(sc.bw)
-
 		if sc.tcpFrameEnabled {
+			// write response before frames
+			sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
+			res.Write(sc.bw)
+
 			if sc.tcpFrameIsRecording {
 				sc.tcpFrameTimeout = true
 				sc.tcpFrameBuffer = multibuffer.New(uint64(sc.s.ReadBufferCount), uint64(sc.s.ReadBufferSize))
@@ -566,6 +572,10 @@ func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 			sc.tcpFrameWriteBuffer.Reset()
 
 			sc.tcpFrameBuffer = nil
+
+			// write response after the background writer has stopped
+			sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
+			res.Write(sc.bw)
 		}
 
 	case sc.tcpFrameEnabled: // write to background write
@@ -0,0 +1,509 @@
+package gortsplib
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/h264"
+	"github.com/aler9/gortsplib/pkg/headers"
+	"github.com/aler9/gortsplib/pkg/mp4"
+	"github.com/aler9/gortsplib/pkg/rtpaac"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+const (
+	serverFileH264PayloadType = 96
+	serverFileAACPayloadType  = 97
+)
+
+// ServerFile is a MP4 file that can be served on demand.
+// Each session that reads the file must use its own ServerFileStream.
+type ServerFile struct {
+	path      string
+	mp4Tracks []*mp4.Track
+	tracks    Tracks
+	duration  time.Duration
+}
+
+// OpenServerFile opens a MP4 file and reads its H264 and AAC tracks.
+func OpenServerFile(path string) (*ServerFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mp4Tracks, err := mp4.ReadTracks(f)
+	if err != nil {
+		return nil, err
+	}
+
+	sf := &ServerFile{
+		path:      path,
+		mp4Tracks: mp4Tracks,
+	}
+
+	for _, mt := range mp4Tracks {
+		var track *Track
+
+		if mt.IsH264() {
+			track, err = NewTrackH264(serverFileH264PayloadType, &TrackConfigH264{
+				SPS: mt.SPS,
+				PPS: mt.PPS,
+			})
+		} else {
+			track, err = NewTrackAAC(serverFileAACPayloadType, &TrackConfigAAC{
+				Type:              int(mt.AACConfig.Type),
+				SampleRate:        mt.AACConfig.SampleRate,
+				ChannelCount:      mt.AACConfig.ChannelCount,
+				AOTSpecificConfig: mt.AACConfig.AOTSpecificConfig,
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sf.tracks = append(sf.tracks, track)
+
+		if mt.Duration > sf.duration {
+			sf.duration = mt.Duration
+		}
+	}
+
+	return sf, nil
+}
+
+// Tracks returns the tracks of the file.
+func (sf *ServerFile) Tracks() Tracks {
+	return sf.tracks
+}
+
+// Duration returns the duration of the file.
+func (sf *ServerFile) Duration() time.Duration {
+	return sf.duration
+}
+
+// NewStream allocates a ServerFileStream, that must be used by a single session.
+func (sf *ServerFile) NewStream() (*ServerFileStream, error) {
+	f, err := os.Open(sf.path)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := &ServerFileStream{
+		sf:     sf,
+		f:      f,
+		stream: NewServerStream(sf.tracks),
+	}
+
+	// packets are generated by the server, therefore sender reports
+	// must be generated too.
+	fs.stream.EnableSenderReports(0)
+
+	for trackID, mt := range sf.mp4Tracks {
+		clockRate, _ := sf.tracks[trackID].ClockRate()
+		seq := uint16(randUint32())
+		ssrc := randUint32()
+		initialTs := randUint32()
+
+		ft := &serverFileTrack{
+			mp4Track:  mt,
+			clockRate: clockRate,
+			nextSeq:   seq,
+			initialTs: initialTs,
+		}
+
+		if mt.IsH264() {
+			ft.h264Encoder = rtph264.NewEncoder(serverFileH264PayloadType, &seq, &ssrc, &initialTs)
+		} else {
+			ft.aacEncoder = rtpaac.NewEncoder(serverFileAACPayloadType, clockRate, &seq, &ssrc, &initialTs)
+		}
+
+		fs.tracks = append(fs.tracks, ft)
+	}
+
+	fs.seek(0)
+	fs.stream.onReaderSetActive = fs.onReaderSetActive
+
+	return fs, nil
+}
+
+type serverFileTrack struct {
+	mp4Track    *mp4.Track
+	clockRate   int
+	h264Encoder *rtph264.Encoder
+	aacEncoder  *rtpaac.Encoder
+	initialTs   uint32
+
+	// written by the sending routine
+	nextSample int
+	nextSeq    uint16
+}
+
+// ServerFileStream is a ServerStream that is fed with the content of a ServerFile.
+// Samples are sent in real time, starting from the position requested
+// by PLAY requests. RTP timestamps are continuous across PAUSE and seeks.
+type ServerFileStream struct {
+	sf     *ServerFile
+	f      *os.File
+	stream *ServerStream
+	tracks []*serverFileTrack
+
+	mutex        sync.Mutex
+	closed       bool
+	playing      bool
+	readerActive bool
+	// position of the file that corresponds to rtpBase
+	position time.Duration
+	// elapsed RTP time
+	rtpBase   time.Duration
+	startTime time.Time
+	ctxCancel func()
+	done      chan struct{}
+}
+
+// Stream returns the ServerStream, that must be returned by OnSetup.
+func (fs *ServerFileStream) Stream() *ServerStream {
+	return fs.stream
+}
+
+// Close closes the ServerFileStream.
+func (fs *ServerFileStream) Close() error {
+	fs.mutex.Lock()
+	fs.stop()
+	fs.closed = true
+	fs.mutex.Unlock()
+
+	fs.stream.Close()
+	return fs.f.Close()
+}
+
+// Play handles a PLAY request. It must be called by OnPlay.
+// When the request contains a Range header, the stream is moved to the
+// nearest keyframe that precedes the requested position.
+func (fs *ServerFileStream) Play(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+	var start *time.Duration
+
+	if v, ok := ctx.Req.Header["Range"]; ok {
+		var ra headers.Range
+		err := ra.Read(v)
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusInvalidRange,
+			}, fmt.Errorf("invalid Range header: %v", err)
+		}
+
+		npt, ok := ra.Value.(*headers.RangeNPT)
+		if !ok {
+			return &base.Response{
+				StatusCode: base.StatusInvalidRange,
+			}, fmt.Errorf("unsupported Range unit")
+		}
+
+		v := time.Duration(npt.Start)
+		if v > fs.sf.duration {
+			return &base.Response{
+				StatusCode: base.StatusInvalidRange,
+			}, fmt.Errorf("requested position (%v) is beyond the end of the file (%v)",
+				v, fs.sf.duration)
+		}
+
+		start = &v
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.closed {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, fmt.Errorf("terminated")
+	}
+
+	fs.stop()
+
+	if start != nil {
+		fs.seek(*start)
+	}
+
+	header := base.Header{
+		"Range": headers.Range{
+			Value: &headers.RangeNPT{
+				Start: headers.RangeNPTTime(fs.position),
+				End: func() *headers.RangeNPTTime {
+					v := headers.RangeNPTTime(fs.sf.duration)
+					return &v
+				}(),
+			},
+		}.Write(),
+	}
+
+	var ri headers.RTPInfo
+	if ctx.Session.setuppedPath != nil {
+		for trackID, ft := range fs.tracks {
+			if _, ok := ctx.Session.setuppedTracks[trackID]; !ok {
+				continue
+			}
+
+			u := &base.URL{
+				Scheme: ctx.Req.URL.Scheme,
+				User:   ctx.Req.URL.User,
+				Host:   ctx.Req.URL.Host,
+				Path:   "/" + *ctx.Session.setuppedPath + "/trackID=" + strconv.FormatInt(int64(trackID), 10),
+			}
+
+			seq := ft.nextSeq
+			ts := ft.initialTs + uint32(fs.rtpBase.Seconds()*float64(ft.clockRate))
+
+			ri = append(ri, &headers.RTPInfoEntry{
+				URL:            u.String(),
+				SequenceNumber: &seq,
+				Timestamp:      &ts,
+			})
+		}
+	}
+	if len(ri) > 0 {
+		header["RTP-Info"] = ri.Write()
+	}
+
+	fs.playing = true
+
+	// when the session is already playing, the reader is active
+	// and sending can start immediately.
+	if fs.readerActive {
+		fs.start()
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Header:     header,
+	}, nil
+}
+
+// Pause handles a PAUSE request. It must be called by OnPause.
+func (fs *ServerFileStream) Pause(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.stop()
+	fs.playing = false
+	fs.readerActive = false
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func (fs *ServerFileStream) onReaderSetActive() {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.readerActive = true
+
+	if fs.playing && !fs.closed && fs.done == nil {
+		fs.start()
+	}
+}
+
+// seek moves the stream to the nearest sync sample that precedes v.
+func (fs *ServerFileStream) seek(v time.Duration) {
+	// find the sync sample of the reference track
+	// (the H264 track if present, otherwise the first track).
+	ref := fs.tracks[0]
+	for _, ft := range fs.tracks {
+		if ft.mp4Track.IsH264() {
+			ref = ft
+			break
+		}
+	}
+
+	pos := time.Duration(0)
+	found := false
+	for _, s := range ref.mp4Track.Samples {
+		if s.IsSync && (s.PTS <= v || !found) {
+			pos = s.PTS
+			found = true
+		}
+	}
+
+	fs.position = pos
+
+	for _, ft := range fs.tracks {
+		ft.nextSample = len(ft.mp4Track.Samples)
+
+		if ft == ref {
+			for i, s := range ft.mp4Track.Samples {
+				if s.IsSync && s.PTS == pos {
+					ft.nextSample = i
+					break
+				}
+			}
+			continue
+		}
+
+		for i, s := range ft.mp4Track.Samples {
+			if s.PTS >= pos {
+				ft.nextSample = i
+				break
+			}
+		}
+	}
+}
+
+func (fs *ServerFileStream) start() {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	fs.ctxCancel = ctxCancel
+	fs.done = make(chan struct{})
+	fs.startTime = time.Now()
+
+	go fs.runSender(ctx, fs.done, fs.startTime, fs.position, fs.rtpBase)
+}
+
+// stop stops the sending routine and saves the current position.
+func (fs *ServerFileStream) stop() {
+	if fs.done == nil {
+		return
+	}
+
+	fs.ctxCancel()
+	<-fs.done
+	fs.done = nil
+
+	elapsed := time.Since(fs.startTime)
+	if fs.position+elapsed > fs.sf.duration {
+		elapsed = fs.sf.duration - fs.position
+		if elapsed < 0 {
+			elapsed = 0
+		}
+	}
+
+	fs.position += elapsed
+	fs.rtpBase += elapsed
+}
+
+func (fs *ServerFileStream) runSender(
+	ctx context.Context,
+	done chan struct{},
+	startTime time.Time,
+	position time.Duration,
+	rtpBase time.Duration,
+) {
+	defer close(done)
+
+	var t *time.Timer
+	defer func() {
+		if t != nil {
+			t.Stop()
+		}
+	}()
+
+	for {
+		// pick the sample with the lowest DTS
+		var next *serverFileTrack
+		var nextTrackID int
+		for trackID, ft := range fs.tracks {
+			if ft.nextSample >= len(ft.mp4Track.Samples) {
+				continue
+			}
+
+			if next == nil || ft.mp4Track.Samples[ft.nextSample].DTS <
+				next.mp4Track.Samples[next.nextSample].DTS {
+				next = ft
+				nextTrackID = trackID
+			}
+		}
+
+		if next == nil {
+			return
+		}
+
+		sample := next.mp4Track.Samples[next.nextSample]
+
+		wait := time.Until(startTime.Add(sample.DTS - position))
+		if wait > 0 {
+			if t == nil {
+				t = time.NewTimer(wait)
+			} else {
+				t.Reset(wait)
+			}
+
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+
+		err := fs.sendSample(nextTrackID, next, sample, rtpBase+sample.PTS-position)
+		if err != nil {
+			// stop sending, the client will close the session
+			// since packets are not received anymore.
+			return
+		}
+
+		next.nextSample++
+	}
+}
+
+func (fs *ServerFileStream) sendSample(
+	trackID int,
+	ft *serverFileTrack,
+	sample *mp4.Sample,
+	pts time.Duration,
+) error {
+	buf := make([]byte, sample.Size)
+	_, err := fs.f.ReadAt(buf, sample.Offset)
+	if err != nil {
+		return err
+	}
+
+	var pkts []*rtp.Packet
+
+	if ft.h264Encoder != nil {
+		nalus, err := h264.DecodeAVCC(buf)
+		if err != nil {
+			return err
+		}
+
+		// send SPS and PPS with every IDR, since the reader can join
+		// at any sync sample.
+		if sample.IsSync {
+			nalus = append([][]byte{ft.mp4Track.SPS, ft.mp4Track.PPS}, nalus...)
+		}
+
+		pkts, err = ft.h264Encoder.Encode(nalus, pts)
+		if err != nil {
+			return err
+		}
+	} else {
+		pkts, err = ft.aacEncoder.Encode([][]byte{buf}, pts)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pkt := range pkts {
+		byts, err := pkt.Marshal()
+		if err != nil {
+			return err
+		}
+
+		fs.stream.WritePacketRTP(trackID, byts)
+		ft.nextSeq = pkt.SequenceNumber + 1
+	}
+
+	return nil
+}
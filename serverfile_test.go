@@ -0,0 +1,216 @@
+package gortsplib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/fmp4"
+	"github.com/aler9/gortsplib/pkg/headers"
+)
+
+// writeTestServerFile writes a MP4 file with 3 GOPs of 1 second each.
+func writeTestServerFile(t *testing.T, path string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w, err := fmp4.NewWriter(f,
+		&fmp4.VideoTrack{SPS: testRecorderSPS, PPS: testRecorderPPS},
+		&fmp4.AudioTrack{Config: &aac.MPEG4AudioConfig{
+			Type:         aac.MPEG4AudioTypeAACLC,
+			SampleRate:   48000,
+			ChannelCount: 2,
+		}})
+	require.NoError(t, err)
+
+	audioPTS := time.Duration(0)
+
+	for i := 0; i < 3*25; i++ {
+		pts := time.Duration(i) * 40 * time.Millisecond
+
+		nalu := []byte{0x41, 0x9a, 0x30}
+		if (i % 25) == 0 {
+			nalu = []byte{0x65, 0x88, 0x86}
+		}
+
+		err := w.WriteH264(pts, [][]byte{nalu})
+		require.NoError(t, err)
+
+		for audioPTS <= pts {
+			err := w.WriteAAC(audioPTS, []byte{0x01, 0x02, 0x03, 0x04})
+			require.NoError(t, err)
+			audioPTS += 1024 * time.Second / 48000
+		}
+	}
+
+	err = w.Close()
+	require.NoError(t, err)
+}
+
+func TestServerFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gortsplib-serverfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fpath := filepath.Join(dir, "test.mp4")
+	writeTestServerFile(t, fpath)
+
+	sf, err := OpenServerFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(sf.Tracks()))
+	require.Equal(t, 3*time.Second, sf.Duration())
+
+	var fs *ServerFileStream
+
+	s := &Server{
+		RTSPAddress: "localhost:8558",
+		Handler: &testServerHandler{
+			onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+				if fs != nil {
+					fs.Close()
+				}
+			},
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, NewServerStream(sf.Tracks()), nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				if fs == nil {
+					var err error
+					fs, err = sf.NewStream()
+					if err != nil {
+						return &base.Response{
+							StatusCode: base.StatusInternalServerError,
+						}, nil, err
+					}
+				}
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, fs.Stream(), nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return fs.Play(ctx)
+			},
+			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				return fs.Pause(ctx)
+			},
+		},
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	videoPkts := make(chan *rtp.Packet, 1024)
+
+	c := Client{
+		Transport: func() *Transport {
+			v := TransportTCP
+			return &v
+		}(),
+		OnPacketRTP: func(trackID int, payload []byte) {
+			if trackID == 0 {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(payload)
+				require.NoError(t, err)
+				videoPkts <- &pkt
+			}
+		},
+	}
+
+	err = c.Start("rtsp", "localhost:8558")
+	require.NoError(t, err)
+	defer c.Close()
+
+	u, err := base.ParseURL("rtsp://localhost:8558/vod")
+	require.NoError(t, err)
+
+	tracks, baseURL, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	for _, track := range tracks {
+		_, err := c.Setup(true, baseURL, track, 0, 0)
+		require.NoError(t, err)
+	}
+
+	readRTPInfo := func(res *base.Response) headers.RTPInfo {
+		var ri headers.RTPInfo
+		err := ri.Read(res.Header["RTP-Info"])
+		require.NoError(t, err)
+		require.Equal(t, 2, len(ri))
+		require.Equal(t, "rtsp://localhost:8558/vod/trackID=0", ri[0].URL)
+		return ri
+	}
+
+	// play from the keyframe that precedes the requested position
+	res, err := c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: headers.RangeNPTTime(1500 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.HeaderValue{"npt=1-3"}, res.Header["Range"])
+
+	ri := readRTPInfo(res)
+	pkt := <-videoPkts
+	require.Equal(t, *ri[0].SequenceNumber, pkt.SequenceNumber)
+	require.Equal(t, *ri[0].Timestamp, pkt.Timestamp)
+	firstTimestamp := pkt.Timestamp
+
+	time.Sleep(300 * time.Millisecond)
+
+	_, err = c.Pause()
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	var last *rtp.Packet
+	for len(videoPkts) > 0 {
+		last = <-videoPkts
+	}
+	require.NotNil(t, last)
+
+	// samples are sent in real time
+	require.InDelta(t, 300*90, float64(last.Timestamp-firstTimestamp), 50*90)
+
+	time.Sleep(200 * time.Millisecond)
+
+	// seek to the beginning: RTP timestamps and sequence numbers are continuous
+	res, err = c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: headers.RangeNPTTime(0),
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, base.HeaderValue{"npt=0-3"}, res.Header["Range"])
+
+	ri = readRTPInfo(res)
+	require.Equal(t, last.SequenceNumber+1, *ri[0].SequenceNumber)
+	require.Less(t, *ri[0].Timestamp-last.Timestamp, uint32(100*90))
+
+	pkt = <-videoPkts
+	require.Equal(t, last.SequenceNumber+1, pkt.SequenceNumber)
+	require.Equal(t, *ri[0].Timestamp, pkt.Timestamp)
+
+	// seek beyond the end of the file
+	_, err = c.Pause()
+	require.NoError(t, err)
+
+	_, err = c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: headers.RangeNPTTime(10 * time.Second),
+		},
+	})
+	require.EqualError(t, err, "bad status code: 457 (Invalid Range)")
+}
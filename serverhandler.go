@@ -107,6 +107,8 @@ type ServerHandlerOnSetup interface {
 	// the stream is needed to
 	// - add the session the the stream's readers
 	// - send the stream SSRC to the session
+	// when the session is publishing, the stream is optional. If it is provided,
+	// keyframe requests of the stream's readers are forwarded to the session.
 	OnSetup(*ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error)
 }
 
@@ -199,6 +201,23 @@ type ServerHandlerOnPacketRTCPCtx struct {
 }
 
 // ServerHandlerOnPacketRTCP can be implemented by a ServerHandler.
+// It is called with RTCP packets sent by both publishers and readers.
+// Keyframe requests (PLI and FIR) sent by readers are forwarded automatically
+// to the publisher whose OnSetup returned the stream; they can also be
+// forwarded manually with ServerSession.ForwardKeyframeRequest.
 type ServerHandlerOnPacketRTCP interface {
 	OnPacketRTCP(*ServerHandlerOnPacketRTCPCtx)
 }
+
+// ServerHandlerOnPacketsLostCtx is the context of lost RTP packets.
+type ServerHandlerOnPacketsLostCtx struct {
+	Session *ServerSession
+	TrackID int
+	Count   int
+}
+
+// ServerHandlerOnPacketsLost can be implemented by a ServerHandler.
+// It is called when the jitter buffer detects that RTP packets are lost.
+type ServerHandlerOnPacketsLost interface {
+	OnPacketsLost(*ServerHandlerOnPacketsLostCtx)
+}
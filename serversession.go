@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -18,6 +19,9 @@ import (
 
 const (
 	serverSessionCheckStreamPeriod = 1 * time.Second
+
+	// minimum interval between keyframe requests forwarded to a publisher.
+	serverSessionKeyframeRequestPeriod = 500 * time.Millisecond
 )
 
 func stringsReverseIndex(s, substr string) int {
@@ -149,6 +153,7 @@ type ServerSessionSetuppedTrack struct {
 type ServerSessionAnnouncedTrack struct {
 	track        *Track
 	rtcpReceiver *rtcpreceiver.RTCPReceiver
+	jitterBuffer *udpJitterBuffer
 }
 
 // ServerSession is a server-side RTSP session.
@@ -166,6 +171,7 @@ type ServerSession struct {
 	setuppedTransport       *Transport
 	setuppedBaseURL         *base.URL     // publish
 	setuppedStream          *ServerStream // read
+	publishedStream         *ServerStream // publish
 	setuppedPath            *string
 	setuppedQuery           *string
 	lastRequestTime         time.Time
@@ -174,6 +180,8 @@ type ServerSession struct {
 	udpLastFrameTime        *int64                        // publish, udp
 	checkStreamTimer        *time.Timer
 	receiverReportTimer     *time.Timer
+	keyframeRequestMutex    sync.Mutex
+	lastKeyframeRequests    map[int]time.Time // publish
 
 	// in
 	request    chan sessionRequestReq
@@ -360,6 +368,7 @@ func (ss *ServerSession) run() {
 		if *ss.setuppedTransport == TransportUDP {
 			ss.s.udpRTPListener.removeClient(ss)
 			ss.s.udpRTCPListener.removeClient(ss)
+			ss.closeJitterBuffers()
 		}
 	}
 
@@ -367,6 +376,10 @@ func (ss *ServerSession) run() {
 		ss.setuppedStream.readerRemove(ss)
 	}
 
+	if ss.publishedStream != nil {
+		ss.publishedStream.publisherRemove(ss)
+	}
+
 	for sc := range ss.conns {
 		if sc == ss.tcpConn {
 			sc.Close()
@@ -690,6 +703,12 @@ func (ss *ServerSession) handleRequest(sc *ServerConn, req *base.Request) (*base
 			ss.setuppedStream = stream
 		}
 
+		// forward keyframe requests of the stream's readers to the publisher
+		if ss.state == ServerSessionStatePrePublish && stream != nil && ss.publishedStream == nil {
+			stream.publisherSet(ss)
+			ss.publishedStream = stream
+		}
+
 		th := headers.Transport{}
 
 		if ss.state == ServerSessionStatePreRead {
@@ -816,7 +835,7 @@ func (ss *ServerSession) handleRequest(sc *ServerConn, req *base.Request) (*base
 			ss.tcpConn = sc
 		}
 
-		// add RTP-Info
+		// add RTP-Info, unless it has been provided by the handler
 		var trackIDs []int
 		for trackID := range ss.setuppedTracks {
 			trackIDs = append(trackIDs, trackID)
@@ -846,7 +865,7 @@ func (ss *ServerSession) handleRequest(sc *ServerConn, req *base.Request) (*base
 				Timestamp:      &ts,
 			})
 		}
-		if len(ri) > 0 {
+		if _, ok := res.Header["RTP-Info"]; !ok && len(ri) > 0 {
 			if res.Header == nil {
 				res.Header = make(base.Header)
 			}
@@ -934,6 +953,10 @@ func (ss *ServerSession) handleRequest(sc *ServerConn, req *base.Request) (*base
 
 		switch *ss.setuppedTransport {
 		case TransportUDP:
+			if ss.s.JitterBufferLatency > 0 {
+				ss.createJitterBuffers()
+			}
+
 			for trackID, track := range ss.setuppedTracks {
 				ss.s.udpRTPListener.addClient(ss.author.ip(), track.udpRTPPort, ss, trackID, true)
 				ss.s.udpRTCPListener.addClient(ss.author.ip(), track.udpRTCPPort, ss, trackID, true)
@@ -1018,6 +1041,7 @@ func (ss *ServerSession) handleRequest(sc *ServerConn, req *base.Request) (*base
 			case TransportUDP:
 				ss.s.udpRTPListener.removeClient(ss)
 				ss.s.udpRTCPListener.removeClient(ss)
+				ss.closeJitterBuffers()
 
 			case TransportUDPMulticast:
 
@@ -1069,6 +1093,82 @@ func (ss *ServerSession) handleRequest(sc *ServerConn, req *base.Request) (*base
 	}, liberrors.ErrServerUnhandledRequest{Req: req}
 }
 
+func (ss *ServerSession) createJitterBuffers() {
+	for trackID := range ss.announcedTracks {
+		cTrackID := trackID
+
+		ss.announcedTracks[trackID].jitterBuffer = newUDPJitterBuffer(
+			ss.s.JitterBufferLatency,
+			func(payload []byte) {
+				if h, ok := ss.s.Handler.(ServerHandlerOnPacketRTP); ok {
+					h.OnPacketRTP(&ServerHandlerOnPacketRTPCtx{
+						Session: ss,
+						TrackID: cTrackID,
+						Payload: payload,
+					})
+				}
+			},
+			func(count int) {
+				if h, ok := ss.s.Handler.(ServerHandlerOnPacketsLost); ok {
+					h.OnPacketsLost(&ServerHandlerOnPacketsLostCtx{
+						Session: ss,
+						TrackID: cTrackID,
+						Count:   count,
+					})
+				}
+			})
+	}
+}
+
+func (ss *ServerSession) closeJitterBuffers() {
+	for trackID, track := range ss.announcedTracks {
+		if track.jitterBuffer != nil {
+			track.jitterBuffer.close()
+			ss.announcedTracks[trackID].jitterBuffer = nil
+		}
+	}
+}
+
+// ForwardKeyframeRequest forwards the Picture Loss Indications (PLI) and
+// Full Intra Requests (FIR) contained in a RTCP packet, that is usually received
+// from a reader, to the session, that must be publishing.
+// SSRCs are rewritten in order to match the publisher, and requests are rate-limited.
+func (ss *ServerSession) ForwardKeyframeRequest(trackID int, payload []byte) {
+	if trackID < 0 || trackID >= len(ss.announcedTracks) {
+		return
+	}
+
+	pli, fir := keyframeRequestTypes(payload)
+	if !pli && !fir {
+		return
+	}
+
+	ss.keyframeRequestMutex.Lock()
+	defer ss.keyframeRequestMutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(ss.lastKeyframeRequests[trackID]) < serverSessionKeyframeRequestPeriod {
+		return
+	}
+
+	if ss.lastKeyframeRequests == nil {
+		ss.lastKeyframeRequests = make(map[int]time.Time)
+	}
+	ss.lastKeyframeRequests[trackID] = now
+
+	rr := ss.announcedTracks[trackID].rtcpReceiver
+	var byts []byte
+
+	if pli {
+		byts = append(byts, rr.PictureLossIndication()...)
+	}
+	if fir {
+		byts = append(byts, rr.FullIntraRequest()...)
+	}
+
+	ss.WritePacketRTCP(trackID, byts)
+}
+
 // WritePacketRTP writes a RTP packet to the session.
 func (ss *ServerSession) WritePacketRTP(trackID int, payload []byte) {
 	if _, ok := ss.setuppedTracks[trackID]; !ok {
@@ -7,7 +7,19 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/pion/rtcp"
+	psdp "github.com/pion/sdp/v3"
+
 	"github.com/aler9/gortsplib/pkg/liberrors"
+	"github.com/aler9/gortsplib/pkg/rtcpsender"
+)
+
+const (
+	// number of RTP packets per track that are kept in order to be
+	// retransmitted when readers send RTCP NACKs.
+	serverStreamHistorySize = 512
+
+	serverStreamDefaultSenderReportPeriod = 10 * time.Second
 )
 
 type listenerPair struct {
@@ -15,11 +27,39 @@ type listenerPair struct {
 	rtcpListener *serverUDPListener
 }
 
+// rtpHistory contains the last RTP packets of a track.
+type rtpHistory struct {
+	mutex   sync.Mutex
+	packets [serverStreamHistorySize][]byte
+}
+
+func (h *rtpHistory) push(sequenceNumber uint16, payload []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	i := sequenceNumber % serverStreamHistorySize
+	h.packets[i] = append(h.packets[i][:0], payload...)
+}
+
+func (h *rtpHistory) get(sequenceNumber uint16) []byte {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	pkt := h.packets[sequenceNumber%serverStreamHistorySize]
+	if len(pkt) < 4 || binary.BigEndian.Uint16(pkt[2:4]) != sequenceNumber {
+		return nil
+	}
+
+	// packets are written asynchronously, therefore they must be copied
+	return append([]byte(nil), pkt...)
+}
+
 type trackInfo struct {
 	lastSequenceNumber uint32
 	lastTimeRTP        uint32
 	lastTimeNTP        int64
 	lastSSRC           uint32
+	history            rtpHistory
 }
 
 // ServerStream represents a single stream.
@@ -27,15 +67,30 @@ type trackInfo struct {
 // - distributing the stream to each reader
 // - allocating multicast listeners
 // - gathering infos about the stream to generate SSRC and RTP-Info
+// - retransmitting RTP packets requested by readers with RTCP NACKs.
+//   Since only readers that use the UDP transport can request retransmissions,
+//   RTP packets are stored only while there's at least one of these readers.
+// - generating RTCP sender reports, if enabled
 type ServerStream struct {
 	s      *Server
 	tracks Tracks
 
 	mutex              sync.RWMutex
 	readersUnicast     map[*ServerSession]struct{}
+	readersUDP         int
 	readers            map[*ServerSession]struct{}
 	multicastListeners []*listenerPair
+	recorders          map[*ServerStreamRecorder]struct{}
 	trackInfos         []*trackInfo
+	rtcpSenders        []*rtcpsender.RTCPSender
+	publisher          *ServerSession
+
+	senderReportTerminate chan struct{}
+	senderReportDone      chan struct{}
+	senderReportCloseOnce sync.Once
+
+	// called when a reader starts reading.
+	onReaderSetActive func()
 }
 
 // NewServerStream allocates a ServerStream.
@@ -43,10 +98,21 @@ func NewServerStream(tracks Tracks) *ServerStream {
 	st := &ServerStream{
 		readersUnicast: make(map[*ServerSession]struct{}),
 		readers:        make(map[*ServerSession]struct{}),
+		recorders:      make(map[*ServerStreamRecorder]struct{}),
 	}
 
 	st.tracks = cloneAndClearTracks(tracks)
 
+	// advertise support for retransmissions
+	for _, track := range st.tracks {
+		if !track.hasRTCPFeedback("nack") {
+			track.Media.Attributes = append(track.Media.Attributes, psdp.Attribute{
+				Key:   "rtcp-fb",
+				Value: "* nack",
+			})
+		}
+	}
+
 	st.trackInfos = make([]*trackInfo, len(tracks))
 	for i := range st.trackInfos {
 		st.trackInfos[i] = &trackInfo{}
@@ -55,8 +121,81 @@ func NewServerStream(tracks Tracks) *ServerStream {
 	return st
 }
 
+// EnableSenderReports enables the generation of RTCP sender reports,
+// that are sent periodically to every reader, and allow readers to
+// synchronize tracks and to compute the absolute time of packets.
+// Sender reports written with WritePacketRTCP are discarded,
+// in order to keep SSRCs and NTP times consistent.
+// Tracks whose clock rate is unknown are skipped.
+// If period is zero, it defaults to 10 seconds.
+func (st *ServerStream) EnableSenderReports(period time.Duration) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.rtcpSenders != nil {
+		return
+	}
+
+	if period == 0 {
+		period = serverStreamDefaultSenderReportPeriod
+	}
+
+	st.rtcpSenders = make([]*rtcpsender.RTCPSender, len(st.tracks))
+	for trackID, track := range st.tracks {
+		clockRate, err := track.ClockRate()
+		if err != nil || clockRate <= 0 {
+			continue
+		}
+
+		st.rtcpSenders[trackID] = rtcpsender.New(clockRate)
+	}
+
+	st.senderReportTerminate = make(chan struct{})
+	st.senderReportDone = make(chan struct{})
+	go st.runSenderReports(period)
+}
+
+func (st *ServerStream) runSenderReports(period time.Duration) {
+	defer close(st.senderReportDone)
+
+	t := time.NewTicker(period)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			now := time.Now()
+			for trackID, rs := range st.rtcpSenders {
+				if rs == nil {
+					continue
+				}
+
+				sr := rs.Report(now)
+				if sr != nil {
+					st.writePacketRTCPToReaders(trackID, sr)
+				}
+			}
+
+		case <-st.senderReportTerminate:
+			return
+		}
+	}
+}
+
 // Close closes a ServerStream.
 func (st *ServerStream) Close() error {
+	st.mutex.RLock()
+	senderReportsEnabled := st.senderReportTerminate != nil
+	st.mutex.RUnlock()
+
+	// stop sender reports before locking the mutex, since they lock it too
+	if senderReportsEnabled {
+		st.senderReportCloseOnce.Do(func() {
+			close(st.senderReportTerminate)
+		})
+		<-st.senderReportDone
+	}
+
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
@@ -190,18 +329,43 @@ func (st *ServerStream) readerRemove(ss *ServerSession) {
 
 func (st *ServerStream) readerSetActive(ss *ServerSession) {
 	st.mutex.Lock()
-	defer st.mutex.Unlock()
 
 	switch *ss.setuppedTransport {
 	case TransportUDP, TransportTCP:
 		st.readersUnicast[ss] = struct{}{}
 
+		if *ss.setuppedTransport == TransportUDP {
+			st.readersUDP++
+		}
+
+		// send sender reports immediately, in order to allow the reader
+		// to synchronize tracks without waiting for the next period.
+		if st.rtcpSenders != nil {
+			now := time.Now()
+			for trackID := range ss.setuppedTracks {
+				if st.rtcpSenders[trackID] == nil {
+					continue
+				}
+
+				sr := st.rtcpSenders[trackID].Report(now)
+				if sr != nil {
+					ss.WritePacketRTCP(trackID, sr)
+				}
+			}
+		}
+
 	default: // UDPMulticast
 		for trackID := range ss.setuppedTracks {
 			st.multicastListeners[trackID].rtcpListener.addClient(
 				ss.author.ip(), st.multicastListeners[trackID].rtcpListener.port(), ss, trackID, false)
 		}
 	}
+
+	st.mutex.Unlock()
+
+	if st.onReaderSetActive != nil {
+		st.onReaderSetActive()
+	}
 }
 
 func (st *ServerStream) readerSetInactive(ss *ServerSession) {
@@ -210,6 +374,10 @@ func (st *ServerStream) readerSetInactive(ss *ServerSession) {
 
 	switch *ss.setuppedTransport {
 	case TransportUDP, TransportTCP:
+		if _, ok := st.readersUnicast[ss]; ok && *ss.setuppedTransport == TransportUDP {
+			st.readersUDP--
+		}
+
 		delete(st.readersUnicast, ss)
 
 	default: // UDPMulticast
@@ -221,6 +389,20 @@ func (st *ServerStream) readerSetInactive(ss *ServerSession) {
 	}
 }
 
+func (st *ServerStream) recorderAdd(r *ServerStreamRecorder) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.recorders[r] = struct{}{}
+}
+
+func (st *ServerStream) recorderRemove(r *ServerStreamRecorder) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	delete(st.recorders, r)
+}
+
 // WritePacketRTP writes a RTP packet to all the readers of the stream.
 func (st *ServerStream) WritePacketRTP(trackID int, payload []byte) {
 	if len(payload) >= 8 {
@@ -240,11 +422,28 @@ func (st *ServerStream) WritePacketRTP(trackID int, payload []byte) {
 	st.mutex.RLock()
 	defer st.mutex.RUnlock()
 
+	if st.rtcpSenders != nil && st.rtcpSenders[trackID] != nil {
+		st.rtcpSenders[trackID].ProcessPacketRTP(time.Now(), payload)
+	}
+
+	// store packets only when they can be requested by readers,
+	// that is, when there's at least one reader that uses the UDP transport.
+	// Packets written before the first of these readers becomes active
+	// can't be retransmitted.
+	if st.readersUDP > 0 && len(payload) >= 12 {
+		st.trackInfos[trackID].history.push(binary.BigEndian.Uint16(payload[2:4]), payload)
+	}
+
 	// send unicast
 	for r := range st.readersUnicast {
 		r.WritePacketRTP(trackID, payload)
 	}
 
+	// send to recorders
+	for r := range st.recorders {
+		r.writePacketRTP(trackID, payload)
+	}
+
 	// send multicast
 	if st.multicastListeners != nil {
 		st.multicastListeners[trackID].rtpListener.write(payload, &net.UDPAddr{
@@ -256,6 +455,53 @@ func (st *ServerStream) WritePacketRTP(trackID int, payload []byte) {
 
 // WritePacketRTCP writes a RTCP packet to all the readers of the stream.
 func (st *ServerStream) WritePacketRTCP(trackID int, payload []byte) {
+	st.mutex.RLock()
+	senderReportsEnabled := st.rtcpSenders != nil && st.rtcpSenders[trackID] != nil
+	st.mutex.RUnlock()
+
+	if senderReportsEnabled {
+		var ok bool
+		payload, ok = removeSenderReports(payload)
+		if !ok {
+			return
+		}
+	}
+
+	st.writePacketRTCPToReaders(trackID, payload)
+}
+
+// removeSenderReports removes sender reports from a compound RTCP packet.
+// It returns false if nothing is left.
+func removeSenderReports(payload []byte) ([]byte, bool) {
+	packets, err := rtcp.Unmarshal(payload)
+	if err != nil {
+		return payload, true
+	}
+
+	var filtered []rtcp.Packet
+	for _, pkt := range packets {
+		if _, ok := pkt.(*rtcp.SenderReport); !ok {
+			filtered = append(filtered, pkt)
+		}
+	}
+
+	if len(filtered) == len(packets) {
+		return payload, true
+	}
+
+	if len(filtered) == 0 {
+		return nil, false
+	}
+
+	byts, err := rtcp.Marshal(filtered)
+	if err != nil {
+		return nil, false
+	}
+
+	return byts, true
+}
+
+func (st *ServerStream) writePacketRTCPToReaders(trackID int, payload []byte) {
 	st.mutex.RLock()
 	defer st.mutex.RUnlock()
 
@@ -272,3 +518,57 @@ func (st *ServerStream) WritePacketRTCP(trackID int, payload []byte) {
 		})
 	}
 }
+
+func (st *ServerStream) publisherSet(ss *ServerSession) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.publisher = ss
+}
+
+func (st *ServerStream) publisherRemove(ss *ServerSession) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.publisher == ss {
+		st.publisher = nil
+	}
+}
+
+// processReaderPacketRTCP forwards keyframe requests of a reader to the publisher,
+// and retransmits the RTP packets that are requested with RTCP NACKs
+// by a reader that is using the UDP transport.
+func (st *ServerStream) processReaderPacketRTCP(ss *ServerSession, trackID int, payload []byte) {
+	st.mutex.RLock()
+	publisher := st.publisher
+	st.mutex.RUnlock()
+
+	if publisher != nil {
+		publisher.ForwardKeyframeRequest(trackID, payload)
+	}
+
+	if *ss.setuppedTransport != TransportUDP {
+		return
+	}
+
+	packets, err := rtcp.Unmarshal(payload)
+	if err != nil {
+		return
+	}
+
+	for _, pkt := range packets {
+		nack, ok := pkt.(*rtcp.TransportLayerNack)
+		if !ok {
+			continue
+		}
+
+		for _, pair := range nack.Nacks {
+			for _, seq := range pair.PacketList() {
+				byts := st.trackInfos[trackID].history.get(seq)
+				if byts != nil {
+					ss.WritePacketRTP(trackID, byts)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,529 @@
+package gortsplib
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/fmp4"
+	"github.com/aler9/gortsplib/pkg/h264"
+	"github.com/aler9/gortsplib/pkg/mpegts"
+	"github.com/aler9/gortsplib/pkg/ringbuffer"
+	"github.com/aler9/gortsplib/pkg/rtpaac"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+// RecordFormat is the format of recorded files.
+type RecordFormat int
+
+// supported record formats.
+const (
+	RecordFormatFMP4 RecordFormat = iota
+	RecordFormatMPEGTS
+)
+
+var recordFormatLabels = map[RecordFormat]string{
+	RecordFormatFMP4:   "fMP4",
+	RecordFormatMPEGTS: "MPEG-TS",
+}
+
+// String implements fmt.Stringer.
+func (f RecordFormat) String() string {
+	if l, ok := recordFormatLabels[f]; ok {
+		return l
+	}
+	return "unknown"
+}
+
+func (f RecordFormat) extension() string {
+	if f == RecordFormatMPEGTS {
+		return ".ts"
+	}
+	return ".mp4"
+}
+
+type recorderPacket struct {
+	trackID int
+	payload []byte
+}
+
+type recorderFile struct {
+	path      string
+	startTime time.Time
+	startPTS  time.Duration
+	f         *os.File
+	bw        *bufio.Writer
+	size      uint64
+
+	fmp4Writer  *fmp4.Writer
+	mpegtsMuxer *mpegts.Muxer
+}
+
+func (f *recorderFile) Write(p []byte) (int, error) {
+	n, err := f.bw.Write(p)
+	f.size += uint64(n)
+	return n, err
+}
+
+// ServerStreamRecorder records the H264 and AAC tracks of a ServerStream to disk.
+// It attaches to the stream as a virtual reader; files are rotated by
+// duration or size, and old files are deleted after a retention window.
+// Errors are reported through OnError and don't stop the stream.
+type ServerStreamRecorder struct {
+	// the stream to record.
+	Stream *ServerStream
+
+	// path of the stream, that is used to fill the %path variable of PathTemplate.
+	Path string
+
+	// format of recorded files.
+	// It defaults to RecordFormatFMP4.
+	Format RecordFormat
+
+	// template of the path of recorded files, without extension.
+	// It can contain the following variables:
+	// %path (Path), %Y %m %d %H %M %S %f (start time of the file).
+	// It defaults to "%path/%Y-%m-%d_%H-%M-%S-%f".
+	PathTemplate string
+
+	// files are rotated when their duration exceeds this value.
+	// When the stream contains a H264 track, files are rotated on the first IDR
+	// that follows.
+	// It defaults to 1 hour.
+	SegmentDuration time.Duration
+
+	// files are rotated when their size exceeds this value.
+	// It defaults to 0 (disabled).
+	SegmentMaxSize uint64
+
+	// files whose start time is older than this value are deleted.
+	// Only files created by the recorder are considered; expired files
+	// are deleted when files are rotated and when the recorder is closed.
+	// It defaults to 0 (disabled).
+	RetentionDuration time.Duration
+
+	// read buffer count.
+	// It allows to queue packets while files are being written.
+	// It defaults to 512.
+	ReadBufferCount int
+
+	// called when an error occurs.
+	OnError func(error)
+
+	//
+	// private
+	//
+
+	h264TrackID int
+	h264Conf    *TrackConfigH264
+	h264Decoder *rtph264.Decoder
+	aacTrackID  int
+	aacConf     *TrackConfigAAC
+	aacDecoder  *rtpaac.Decoder
+	tsEncoder   *mpegts.TimestampEncoder
+
+	ringBuffer *ringbuffer.RingBuffer
+	wg         sync.WaitGroup
+	startTime  time.Time
+	started    bool
+	firstPTS   time.Duration
+	lastPTS    time.Duration
+	cur        *recorderFile
+	files      []*recorderFile
+}
+
+// Start starts the recorder.
+func (r *ServerStreamRecorder) Start() error {
+	if r.Stream == nil {
+		return fmt.Errorf("stream is missing")
+	}
+
+	if r.PathTemplate == "" {
+		r.PathTemplate = "%path/%Y-%m-%d_%H-%M-%S-%f"
+	}
+	if r.SegmentDuration == 0 {
+		r.SegmentDuration = 1 * time.Hour
+	}
+	if r.ReadBufferCount == 0 {
+		r.ReadBufferCount = 512
+	}
+	if r.OnError == nil {
+		r.OnError = func(error) {}
+	}
+
+	switch r.Format {
+	case RecordFormatFMP4, RecordFormatMPEGTS:
+	default:
+		return fmt.Errorf("invalid record format (%d)", r.Format)
+	}
+
+	r.h264TrackID = -1
+	r.aacTrackID = -1
+
+	for i, t := range r.Stream.Tracks() {
+		switch {
+		case t.IsH264() && r.h264TrackID < 0:
+			conf, err := t.ExtractConfigH264()
+			if err != nil {
+				return err
+			}
+
+			r.h264TrackID = i
+			r.h264Conf = conf
+			r.h264Decoder = rtph264.NewDecoder()
+
+		case t.IsAAC() && r.aacTrackID < 0:
+			conf, err := t.ExtractConfigAAC()
+			if err != nil {
+				return err
+			}
+
+			r.aacTrackID = i
+			r.aacConf = conf
+			r.aacDecoder = rtpaac.NewDecoder(conf.SampleRate)
+		}
+	}
+
+	if r.h264TrackID < 0 && r.aacTrackID < 0 {
+		return fmt.Errorf("the stream doesn't contain any H264 or AAC track")
+	}
+
+	if r.Format == RecordFormatMPEGTS {
+		var videoTrack *mpegts.VideoTrack
+		if r.h264TrackID >= 0 {
+			videoTrack = &mpegts.VideoTrack{SPS: r.h264Conf.SPS, PPS: r.h264Conf.PPS}
+		}
+
+		var err error
+		r.tsEncoder, err = mpegts.NewTimestampEncoder(videoTrack)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.startTime = time.Now()
+	r.ringBuffer = ringbuffer.New(uint64(r.ReadBufferCount))
+
+	r.wg.Add(1)
+	go r.run()
+
+	r.Stream.recorderAdd(r)
+
+	return nil
+}
+
+// Close stops the recorder and closes the current file.
+func (r *ServerStreamRecorder) Close() {
+	// the recorder has not been started
+	if r.ringBuffer == nil {
+		return
+	}
+
+	r.Stream.recorderRemove(r)
+	r.ringBuffer.Close()
+	r.wg.Wait()
+
+	if r.cur != nil {
+		err := r.closeFile()
+		if err != nil {
+			r.OnError(err)
+		}
+
+		// the end time of the recording is computed from timestamps,
+		// like the start time of files.
+		r.deleteExpiredFiles(r.startTime.Add(r.lastPTS - r.firstPTS))
+	}
+}
+
+// writePacketRTP is called by the ServerStream.
+func (r *ServerStreamRecorder) writePacketRTP(trackID int, payload []byte) {
+	if trackID != r.h264TrackID && trackID != r.aacTrackID {
+		return
+	}
+
+	r.ringBuffer.Push(recorderPacket{
+		trackID: trackID,
+		payload: append([]byte(nil), payload...),
+	})
+}
+
+func (r *ServerStreamRecorder) run() {
+	defer r.wg.Done()
+
+	for {
+		tmp, ok := r.ringBuffer.Pull()
+		if !ok {
+			return
+		}
+		pkt := tmp.(recorderPacket)
+
+		err := r.processPacket(pkt.trackID, pkt.payload)
+		if err != nil {
+			r.OnError(err)
+		}
+	}
+}
+
+func (r *ServerStreamRecorder) processPacket(trackID int, payload []byte) error {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if trackID == r.h264TrackID {
+		nalus, pts, err := r.h264Decoder.DecodeUntilMarker(&pkt)
+		if err != nil {
+			if err == rtph264.ErrMorePacketsNeeded ||
+				err == rtph264.ErrNonStartingPacketAndNoPrevious {
+				return nil
+			}
+			return err
+		}
+
+		return r.writeH264(pts, nalus)
+	}
+
+	aus, pts, err := r.aacDecoder.Decode(&pkt)
+	if err != nil {
+		if err == rtpaac.ErrMorePacketsNeeded {
+			return nil
+		}
+		return err
+	}
+
+	return r.writeAAC(pts, aus)
+}
+
+// shouldRotate returns whether the current file must be rotated.
+// It must be called on random access points only.
+func (r *ServerStreamRecorder) shouldRotate(pts time.Duration) bool {
+	return (pts-r.cur.startPTS) >= r.SegmentDuration ||
+		(r.SegmentMaxSize != 0 && r.cur.size >= r.SegmentMaxSize)
+}
+
+func (r *ServerStreamRecorder) writeH264(pts time.Duration, nalus [][]byte) error {
+	idr := false
+	for _, nalu := range nalus {
+		if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeIDR {
+			idr = true
+			break
+		}
+	}
+
+	if r.cur == nil {
+		// wait for the first IDR
+		if !idr {
+			return nil
+		}
+
+		err := r.openFile(pts)
+		if err != nil {
+			return err
+		}
+	} else if idr && r.shouldRotate(pts) {
+		err := r.rotate(pts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pts > r.lastPTS {
+		r.lastPTS = pts
+	}
+
+	if r.Format == RecordFormatFMP4 {
+		return r.cur.fmp4Writer.WriteH264(pts, nalus)
+	}
+
+	pts, dts, err := r.tsEncoder.EncodeH264(pts, nalus)
+	if err != nil {
+		return err
+	}
+
+	return r.cur.mpegtsMuxer.WriteH264(pts, dts, nalus)
+}
+
+func (r *ServerStreamRecorder) writeAAC(pts time.Duration, aus [][]byte) error {
+	if r.h264TrackID >= 0 {
+		// wait for the first IDR
+		if r.cur == nil {
+			return nil
+		}
+	} else {
+		if r.cur == nil {
+			err := r.openFile(pts)
+			if err != nil {
+				return err
+			}
+		} else if r.shouldRotate(pts) {
+			err := r.rotate(pts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if pts > r.lastPTS {
+		r.lastPTS = pts
+	}
+
+	if r.Format == RecordFormatFMP4 {
+		for i, au := range aus {
+			err := r.cur.fmp4Writer.WriteAAC(
+				pts+time.Duration(i)*1024*time.Second/time.Duration(r.aacConf.SampleRate), au)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return r.cur.mpegtsMuxer.WriteAAC(r.tsEncoder.EncodeAAC(pts), aus)
+}
+
+func (r *ServerStreamRecorder) filePath(t time.Time) string {
+	return strings.NewReplacer(
+		"%path", r.Path,
+		"%Y", strconv.FormatInt(int64(t.Year()), 10),
+		"%m", fmt.Sprintf("%02d", int(t.Month())),
+		"%d", fmt.Sprintf("%02d", t.Day()),
+		"%H", fmt.Sprintf("%02d", t.Hour()),
+		"%M", fmt.Sprintf("%02d", t.Minute()),
+		"%S", fmt.Sprintf("%02d", t.Second()),
+		"%f", fmt.Sprintf("%06d", t.Nanosecond()/1000),
+	).Replace(r.PathTemplate) + r.Format.extension()
+}
+
+func (r *ServerStreamRecorder) openFile(pts time.Duration) error {
+	if !r.started {
+		r.started = true
+		r.firstPTS = pts
+	}
+
+	// the start time of files is computed from timestamps,
+	// in order to be consistent with their content.
+	startTime := r.startTime.Add(pts - r.firstPTS)
+	path := r.filePath(startTime)
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	rf := &recorderFile{
+		path:      path,
+		startTime: startTime,
+		startPTS:  pts,
+		f:         f,
+		bw:        bufio.NewWriter(f),
+	}
+
+	var videoTrack *fmp4.VideoTrack
+	var audioTrack *fmp4.AudioTrack
+
+	if r.Format == RecordFormatFMP4 {
+		if r.h264TrackID >= 0 {
+			videoTrack = &fmp4.VideoTrack{SPS: r.h264Conf.SPS, PPS: r.h264Conf.PPS}
+		}
+		if r.aacTrackID >= 0 {
+			audioTrack = &fmp4.AudioTrack{Config: r.aacConf.MPEG4AudioConfig()}
+		}
+
+		rf.fmp4Writer, err = fmp4.NewWriter(rf, videoTrack, audioTrack)
+	} else {
+		var videoTrack *mpegts.VideoTrack
+		var audioTrack *mpegts.AudioTrack
+
+		if r.h264TrackID >= 0 {
+			videoTrack = &mpegts.VideoTrack{SPS: r.h264Conf.SPS, PPS: r.h264Conf.PPS}
+		}
+		if r.aacTrackID >= 0 {
+			audioTrack = &mpegts.AudioTrack{Config: r.aacConf.MPEG4AudioConfig()}
+		}
+
+		rf.mpegtsMuxer, err = mpegts.NewMuxer(rf, videoTrack, audioTrack)
+	}
+
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	r.cur = rf
+	r.files = append(r.files, rf)
+
+	return nil
+}
+
+func (r *ServerStreamRecorder) closeFile() error {
+	var err error
+	if r.cur.fmp4Writer != nil {
+		err = r.cur.fmp4Writer.Close()
+	}
+
+	err2 := r.cur.bw.Flush()
+	if err == nil {
+		err = err2
+	}
+
+	err2 = r.cur.f.Close()
+	if err == nil {
+		err = err2
+	}
+
+	r.cur = nil
+	return err
+}
+
+func (r *ServerStreamRecorder) rotate(pts time.Duration) error {
+	err := r.closeFile()
+	if err != nil {
+		r.OnError(err)
+	}
+
+	err = r.openFile(pts)
+	if err != nil {
+		return err
+	}
+
+	r.deleteExpiredFiles(r.cur.startTime)
+
+	return nil
+}
+
+func (r *ServerStreamRecorder) deleteExpiredFiles(now time.Time) {
+	if r.RetentionDuration == 0 {
+		return
+	}
+
+	limit := now.Add(-r.RetentionDuration)
+
+	// files are sorted by start time
+	n := sort.Search(len(r.files), func(i int) bool {
+		return !r.files[i].startTime.Before(limit)
+	})
+
+	for _, f := range r.files[:n] {
+		err := os.Remove(f.path)
+		if err != nil {
+			r.OnError(err)
+		}
+	}
+
+	r.files = r.files[n:]
+}
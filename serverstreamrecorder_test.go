@@ -0,0 +1,243 @@
+package gortsplib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/mpegts"
+	"github.com/aler9/gortsplib/pkg/rtpaac"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+var (
+	testRecorderSPS = []byte{0x67, 0x42, 0xc0, 0x28, 0xda, 0x01, 0xe0, 0x08, 0x9f, 0x95}
+	testRecorderPPS = []byte{0x68, 0xce, 0x3c, 0x80}
+)
+
+func newTestRecorderStream(t *testing.T, video bool, audio bool) *ServerStream {
+	var tracks Tracks
+
+	if video {
+		track, err := NewTrackH264(96, &TrackConfigH264{SPS: testRecorderSPS, PPS: testRecorderPPS})
+		require.NoError(t, err)
+		tracks = append(tracks, track)
+	}
+
+	if audio {
+		track, err := NewTrackAAC(97, &TrackConfigAAC{Type: 2, SampleRate: 48000, ChannelCount: 2})
+		require.NoError(t, err)
+		tracks = append(tracks, track)
+	}
+
+	return NewServerStream(tracks)
+}
+
+// writeTestRecorderStream writes GOPs of 1 second, with 25 frames each.
+func writeTestRecorderStream(t *testing.T, stream *ServerStream, video bool, audio bool, gopCount int) {
+	videoEnc := rtph264.NewEncoder(96, nil, nil, nil)
+	audioEnc := rtpaac.NewEncoder(97, 48000, nil, nil, nil)
+	audioPTS := time.Duration(0)
+	audioTrackID := 0
+	if video {
+		audioTrackID = 1
+	}
+
+	for i := 0; i < gopCount*25; i++ {
+		pts := time.Duration(i) * 40 * time.Millisecond
+
+		if video {
+			nalu := []byte{0x41, 0x9a, 0x30}
+			if (i % 25) == 0 {
+				nalu = []byte{0x65, 0x88, 0x86}
+			}
+
+			pkts, err := videoEnc.Encode([][]byte{nalu}, pts)
+			require.NoError(t, err)
+			for _, pkt := range pkts {
+				byts, _ := pkt.Marshal()
+				stream.WritePacketRTP(0, byts)
+			}
+		}
+
+		if audio {
+			for audioPTS <= pts {
+				pkts, err := audioEnc.Encode([][]byte{{0x01, 0x02, 0x03, 0x04}}, audioPTS)
+				require.NoError(t, err)
+				for _, pkt := range pkts {
+					byts, _ := pkt.Marshal()
+					stream.WritePacketRTP(audioTrackID, byts)
+				}
+				audioPTS += 1024 * time.Second / 48000
+			}
+		}
+
+		// avoid overflowing the read buffer
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func listRecordedFiles(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+
+	var ret []string
+	for _, info := range infos {
+		ret = append(ret, filepath.Join(dir, info.Name()))
+	}
+	return ret
+}
+
+func TestServerStreamRecorder(t *testing.T) {
+	for _, format := range []RecordFormat{
+		RecordFormatFMP4,
+		RecordFormatMPEGTS,
+	} {
+		t.Run(format.String(), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gortsplib-recorder")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			stream := newTestRecorderStream(t, true, true)
+			defer stream.Close()
+
+			r := &ServerStreamRecorder{
+				Stream:            stream,
+				Path:              "mystream",
+				Format:            format,
+				PathTemplate:      filepath.Join(dir, "%path", "%Y-%m-%d_%H-%M-%S-%f"),
+				SegmentDuration:   1 * time.Second,
+				RetentionDuration: 1500 * time.Millisecond,
+				OnError: func(err error) {
+					t.Errorf("unexpected error: %v", err)
+				},
+			}
+			err = r.Start()
+			require.NoError(t, err)
+
+			writeTestRecorderStream(t, stream, true, true, 4)
+			r.Close()
+
+			// 4 files have been created, the first 2 have been deleted when
+			// files were rotated, the third one when the recorder was closed
+			files := listRecordedFiles(t, filepath.Join(dir, "mystream"))
+			require.Equal(t, 1, len(files))
+
+			for _, f := range files {
+				require.Equal(t, format.extension(), filepath.Ext(f))
+			}
+
+			byts, err := ioutil.ReadFile(files[0])
+			require.NoError(t, err)
+
+			if format == RecordFormatFMP4 {
+				require.Equal(t, []byte("ftyp"), byts[4:8])
+				require.True(t, bytes.Contains(byts, []byte("moof")))
+			} else {
+				d := mpegts.NewDemuxer()
+				videoCount := 0
+				for len(byts) > 0 {
+					frames, err := d.Decode(byts[:mpegts.PacketSize])
+					require.NoError(t, err)
+					for _, f := range frames {
+						if f.Type == mpegts.StreamTypeH264 {
+							videoCount++
+						}
+					}
+					byts = byts[mpegts.PacketSize:]
+				}
+				for _, f := range d.Flush() {
+					if f.Type == mpegts.StreamTypeH264 {
+						videoCount++
+					}
+				}
+				require.Equal(t, 25, videoCount)
+			}
+		})
+	}
+}
+
+func TestServerStreamRecorderMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gortsplib-recorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	stream := newTestRecorderStream(t, false, true)
+	defer stream.Close()
+
+	r := &ServerStreamRecorder{
+		Stream:         stream,
+		Format:         RecordFormatMPEGTS,
+		PathTemplate:   filepath.Join(dir, "%Y-%m-%d_%H-%M-%S-%f"),
+		SegmentMaxSize: 1000,
+	}
+	err = r.Start()
+	require.NoError(t, err)
+
+	writeTestRecorderStream(t, stream, false, true, 1)
+	r.Close()
+
+	// each file contains PAT, PMT and 4 audio packets
+	files := listRecordedFiles(t, dir)
+	require.Equal(t, 12, len(files))
+
+	for _, f := range files[:len(files)-1] {
+		info, err := os.Stat(f)
+		require.NoError(t, err)
+		require.Equal(t, int64(6*mpegts.PacketSize), info.Size())
+	}
+}
+
+func TestServerStreamRecorderCloseBeforeStart(t *testing.T) {
+	stream := newTestRecorderStream(t, true, false)
+	defer stream.Close()
+
+	r := &ServerStreamRecorder{Stream: stream}
+	r.Close()
+}
+
+func TestServerStreamRecorderErrors(t *testing.T) {
+	t.Run("no supported tracks", func(t *testing.T) {
+		track, err := NewTrackOpus(96, &TrackConfigOpus{SampleRate: 48000, ChannelCount: 2})
+		require.NoError(t, err)
+
+		stream := NewServerStream(Tracks{track})
+		defer stream.Close()
+
+		r := &ServerStreamRecorder{Stream: stream}
+		err = r.Start()
+		require.EqualError(t, err, "the stream doesn't contain any H264 or AAC track")
+	})
+
+	t.Run("invalid packet", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "gortsplib-recorder")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		stream := newTestRecorderStream(t, true, false)
+		defer stream.Close()
+
+		errorRecv := make(chan error, 1)
+
+		r := &ServerStreamRecorder{
+			Stream:       stream,
+			PathTemplate: filepath.Join(dir, "%Y"),
+			OnError: func(err error) {
+				errorRecv <- err
+			},
+		}
+		err = r.Start()
+		require.NoError(t, err)
+		defer r.Close()
+
+		stream.WritePacketRTP(0, []byte{0x01, 0x02})
+
+		err = <-errorRecv
+		require.EqualError(t, err, "RTP header size insufficient: 2 < 4")
+	})
+}
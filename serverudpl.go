@@ -197,6 +197,10 @@ func (u *serverUDPListener) run() {
 				break
 			}
 
+			if u.s.Capture != nil {
+				u.s.Capture.writeUDP(addr, captureUDPAddr(u.pc.LocalAddr()), buf[:n])
+			}
+
 			func() {
 				u.clientsMutex.RLock()
 				defer u.clientsMutex.RUnlock()
@@ -229,6 +233,10 @@ func (u *serverUDPListener) run() {
 			}
 			pair := tmp.(bufAddrPair)
 
+			if u.s.Capture != nil {
+				u.s.Capture.writeUDP(captureUDPAddr(u.pc.LocalAddr()), pair.addr, pair.buf)
+			}
+
 			u.pc.SetWriteDeadline(time.Now().Add(u.writeTimeout))
 			u.pc.WriteTo(pair.buf, pair.addr)
 		}
@@ -241,7 +249,13 @@ func (u *serverUDPListener) run() {
 }
 
 func (u *serverUDPListener) processRTP(now time.Time, clientData *clientData, payload []byte) {
-	clientData.ss.announcedTracks[clientData.trackID].rtcpReceiver.ProcessPacketRTP(now, payload)
+	track := clientData.ss.announcedTracks[clientData.trackID]
+	track.rtcpReceiver.ProcessPacketRTP(now, payload)
+
+	if track.jitterBuffer != nil {
+		track.jitterBuffer.push(now, payload)
+		return
+	}
 
 	if h, ok := u.s.Handler.(ServerHandlerOnPacketRTP); ok {
 		h.OnPacketRTP(&ServerHandlerOnPacketRTPCtx{
@@ -255,6 +269,8 @@ func (u *serverUDPListener) processRTP(now time.Time, clientData *clientData, pa
 func (u *serverUDPListener) processRTCP(now time.Time, clientData *clientData, payload []byte) {
 	if clientData.isPublishing {
 		clientData.ss.announcedTracks[clientData.trackID].rtcpReceiver.ProcessPacketRTCP(now, payload)
+	} else {
+		clientData.ss.setuppedStream.processReaderPacketRTCP(clientData.ss, clientData.trackID, payload)
 	}
 
 	if h, ok := u.s.Handler.(ServerHandlerOnPacketRTCP); ok {
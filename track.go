@@ -26,6 +26,32 @@ func (t *Track) hasControlAttribute() bool {
 	return false
 }
 
+// hasRTCPFeedback checks whether the track supports a RTCP feedback
+// message, declared with an attribute like "a=rtcp-fb:* nack".
+func (t *Track) hasRTCPFeedback(typ string) bool {
+	for _, attr := range t.Media.Attributes {
+		if attr.Key != "rtcp-fb" {
+			continue
+		}
+
+		parts := strings.SplitN(attr.Value, " ", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) != typ {
+			continue
+		}
+
+		if parts[0] == "*" {
+			return true
+		}
+
+		for _, f := range t.Media.MediaName.Formats {
+			if f == parts[0] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // URL returns the track URL.
 func (t *Track) URL(contentBase *base.URL) (*base.URL, error) {
 	if contentBase == nil {
@@ -98,6 +124,12 @@ func (t *Track) ClockRate() (int, error) {
 		return 22050, nil
 	}
 
+	// MPEG-1/2 audio always uses 90khz, even with dynamic payload types
+	// https://tools.ietf.org/html/rfc3551#section-4.5.13
+	if t.IsMPEGAudio() {
+		return 90000, nil
+	}
+
 	// get clock rate from rtpmap
 	// https://tools.ietf.org/html/rfc4566
 	// a=rtpmap:<payload type> <encoding name>/<clock rate> [/<encoding parameters>]
@@ -19,14 +19,19 @@ type TrackConfigAAC struct {
 	AOTSpecificConfig []byte
 }
 
+// MPEG4AudioConfig returns the configuration as a MPEG-4 audio configuration.
+func (c *TrackConfigAAC) MPEG4AudioConfig() *aac.MPEG4AudioConfig {
+	return &aac.MPEG4AudioConfig{
+		Type:              aac.MPEG4AudioType(c.Type),
+		SampleRate:        c.SampleRate,
+		ChannelCount:      c.ChannelCount,
+		AOTSpecificConfig: c.AOTSpecificConfig,
+	}
+}
+
 // NewTrackAAC initializes an AAC track.
 func NewTrackAAC(payloadType uint8, conf *TrackConfigAAC) (*Track, error) {
-	mpegConf, err := aac.MPEG4AudioConfig{
-		Type:              aac.MPEG4AudioType(conf.Type),
-		SampleRate:        conf.SampleRate,
-		ChannelCount:      conf.ChannelCount,
-		AOTSpecificConfig: conf.AOTSpecificConfig,
-	}.Encode()
+	mpegConf, err := conf.MPEG4AudioConfig().Encode()
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +85,13 @@ func (t *Track) IsAAC() bool {
 }
 
 // ExtractConfigAAC extracts the configuration of an AAC track.
+// AAC-LATM tracks use a different payload format and must be handled
+// with ExtractConfigAACLATM.
 func (t *Track) ExtractConfigAAC() (*TrackConfigAAC, error) {
+	if t.IsAACLATM() {
+		return nil, fmt.Errorf("track is an AAC-LATM track")
+	}
+
 	v, ok := t.Media.Attribute("fmtp")
 	if !ok {
 		return nil, fmt.Errorf("fmtp attribute is missing")
@@ -0,0 +1,145 @@
+package gortsplib
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+// TrackConfigAACLATM is the configuration of an AAC-LATM (MP4A-LATM) track.
+type TrackConfigAACLATM struct {
+	// whether the StreamMuxConfig is transmitted in-band.
+	CPresent bool
+
+	// StreamMuxConfig of the stream.
+	// It is always required by NewTrackAACLATM, since it provides
+	// the sample rate and the channel count of the track.
+	// It can be nil in configurations returned by ExtractConfigAACLATM,
+	// when the StreamMuxConfig is transmitted in-band.
+	StreamMuxConfig *aac.StreamMuxConfig
+}
+
+// NewTrackAACLATM initializes an AAC-LATM track.
+func NewTrackAACLATM(payloadType uint8, conf *TrackConfigAACLATM) (*Track, error) {
+	if conf.StreamMuxConfig == nil || conf.StreamMuxConfig.Config == nil {
+		return nil, fmt.Errorf("StreamMuxConfig is missing")
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	fmtp := typ + " profile-level-id=30; " +
+		"object=" + strconv.FormatInt(int64(conf.StreamMuxConfig.Config.Type), 10) + "; "
+
+	if conf.CPresent {
+		fmtp += "cpresent=1"
+	} else {
+		enc, err := conf.StreamMuxConfig.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		fmtp += "cpresent=0; " +
+			"config=" + hex.EncodeToString(enc)
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key: "rtpmap",
+					Value: typ + " MP4A-LATM/" + strconv.FormatInt(int64(conf.StreamMuxConfig.Config.SampleRate), 10) +
+						"/" + strconv.FormatInt(int64(conf.StreamMuxConfig.Config.ChannelCount), 10),
+				},
+				{
+					Key:   "fmtp",
+					Value: fmtp,
+				},
+			},
+		},
+	}, nil
+}
+
+// IsAACLATM checks whether the track is an AAC-LATM track.
+func (t *Track) IsAACLATM() bool {
+	if t.Media.MediaName.Media != "audio" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToLower(vals[1]), "mp4a-latm/")
+}
+
+// ExtractConfigAACLATM extracts the configuration of an AAC-LATM track.
+func (t *Track) ExtractConfigAACLATM() (*TrackConfigAACLATM, error) {
+	v, ok := t.Media.Attribute("fmtp")
+	if !ok {
+		return nil, fmt.Errorf("fmtp attribute is missing")
+	}
+
+	tmp := strings.SplitN(v, " ", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid fmtp (%v)", v)
+	}
+
+	// cpresent is 1 by default
+	conf := &TrackConfigAACLATM{
+		CPresent: true,
+	}
+
+	for _, kv := range strings.Split(tmp[1], ";") {
+		kv = strings.Trim(kv, " ")
+
+		if len(kv) == 0 {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+		if len(tmp) != 2 {
+			return nil, fmt.Errorf("invalid fmtp (%v)", v)
+		}
+
+		switch strings.ToLower(tmp[0]) {
+		case "cpresent":
+			conf.CPresent = (tmp[1] != "0")
+
+		case "config":
+			enc, err := hex.DecodeString(tmp[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid AAC-LATM config (%v)", tmp[1])
+			}
+
+			var smc aac.StreamMuxConfig
+			err = smc.Decode(enc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid AAC-LATM config (%v)", tmp[1])
+			}
+
+			conf.StreamMuxConfig = &smc
+		}
+	}
+
+	if !conf.CPresent && conf.StreamMuxConfig == nil {
+		return nil, fmt.Errorf("config is missing (%v)", v)
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,244 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
+)
+
+func TestTrackAACLATMNew(t *testing.T) {
+	track, err := NewTrackAACLATM(96, &TrackConfigAACLATM{
+		StreamMuxConfig: &aac.StreamMuxConfig{
+			Config: &aac.MPEG4AudioConfig{
+				Type:         2,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			LatmBufferFullness: 255,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 MP4A-LATM/44100/2",
+				},
+				{
+					Key:   "fmtp",
+					Value: "96 profile-level-id=30; object=2; cpresent=0; config=400024203fc0",
+				},
+			},
+		},
+	}, track)
+}
+
+func TestTrackAACLATMNewInBand(t *testing.T) {
+	track, err := NewTrackAACLATM(96, &TrackConfigAACLATM{
+		CPresent: true,
+		StreamMuxConfig: &aac.StreamMuxConfig{
+			Config: &aac.MPEG4AudioConfig{
+				Type:         2,
+				SampleRate:   48000,
+				ChannelCount: 1,
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []psdp.Attribute{
+		{
+			Key:   "rtpmap",
+			Value: "96 MP4A-LATM/48000/1",
+		},
+		{
+			Key:   "fmtp",
+			Value: "96 profile-level-id=30; object=2; cpresent=1",
+		},
+	}, track.Media.Attributes)
+}
+
+func TestTrackIsAACLATM(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"standard",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4A-LATM/44100/2",
+						},
+					},
+				},
+			},
+		},
+		{
+			"lowercase",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 mp4a-latm/44100/2",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsAACLATM())
+			require.Equal(t, false, ca.track.IsAAC())
+		})
+	}
+}
+
+func TestTrackExtractConfigAACLATM(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigAACLATM
+	}{
+		{
+			"out-of-band",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4A-LATM/44100/2",
+						},
+						{
+							Key:   "fmtp",
+							Value: "96 profile-level-id=30; object=2; cpresent=0; config=400024203fc0",
+						},
+					},
+				},
+			},
+			&TrackConfigAACLATM{
+				StreamMuxConfig: &aac.StreamMuxConfig{
+					Config: &aac.MPEG4AudioConfig{
+						Type:         2,
+						SampleRate:   44100,
+						ChannelCount: 2,
+					},
+					LatmBufferFullness: 255,
+				},
+			},
+		},
+		{
+			"in-band",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4A-LATM/44100/2",
+						},
+						{
+							Key:   "fmtp",
+							Value: "96 profile-level-id=30; object=2",
+						},
+					},
+				},
+			},
+			&TrackConfigAACLATM{
+				CPresent: true,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigAACLATM()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigAACLATMErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp string
+		err  string
+	}{
+		{
+			"invalid fmtp",
+			"96",
+			"invalid fmtp (96)",
+		},
+		{
+			"fmtp without value",
+			"96 profile-level-id",
+			"invalid fmtp (96 profile-level-id)",
+		},
+		{
+			"missing config",
+			"96 profile-level-id=30; cpresent=0",
+			"config is missing (96 profile-level-id=30; cpresent=0)",
+		},
+		{
+			"invalid config",
+			"96 profile-level-id=30; cpresent=0; config=zz",
+			"invalid AAC-LATM config (zz)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4A-LATM/44100/2",
+						},
+						{
+							Key:   "fmtp",
+							Value: ca.fmtp,
+						},
+					},
+				},
+			}
+			_, err := track.ExtractConfigAACLATM()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+
+	_, err := NewTrackAACLATM(96, &TrackConfigAACLATM{})
+	require.EqualError(t, err, "StreamMuxConfig is missing")
+}
@@ -5,6 +5,8 @@ import (
 
 	psdp "github.com/pion/sdp/v3"
 	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/aac"
 )
 
 func TestTrackAACNew(t *testing.T) {
@@ -160,12 +162,50 @@ func TestTrackExtractConfigAAC(t *testing.T) {
 	}
 }
 
+func TestTrackConfigAACMPEG4AudioConfig(t *testing.T) {
+	conf := &TrackConfigAAC{
+		Type:              2,
+		SampleRate:        44100,
+		ChannelCount:      2,
+		AOTSpecificConfig: []byte{0x0A, 0xDC, 0xA0},
+	}
+	require.Equal(t, &aac.MPEG4AudioConfig{
+		Type:              aac.MPEG4AudioTypeAACLC,
+		SampleRate:        44100,
+		ChannelCount:      2,
+		AOTSpecificConfig: []byte{0x0A, 0xDC, 0xA0},
+	}, conf.MPEG4AudioConfig())
+}
+
 func TestTrackConfigAACErrors(t *testing.T) {
 	for _, ca := range []struct {
 		name  string
 		track *Track
 		err   string
 	}{
+		{
+			"latm",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4A-LATM/44100/2",
+						},
+						{
+							Key:   "fmtp",
+							Value: "96 profile-level-id=30; object=2; cpresent=0; config=400024203fc0",
+						},
+					},
+				},
+			},
+			"track is an AAC-LATM track",
+		},
 		{
 			"missing fmtp",
 			&Track{
@@ -0,0 +1,132 @@
+package gortsplib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// TrackConfigAV1 is the configuration of an AV1 track.
+type TrackConfigAV1 struct {
+	LevelIdx *int
+	Profile  *int
+	Tier     *int
+}
+
+// NewTrackAV1 initializes an AV1 track.
+func NewTrackAV1(payloadType uint8, conf *TrackConfigAV1) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	attrs := []psdp.Attribute{
+		{
+			Key:   "rtpmap",
+			Value: typ + " AV1/90000",
+		},
+	}
+
+	var tmp []string
+	if conf.LevelIdx != nil {
+		tmp = append(tmp, "level-idx="+strconv.FormatInt(int64(*conf.LevelIdx), 10))
+	}
+	if conf.Profile != nil {
+		tmp = append(tmp, "profile="+strconv.FormatInt(int64(*conf.Profile), 10))
+	}
+	if conf.Tier != nil {
+		tmp = append(tmp, "tier="+strconv.FormatInt(int64(*conf.Tier), 10))
+	}
+	if tmp != nil {
+		attrs = append(attrs, psdp.Attribute{
+			Key:   "fmtp",
+			Value: typ + " " + strings.Join(tmp, ";"),
+		})
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: attrs,
+		},
+	}, nil
+}
+
+// IsAV1 checks whether the track is an AV1 track.
+func (t *Track) IsAV1() bool {
+	if t.Media.MediaName.Media != "video" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	v = strings.TrimSpace(v)
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.ToUpper(vals[1]) == "AV1/90000"
+}
+
+// ExtractConfigAV1 extracts the configuration of an AV1 track.
+func (t *Track) ExtractConfigAV1() (*TrackConfigAV1, error) {
+	conf := &TrackConfigAV1{}
+
+	v, ok := t.Media.Attribute("fmtp")
+	if !ok {
+		return conf, nil
+	}
+
+	tmp := strings.SplitN(v, " ", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid fmtp attribute (%v)", v)
+	}
+
+	for _, kv := range strings.Split(tmp[1], ";") {
+		kv = strings.Trim(kv, " ")
+
+		if len(kv) == 0 {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+		if len(tmp) != 2 {
+			return nil, fmt.Errorf("invalid fmtp attribute (%v)", v)
+		}
+
+		switch tmp[0] {
+		case "level-idx":
+			val, err := strconv.ParseUint(tmp[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid level-idx (%v)", tmp[1])
+			}
+			v2 := int(val)
+			conf.LevelIdx = &v2
+
+		case "profile":
+			val, err := strconv.ParseUint(tmp[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid profile (%v)", tmp[1])
+			}
+			v2 := int(val)
+			conf.Profile = &v2
+
+		case "tier":
+			val, err := strconv.ParseUint(tmp[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tier (%v)", tmp[1])
+			}
+			v2 := int(val)
+			conf.Tier = &v2
+		}
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,210 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackAV1New(t *testing.T) {
+	levelIdx := 8
+	profile := 1
+	tier := 0
+	tr, err := NewTrackAV1(96, &TrackConfigAV1{
+		LevelIdx: &levelIdx,
+		Profile:  &profile,
+		Tier:     &tier,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 AV1/90000",
+				},
+				{
+					Key:   "fmtp",
+					Value: "96 level-idx=8;profile=1;tier=0",
+				},
+			},
+		},
+	}, tr)
+}
+
+func TestTrackIsAV1(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"standard",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 AV1/90000",
+						},
+					},
+				},
+			},
+		},
+		{
+			"lowercase",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 av1/90000",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsAV1())
+		})
+	}
+}
+
+func TestTrackExtractConfigAV1(t *testing.T) {
+	levelIdx := 8
+	profile := 1
+	tier := 0
+
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigAV1
+	}{
+		{
+			"without fmtp",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 AV1/90000",
+						},
+					},
+				},
+			},
+			&TrackConfigAV1{},
+		},
+		{
+			"with fmtp",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 AV1/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: "96 profile=1; level-idx=8; tier=0",
+						},
+					},
+				},
+			},
+			&TrackConfigAV1{
+				LevelIdx: &levelIdx,
+				Profile:  &profile,
+				Tier:     &tier,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigAV1()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigAV1Errors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp string
+		err  string
+	}{
+		{
+			"invalid fmtp",
+			"96",
+			"invalid fmtp attribute (96)",
+		},
+		{
+			"fmtp without key",
+			"96 profile",
+			"invalid fmtp attribute (96 profile)",
+		},
+		{
+			"invalid level-idx",
+			"96 level-idx=aaa",
+			"invalid level-idx (aaa)",
+		},
+		{
+			"invalid profile",
+			"96 profile=aaa",
+			"invalid profile (aaa)",
+		},
+		{
+			"invalid tier",
+			"96 tier=aaa",
+			"invalid tier (aaa)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 AV1/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: ca.fmtp,
+						},
+					},
+				},
+			}
+			_, err := track.ExtractConfigAV1()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
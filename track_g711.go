@@ -0,0 +1,102 @@
+package gortsplib
+
+import (
+	"fmt"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// TrackConfigG711 is the configuration of a G711 track.
+type TrackConfigG711 struct {
+	// whether the track uses the μ-law (PCMU) or the A-law (PCMA) variant.
+	MULaw bool
+}
+
+// NewTrackG711 initializes a G711 track.
+// The track uses the static payload type 0 (PCMU) or 8 (PCMA).
+func NewTrackG711(conf *TrackConfigG711) (*Track, error) {
+	typ := "8"
+	encoding := "PCMA"
+	if conf.MULaw {
+		typ = "0"
+		encoding = "PCMU"
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " " + encoding + "/8000",
+				},
+			},
+		},
+	}, nil
+}
+
+func (t *Track) g711Encoding() string {
+	if len(t.Media.MediaName.Formats) != 1 {
+		return ""
+	}
+
+	switch t.Media.MediaName.Formats[0] {
+	case "0":
+		return "PCMU"
+
+	case "8":
+		return "PCMA"
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return ""
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return ""
+	}
+
+	encoding := strings.ToUpper(vals[1])
+	switch {
+	case strings.HasPrefix(encoding, "PCMU/"):
+		return "PCMU"
+
+	case strings.HasPrefix(encoding, "PCMA/"):
+		return "PCMA"
+	}
+
+	return ""
+}
+
+// IsG711 checks whether the track is a G711 track.
+func (t *Track) IsG711() bool {
+	if t.Media.MediaName.Media != "audio" {
+		return false
+	}
+
+	return t.g711Encoding() != ""
+}
+
+// ExtractConfigG711 extracts the configuration of a G711 track.
+func (t *Track) ExtractConfigG711() (*TrackConfigG711, error) {
+	if t.Media.MediaName.Media != "audio" {
+		return nil, fmt.Errorf("track is not a G711 track")
+	}
+
+	switch t.g711Encoding() {
+	case "PCMU":
+		return &TrackConfigG711{MULaw: true}, nil
+
+	case "PCMA":
+		return &TrackConfigG711{MULaw: false}, nil
+	}
+
+	return nil, fmt.Errorf("track is not a G711 track")
+}
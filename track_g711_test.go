@@ -0,0 +1,161 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackG711New(t *testing.T) {
+	track, err := NewTrackG711(&TrackConfigG711{MULaw: true})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"0"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "0 PCMU/8000",
+				},
+			},
+		},
+	}, track)
+
+	track, err = NewTrackG711(&TrackConfigG711{MULaw: false})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"8"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "8 PCMA/8000",
+				},
+			},
+		},
+	}, track)
+}
+
+func TestTrackIsG711(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"static payload type",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"8"},
+					},
+				},
+			},
+		},
+		{
+			"dynamic payload type",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"97"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "97 pcmu/8000",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsG711())
+		})
+	}
+}
+
+func TestTrackExtractConfigG711(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigG711
+	}{
+		{
+			"pcmu",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"0"},
+					},
+				},
+			},
+			&TrackConfigG711{MULaw: true},
+		},
+		{
+			"pcma",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"97"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "97 PCMA/8000",
+						},
+					},
+				},
+			},
+			&TrackConfigG711{MULaw: false},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigG711()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigG711Errors(t *testing.T) {
+	track := &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"97"},
+			},
+		},
+	}
+	_, err := track.ExtractConfigG711()
+	require.EqualError(t, err, "track is not a G711 track")
+
+	track = &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"0"},
+			},
+		},
+	}
+	_, err = track.ExtractConfigG711()
+	require.EqualError(t, err, "track is not a G711 track")
+}
@@ -0,0 +1,55 @@
+package gortsplib
+
+import (
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// NewTrackG722 initializes a G722 track.
+// The track uses the static payload type 9.
+// The RTP clock rate is 8000, even if the sample rate is 16000.
+func NewTrackG722() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"9"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "9 G722/8000",
+				},
+			},
+		},
+	}, nil
+}
+
+// IsG722 checks whether the track is a G722 track.
+func (t *Track) IsG722() bool {
+	if t.Media.MediaName.Media != "audio" {
+		return false
+	}
+
+	if len(t.Media.MediaName.Formats) != 1 {
+		return false
+	}
+
+	if t.Media.MediaName.Formats[0] == "9" {
+		return true
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToUpper(vals[1]), "G722/")
+}
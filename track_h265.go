@@ -0,0 +1,154 @@
+package gortsplib
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// TrackConfigH265 is the configuration of an H265 track.
+type TrackConfigH265 struct {
+	VPS        []byte
+	SPS        []byte
+	PPS        []byte
+	MaxDONDiff int
+}
+
+// NewTrackH265 initializes an H265 track.
+func NewTrackH265(payloadType uint8, conf *TrackConfigH265) (*Track, error) {
+	if len(conf.VPS) < 2 {
+		return nil, fmt.Errorf("invalid VPS")
+	}
+
+	if len(conf.SPS) < 2 {
+		return nil, fmt.Errorf("invalid SPS")
+	}
+
+	if len(conf.PPS) < 2 {
+		return nil, fmt.Errorf("invalid PPS")
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	fmtp := typ + " sprop-vps=" + base64.StdEncoding.EncodeToString(conf.VPS) + "; " +
+		"sprop-sps=" + base64.StdEncoding.EncodeToString(conf.SPS) + "; " +
+		"sprop-pps=" + base64.StdEncoding.EncodeToString(conf.PPS)
+	if conf.MaxDONDiff != 0 {
+		fmtp += "; sprop-max-don-diff=" + strconv.FormatInt(int64(conf.MaxDONDiff), 10)
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " H265/90000",
+				},
+				{
+					Key:   "fmtp",
+					Value: fmtp,
+				},
+			},
+		},
+	}, nil
+}
+
+// IsH265 checks whether the track is an H265 track.
+func (t *Track) IsH265() bool {
+	if t.Media.MediaName.Media != "video" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	v = strings.TrimSpace(v)
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return vals[1] == "H265/90000"
+}
+
+// ExtractConfigH265 extracts the configuration of an H265 track.
+func (t *Track) ExtractConfigH265() (*TrackConfigH265, error) {
+	v, ok := t.Media.Attribute("fmtp")
+	if !ok {
+		return nil, fmt.Errorf("fmtp attribute is missing")
+	}
+
+	tmp := strings.SplitN(v, " ", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid fmtp attribute (%v)", v)
+	}
+
+	conf := &TrackConfigH265{}
+
+	for _, kv := range strings.Split(tmp[1], ";") {
+		kv = strings.Trim(kv, " ")
+
+		if len(kv) == 0 {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+		if len(tmp) != 2 {
+			return nil, fmt.Errorf("invalid fmtp attribute (%v)", v)
+		}
+
+		switch tmp[0] {
+		case "sprop-vps":
+			var err error
+			conf.VPS, err = base64.StdEncoding.DecodeString(tmp[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid sprop-vps (%v)", v)
+			}
+
+		case "sprop-sps":
+			var err error
+			conf.SPS, err = base64.StdEncoding.DecodeString(tmp[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid sprop-sps (%v)", v)
+			}
+
+		case "sprop-pps":
+			var err error
+			conf.PPS, err = base64.StdEncoding.DecodeString(tmp[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid sprop-pps (%v)", v)
+			}
+
+		case "sprop-max-don-diff":
+			val, err := strconv.ParseUint(tmp[1], 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sprop-max-don-diff (%v)", v)
+			}
+			conf.MaxDONDiff = int(val)
+		}
+	}
+
+	if conf.VPS == nil {
+		return nil, fmt.Errorf("sprop-vps is missing (%v)", v)
+	}
+
+	if conf.SPS == nil {
+		return nil, fmt.Errorf("sprop-sps is missing (%v)", v)
+	}
+
+	if conf.PPS == nil {
+		return nil, fmt.Errorf("sprop-pps is missing (%v)", v)
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,242 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+var testH265VPS = []byte{
+	0x40, 0x01, 0x0c, 0x01, 0xff, 0xff, 0x01, 0x60,
+	0x00, 0x00, 0x03, 0x00, 0x90, 0x00, 0x00, 0x03,
+	0x00, 0x00, 0x03, 0x00, 0x78, 0x99, 0x98, 0x09,
+}
+
+var testH265SPS = []byte{
+	0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+	0x00, 0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+	0x00, 0x78, 0xa0, 0x03, 0xc0, 0x80, 0x10, 0xe5,
+	0x96, 0x66, 0x69, 0x24, 0xca, 0xe0, 0x10, 0x00,
+	0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x01,
+	0xe0, 0x80,
+}
+
+var testH265PPS = []byte{
+	0x44, 0x01, 0xc1, 0x72, 0xb4, 0x62, 0x40,
+}
+
+func TestTrackH265New(t *testing.T) {
+	tr, err := NewTrackH265(96, &TrackConfigH265{
+		VPS: testH265VPS,
+		SPS: testH265SPS,
+		PPS: testH265PPS,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 H265/90000",
+				},
+				{
+					Key: "fmtp",
+					Value: "96 sprop-vps=QAEMAf//AWAAAAMAkAAAAwAAAwB4mZgJ; " +
+						"sprop-sps=QgEBAWAAAAMAkAAAAwAAAwB4oAPAgBDllmZpJMrgEAAAAwAQAAADAeCA; " +
+						"sprop-pps=RAHBcrRiQA==",
+				},
+			},
+		},
+	}, tr)
+}
+
+func TestTrackIsH265(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"standard",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 H265/90000",
+						},
+					},
+				},
+			},
+		},
+		{
+			"space at the end rtpmap",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 H265/90000 ",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsH265())
+		})
+	}
+}
+
+func TestTrackExtractConfigH265(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigH265
+	}{
+		{
+			"generic",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 H265/90000",
+						},
+						{
+							Key: "fmtp",
+							Value: "96 sprop-vps=QAEMAf//AWAAAAMAkAAAAwAAAwB4mZgJ; " +
+								"sprop-sps=QgEBAWAAAAMAkAAAAwAAAwB4oAPAgBDllmZpJMrgEAAAAwAQAAADAeCA; " +
+								"sprop-pps=RAHBcrRiQA==",
+						},
+					},
+				},
+			},
+			&TrackConfigH265{
+				VPS: testH265VPS,
+				SPS: testH265SPS,
+				PPS: testH265PPS,
+			},
+		},
+		{
+			"with max don diff",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 H265/90000",
+						},
+						{
+							Key: "fmtp",
+							Value: "96 sprop-max-don-diff=2;sprop-vps=QAEMAf//AWAAAAMAkAAAAwAAAwB4mZgJ;" +
+								"sprop-sps=QgEBAWAAAAMAkAAAAwAAAwB4oAPAgBDllmZpJMrgEAAAAwAQAAADAeCA;" +
+								"sprop-pps=RAHBcrRiQA==",
+						},
+					},
+				},
+			},
+			&TrackConfigH265{
+				VPS:        testH265VPS,
+				SPS:        testH265SPS,
+				PPS:        testH265PPS,
+				MaxDONDiff: 2,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigH265()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigH265Errors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp string
+		err  string
+	}{
+		{
+			"invalid fmtp",
+			"96",
+			"invalid fmtp attribute (96)",
+		},
+		{
+			"fmtp without key",
+			"96 sprop-vps",
+			"invalid fmtp attribute (96 sprop-vps)",
+		},
+		{
+			"invalid sprop-vps",
+			"96 sprop-vps=aaaaaa",
+			"invalid sprop-vps (96 sprop-vps=aaaaaa)",
+		},
+		{
+			"invalid sprop-max-don-diff",
+			"96 sprop-max-don-diff=aa",
+			"invalid sprop-max-don-diff (96 sprop-max-don-diff=aa)",
+		},
+		{
+			"missing sprop-vps",
+			"96 sprop-sps=RAHBcrRiQA==",
+			"sprop-vps is missing (96 sprop-sps=RAHBcrRiQA==)",
+		},
+		{
+			"missing sprop-pps",
+			"96 sprop-vps=RAHBcrRiQA==; sprop-sps=RAHBcrRiQA==",
+			"sprop-pps is missing (96 sprop-vps=RAHBcrRiQA==; sprop-sps=RAHBcrRiQA==)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 H265/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: ca.fmtp,
+						},
+					},
+				},
+			}
+			_, err := track.ExtractConfigH265()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package gortsplib
+
+import (
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// NewTrackJPEG initializes a JPEG track.
+// The track uses the static payload type 26.
+func NewTrackJPEG() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"26"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "26 JPEG/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// IsJPEG checks whether the track is a JPEG track.
+func (t *Track) IsJPEG() bool {
+	if t.Media.MediaName.Media != "video" {
+		return false
+	}
+
+	if len(t.Media.MediaName.Formats) != 1 {
+		return false
+	}
+
+	if t.Media.MediaName.Formats[0] == "26" {
+		return true
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToUpper(vals[1]), "JPEG/")
+}
@@ -0,0 +1,49 @@
+package gortsplib
+
+import (
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// NewTrackKLV initializes a KLV (SMPTE 336M) track.
+// KLV tracks carry metadata, like the one produced by drones.
+func NewTrackKLV(payloadType uint8) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "application",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " smpte336m/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// IsKLV checks whether the track is a KLV (SMPTE 336M) track.
+func (t *Track) IsKLV() bool {
+	if t.Media.MediaName.Media != "application" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToLower(vals[1]), "smpte336m/")
+}
@@ -0,0 +1,47 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackKLVNew(t *testing.T) {
+	track, err := NewTrackKLV(97)
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "application",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"97"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "97 smpte336m/90000",
+				},
+			},
+		},
+	}, track)
+}
+
+func TestTrackIsKLV(t *testing.T) {
+	tracks, err := ReadTracks([]byte("v=0\r\n" +
+		"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+		"s=Session\r\n" +
+		"t=0 0\r\n" +
+		"m=video 0 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"m=application 0 RTP/AVP 97\r\n" +
+		"a=rtpmap:97 SMPTE336M/1000\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, false, tracks[0].IsKLV())
+	require.Equal(t, true, tracks[1].IsKLV())
+	require.Equal(t, false, tracks[1].IsONVIFMetadata())
+
+	clockRate, err := tracks[1].ClockRate()
+	require.NoError(t, err)
+	require.Equal(t, 1000, clockRate)
+}
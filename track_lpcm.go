@@ -0,0 +1,160 @@
+package gortsplib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// TrackConfigLPCM is the configuration of a LPCM track.
+type TrackConfigLPCM struct {
+	BitDepth     int
+	SampleRate   int
+	ChannelCount int
+}
+
+// NewTrackLPCM initializes a LPCM track.
+func NewTrackLPCM(payloadType uint8, conf *TrackConfigLPCM) (*Track, error) {
+	switch conf.BitDepth {
+	case 8, 16, 24:
+	default:
+		return nil, fmt.Errorf("unsupported bit depth (%d)", conf.BitDepth)
+	}
+
+	if conf.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate (%d)", conf.SampleRate)
+	}
+
+	if conf.ChannelCount <= 0 {
+		return nil, fmt.Errorf("invalid channel count (%d)", conf.ChannelCount)
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key: "rtpmap",
+					Value: typ + " L" + strconv.FormatInt(int64(conf.BitDepth), 10) +
+						"/" + strconv.FormatInt(int64(conf.SampleRate), 10) +
+						"/" + strconv.FormatInt(int64(conf.ChannelCount), 10),
+				},
+			},
+		},
+	}, nil
+}
+
+// IsLPCM checks whether the track is a LPCM track.
+func (t *Track) IsLPCM() bool {
+	if t.Media.MediaName.Media != "audio" {
+		return false
+	}
+
+	if len(t.Media.MediaName.Formats) != 1 {
+		return false
+	}
+
+	switch t.Media.MediaName.Formats[0] {
+	case "10", "11":
+		return true
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	encoding := strings.ToUpper(vals[1])
+	return strings.HasPrefix(encoding, "L8/") ||
+		strings.HasPrefix(encoding, "L16/") ||
+		strings.HasPrefix(encoding, "L24/")
+}
+
+// ExtractConfigLPCM extracts the configuration of a LPCM track.
+func (t *Track) ExtractConfigLPCM() (*TrackConfigLPCM, error) {
+	if len(t.Media.MediaName.Formats) == 1 {
+		switch t.Media.MediaName.Formats[0] {
+		case "10":
+			return &TrackConfigLPCM{
+				BitDepth:     16,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			}, nil
+
+		case "11":
+			return &TrackConfigLPCM{
+				BitDepth:     16,
+				SampleRate:   44100,
+				ChannelCount: 1,
+			}, nil
+		}
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return nil, fmt.Errorf("rtpmap attribute is missing")
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return nil, fmt.Errorf("invalid rtpmap (%v)", v)
+	}
+
+	tmp := strings.Split(vals[1], "/")
+	if len(tmp) != 2 && len(tmp) != 3 {
+		return nil, fmt.Errorf("invalid rtpmap (%v)", v)
+	}
+
+	var bitDepth int
+	switch strings.ToUpper(tmp[0]) {
+	case "L8":
+		bitDepth = 8
+
+	case "L16":
+		bitDepth = 16
+
+	case "L24":
+		bitDepth = 24
+
+	default:
+		return nil, fmt.Errorf("invalid rtpmap (%v)", v)
+	}
+
+	sampleRate, err := strconv.ParseInt(tmp[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate (%d)", sampleRate)
+	}
+
+	channelCount := int64(1)
+	if len(tmp) == 3 {
+		channelCount, err = strconv.ParseInt(tmp[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if channelCount <= 0 {
+			return nil, fmt.Errorf("invalid channel count (%d)", channelCount)
+		}
+	}
+
+	return &TrackConfigLPCM{
+		BitDepth:     bitDepth,
+		SampleRate:   int(sampleRate),
+		ChannelCount: int(channelCount),
+	}, nil
+}
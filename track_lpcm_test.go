@@ -0,0 +1,244 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackLPCMNew(t *testing.T) {
+	track, err := NewTrackLPCM(96, &TrackConfigLPCM{
+		BitDepth:     24,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 L24/48000/2",
+				},
+			},
+		},
+	}, track)
+
+	_, err = NewTrackLPCM(96, &TrackConfigLPCM{
+		BitDepth:     12,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	})
+	require.EqualError(t, err, "unsupported bit depth (12)")
+}
+
+func TestTrackIsLPCM(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"static payload type",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"11"},
+					},
+				},
+			},
+		},
+		{
+			"dynamic payload type",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 L16/16000/2",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsLPCM())
+		})
+	}
+}
+
+func TestTrackExtractConfigLPCM(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigLPCM
+	}{
+		{
+			"static payload type",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"10"},
+					},
+				},
+			},
+			&TrackConfigLPCM{
+				BitDepth:     16,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+		},
+		{
+			"dynamic payload type",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 L16/16000/2",
+						},
+					},
+				},
+			},
+			&TrackConfigLPCM{
+				BitDepth:     16,
+				SampleRate:   16000,
+				ChannelCount: 2,
+			},
+		},
+		{
+			"without channels",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 L8/8000",
+						},
+					},
+				},
+			},
+			&TrackConfigLPCM{
+				BitDepth:     8,
+				SampleRate:   8000,
+				ChannelCount: 1,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigLPCM()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigLPCMErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		err   string
+	}{
+		{
+			"missing rtpmap",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{},
+				},
+			},
+			"rtpmap attribute is missing",
+		},
+		{
+			"invalid rtpmap",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 L12/8000",
+						},
+					},
+				},
+			},
+			"invalid rtpmap (96 L12/8000)",
+		},
+		{
+			"invalid sample rate",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 L16/0",
+						},
+					},
+				},
+			},
+			"invalid sample rate (0)",
+		},
+		{
+			"invalid channel count",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "audio",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 L16/44100/0",
+						},
+					},
+				},
+			},
+			"invalid channel count (0)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := ca.track.ExtractConfigLPCM()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package gortsplib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// TrackConfigMPEG4Video is the configuration of a MPEG-4 Video (MP4V-ES) track.
+type TrackConfigMPEG4Video struct {
+	ProfileLevelID int
+
+	// visual object sequence, visual object and video object layer headers.
+	Config []byte
+}
+
+// mpeg4VideoConfigValid checks whether a configuration contains
+// a video object layer (VOL) header.
+func mpeg4VideoConfigValid(config []byte) bool {
+	// ref: ISO 14496-2, 6.2.3
+	for {
+		i := bytes.Index(config, []byte{0x00, 0x00, 0x01})
+		if i < 0 || len(config) < (i+4) {
+			return false
+		}
+
+		if (config[i+3] & 0xF0) == 0x20 {
+			return true
+		}
+
+		config = config[i+3:]
+	}
+}
+
+// NewTrackMPEG4Video initializes a MPEG-4 Video (MP4V-ES) track.
+func NewTrackMPEG4Video(payloadType uint8, conf *TrackConfigMPEG4Video) (*Track, error) {
+	if !mpeg4VideoConfigValid(conf.Config) {
+		return nil, fmt.Errorf("invalid MPEG-4 Video config")
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " MP4V-ES/90000",
+				},
+				{
+					Key: "fmtp",
+					Value: typ + " profile-level-id=" + strconv.FormatInt(int64(conf.ProfileLevelID), 10) +
+						"; config=" + strings.ToUpper(hex.EncodeToString(conf.Config)),
+				},
+			},
+		},
+	}, nil
+}
+
+// IsMPEG4Video checks whether the track is a MPEG-4 Video (MP4V-ES) track.
+func (t *Track) IsMPEG4Video() bool {
+	if t.Media.MediaName.Media != "video" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToUpper(vals[1]), "MP4V-ES/")
+}
+
+// ExtractConfigMPEG4Video extracts the configuration of a MPEG-4 Video (MP4V-ES) track.
+func (t *Track) ExtractConfigMPEG4Video() (*TrackConfigMPEG4Video, error) {
+	v, ok := t.Media.Attribute("fmtp")
+	if !ok {
+		return nil, fmt.Errorf("fmtp attribute is missing")
+	}
+
+	tmp := strings.SplitN(v, " ", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid fmtp (%v)", v)
+	}
+
+	conf := &TrackConfigMPEG4Video{
+		// default value
+		// ref: RFC 6416, 7.1
+		ProfileLevelID: 1,
+	}
+
+	for _, kv := range strings.Split(tmp[1], ";") {
+		kv = strings.Trim(kv, " ")
+
+		if len(kv) == 0 {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+		if len(tmp) != 2 {
+			return nil, fmt.Errorf("invalid fmtp (%v)", v)
+		}
+
+		switch strings.ToLower(tmp[0]) {
+		case "profile-level-id":
+			val, err := strconv.ParseUint(tmp[1], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid profile-level-id (%v)", tmp[1])
+			}
+			conf.ProfileLevelID = int(val)
+
+		case "config":
+			enc, err := hex.DecodeString(tmp[1])
+			if err != nil || !mpeg4VideoConfigValid(enc) {
+				return nil, fmt.Errorf("invalid MPEG-4 Video config (%v)", tmp[1])
+			}
+			conf.Config = enc
+		}
+	}
+
+	if conf.Config == nil {
+		return nil, fmt.Errorf("config is missing (%v)", v)
+	}
+
+	return conf, nil
+}
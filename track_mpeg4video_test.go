@@ -0,0 +1,171 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+var testMPEG4VideoConfig = []byte{
+	0x00, 0x00, 0x01, 0xb0, 0x01, 0x00, 0x00, 0x01,
+	0xb5, 0x89, 0x13, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x01, 0x20, 0x00, 0xc4, 0x8d, 0x88, 0x00,
+	0xf5, 0x0a, 0x04, 0x1e, 0x14, 0x63,
+}
+
+func TestTrackMPEG4VideoNew(t *testing.T) {
+	track, err := NewTrackMPEG4Video(96, &TrackConfigMPEG4Video{
+		ProfileLevelID: 1,
+		Config:         testMPEG4VideoConfig,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 MP4V-ES/90000",
+				},
+				{
+					Key:   "fmtp",
+					Value: "96 profile-level-id=1; config=000001B001000001B58913000001000000012000C48D8800F50A041E1463",
+				},
+			},
+		},
+	}, track)
+
+	_, err = NewTrackMPEG4Video(96, &TrackConfigMPEG4Video{
+		Config: []byte{0x00, 0x00, 0x01, 0xb0, 0x01},
+	})
+	require.EqualError(t, err, "invalid MPEG-4 Video config")
+}
+
+func TestTrackIsMPEG4Video(t *testing.T) {
+	track, err := NewTrackMPEG4Video(96, &TrackConfigMPEG4Video{
+		ProfileLevelID: 1,
+		Config:         testMPEG4VideoConfig,
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, track.IsMPEG4Video())
+	require.Equal(t, false, track.IsH264())
+}
+
+func TestTrackExtractConfigMPEG4Video(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp string
+		conf *TrackConfigMPEG4Video
+	}{
+		{
+			"standard",
+			"96 profile-level-id=3; config=000001B001000001B58913000001000000012000C48D8800F50A041E1463",
+			&TrackConfigMPEG4Video{
+				ProfileLevelID: 3,
+				Config:         testMPEG4VideoConfig,
+			},
+		},
+		{
+			"default profile-level-id",
+			"96 config=000001b001000001b58913000001000000012000c48d8800f50a041e1463",
+			&TrackConfigMPEG4Video{
+				ProfileLevelID: 1,
+				Config:         testMPEG4VideoConfig,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4V-ES/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: ca.fmtp,
+						},
+					},
+				},
+			}
+			conf, err := track.ExtractConfigMPEG4Video()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackExtractConfigMPEG4VideoErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp *string
+		err  string
+	}{
+		{
+			"missing fmtp",
+			nil,
+			"fmtp attribute is missing",
+		},
+		{
+			"missing config",
+			func() *string {
+				v := "96 profile-level-id=1"
+				return &v
+			}(),
+			"config is missing (96 profile-level-id=1)",
+		},
+		{
+			"invalid config",
+			func() *string {
+				v := "96 config=000001B0"
+				return &v
+			}(),
+			"invalid MPEG-4 Video config (000001B0)",
+		},
+		{
+			"invalid profile-level-id",
+			func() *string {
+				v := "96 profile-level-id=aa"
+				return &v
+			}(),
+			"invalid profile-level-id (aa)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 MP4V-ES/90000",
+						},
+					},
+				},
+			}
+			if ca.fmtp != nil {
+				track.Media.Attributes = append(track.Media.Attributes, psdp.Attribute{
+					Key:   "fmtp",
+					Value: *ca.fmtp,
+				})
+			}
+			_, err := track.ExtractConfigMPEG4Video()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
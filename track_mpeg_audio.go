@@ -0,0 +1,55 @@
+package gortsplib
+
+import (
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// NewTrackMPEGAudio initializes a MPEG-1/2 audio track.
+// The track uses the static payload type 14.
+// The RTP clock rate is always 90000, regardless of the sample rate.
+func NewTrackMPEGAudio() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"14"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "14 MPA/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// IsMPEGAudio checks whether the track is a MPEG-1/2 audio track.
+func (t *Track) IsMPEGAudio() bool {
+	if t.Media.MediaName.Media != "audio" {
+		return false
+	}
+
+	if len(t.Media.MediaName.Formats) != 1 {
+		return false
+	}
+
+	if t.Media.MediaName.Formats[0] == "14" {
+		return true
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToUpper(vals[1]), "MPA/")
+}
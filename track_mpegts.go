@@ -0,0 +1,50 @@
+package gortsplib
+
+import (
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// NewTrackMPEGTS initializes a MPEG-TS track.
+// The track uses the static payload type 33.
+func NewTrackMPEGTS() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"33"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "33 MP2T/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// IsMPEGTS checks whether the track is a MPEG-TS track.
+func (t *Track) IsMPEGTS() bool {
+	if len(t.Media.MediaName.Formats) != 1 {
+		return false
+	}
+
+	if t.Media.MediaName.Formats[0] == "33" {
+		return true
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToUpper(vals[1]), "MP2T/")
+}
@@ -0,0 +1,49 @@
+package gortsplib
+
+import (
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// NewTrackONVIFMetadata initializes an ONVIF metadata track.
+// ONVIF metadata tracks carry XML documents produced by analytics cameras.
+func NewTrackONVIFMetadata(payloadType uint8) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "application",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " vnd.onvif.metadata/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// IsONVIFMetadata checks whether the track is an ONVIF metadata track.
+func (t *Track) IsONVIFMetadata() bool {
+	if t.Media.MediaName.Media != "application" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToLower(vals[1]), "vnd.onvif.metadata/")
+}
@@ -0,0 +1,49 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackONVIFMetadataNew(t *testing.T) {
+	track, err := NewTrackONVIFMetadata(107)
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "application",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"107"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "107 vnd.onvif.metadata/90000",
+				},
+			},
+		},
+	}, track)
+
+	clockRate, err := track.ClockRate()
+	require.NoError(t, err)
+	require.Equal(t, 90000, clockRate)
+}
+
+func TestTrackIsONVIFMetadata(t *testing.T) {
+	tracks, err := ReadTracks([]byte("v=0\r\n" +
+		"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+		"s=Session\r\n" +
+		"t=0 0\r\n" +
+		"m=video 0 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"m=application 0 RTP/AVP 107\r\n" +
+		"a=control:trackID=1\r\n" +
+		"a=recvonly\r\n" +
+		"a=rtpmap:107 vnd.onvif.metadata/90000\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, false, tracks[0].IsONVIFMetadata())
+	require.Equal(t, true, tracks[1].IsONVIFMetadata())
+	require.Equal(t, false, tracks[1].IsKLV())
+}
@@ -218,3 +218,62 @@ func TestTrackClockRate(t *testing.T) {
 		})
 	}
 }
+
+func TestTrackRTCPFeedback(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		sdp  []byte
+		nack bool
+	}{
+		{
+			"wildcard",
+			[]byte("v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=-\r\n" +
+				"t=0 0\r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp-fb:* nack\r\n"),
+			true,
+		},
+		{
+			"payload type",
+			[]byte("v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=-\r\n" +
+				"t=0 0\r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp-fb:96 nack\r\n"),
+			true,
+		},
+		{
+			"other payload type",
+			[]byte("v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=-\r\n" +
+				"t=0 0\r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp-fb:97 nack\r\n"),
+			false,
+		},
+		{
+			"other feedback",
+			[]byte("v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=-\r\n" +
+				"t=0 0\r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp-fb:* nack pli\r\n"),
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			tracks, err := ReadTracks(ca.sdp)
+			require.NoError(t, err)
+			require.Equal(t, ca.nack, tracks[0].hasRTCPFeedback("nack"))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package gortsplib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+)
+
+// TrackConfigVP8 is the configuration of a VP8 track.
+type TrackConfigVP8 struct {
+	MaxFR *int
+	MaxFS *int
+}
+
+// NewTrackVP8 initializes a VP8 track.
+func NewTrackVP8(payloadType uint8, conf *TrackConfigVP8) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	attrs := []psdp.Attribute{
+		{
+			Key:   "rtpmap",
+			Value: typ + " VP8/90000",
+		},
+	}
+
+	var tmp []string
+	if conf.MaxFR != nil {
+		tmp = append(tmp, "max-fr="+strconv.FormatInt(int64(*conf.MaxFR), 10))
+	}
+	if conf.MaxFS != nil {
+		tmp = append(tmp, "max-fs="+strconv.FormatInt(int64(*conf.MaxFS), 10))
+	}
+	if tmp != nil {
+		attrs = append(attrs, psdp.Attribute{
+			Key:   "fmtp",
+			Value: typ + " " + strings.Join(tmp, ";"),
+		})
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: attrs,
+		},
+	}, nil
+}
+
+// IsVP8 checks whether the track is a VP8 track.
+func (t *Track) IsVP8() bool {
+	if t.Media.MediaName.Media != "video" {
+		return false
+	}
+
+	v, ok := t.Media.Attribute("rtpmap")
+	if !ok {
+		return false
+	}
+
+	v = strings.TrimSpace(v)
+	vals := strings.Split(v, " ")
+	if len(vals) != 2 {
+		return false
+	}
+
+	return strings.ToUpper(vals[1]) == "VP8/90000"
+}
+
+// ExtractConfigVP8 extracts the configuration of a VP8 track.
+func (t *Track) ExtractConfigVP8() (*TrackConfigVP8, error) {
+	conf := &TrackConfigVP8{}
+
+	v, ok := t.Media.Attribute("fmtp")
+	if !ok {
+		return conf, nil
+	}
+
+	tmp := strings.SplitN(v, " ", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid fmtp attribute (%v)", v)
+	}
+
+	for _, kv := range strings.Split(tmp[1], ";") {
+		kv = strings.Trim(kv, " ")
+
+		if len(kv) == 0 {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+		if len(tmp) != 2 {
+			return nil, fmt.Errorf("invalid fmtp attribute (%v)", v)
+		}
+
+		switch tmp[0] {
+		case "max-fr":
+			val, err := strconv.ParseUint(tmp[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max-fr (%v)", tmp[1])
+			}
+			v2 := int(val)
+			conf.MaxFR = &v2
+
+		case "max-fs":
+			val, err := strconv.ParseUint(tmp[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max-fs (%v)", tmp[1])
+			}
+			v2 := int(val)
+			conf.MaxFS = &v2
+		}
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,201 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackVP8New(t *testing.T) {
+	maxFR := 123
+	maxFS := 456
+	tr, err := NewTrackVP8(96, &TrackConfigVP8{
+		MaxFR: &maxFR,
+		MaxFS: &maxFS,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 VP8/90000",
+				},
+				{
+					Key:   "fmtp",
+					Value: "96 max-fr=123;max-fs=456",
+				},
+			},
+		},
+	}, tr)
+}
+
+func TestTrackIsVP8(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"standard",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP8/90000",
+						},
+					},
+				},
+			},
+		},
+		{
+			"lowercase",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 vp8/90000",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsVP8())
+		})
+	}
+}
+
+func TestTrackExtractConfigVP8(t *testing.T) {
+	maxFR := 123
+	maxFS := 456
+
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigVP8
+	}{
+		{
+			"without fmtp",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP8/90000",
+						},
+					},
+				},
+			},
+			&TrackConfigVP8{},
+		},
+		{
+			"with fmtp",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP8/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: "96 max-fr=123; max-fs=456",
+						},
+					},
+				},
+			},
+			&TrackConfigVP8{
+				MaxFR: &maxFR,
+				MaxFS: &maxFS,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigVP8()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigVP8Errors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp string
+		err  string
+	}{
+		{
+			"invalid fmtp",
+			"96",
+			"invalid fmtp attribute (96)",
+		},
+		{
+			"fmtp without key",
+			"96 max-fr",
+			"invalid fmtp attribute (96 max-fr)",
+		},
+		{
+			"invalid max-fr",
+			"96 max-fr=aaa",
+			"invalid max-fr (aaa)",
+		},
+		{
+			"invalid max-fs",
+			"96 max-fs=aaa",
+			"invalid max-fs (aaa)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP8/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: ca.fmtp,
+						},
+					},
+				},
+			}
+			_, err := track.ExtractConfigVP8()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
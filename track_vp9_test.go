@@ -0,0 +1,210 @@
+package gortsplib
+
+import (
+	"testing"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackVP9New(t *testing.T) {
+	maxFR := 123
+	maxFS := 456
+	profileID := 2
+	tr, err := NewTrackVP9(96, &TrackConfigVP9{
+		MaxFR:     &maxFR,
+		MaxFS:     &maxFS,
+		ProfileID: &profileID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"96"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "96 VP9/90000",
+				},
+				{
+					Key:   "fmtp",
+					Value: "96 max-fr=123;max-fs=456;profile-id=2",
+				},
+			},
+		},
+	}, tr)
+}
+
+func TestTrackIsVP9(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		track *Track
+	}{
+		{
+			"standard",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP9/90000",
+						},
+					},
+				},
+			},
+		},
+		{
+			"lowercase",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 vp9/90000",
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, true, ca.track.IsVP9())
+		})
+	}
+}
+
+func TestTrackExtractConfigVP9(t *testing.T) {
+	maxFR := 123
+	maxFS := 456
+	profileID := 2
+
+	for _, ca := range []struct {
+		name  string
+		track *Track
+		conf  *TrackConfigVP9
+	}{
+		{
+			"without fmtp",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP9/90000",
+						},
+					},
+				},
+			},
+			&TrackConfigVP9{},
+		},
+		{
+			"with fmtp",
+			&Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP9/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: "96 max-fr=123; max-fs=456; profile-id=2",
+						},
+					},
+				},
+			},
+			&TrackConfigVP9{
+				MaxFR:     &maxFR,
+				MaxFS:     &maxFS,
+				ProfileID: &profileID,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			conf, err := ca.track.ExtractConfigVP9()
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestTrackConfigVP9Errors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		fmtp string
+		err  string
+	}{
+		{
+			"invalid fmtp",
+			"96",
+			"invalid fmtp attribute (96)",
+		},
+		{
+			"fmtp without key",
+			"96 max-fr",
+			"invalid fmtp attribute (96 max-fr)",
+		},
+		{
+			"invalid max-fr",
+			"96 max-fr=aaa",
+			"invalid max-fr (aaa)",
+		},
+		{
+			"invalid max-fs",
+			"96 max-fs=aaa",
+			"invalid max-fs (aaa)",
+		},
+		{
+			"invalid profile-id",
+			"96 profile-id=aaa",
+			"invalid profile-id (aaa)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			track := &Track{
+				Media: &psdp.MediaDescription{
+					MediaName: psdp.MediaName{
+						Media:   "video",
+						Protos:  []string{"RTP", "AVP"},
+						Formats: []string{"96"},
+					},
+					Attributes: []psdp.Attribute{
+						{
+							Key:   "rtpmap",
+							Value: "96 VP9/90000",
+						},
+						{
+							Key:   "fmtp",
+							Value: ca.fmtp,
+						},
+					},
+				},
+			}
+			_, err := track.ExtractConfigVP9()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
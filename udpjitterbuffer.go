@@ -0,0 +1,94 @@
+package gortsplib
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/jitterbuffer"
+)
+
+// udpJitterBuffer reorders RTP packets received with UDP
+// and delivers them in order, either when they arrive
+// or when the latency of the buffer expires.
+type udpJitterBuffer struct {
+	onPacket func([]byte)
+	onLost   func(int)
+
+	mutex  sync.Mutex
+	jb     *jitterbuffer.JitterBuffer
+	timer  *time.Timer
+	closed bool
+}
+
+func newUDPJitterBuffer(
+	latency time.Duration,
+	onPacket func([]byte),
+	onLost func(int),
+) *udpJitterBuffer {
+	return &udpJitterBuffer{
+		onPacket: onPacket,
+		onLost:   onLost,
+		jb:       jitterbuffer.New(latency),
+	}
+}
+
+func (b *udpJitterBuffer) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+func (b *udpJitterBuffer) push(now time.Time, payload []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	// packets that are not RTP are delivered directly
+	if len(payload) < 12 {
+		b.onPacket(payload)
+		return
+	}
+
+	seq := uint16(payload[2])<<8 | uint16(payload[3])
+	out, lost := b.jb.Push(now, seq, payload)
+	b.deliver(out, lost)
+}
+
+func (b *udpJitterBuffer) expire() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	out, lost := b.jb.Expire(time.Now())
+	b.deliver(out, lost)
+}
+
+func (b *udpJitterBuffer) deliver(out [][]byte, lost int) {
+	if lost > 0 {
+		b.onLost(lost)
+	}
+
+	for _, pkt := range out {
+		b.onPacket(pkt)
+	}
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	deadline, ok := b.jb.NextDeadline()
+	if ok {
+		b.timer = time.AfterFunc(time.Until(deadline), b.expire)
+	}
+}
@@ -0,0 +1,213 @@
+package gortsplib
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+func testJitterBufferPacket(t *testing.T, seq uint16) []byte {
+	byts, err := (&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: seq,
+			Timestamp:      uint32(seq) * 3000,
+			SSRC:           0x38F27A2F,
+		},
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}).Marshal()
+	require.NoError(t, err)
+	return byts
+}
+
+func testJitterBufferSequenceNumbers(t *testing.T, received [][]byte) []uint16 {
+	var ret []uint16
+	for _, byts := range received {
+		var pkt rtp.Packet
+		err := pkt.Unmarshal(byts)
+		require.NoError(t, err)
+		ret = append(ret, pkt.SequenceNumber)
+	}
+	return ret
+}
+
+func TestClientReadJitterBuffer(t *testing.T) {
+	track, err := NewTrackH264(96, &TrackConfigH264{
+		SPS: testRecorderSPS,
+		PPS: testRecorderPPS,
+	})
+	require.NoError(t, err)
+
+	stream := NewServerStream(Tracks{track})
+	defer stream.Close()
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					for _, seq := range []uint16{65534, 0, 65535, 1, 3} {
+						stream.WritePacketRTP(0, testJitterBufferPacket(t, seq))
+					}
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
+		RTSPAddress:    "localhost:8554",
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	var mutex sync.Mutex
+	var received [][]byte
+	lost := 0
+	done := make(chan struct{})
+
+	c := Client{
+		Transport: func() *Transport {
+			v := TransportUDP
+			return &v
+		}(),
+		JitterBufferLatency: 200 * time.Millisecond,
+		OnPacketRTP: func(trackID int, payload []byte) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			received = append(received, payload)
+			if len(received) == 5 {
+				close(done)
+			}
+		},
+		OnPacketsLost: func(trackID int, count int) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			require.Equal(t, 0, trackID)
+			lost += count
+		},
+	}
+
+	err = c.StartReading("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+	defer c.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("packets not received")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.Equal(t, []uint16{65534, 65535, 0, 1, 3}, testJitterBufferSequenceNumbers(t, received))
+	require.Equal(t, 1, lost)
+}
+
+func TestServerPublishJitterBuffer(t *testing.T) {
+	var mutex sync.Mutex
+	var received [][]byte
+	lost := 0
+	done := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPacketRTP: func(ctx *ServerHandlerOnPacketRTPCtx) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				received = append(received, ctx.Payload)
+				if len(received) == 4 {
+					close(done)
+				}
+			},
+			onPacketsLost: func(ctx *ServerHandlerOnPacketsLostCtx) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				require.Equal(t, 0, ctx.TrackID)
+				lost += ctx.Count
+			},
+		},
+		UDPRTPAddress:       "127.0.0.1:8000",
+		UDPRTCPAddress:      "127.0.0.1:8001",
+		RTSPAddress:         "localhost:8554",
+		JitterBufferLatency: 200 * time.Millisecond,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	track, err := NewTrackH264(96, &TrackConfigH264{
+		SPS: testRecorderSPS,
+		PPS: testRecorderPPS,
+	})
+	require.NoError(t, err)
+
+	c := Client{
+		Transport: func() *Transport {
+			v := TransportUDP
+			return &v
+		}(),
+	}
+
+	err = c.StartPublishing("rtsp://localhost:8554/teststream", Tracks{track})
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, seq := range []uint16{100, 102, 101, 104} {
+		err := c.WritePacketRTP(0, testJitterBufferPacket(t, seq))
+		require.NoError(t, err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("packets not received")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.Equal(t, []uint16{100, 101, 102, 104}, testJitterBufferSequenceNumbers(t, received))
+	require.Equal(t, 1, lost)
+}